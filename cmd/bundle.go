@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"http-mock-server/internal/bundle"
+)
+
+// runBundle implements `http-mock-server bundle`: it packages a configuration and
+// the dataset, schema, certificate, and secret files it references into one archive
+// that `http-mock-server --bundle` can run.
+func runBundle(args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	configPath := fs.String("config", "", "Configuration file to bundle (defaults to config.yaml, .json, or .toml, in . or config/)")
+	output := fs.String("o", "mocks.tar.gz", "Archive to write")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path, err := configFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	files, err := bundle.Create(f, path)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(*output)
+		return fmt.Errorf("could not bundle %s: %w", path, err)
+	}
+
+	fmt.Printf("Bundled %s with %d file(s) into %s\n", path, len(files), *output)
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+	return nil
+}
+
+// extractBundle unpacks a bundle into a new temporary directory and returns it
+func extractBundle(archive string) (string, error) {
+	dir, err := os.MkdirTemp("", "http-mock-server-bundle-")
+	if err != nil {
+		return "", err
+	}
+	if err := bundle.Extract(archive, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
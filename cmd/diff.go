@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"http-mock-server/internal/config"
+)
+
+// runDiff implements `http-mock-server diff`: it submits a local configuration to a
+// running server's dry-run reload endpoint and prints which rules would change.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	serverURL := fs.String("url", "http://localhost:8080", "Base URL of the running mock server")
+	configPath := fs.String("config", "", "Configuration file to compare (defaults to config.yaml, .json, or .toml, in . or config/)")
+	token := fs.String("token", os.Getenv("MOCK_SERVER_ADMIN_TOKEN"), "Admin token of the running server, defaults to $MOCK_SERVER_ADMIN_TOKEN")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path, err := configFile(*configPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file %s: %w", path, err)
+	}
+	// The reload endpoint reads YAML, which JSON and TOML files are converted to
+	if config.FormatOf(path) != config.FormatYAML {
+		doc, err := config.DecodeDocument(data, path)
+		if err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+	}
+
+	endpoint := strings.TrimRight(*serverURL, "/") + "/__admin/reload?dryRun=true"
+	client := &http.Client{Timeout: 30 * time.Second}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("dry-run request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read dry-run response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server rejected configuration (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Diff     config.RuleDiff `json:"diff"`
+		Warnings []string        `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("invalid dry-run response: %w", err)
+	}
+
+	printDiff(os.Stdout, path, *serverURL, result.Diff, result.Warnings)
+	return nil
+}
+
+// configFile returns path, or the configuration file the server would load by default
+func configFile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	for _, candidate := range config.DefaultPaths {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no configuration file found, use -config")
+}
+
+func printDiff(w io.Writer, path, serverURL string, diff config.RuleDiff, warnings []string) {
+	fmt.Fprintf(w, "Comparing %s with %s\n", path, serverURL)
+	if diff.Empty() {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+	for _, key := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", key)
+	}
+	for _, key := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", key)
+	}
+	for _, key := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", key)
+	}
+	fmt.Fprintf(
+		w, "%d added, %d removed, %d changed, %d unchanged\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged,
+	)
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+}
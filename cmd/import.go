@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"http-mock-server/internal/importer"
+)
+
+// runImport implements `http-mock-server import`: it converts an Insomnia export, a
+// Bruno collection, a browser HAR file, WireMock mappings, or an OpenAPI document
+// into a configuration with one rule per saved request, recorded request, stub
+// mapping, or operation.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "Collection format, insomnia, bruno, har, wiremock, or openapi (detected when empty)")
+	output := fs.String("o", "", "Configuration file to write (defaults to standard output)")
+	random := fs.Bool("random", false, "Generate a new body from the OpenAPI schema for every request instead of once")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: http-mock-server import [flags] <insomnia-export | bruno-collection | har-file | wiremock-mappings | openapi-spec>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one collection to import")
+	}
+	path := fs.Arg(0)
+
+	if *format == "" {
+		var err error
+		if *format, err = importer.Detect(path); err != nil {
+			return err
+		}
+	}
+	file, err := importer.Import(path, *format, importer.Options{RandomBodies: *random})
+	if err != nil {
+		return fmt.Errorf("could not import %s: %w", path, err)
+	}
+	data, err := file.Marshal()
+	if err != nil {
+		return err
+	}
+	for _, warning := range file.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if _, err := os.Stat(*output); err == nil {
+		return fmt.Errorf("%s already exists; choose another file or merge the output by hand", *output)
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d request(s) from %s into %s\n", len(file.Requests), path, *output)
+	return nil
+}
@@ -1,18 +1,128 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	"http-mock-server/internal/app"
+	"http-mock-server/internal/bundle"
+	"http-mock-server/internal/config"
 )
 
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatalf("Application failed: %v", err)
 	}
 }
 
-func run() error {
-	application := app.New()
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "diff":
+			return runDiff(args[1:])
+		case "bundle":
+			return runBundle(args[1:])
+		case "import":
+			return runImport(args[1:])
+		case "record":
+			return runRecord(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("http-mock-server", flag.ExitOnError)
+	configPath := fs.String("config", "", "Configuration file to load, instead of $"+config.PathEnv+" or config.yaml, .json, or .toml in . or config/")
+	configDir := fs.String("config-dir", "", "Load and merge every YAML file in this directory instead of a single configuration file")
+	port := fs.Uint("port", 0, "Port to listen on instead of server.port")
+	portRange := fs.String("port-range", "", "Listen on the first free port in a range such as 8080-8090")
+	daemon := fs.Bool("daemon", false, "Run in the background, detached from the terminal (Unix only)")
+	pidFile := fs.String("pidfile", "", "Write the process ID to this file while the server runs")
+	logFile := fs.String("log-file", "", "Append logs to this file instead of stderr")
+	bundlePath := fs.String("bundle", "", "Run the configuration in an archive created by the bundle command")
+	watch := fs.Bool("watch", false, "Reload the rules whenever the configuration file or directory changes")
+	interactive := fs.Bool("interactive", false, "Show requests on the terminal and answer those held by rules with hold set")
+	printRoutes := fs.Bool("print-routes", false, "Print a table of the mocked endpoints and exit")
+	updateGolden := fs.Bool("update-golden", false, "Write rendered response bodies to their golden files instead of comparing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *port > 65535 {
+		return fmt.Errorf("--port %d is not a valid port", *port)
+	}
+	if *port != 0 && *portRange != "" {
+		return fmt.Errorf("--port and --port-range cannot be combined")
+	}
+	if *configPath != "" && *configDir != "" {
+		return fmt.Errorf("--config and --config-dir cannot be combined")
+	}
+	if (*configPath != "" || *configDir != "") && *bundlePath != "" {
+		return fmt.Errorf("--config and --config-dir cannot be combined with --bundle")
+	}
+	if *configDir != "" {
+		if info, err := os.Stat(*configDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("--config-dir %s is not a directory", *configDir)
+		}
+		*configPath = *configDir
+	}
+	if *daemon && *interactive {
+		return fmt.Errorf("--daemon and --interactive cannot be combined")
+	}
+	if *printRoutes && (*daemon || *interactive || *watch) {
+		return fmt.Errorf("--print-routes cannot be combined with --daemon, --interactive, or --watch")
+	}
+
+	if *daemon && !app.IsDaemon() {
+		pid, err := app.Daemonize()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Started in the background with pid %d\n", pid)
+		return nil
+	}
+
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("could not open log file: %w", err)
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	if *bundlePath != "" {
+		// Paths in a bundled configuration are relative to the bundle
+		if *pidFile != "" {
+			abs, err := filepath.Abs(*pidFile)
+			if err != nil {
+				return err
+			}
+			*pidFile = abs
+		}
+		dir, err := extractBundle(*bundlePath)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		if err := os.Chdir(dir); err != nil {
+			return err
+		}
+		*configPath = bundle.ConfigFile
+	}
+
+	if *printRoutes {
+		cfg, err := config.LoadFrom(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return cfg.WriteRoutes(os.Stdout)
+	}
+
+	application := app.New(app.Options{ConfigPath: *configPath, Port: *port, PortRange: *portRange, PIDFile: *pidFile, Interactive: *interactive, UpdateGolden: *updateGolden, Watch: *watch})
+	if app.IsService() {
+		return application.RunService()
+	}
 	return application.Run()
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/importer"
+	"http-mock-server/internal/storage"
+)
+
+// runRecord implements `http-mock-server record`: it proxies requests to a target and,
+// when interrupted, writes every exchange it saw as a request rule.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	target := fs.String("target", "", "Base URL of the API to record")
+	port := fs.Uint("port", 8080, "Port to listen on")
+	output := fs.String("o", "", "Configuration file to write (defaults to standard output)")
+	configPath := fs.String("config", "", "Configuration whose server.storage receives the -o file instead of the local file system")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: http-mock-server record --target <url> [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	u, err := url.Parse(*target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fs.Usage()
+		return fmt.Errorf("--target must be an absolute http or https URL")
+	}
+	var store storage.Store
+	if *configPath != "" {
+		cfg, err := config.LoadFile(*configPath)
+		if err != nil {
+			return err
+		}
+		if cfg.Server.Storage == nil {
+			return fmt.Errorf("%s doesn't configure server.storage", *configPath)
+		}
+		if err := storage.CheckKey(*output); err != nil {
+			return fmt.Errorf("-o: %w", err)
+		}
+		store = storage.New(cfg.Server.Storage)
+		if _, err := store.Get(context.Background(), *output); err == nil {
+			return fmt.Errorf("%s already exists in %s; choose another key or merge the output by hand", *output, store)
+		} else if !storage.IsNotExist(err) {
+			return err
+		}
+	} else if *output != "" {
+		if _, err := os.Stat(*output); err == nil {
+			return fmt.Errorf("%s already exists; choose another file or merge the output by hand", *output)
+		}
+	}
+
+	rec := importer.NewRecorder(u)
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", *port), Handler: rec}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	log.Printf("Recording requests to %s on port %d, press Ctrl+C to write the rules", *target, *port)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	file, err := rec.File()
+	if err != nil {
+		return err
+	}
+	data, err := file.Marshal()
+	if err != nil {
+		return err
+	}
+	switch {
+	case store != nil:
+		if err := store.Put(context.Background(), *output, data); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Recorded %d request(s) from %s into %s in %s\n", len(file.Requests), *target, *output, store)
+		return nil
+	case *output == "":
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Recorded %d request(s) from %s into %s\n", len(file.Requests), *target, *output)
+	return nil
+}
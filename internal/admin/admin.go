@@ -0,0 +1,511 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+// PathPrefix is the URL prefix under which the admin API is served
+const PathPrefix = "/__admin/"
+
+// maxConfigUploadBytes limits the size of configurations submitted to the admin API
+const maxConfigUploadBytes = 10 * 1024 * 1024
+
+// Handler serves the admin API for a running mock server
+type Handler struct {
+	mock  *handler.MockHandler
+	load  func() (*config.Config, error)
+	admin *config.Admin // Server settings at startup; reloads don't change them
+	mux   *http.ServeMux
+}
+
+// NewHandler creates an admin API handler. The load function re-reads the
+// configuration from its original source for reloads without a request body.
+func NewHandler(mock *handler.MockHandler, load func() (*config.Config, error)) *Handler {
+	h := &Handler{
+		mock:  mock,
+		load:  load,
+		admin: mock.Config().Server.Admin,
+		mux:   http.NewServeMux(),
+	}
+	h.mux.HandleFunc("POST "+PathPrefix+"reload", h.reload)
+	h.mux.HandleFunc("POST "+PathPrefix+"validate", h.validate)
+	h.mux.HandleFunc("GET "+PathPrefix+"scenarios", h.listScenarios)
+	h.mux.HandleFunc("PUT "+PathPrefix+"scenarios", h.replaceScenarios)
+	h.mux.HandleFunc("POST "+PathPrefix+"scenarios/reset", h.resetScenarios)
+	h.mux.HandleFunc("PUT "+PathPrefix+"scenarios/{name}", h.setScenario)
+	h.mux.HandleFunc("POST "+PathPrefix+"scenarios/{name}/reset", h.resetScenario)
+	h.mux.HandleFunc("POST "+PathPrefix+"calls/reset", h.resetCalls)
+	h.mux.HandleFunc("GET "+PathPrefix+"resources", h.listResources)
+	h.mux.HandleFunc("POST "+PathPrefix+"resources/reset", h.resetResources)
+	h.mux.HandleFunc("GET "+PathPrefix+"concurrency", h.concurrency)
+	h.mux.HandleFunc("POST "+PathPrefix+"concurrency/reset", h.resetConcurrency)
+	h.mux.HandleFunc("GET "+PathPrefix+"limits", h.limits)
+	h.mux.HandleFunc("GET "+PathPrefix+"mirror", h.mirror)
+	h.mux.HandleFunc("GET "+PathPrefix+"freshness", h.freshness)
+	h.mux.HandleFunc("GET "+PathPrefix+"golden", h.golden)
+	h.mux.HandleFunc("GET "+PathPrefix+"grpc-health", h.grpcHealth)
+	h.mux.HandleFunc("PUT "+PathPrefix+"grpc-health/{service...}", h.setGRPCHealth)
+	h.mux.HandleFunc("GET "+PathPrefix+"journal", h.listJournal)
+	h.mux.HandleFunc("GET "+PathPrefix+"journal/export", h.exportJournal)
+	h.mux.HandleFunc("POST "+PathPrefix+"journal/export", h.storeJournal)
+	h.mux.HandleFunc("POST "+PathPrefix+"journal/reset", h.resetJournal)
+	h.mux.HandleFunc("GET "+PathPrefix+"webhooks", h.listWebhooks)
+	h.mux.HandleFunc("GET "+PathPrefix+"webhooks/{id}", h.getWebhook)
+	h.mux.HandleFunc("POST "+PathPrefix+"webhooks/reset", h.resetWebhooks)
+	h.mux.HandleFunc("GET "+PathPrefix+"datasets", h.listDatasets)
+	h.mux.HandleFunc("GET "+PathPrefix+"datasets/{name}", h.getDataset)
+	h.mux.HandleFunc("PUT "+PathPrefix+"datasets/{name}", h.putDataset)
+	h.mux.HandleFunc("DELETE "+PathPrefix+"datasets/{name}", h.deleteDataset)
+	h.mux.HandleFunc("GET "+PathPrefix+"checkpoints", h.listCheckpoints)
+	h.mux.HandleFunc("PUT "+PathPrefix+"checkpoints/{name}", h.saveCheckpoint)
+	h.mux.HandleFunc("POST "+PathPrefix+"checkpoints/{name}/restore", h.restoreCheckpoint)
+	h.mux.HandleFunc("DELETE "+PathPrefix+"checkpoints/{name}", h.deleteCheckpoint)
+	h.mux.HandleFunc("GET "+PathPrefix+"storage/{key...}", h.getObject)
+	h.mux.HandleFunc("PUT "+PathPrefix+"storage/{key...}", h.putObject)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// reloadResponse is returned by the reload endpoint
+type reloadResponse struct {
+	DryRun   bool            `json:"dryRun"`
+	Applied  bool            `json:"applied"`
+	Diff     config.RuleDiff `json:"diff"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// reload replaces the active rule set with the configuration in the request body,
+// or with the configuration re-read from disk when the body is empty.
+// With ?dryRun=true the new configuration is validated and diffed but not applied.
+func (h *Handler) reload(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid dryRun value %q", v))
+			return
+		}
+	}
+
+	cfg, err := h.readConfig(r)
+	var refused *refusedConfigError
+	if errors.As(err, &refused) {
+		writeError(w, refused.status, err)
+		return
+	}
+	if err != nil {
+		writeConfigError(w, err)
+		return
+	}
+
+	resp := reloadResponse{
+		DryRun: dryRun,
+		Diff:   config.Diff(h.mock.Config(), cfg),
+	}
+	if resp.Diff.ServerChanged {
+		resp.Warnings = append(resp.Warnings, "server settings changed; restart required to apply them")
+	}
+
+	if !dryRun {
+		if err := h.mock.Reload(cfg); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		resp.Applied = true
+		log.Printf(
+			"Reloaded configuration: %d added, %d removed, %d changed, %d unchanged",
+			len(resp.Diff.Added), len(resp.Diff.Removed), len(resp.Diff.Changed), resp.Diff.Unchanged,
+		)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateResponse is returned by the validate endpoint
+type validateResponse struct {
+	Valid  bool                      `json:"valid"`
+	Rules  int                       `json:"rules"`
+	Errors []*config.ValidationError `json:"errors,omitempty"`
+}
+
+// validate checks the configuration in the request body, or the one on disk when the
+// body is empty, and lists every problem found without applying anything
+func (h *Handler) validate(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.readConfig(r)
+	var refused *refusedConfigError
+	if errors.As(err, &refused) {
+		writeError(w, refused.status, err)
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, validateResponse{Errors: validationErrors(err)})
+		return
+	}
+	writeJSON(w, http.StatusOK, validateResponse{Valid: true, Rules: len(cfg.Requests)})
+}
+
+// listScenarios returns the current state of every known scenario
+func (h *Handler) listScenarios(w http.ResponseWriter, r *http.Request) {
+	names := handler.ScenarioNames(h.mock.Config())
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"scenarios": h.mock.Scenarios().Snapshot(names),
+	})
+}
+
+// replaceScenarios stages every scenario at once: the listed scenarios move to their
+// states and all others return to their initial state. The body has the shape of the
+// list response, so a saved list can be put back.
+func (h *Handler) replaceScenarios(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Scenarios []handler.ScenarioState `json:"scenarios"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxConfigUploadBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	states := make(map[string]string, len(req.Scenarios))
+	for _, s := range req.Scenarios {
+		if s.Name == "" || s.State == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("every scenario needs a name and a state"))
+			return
+		}
+		if _, ok := states[s.Name]; ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("scenario %q is listed twice", s.Name))
+			return
+		}
+		states[s.Name] = s.State
+	}
+	h.mock.Scenarios().Replace(states)
+	log.Printf("Set %d scenarios and reset the others", len(states))
+	h.listScenarios(w, r)
+}
+
+// setScenario moves a single scenario to the given state
+func (h *Handler) setScenario(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.State == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("state is required"))
+		return
+	}
+	name := r.PathValue("name")
+	h.mock.Scenarios().SetState(name, req.State)
+	log.Printf("Set scenario %q to %q", name, req.State)
+	writeJSON(w, http.StatusOK, handler.ScenarioState{Name: name, State: req.State})
+}
+
+// resetScenarios returns all scenarios to their initial state
+func (h *Handler) resetScenarios(w http.ResponseWriter, r *http.Request) {
+	h.mock.Scenarios().Reset()
+	log.Println("Reset all scenarios")
+	h.listScenarios(w, r)
+}
+
+// resetScenario returns a single scenario to its initial state
+func (h *Handler) resetScenario(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	h.mock.Scenarios().ResetScenario(name)
+	log.Printf("Reset scenario %q", name)
+	writeJSON(w, http.StatusOK, handler.ScenarioState{Name: name, State: h.mock.Scenarios().State(name)})
+}
+
+// resetCalls restarts the onCall/afterCalls counters of all rules
+func (h *Handler) resetCalls(w http.ResponseWriter, r *http.Request) {
+	h.mock.ResetCallCounts()
+	log.Println("Reset call counters")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listResources reports how many items each resource collection holds
+func (h *Handler) listResources(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.mock.ResourceInfo()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resources": infos,
+	})
+}
+
+// resetResources returns every resource collection to its seed
+func (h *Handler) resetResources(w http.ResponseWriter, r *http.Request) {
+	h.mock.Resources().Reset()
+	log.Println("Reset resource collections")
+	h.listResources(w, r)
+}
+
+// concurrency reports how many requests each rule served at once
+func (h *Handler) concurrency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"rules": h.mock.ConcurrencyStats(),
+	})
+}
+
+// resetConcurrency clears the concurrency maxima and histograms
+func (h *Handler) resetConcurrency(w http.ResponseWriter, r *http.Request) {
+	h.mock.ResetConcurrencyStats()
+	log.Println("Reset concurrency statistics")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// limits reports usage against the server limits and how many requests were shed
+func (h *Handler) limits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.mock.LoadStats())
+}
+
+// mirror reports how many mirrored copies were sent, failed, or dropped
+func (h *Handler) mirror(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.mock.MirrorStats())
+}
+
+// freshness reports when each rule last changed and was last matched, and which
+// rules are stale
+func (h *Handler) freshness(w http.ResponseWriter, r *http.Request) {
+	cfg := h.mock.Config().Server.Freshness
+	if cfg == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("server freshness is not configured"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"staleAfterDays": cfg.StaleAfterDays, "rules": h.mock.Freshness()})
+}
+
+// golden lists the golden files whose latest response didn't match
+func (h *Handler) golden(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"update": h.mock.GoldenUpdating(), "mismatches": h.mock.GoldenMismatches()})
+}
+
+// grpcHealth returns the serving status of each gRPC health service
+func (h *Handler) grpcHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"services": h.mock.GRPCHealth()})
+}
+
+// setGRPCHealth changes a service's serving status from a {"status": ...} body.
+// The server as a whole is the empty service name.
+func (h *Handler) setGRPCHealth(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	service := r.PathValue("service")
+	if err := h.mock.SetGRPCHealth(service, req.Status); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	log.Printf("Set gRPC health of %q to %s", service, req.Status)
+	h.grpcHealth(w, r)
+}
+
+// listJournal returns the recorded requests as received
+func (h *Handler) listJournal(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entries": h.mock.Journal()})
+}
+
+// exportJournal returns the recorded requests anonymized for sharing
+func (h *Handler) exportJournal(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entries": h.mock.ExportJournal()})
+}
+
+// resetJournal clears the request journal
+func (h *Handler) resetJournal(w http.ResponseWriter, r *http.Request) {
+	h.mock.ResetJournal()
+	log.Println("Reset request journal")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listWebhooks returns the webhook delivery journal, optionally filtered by ?rule= and ?status=
+func (h *Handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	rule, status := r.URL.Query().Get("rule"), r.URL.Query().Get("status")
+	deliveries := []handler.WebhookDelivery{}
+	for _, d := range h.mock.WebhookDeliveries() {
+		if (rule == "" || d.Rule == rule) && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// getWebhook returns a single webhook delivery with its attempts
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	for _, d := range h.mock.WebhookDeliveries() {
+		if d.ID == id {
+			writeJSON(w, http.StatusOK, d)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("unknown webhook delivery %q", id))
+}
+
+// resetWebhooks clears the webhook delivery journal
+func (h *Handler) resetWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.mock.ResetWebhookDeliveries()
+	log.Println("Reset webhook deliveries")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) readConfig(r *http.Request) (*config.Config, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxConfigUploadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(data) > maxConfigUploadBytes {
+		return nil, fmt.Errorf("configuration exceeds %d bytes", maxConfigUploadBytes)
+	}
+	if len(data) == 0 {
+		return h.load()
+	}
+	if err := h.authorizeUpload(r); err != nil {
+		return nil, err
+	}
+	cfg, err := config.Parse(data, "request body")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubmitted(cfg); err != nil {
+		return nil, &refusedConfigError{status: http.StatusBadRequest, err: err}
+	}
+	return cfg, nil
+}
+
+// refusedConfigError reports a configuration the admin API won't take from a
+// request body, with the status to answer with
+type refusedConfigError struct {
+	status int
+	err    error
+}
+
+func (e *refusedConfigError) Error() string {
+	return e.err.Error()
+}
+
+// authorizeUpload lets a request submit a configuration only when server.admin
+// accepts them, and with the bearer token when one is set
+func (h *Handler) authorizeUpload(r *http.Request) error {
+	a := h.admin
+	if a == nil || !a.AcceptConfigs {
+		return &refusedConfigError{status: http.StatusForbidden, err: fmt.Errorf("configurations in the request body are disabled, set server.admin.acceptConfigs")}
+	}
+	if token := a.Token.Value(); token != "" {
+		given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return &refusedConfigError{status: http.StatusUnauthorized, err: fmt.Errorf("a valid admin token is required to submit a configuration")}
+		}
+	}
+	return nil
+}
+
+// checkSubmitted refuses settings of a configuration from a request body that would
+// let the request run code or read files anywhere on the server. Transformer plugins
+// run their init code when they are opened, so they may only come from
+// configurations on disk, and files must be relative paths that stay below the
+// working directory or storage.
+func checkSubmitted(cfg *config.Config) error {
+	var paths []string
+	if s := cfg.Server.Storage; s != nil && s.Dir != "" {
+		paths = append(paths, s.Dir)
+	}
+	for _, path := range cfg.Datasets {
+		paths = append(paths, path)
+	}
+	for _, path := range cfg.Partials {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		if err := checkSubmittedPath(path); err != nil {
+			return err
+		}
+	}
+
+	for i := range cfg.Requests {
+		rule := &cfg.Requests[i]
+		paths := []string{rule.BodySchema}
+		if rule.Assert != nil {
+			paths = append(paths, rule.Assert.BodySchema)
+		}
+		for _, spec := range rule.AllResponses() {
+			if t := spec.Transformer; t != nil {
+				return fmt.Errorf("request rule %d: transformer plugin %s cannot be submitted to the admin API, load it from the configuration file", i, t.Plugin)
+			}
+			paths = append(paths, spec.BodyFile, spec.Golden)
+			if spec.SchemaBody != nil {
+				schema, _, _ := strings.Cut(spec.SchemaBody.Schema, "#")
+				paths = append(paths, schema)
+			}
+		}
+		for _, path := range paths {
+			if err := checkSubmittedPath(path); err != nil {
+				return fmt.Errorf("request rule %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSubmittedPath rejects absolute paths and paths with .. segments
+func checkSubmittedPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || strings.HasPrefix(path, `\`) || filepath.VolumeName(path) != "" {
+		return fmt.Errorf("file %s cannot be submitted to the admin API, use a relative path", path)
+	}
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return fmt.Errorf("file %s cannot be submitted to the admin API, use a path without .. segments", path)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("Error writing admin response: %v", err)
+	}
+}
+
+// validationErrors lists the problems of a configuration that failed to load. Errors
+// that aren't about validation, such as YAML syntax errors, become a single problem.
+func validationErrors(err error) []*config.ValidationError {
+	var errs config.ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return []*config.ValidationError{{Rule: -1, Reason: err.Error(), Message: err.Error()}}
+}
+
+// writeConfigError reports a configuration that failed to load, listing its problems
+func writeConfigError(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error(), "errors": validationErrors(err)})
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,416 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+const reloadedConfig = `
+requests:
+  - path: /a
+    response:
+      status-code: 200
+  - path: /new
+    method: POST
+    response:
+      status-code: 201
+`
+
+// testToken is the admin token of newTestAdmin
+const testToken = "test-token"
+
+func newTestAdmin(t *testing.T) (*Handler, *handler.MockHandler) {
+	t.Helper()
+	cfg, err := config.Parse([]byte("server:\n  admin: {acceptConfigs: true, token: "+testToken+"}\nrequests:\n  - path: /a\n  - path: /old\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	return NewHandler(mock, func() (*config.Config, error) {
+		return config.Parse([]byte(reloadedConfig), "disk")
+	}), mock
+}
+
+func doReload(t *testing.T, h http.Handler, query, body string) (*httptest.ResponseRecorder, reloadResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/__admin/reload"+query, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var resp reloadResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON response: %v", err)
+		}
+	}
+	return rr, resp
+}
+
+func TestReload_DryRunDoesNotApply(t *testing.T) {
+	h, mock := newTestAdmin(t)
+
+	rr, resp := doReload(t, h, "?dryRun=true", reloadedConfig)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !resp.DryRun || resp.Applied {
+		t.Fatalf("expected dry run without apply, got %+v", resp)
+	}
+	if strings.Join(resp.Diff.Added, ",") != "POST /new" || strings.Join(resp.Diff.Removed, ",") != "GET /old" {
+		t.Fatalf("unexpected diff: %+v", resp.Diff)
+	}
+	if len(mock.Config().Requests) != 2 || mock.Config().Requests[1].Path != "/old" {
+		t.Fatal("dry run must not change the active configuration")
+	}
+}
+
+func TestReload_AppliesBodyConfig(t *testing.T) {
+	h, mock := newTestAdmin(t)
+
+	rr, resp := doReload(t, h, "", reloadedConfig)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !resp.Applied {
+		t.Fatalf("expected reload to be applied, got %+v", resp)
+	}
+
+	res := httptest.NewRecorder()
+	mock.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/new", nil))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected reloaded rule to respond %d, got %d", http.StatusCreated, res.Code)
+	}
+}
+
+func TestReload_EmptyBodyReadsFromSource(t *testing.T) {
+	h, mock := newTestAdmin(t)
+
+	rr, _ := doReload(t, h, "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if got := mock.Config().Requests[1].Path; got != "/new" {
+		t.Fatalf("expected configuration from source to be applied, got rule path %q", got)
+	}
+}
+
+func TestReload_InvalidConfigKeepsCurrent(t *testing.T) {
+	h, mock := newTestAdmin(t)
+
+	rr, _ := doReload(t, h, "", "requests:\n  - method: GET\n")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "path is required") {
+		t.Fatalf("expected validation error in body, got %s", rr.Body.String())
+	}
+	if len(mock.Config().Requests) != 2 {
+		t.Fatal("invalid configuration must not replace the active one")
+	}
+}
+
+func TestReload_RefusesTransformerPlugins(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	// Not a real plugin, so opening it would fail with a different error
+	plugin := filepath.Join(t.TempDir(), "evil.so")
+	if err := os.WriteFile(plugin, []byte("not a plugin"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	body := "requests:\n  - path: /run\n    response:\n      transformer:\n        plugin: " + plugin + "\n"
+
+	for _, path := range []string{"/__admin/reload", "/__admin/reload?dryRun=true", "/__admin/validate"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status %d, got %d: %s", path, http.StatusBadRequest, rr.Code, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), "cannot be submitted to the admin API") || strings.Contains(rr.Body.String(), "plugin.Open") {
+			t.Fatalf("%s: expected the plugin to be refused without opening it, got %s", path, rr.Body.String())
+		}
+	}
+	if len(mock.Config().Requests) != 2 {
+		t.Fatal("a refused configuration must not replace the active one")
+	}
+}
+
+func TestReload_RequiresAuthorizedUploads(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	for _, token := range []string{"", "wrong"} {
+		req := httptest.NewRequest(http.MethodPost, "/__admin/reload", strings.NewReader(reloadedConfig))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected status %d, got %d: %s", token, http.StatusUnauthorized, rr.Code, rr.Body.String())
+		}
+	}
+	if len(mock.Config().Requests) != 2 {
+		t.Fatal("an unauthorized configuration must not replace the active one")
+	}
+
+	// Without acceptConfigs only the configuration on disk can be reloaded
+	cfg, err := config.Parse([]byte("requests:\n  - path: /a\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closed := NewHandler(handler.NewMockHandler(cfg), func() (*config.Config, error) {
+		return config.Parse([]byte(reloadedConfig), "disk")
+	})
+	if rr, _ := doReload(t, closed, "", reloadedConfig); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusForbidden, rr.Code, rr.Body.String())
+	}
+	if rr, _ := doReload(t, closed, "", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected a reload from disk, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReload_RefusesPathsOutsideTheServer(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	for _, body := range []string{
+		"requests:\n  - path: /a\n    response:\n      bodyFile: /etc/passwd\n",
+		"requests:\n  - path: /a\n    response:\n      bodyFile: ../../go.mod\n",
+		"requests:\n  - path: /a\n    response:\n      download: {filename: passwd, file: /etc/passwd}\n",
+		"server:\n  storage: {dir: /etc}\nrequests:\n  - path: /a\n    response:\n      bodyFile: passwd\n",
+	} {
+		rr, _ := doReload(t, h, "", body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "cannot be submitted to the admin API") {
+			t.Fatalf("%s: expected status %d, got %d: %s", body, http.StatusBadRequest, rr.Code, rr.Body.String())
+		}
+	}
+	if len(mock.Config().Requests) != 2 {
+		t.Fatal("a refused configuration must not replace the active one")
+	}
+}
+
+func TestReload_InvalidDryRunValue(t *testing.T) {
+	h, _ := newTestAdmin(t)
+
+	rr, _ := doReload(t, h, "?dryRun=maybe", reloadedConfig)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestScenarios_ListAndReset(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /toggle
+    method: POST
+    scenario: light
+    newState: "on"
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/toggle", nil))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/scenarios", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"state": "on"`) {
+		t.Fatalf("expected scenario light in state on, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/__admin/scenarios/light/reset", nil))
+	if rr.Code != http.StatusOK || mock.Scenarios().State("light") != handler.ScenarioStarted {
+		t.Fatalf("expected scenario reset, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/toggle", nil))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/__admin/scenarios/reset", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"state": "Started"`) {
+		t.Fatalf("expected all scenarios reset, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGRPCHealth_Set(t *testing.T) {
+	cfg, err := config.Parse([]byte("server:\n  grpcHealth: {}\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/grpc-health/", strings.NewReader(`{"status": "NOT_SERVING"}`)))
+	if rr.Code != http.StatusOK || mock.GRPCHealth()[""] != config.HealthNotServing {
+		t.Fatalf("expected the server to be NOT_SERVING, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/grpc-health/orders.v1.Orders", strings.NewReader(`{"status": "SERVING"}`)))
+	if rr.Code != http.StatusOK || mock.GRPCHealth()["orders.v1.Orders"] != config.HealthServing {
+		t.Fatalf("expected orders.v1.Orders to be added as SERVING, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/grpc-health/x", strings.NewReader(`{"status": "DOWN"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid status, got %d", rr.Code)
+	}
+}
+
+func TestResources_ListAndReset(t *testing.T) {
+	cfg, err := config.Parse([]byte("requests:\n  - path: /notes\n    resource: {}\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	mock.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(`{"text": "hi"}`)))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/resources", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"items": 1`) {
+		t.Fatalf("expected one note, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/__admin/resources/reset", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"items": 0`) {
+		t.Fatalf("expected the notes to be reset, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestScenarios_SetAndReplace(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+scenarios:
+  door: closed
+requests:
+  - path: /toggle
+    method: POST
+    scenario: light
+    newState: "on"
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/scenarios", nil))
+	if !strings.Contains(rr.Body.String(), `"name": "door",`+"\n"+`      "state": "closed"`) {
+		t.Fatalf("expected door to start closed, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/scenarios/light", strings.NewReader(`{"state": "dimmed"}`)))
+	if rr.Code != http.StatusOK || mock.Scenarios().State("light") != "dimmed" {
+		t.Fatalf("expected light to be dimmed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/scenarios", strings.NewReader(`{"scenarios": [{"name": "door", "state": "open"}]}`)))
+	if rr.Code != http.StatusOK || mock.Scenarios().State("door") != "open" || mock.Scenarios().State("light") != handler.ScenarioStarted {
+		t.Fatalf("expected door open and light reset, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, body := range []string{`{"state": ""}`, `not json`} {
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/scenarios/light", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/scenarios", strings.NewReader(`{"scenarios": [{"name": "door", "state": "a"}, {"name": "door", "state": "b"}]}`)))
+	if rr.Code != http.StatusBadRequest || mock.Scenarios().State("door") != "open" {
+		t.Fatalf("expected a duplicate to be rejected without changes, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	validate := func(body string) (*httptest.ResponseRecorder, validateResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/__admin/validate", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		var resp validateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON response: %v", err)
+		}
+		return rr, resp
+	}
+
+	rr, resp := validate(reloadedConfig)
+	if rr.Code != http.StatusOK || !resp.Valid || resp.Rules != 2 {
+		t.Fatalf("expected a valid configuration with 2 rules, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr, resp = validate(""); rr.Code != http.StatusOK || !resp.Valid {
+		t.Fatalf("expected the configuration on disk to be valid, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr, resp = validate("requests:\n  - path: /a\n    weight: -1\n  - path: /b\n    response: {status-code: 999}\n")
+	if rr.Code != http.StatusUnprocessableEntity || resp.Valid || len(resp.Errors) != 2 {
+		t.Fatalf("expected two problems, got %d %s", rr.Code, rr.Body.String())
+	}
+	if e := resp.Errors[1]; e.Rule != 1 || e.RuleKey != "GET /b" || e.Reason != "invalid status code 999" {
+		t.Fatalf("unexpected problem %+v", e)
+	}
+
+	rr, resp = validate("requests: [")
+	if rr.Code != http.StatusUnprocessableEntity || len(resp.Errors) != 1 || resp.Errors[0].Rule != -1 {
+		t.Fatalf("expected one problem for invalid YAML, got %d %s", rr.Code, rr.Body.String())
+	}
+	if len(mock.Config().Requests) != 2 || mock.Config().Requests[1].Path != "/old" {
+		t.Fatal("expected validation to leave the active configuration alone")
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/freshness", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without server freshness, got %d", rr.Code)
+	}
+
+	cfg, err := config.Parse([]byte("server:\n  freshness: {}\nrequests:\n  - path: /a\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.Reload(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/freshness", nil))
+	var resp struct {
+		StaleAfterDays int                     `json:"staleAfterDays"`
+		Rules          []handler.RuleFreshness `json:"rules"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if rr.Code != http.StatusOK || resp.StaleAfterDays != 30 || len(resp.Rules) != 1 || resp.Rules[0].Rule != "GET /a" || resp.Rules[0].Stale {
+		t.Fatalf("unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGolden(t *testing.T) {
+	h, _ := newTestAdmin(t)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/__admin/golden", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"update": false`) || !strings.Contains(rr.Body.String(), `"mismatches": []`) {
+		t.Fatalf("unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+}
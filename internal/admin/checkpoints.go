@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// listCheckpoints describes every saved checkpoint
+func (h *Handler) listCheckpoints(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"checkpoints": h.mock.CheckpointInfo(),
+	})
+}
+
+// saveCheckpoint copies the current scenario states, resources, uploaded datasets,
+// and call counts under the name, replacing an earlier checkpoint of that name
+func (h *Handler) saveCheckpoint(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	info, replaced, err := h.mock.SaveCheckpoint(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status := http.StatusCreated
+	if replaced {
+		status = http.StatusOK
+	}
+	log.Printf("Saved checkpoint %q", name)
+	writeJSON(w, status, info)
+}
+
+// restoreCheckpoint returns the mock to the state saved under the name
+func (h *Handler) restoreCheckpoint(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	ok, err := h.mock.RestoreCheckpoint(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no checkpoint %q", name))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	log.Printf("Restored checkpoint %q", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteCheckpoint discards a saved checkpoint
+func (h *Handler) deleteCheckpoint(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !h.mock.DeleteCheckpoint(name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no checkpoint %q", name))
+		return
+	}
+	log.Printf("Deleted checkpoint %q", name)
+	w.WriteHeader(http.StatusNoContent)
+}
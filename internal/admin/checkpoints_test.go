@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckpoints(t *testing.T) {
+	h, mock := newTestAdmin(t)
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader("")))
+		return rr
+	}
+
+	mock.Scenarios().SetState("door", "open")
+	if rr := do(http.MethodPut, "/__admin/checkpoints/after-login"); rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"scenarios": 1`) {
+		t.Fatalf("expected the checkpoint to be created, got %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPut, "/__admin/checkpoints/after-login"); rr.Code != http.StatusOK {
+		t.Fatalf("expected the checkpoint to be replaced, got %d", rr.Code)
+	}
+
+	mock.Scenarios().SetState("door", "closed")
+	if rr := do(http.MethodPost, "/__admin/checkpoints/after-login/restore"); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d %s", rr.Code, rr.Body.String())
+	}
+	if got := mock.Scenarios().State("door"); got != "open" {
+		t.Fatalf("expected the door open again, got %s", got)
+	}
+	if rr := do(http.MethodPost, "/__admin/checkpoints/missing/restore"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+
+	var list struct {
+		Checkpoints []struct{ Name string } `json:"checkpoints"`
+	}
+	rr := do(http.MethodGet, "/__admin/checkpoints")
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list.Checkpoints) != 1 || list.Checkpoints[0].Name != "after-login" {
+		t.Fatalf("unexpected list %s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodDelete, "/__admin/checkpoints/after-login"); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/__admin/checkpoints/after-login"); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// maxDatasetUploadBytes limits the size of datasets uploaded to the admin API
+const maxDatasetUploadBytes = 50 * 1024 * 1024
+
+// listDatasets describes every configured and uploaded dataset
+func (h *Handler) listDatasets(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"datasets": h.mock.DatasetInfo(),
+	})
+}
+
+// getDataset returns the current content of a dataset as JSON
+func (h *Handler) getDataset(w http.ResponseWriter, r *http.Request) {
+	data, err := h.mock.Dataset(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// putDataset replaces a dataset with the JSON or YAML content of the request body.
+// The content is validated before it replaces the current dataset.
+func (h *Handler) putDataset(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDatasetUploadBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+	if len(body) > maxDatasetUploadBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("dataset exceeds %d bytes", maxDatasetUploadBytes))
+		return
+	}
+	data, err := config.ParseDataset(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.mock.Datasets().Set(name, data, body, time.Now())
+	log.Printf("Uploaded dataset %q (%d bytes)", name, len(body))
+	h.listDatasets(w, r)
+}
+
+// deleteDataset discards an uploaded dataset, reverting to the configured file if any
+func (h *Handler) deleteDataset(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !h.mock.Datasets().Delete(name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no uploaded dataset %q", name))
+		return
+	}
+	log.Printf("Deleted uploaded dataset %q", name)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+func TestDatasets_UploadReplacesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(`[{"name": "ada"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+datasets:
+  users: `+path+`
+requests:
+  - path: /users
+    response:
+      dataset: users
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		if strings.HasPrefix(target, PathPrefix) {
+			h.ServeHTTP(rr, req)
+		} else {
+			mock.ServeHTTP(rr, req)
+		}
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/users", ""); rr.Body.String() != `[{"name":"ada"}]` {
+		t.Fatalf("expected file dataset, got %s", rr.Body.String())
+	}
+
+	rr := do(http.MethodPut, "/__admin/datasets/users", "- name: grace\n- name: linus\n")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"source": "upload"`) {
+		t.Fatalf("expected upload to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users", ""); rr.Body.String() != `[{"name":"grace"},{"name":"linus"}]` {
+		t.Fatalf("expected uploaded dataset, got %s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodPut, "/__admin/datasets/users", "{not: [valid"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid upload to be rejected, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/__admin/datasets/users", ""); !strings.Contains(rr.Body.String(), "grace") {
+		t.Fatalf("expected rejected upload to keep previous content, got %s", rr.Body.String())
+	}
+
+	if rr := do(http.MethodDelete, "/__admin/datasets/users", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr := do(http.MethodGet, "/users", ""); rr.Body.String() != `[{"name":"ada"}]` {
+		t.Fatalf("expected file dataset after delete, got %s", rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, "/__admin/datasets/users", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr := do(http.MethodGet, "/__admin/datasets/missing", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestDatasets_UploadServedByBodyFileAndResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(`[{"id": 1, "name": "ada"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+datasets:
+  users: `+path+`
+requests:
+  - path: /users.json
+    response:
+      bodyFile: `+path+`
+  - path: /users
+    resource:
+      seed: users
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		if strings.HasPrefix(target, PathPrefix) {
+			h.ServeHTTP(rr, req)
+		} else {
+			mock.ServeHTTP(rr, req)
+		}
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/users/1", ""); !strings.Contains(rr.Body.String(), "ada") {
+		t.Fatalf("expected the resource seeded from the file, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	upload := `[{"id": 7, "name": "grace"}]`
+	if rr := do(http.MethodPut, "/__admin/datasets/users", upload); rr.Code != http.StatusOK {
+		t.Fatalf("expected upload to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users.json", ""); rr.Code != http.StatusOK || rr.Body.String() != upload {
+		t.Fatalf("expected bodyFile to serve the upload, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users/7", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "grace") {
+		t.Fatalf("expected the resource to be seeded from the upload, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users/1", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected the file's items to be gone, got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodDelete, "/__admin/datasets/users", ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr := do(http.MethodGet, "/users.json", ""); rr.Body.String() != `[{"id": 1, "name": "ada"}]` {
+		t.Fatalf("expected bodyFile to serve the file after delete, got %s", rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users/1", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected the resource to be seeded from the file after delete, got %d", rr.Code)
+	}
+}
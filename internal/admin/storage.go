@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/storage"
+)
+
+// maxStorageUploadBytes limits the size of objects uploaded to the admin API
+const maxStorageUploadBytes = 512 * 1024 * 1024
+
+// storageKey returns the key named in the path, or writes an error when server.storage
+// isn't configured or the key is invalid
+func (h *Handler) storageKey(w http.ResponseWriter, key string) (string, bool) {
+	if h.mock.Config().Server.Storage == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("server storage is not configured"))
+		return "", false
+	}
+	if err := storage.CheckKey(key); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	return key, true
+}
+
+// storageWritable writes an error unless the admin API may write to the configured
+// storage. Local storage without a dir would write below the working directory,
+// where the server's own configuration and binary usually live.
+func (h *Handler) storageWritable(w http.ResponseWriter) bool {
+	if st := h.mock.Config().Server.Storage; st.Type == config.StorageLocal && st.Dir == "" {
+		writeError(w, http.StatusForbidden, fmt.Errorf("writing to local storage needs server.storage.dir"))
+		return false
+	}
+	return true
+}
+
+// getObject returns an object from the configured storage
+func (h *Handler) getObject(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.storageKey(w, r.PathValue("key"))
+	if !ok {
+		return
+	}
+	obj, err := h.mock.Store().Get(r.Context(), key)
+	if storage.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no object %q", key))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if !obj.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+	}
+	_, _ = w.Write(obj.Data)
+}
+
+// putObject writes the request body to the configured storage, e.g. to seed body
+// files in memory storage
+func (h *Handler) putObject(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.storageKey(w, r.PathValue("key"))
+	if !ok || !h.storageWritable(w) {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStorageUploadBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+	if len(body) > maxStorageUploadBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("object exceeds %d bytes", maxStorageUploadBytes))
+		return
+	}
+	store := h.mock.Store()
+	if err := store.Put(r.Context(), key, body); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	log.Printf("Stored %s in %s (%d bytes)", key, store, len(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// storeJournal writes the anonymized journal export to the configured storage under
+// the key given by ?key=
+func (h *Handler) storeJournal(w http.ResponseWriter, r *http.Request) {
+	key, ok := h.storageKey(w, r.URL.Query().Get("key"))
+	if !ok || !h.storageWritable(w) {
+		return
+	}
+	entries := h.mock.ExportJournal()
+	data, err := json.MarshalIndent(map[string]interface{}{"entries": entries}, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	store := h.mock.Store()
+	if err := store.Put(r.Context(), key, data); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	log.Printf("Stored journal export of %d requests as %s in %s", len(entries), key, store)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"key": key, "entries": len(entries)})
+}
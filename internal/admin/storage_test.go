@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+func TestStorage_PutGetAndExportJournal(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  storage:
+    type: memory
+  journal: {}
+requests:
+  - path: /users
+    response:
+      bodyFile: users.json
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	h := NewHandler(mock, nil)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		if strings.HasPrefix(target, PathPrefix) {
+			h.ServeHTTP(rr, req)
+		} else {
+			mock.ServeHTTP(rr, req)
+		}
+		return rr
+	}
+
+	if rr := do(http.MethodGet, "/__admin/storage/users.json", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing object, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPut, "/__admin/storage/users.json", `["ada"]`); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected upload to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/users", ""); rr.Code != http.StatusOK || rr.Body.String() != `["ada"]` {
+		t.Fatalf("expected the uploaded body file, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/__admin/storage/users.json", ""); rr.Code != http.StatusOK || rr.Body.String() != `["ada"]` {
+		t.Fatalf("expected the stored object, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPut, "/__admin/storage/a/../../etc/passwd", "x"); rr.Code == http.StatusNoContent {
+		t.Fatalf("expected a key with .. segments to be rejected")
+	}
+
+	rr := do(http.MethodPost, "/__admin/journal/export?key=exports/run.json", "")
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"entries": 1`) {
+		t.Fatalf("expected the export to be stored, got %d: %s", rr.Code, rr.Body.String())
+	}
+	obj, err := mock.Store().Get(context.Background(), "exports/run.json")
+	if err != nil || !strings.Contains(string(obj.Data), `"/users"`) {
+		t.Fatalf("unexpected stored export %q, %v", obj.Data, err)
+	}
+	if rr := do(http.MethodPost, "/__admin/journal/export", ""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected a missing key to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestStorage_NotConfigured(t *testing.T) {
+	h, _ := newTestAdmin(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/__admin/storage/config.yaml", nil),
+		httptest.NewRequest(http.MethodPut, "/__admin/storage/config.yaml", strings.NewReader("x")),
+		httptest.NewRequest(http.MethodPost, "/__admin/journal/export?key=run.json", nil),
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected 404 without server.storage, got %d", req.Method, req.URL, rr.Code)
+		}
+	}
+}
+
+func TestStorage_LocalWritesNeedDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		storage string
+		status  int
+	}{
+		{storage: "{type: local}", status: http.StatusForbidden},
+		{storage: "{type: local, dir: " + dir + "}", status: http.StatusNoContent},
+	} {
+		cfg, err := config.Parse([]byte("server:\n  storage: "+tt.storage+"\n  journal: {}\nrequests:\n  - path: /a\n"), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		h := NewHandler(handler.NewMockHandler(cfg), nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/__admin/storage/config/config.yaml", strings.NewReader("requests: []")))
+		if rr.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.storage, tt.status, rr.Code, rr.Body.String())
+		}
+		if tt.status == http.StatusForbidden {
+			rr = httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/__admin/journal/export?key=config/config.yaml", nil))
+			if rr.Code != http.StatusForbidden {
+				t.Fatalf("expected the journal export to be refused, got %d: %s", rr.Code, rr.Body.String())
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config", "config.yaml")); err != nil {
+		t.Fatalf("expected the upload in the storage dir: %v", err)
+	}
+}
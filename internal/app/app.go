@@ -6,52 +6,173 @@ import (
 	"fmt"
 	"http-mock-server/pkg/version"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"http-mock-server/internal/admin"
 	"http-mock-server/internal/config"
 	"http-mock-server/internal/handler"
 )
 
+// Options override configuration settings from the command line
+type Options struct {
+	ConfigPath string // Configuration file or directory to load instead of searching for one
+	Port       uint   // Replaces server.port and server.portRange when set
+	PortRange  string // Replaces server.port and server.portRange when set
+	PIDFile    string // Holds the process ID while the server runs
+
+	Interactive  bool // Run the console on stdin and stdout to watch requests and answer held ones
+	UpdateGolden bool // Write rendered bodies to golden files instead of comparing them
+	Watch        bool // Reload the rules whenever the configuration file or directory changes
+}
+
 // App represents the application
 type App struct {
+	opts   Options
+	port   int // Port the server is listening on
 	config *config.Config
 	server *http.Server
+	mock   *handler.MockHandler
+
+	// signals receives the shutdown and reload signals, and the Windows service
+	// control requests translated into them
+	signals chan os.Signal
 }
 
 // New creates a new application instance
-func New() *App {
-	return &App{}
+func New(opts Options) *App {
+	return &App{opts: opts, signals: make(chan os.Signal, 1)}
 }
 
 // Run starts the application
 func (a *App) Run() error {
 	log.Printf("Starting HTTP mock server %s\n", version.Version)
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.LoadFrom(a.opts.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 	a.config = cfg
 
+	var changes <-chan struct{}
+	if a.opts.Watch {
+		watcher, err := watchConfig(cfg.Source)
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+		changes = watcher.Changes()
+		log.Printf("Watching %s for changes\n", cfg.Source)
+	}
+
+	// Bind before serving so startup checks can connect immediately
+	server := cfg.Server
+	if a.opts.Port != 0 {
+		server.Port, server.PortRange = a.opts.Port, ""
+	}
+	if a.opts.PortRange != "" {
+		server.PortRange = a.opts.PortRange
+	}
+	listener, err := listen(server)
+	if err != nil {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	a.port = listener.Addr().(*net.TCPAddr).Port
+
+	if path := a.opts.PIDFile; path != "" {
+		if err := writePIDFile(path); err != nil {
+			_ = listener.Close()
+			return err
+		}
+		defer os.Remove(path)
+	}
+
 	// Setup HTTP server
 	a.setupServer()
+	var cons *console
+	if a.opts.Interactive {
+		cons = newConsole(a.mock, os.Stdout)
+	}
 
-	// Start server in goroutine
-	serverErr := make(chan error, 1)
-	go func() {
-		log.Printf("Listening on port %d\n", cfg.Server.Port)
-		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			serverErr <- fmt.Errorf("server failed: %w", err)
+	// Audience ports share the server, and its handlers tell them apart by local address
+	listeners := []net.Listener{listener}
+	if aud := cfg.Server.Audiences; aud != nil {
+		for name, port := range aud.Ports {
+			l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+			if err != nil {
+				for _, l := range listeners {
+					_ = l.Close()
+				}
+				return fmt.Errorf("server failed: audience %q: %w", name, err)
+			}
+			log.Printf("Serving audience %q on port %d\n", name, port)
+			listeners = append(listeners, l)
 		}
-		close(serverErr)
-	}()
+	}
+
+	// Start serving in goroutines
+	serverErr := make(chan error, len(listeners))
+	useTLS := a.config.Server.TLS != nil // Serve may fill in TLSConfig for HTTP/2
+	if useTLS {
+		log.Printf("Listening on port %d (HTTPS)\n", a.port)
+	} else {
+		log.Printf("Listening on port %d\n", a.port)
+	}
+	var routes strings.Builder
+	_ = cfg.WriteRoutes(&routes)
+	log.Printf("Serving %d request rules:\n%s", len(cfg.Requests), strings.TrimSuffix(routes.String(), "\n"))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			var err error
+			if useTLS {
+				err = a.server.ServeTLS(l, "", "")
+			} else {
+				err = a.server.Serve(l)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("server failed: %w", err)
+			}
+		}(l)
+	}
+
+	if err := a.runStartupChecks(); err != nil {
+		_ = a.server.Close()
+		return err
+	}
+	if cons != nil {
+		go cons.run(os.Stdin)
+	}
 
 	// Wait for shutdown signal or server error
-	return a.waitForShutdown(serverErr)
+	return a.waitForShutdown(serverErr, changes)
+}
+
+// runStartupChecks verifies the configured startup checks against the running server.
+// Scenario states, call counters, and seq counters advanced by the checks are reset afterwards.
+func (a *App) runStartupChecks() error {
+	if len(a.config.StartupChecks) == 0 {
+		return nil
+	}
+	scheme := "http"
+	if a.config.Server.TLS != nil {
+		scheme = "https"
+	}
+	baseURL := fmt.Sprintf("%s://127.0.0.1:%d", scheme, a.port)
+	if err := runStartupChecks(baseURL, a.config.StartupChecks); err != nil {
+		return err
+	}
+	if err := goldenFailures(a.mock.GoldenMismatches()); err != nil {
+		return err
+	}
+	a.mock.Scenarios().Reset()
+	a.mock.ResetCallCounts()
+	a.mock.ResetSequences()
+	return nil
 }
 
 func (a *App) setupServer() {
@@ -66,26 +187,92 @@ func (a *App) setupServer() {
 	)
 
 	// Add mock handler
+	acl := a.config.Server.AccessControl
 	mockHandler := handler.NewMockHandler(a.config)
-	mux.Handle("/", handler.LoggingMiddleware(mockHandler))
+	a.mock = mockHandler
+	if a.opts.UpdateGolden {
+		mockHandler.UpdateGolden()
+	}
+	mockChain := handler.DecompressionMiddleware(handler.LoggingMiddleware(mockHandler))
+	mux.Handle("/", handler.AccessControlMiddleware(acl, mockHandler.LimitsMiddleware(mockHandler.MirrorMiddleware(mockChain))))
+
+	// Add the gRPC health service
+	if a.config.Server.GRPCHealth != nil {
+		mux.Handle(handler.GRPCHealthPrefix, handler.AccessControlMiddleware(acl, mockHandler.GRPCHealthHandler()))
+	}
+
+	// Add the Prometheus metrics endpoint
+	if m := a.config.Server.Metrics; m != nil {
+		mux.Handle("GET "+m.Path, handler.AccessControlMiddleware(acl, mockHandler.MetricsHandler()))
+	}
+
+	// Add admin API
+	source := a.config.Source
+	adminHandler := admin.NewHandler(mockHandler, func() (*config.Config, error) {
+		return config.LoadSource(source)
+	})
+	mux.Handle(admin.PathPrefix, handler.AccessControlMiddleware(acl, adminHandler))
 
 	a.server = &http.Server{
-		Addr:        fmt.Sprintf(":%d", a.config.Server.Port),
+		Addr:        fmt.Sprintf(":%d", a.port),
 		Handler:     mux,
 		ReadTimeout: 15 * time.Second,
 		IdleTimeout: 60 * time.Second,
 	}
+	if t := a.config.Server.TLS; t != nil {
+		a.server.TLSConfig = t.Config.Clone()
+	}
+	if a.config.Server.GRPCHealth != nil && a.config.Server.TLS == nil && !enableH2C(a.server) {
+		log.Println("gRPC health checks need server.tls in builds before Go 1.24")
+	}
+}
+
+// reload replaces the rules with the configuration re-read from its source file and
+// logs which rules changed. The current rules stay active if it is invalid; the
+// listeners are untouched either way, so requests in flight finish normally.
+func (a *App) reload() {
+	cfg, err := config.LoadSource(a.config.Source)
+	if err != nil {
+		log.Printf("Reload failed, keeping current rules: %v", err)
+		return
+	}
+	diff := config.Diff(a.mock.Config(), cfg)
+	if err := a.mock.Reload(cfg); err != nil {
+		log.Printf("Reload failed, keeping current rules: %v", err)
+		return
+	}
+	log.Printf(
+		"Reloaded configuration from %s: %d added, %d removed, %d changed, %d unchanged",
+		a.config.Source, len(diff.Added), len(diff.Removed), len(diff.Changed), diff.Unchanged,
+	)
+	if diff.ServerChanged {
+		log.Println("Server settings changed; restart required to apply them")
+	}
 }
 
-func (a *App) waitForShutdown(serverErr <-chan error) error {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// waitForShutdown serves until SIGINT or SIGTERM arrives or the server fails.
+// SIGHUP reloads the rules from the configuration file, like the admin API, and so
+// do changes to the file when it is watched.
+func (a *App) waitForShutdown(serverErr <-chan error, changes <-chan struct{}) error {
+	sigChan := a.signals
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
 
-	select {
-	case err := <-serverErr:
-		return err
-	case sig := <-sigChan:
-		log.Printf("Received signal %v, shutting down...", sig)
+	for {
+		select {
+		case err := <-serverErr:
+			return err
+		case <-changes:
+			a.reload()
+			continue
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				a.reload()
+				continue
+			}
+			log.Printf("Received signal %v, shutting down...", sig)
+		}
+		break
 	}
 
 	// Graceful shutdown
@@ -95,6 +282,9 @@ func (a *App) waitForShutdown(serverErr <-chan error) error {
 	if err := a.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
+	if err := a.mock.SaveFreshness(ctx); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
 
 	log.Println("Server stopped gracefully")
 	return nil
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("requests:\n  - path: /a\n  - path: /b\n")
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{config: cfg, mock: handler.NewMockHandler(cfg)}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	write("server:\n  port: 9090\nrequests:\n  - path: /a\n  - path: /c\n")
+	a.reload()
+	if got := a.mock.Config().Requests[1].Path; got != "/c" {
+		t.Fatalf("expected the new rules, got %s", got)
+	}
+	if out := logs.String(); !strings.Contains(out, "1 added, 1 removed, 0 changed, 1 unchanged") || !strings.Contains(out, "restart required") {
+		t.Errorf("expected the changes to be logged, got %q", out)
+	}
+
+	logs.Reset()
+	write("requests:\n  - method: GET\n")
+	a.reload()
+	if got := a.mock.Config().Requests[1].Path; got != "/c" {
+		t.Fatalf("expected the rules to be kept, got %s", got)
+	}
+	if out := logs.String(); !strings.Contains(out, "keeping current rules") || !strings.Contains(out, "path is required") {
+		t.Errorf("expected the rejection to be logged, got %q", out)
+	}
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/handler"
+)
+
+const consoleHelp = `Commands:
+  list                         Requests waiting for a response
+  show <id>                    Headers, body, and response choices of a held request
+  send <id> [choice] [status]  Answer a held request with response number choice ("-" or
+                               omitted to choose as usual), optionally with another status
+  help                         This help
+`
+
+// console is the command line of interactive mode. It prints every request the mock
+// answers and lets the operator answer the requests held by rules with hold set.
+type console struct {
+	mock *handler.MockHandler
+	mu   sync.Mutex // Serializes output
+	out  io.Writer
+}
+
+func newConsole(mock *handler.MockHandler, out io.Writer) *console {
+	c := &console{mock: mock, out: out}
+	mock.EnableInteractive(c.held, c.observed)
+	return c
+}
+
+func (c *console) printf(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+func (c *console) held(hr handler.HeldRequest) {
+	c.printf("#%d held: %s %s (%s), waiting for send %d\n", hr.ID, hr.Method, hr.URL, hr.Rule, hr.ID)
+}
+
+func (c *console) observed(o handler.ObservedRequest) {
+	rule := o.Rule
+	if rule == "" {
+		rule = "no rule matched"
+	}
+	c.printf("%s %s -> %d (%s, %s)\n", o.Method, o.URL, o.Status, rule, o.Duration.Round(time.Millisecond))
+}
+
+// run reads commands until the input ends
+func (c *console) run(in io.Reader) {
+	c.printf("Interactive mode: type help for commands\n")
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if err := c.exec(strings.Fields(scanner.Text())); err != nil {
+			c.printf("error: %v\n", err)
+		}
+	}
+}
+
+func (c *console) exec(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	switch args[0] {
+	case "help":
+		c.printf("%s", consoleHelp)
+	case "list", "ls":
+		held := c.mock.HeldRequests()
+		if len(held) == 0 {
+			c.printf("No held requests\n")
+		}
+		for _, hr := range held {
+			c.printf("#%d %s %s (%s), held %s\n", hr.ID, hr.Method, hr.URL, hr.Rule, time.Since(hr.Time).Round(time.Second))
+		}
+	case "show":
+		hr, err := c.find(args)
+		if err != nil {
+			return err
+		}
+		c.show(hr)
+	case "send":
+		if len(args) < 2 || len(args) > 4 {
+			return fmt.Errorf("usage: send <id> [choice] [status]")
+		}
+		n := make([]int, 3)
+		for i, arg := range args[1:] {
+			if arg == "-" && i == 1 {
+				continue
+			}
+			v, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", arg)
+			}
+			n[i] = v
+		}
+		if err := c.mock.Release(n[0], n[1], n[2]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %q, type help for commands", args[0])
+	}
+	return nil
+}
+
+// find returns the held request named by the command's ID argument
+func (c *console) find(args []string) (handler.HeldRequest, error) {
+	if len(args) != 2 {
+		return handler.HeldRequest{}, fmt.Errorf("usage: %s <id>", args[0])
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+	if err != nil {
+		return handler.HeldRequest{}, fmt.Errorf("%q is not a request ID", args[1])
+	}
+	for _, hr := range c.mock.HeldRequests() {
+		if hr.ID == id {
+			return hr, nil
+		}
+	}
+	return handler.HeldRequest{}, fmt.Errorf("no held request %d", id)
+}
+
+func (c *console) show(hr handler.HeldRequest) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s %s (%s)\n", hr.ID, hr.Method, hr.URL, hr.Rule)
+	names := make([]string, 0, len(hr.Header))
+	for name := range hr.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range hr.Header[name] {
+			fmt.Fprintf(&b, "  %s: %s\n", name, value)
+		}
+	}
+	if hr.Body != "" {
+		fmt.Fprintf(&b, "  %s\n", hr.Body)
+	}
+	b.WriteString("Responses:\n")
+	for i, choice := range hr.Choices {
+		fmt.Fprintf(&b, "  %d) %s\n", i+1, choice)
+	}
+	c.printf("%s", b.String())
+}
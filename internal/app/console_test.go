@@ -0,0 +1,78 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+// syncBuffer is a bytes.Buffer safe for the console and the test to share
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestConsole(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /pay
+    method: POST
+    hold: true
+    response:
+      status-code: 201
+      body: created
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock := handler.NewMockHandler(cfg)
+	var out syncBuffer
+	c := newConsole(mock, &out)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		mock.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/pay", strings.NewReader("amount=5")))
+		done <- rr
+	}()
+	for len(mock.HeldRequests()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	c.run(strings.NewReader("list\nshow 1\nsend 1 x\nfrobnicate\nsend 1 - 503\n"))
+	rr := <-done
+	if rr.Code != http.StatusServiceUnavailable || rr.Body.String() != "created" {
+		t.Fatalf("expected the response with the edited status, got %d %q", rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{
+		"#1 held: POST /pay (POST /pay), waiting for send 1",
+		"#1 POST /pay (POST /pay), held",
+		"  amount=5\nResponses:\n  1) 201, \"created\"",
+		`error: "x" is not a number`,
+		`error: unknown command "frobnicate"`,
+		"POST /pay -> 503 (POST /pay,",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected console output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
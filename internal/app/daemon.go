@@ -0,0 +1,36 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// daemonEnv marks the background copy started by Daemonize
+const daemonEnv = "HTTP_MOCK_SERVER_DAEMON"
+
+// IsDaemon reports whether this process was started by Daemonize
+func IsDaemon() bool {
+	return os.Getenv(daemonEnv) == "1"
+}
+
+// writePIDFile records this process's ID at path. It refuses to replace the pidfile
+// of a server that is still running, but takes over stale ones.
+func writePIDFile(path string) error {
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid != os.Getpid() && processAlive(pid) {
+			return fmt.Errorf("pidfile %s: server already running with pid %d", path, pid)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("pidfile %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("pidfile %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package app
+
+import (
+	"fmt"
+	"os"
+)
+
+// Daemonize is only supported on Unix. On Windows, register the server as a service
+// instead; see RunService.
+func Daemonize() (int, error) {
+	return 0, fmt.Errorf("--daemon is not supported on this platform; run the server as a service instead")
+}
+
+// processAlive reports whether a process with the given ID exists
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
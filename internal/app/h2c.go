@@ -0,0 +1,16 @@
+//go:build go1.24
+
+package app
+
+import "net/http"
+
+// enableH2C lets gRPC clients speak HTTP/2 to the plaintext listener with prior
+// knowledge, alongside HTTP/1.x. TLS listeners negotiate HTTP/2 regardless.
+func enableH2C(server *http.Server) bool {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	server.Protocols = protocols
+	return true
+}
@@ -0,0 +1,10 @@
+//go:build !go1.24
+
+package app
+
+import "net/http"
+
+// enableH2C needs http.Protocols, added in Go 1.24; older builds serve gRPC over TLS only
+func enableH2C(server *http.Server) bool {
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package app
+
+// portHolder is only implemented on Linux
+func portHolder(port uint) string {
+	return ""
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"syscall"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// BindError reports that the server could not bind any of its ports
+type BindError struct {
+	Ports    string // The port or port range that was tried
+	Attempts int
+	Holder   string // Process holding a single port, if it could be determined
+	Err      error  // Error from the last bind attempt
+}
+
+func (e *BindError) Error() string {
+	msg := fmt.Sprintf("could not bind port %s after %d attempt(s): %v", e.Ports, e.Attempts, e.Err)
+	if e.Holder != "" {
+		msg += fmt.Sprintf(" (held by %s)", e.Holder)
+	}
+	return msg
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// listen binds the first free port of the configured port or range. While every port
+// is in use it retries according to bindRetry; other errors fail immediately.
+func listen(s config.ServerConfig) (net.Listener, error) {
+	first, last := s.Port, s.Port
+	ports := fmt.Sprint(s.Port)
+	if s.PortRange != "" {
+		var err error
+		if first, last, err = config.ParsePortRange(s.PortRange); err != nil {
+			return nil, err
+		}
+		ports = s.PortRange
+	}
+
+	attempts, backoff := 1, time.Duration(0)
+	if r := s.BindRetry; r != nil {
+		attempts, backoff = r.Attempts, time.Duration(r.Backoff)*time.Millisecond
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		for port := first; port <= last; port++ {
+			var listener net.Listener
+			listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+			if err == nil {
+				return listener, nil
+			}
+			if !errors.Is(err, syscall.EADDRINUSE) {
+				return nil, &BindError{Ports: ports, Attempts: attempt, Err: err}
+			}
+		}
+		if attempt < attempts {
+			log.Printf("Port %s in use, retrying in %v", ports, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	bindErr := &BindError{Ports: ports, Attempts: attempts, Err: err}
+	if first == last {
+		bindErr.Holder = portHolder(first)
+	}
+	return nil, bindErr
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// occupy binds a free port for the duration of the test
+func occupy(t *testing.T) (net.Listener, uint) {
+	t.Helper()
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l, uint(l.Addr().(*net.TCPAddr).Port)
+}
+
+func TestListen_BusyPortReportsHolder(t *testing.T) {
+	_, port := occupy(t)
+
+	_, err := listen(config.ServerConfig{Port: port, BindRetry: &config.BindRetry{Attempts: 2, Backoff: 1}})
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected BindError, got %v", err)
+	}
+	if bindErr.Attempts != 2 || !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("unexpected error: %+v", bindErr)
+	}
+	if runtime.GOOS == "linux" && !strings.Contains(bindErr.Holder, fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Fatalf("expected this process as holder, got %q", bindErr.Holder)
+	}
+}
+
+func TestListen_RetriesUntilPortIsFree(t *testing.T) {
+	l, port := occupy(t)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = l.Close()
+	}()
+
+	listener, err := listen(config.ServerConfig{Port: port, BindRetry: &config.BindRetry{Attempts: 10, Backoff: 20}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+	if got := uint(listener.Addr().(*net.TCPAddr).Port); got != port {
+		t.Fatalf("expected port %d, got %d", port, got)
+	}
+}
+
+func TestListen_PortRangePicksFirstFree(t *testing.T) {
+	// Find a busy port whose successor is free
+	var port uint
+	for i := 0; i < 10 && port == 0; i++ {
+		_, p := occupy(t)
+		if next, err := net.Listen("tcp", fmt.Sprintf(":%d", p+1)); err == nil {
+			_ = next.Close()
+			port = p
+		}
+	}
+	if port == 0 {
+		t.Skip("no free adjacent port found")
+	}
+
+	listener, err := listen(config.ServerConfig{Port: 8080, PortRange: fmt.Sprintf("%d-%d", port, port+1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer listener.Close()
+	if got := uint(listener.Addr().(*net.TCPAddr).Port); got != port+1 {
+		t.Fatalf("expected port %d, got %d", port+1, got)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package app
+
+import "fmt"
+
+// IsService reports whether the Windows service control manager started this
+// process, which is never the case on this platform
+func IsService() bool {
+	return false
+}
+
+// RunService is only supported on Windows
+func (a *App) RunService() error {
+	return fmt.Errorf("running as a Windows service is not supported on this platform")
+}
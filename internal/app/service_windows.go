@@ -0,0 +1,83 @@
+//go:build windows
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// serviceName is the name the server registers under with the service control manager
+const serviceName = "http-mock-server"
+
+// IsService reports whether the Windows service control manager started this process
+func IsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// RunService runs the application as a Windows service. Stop and shutdown requests
+// from the service control manager shut the server down gracefully like SIGTERM, and
+// parameter change requests (sc.exe control <name> paramchange) reload the rules
+// like SIGHUP.
+func (a *App) RunService() error {
+	h := &serviceHandler{run: a.Run, signals: a.signals}
+	if err := svc.Run(serviceName, h); err != nil {
+		return fmt.Errorf("service failed: %w", err)
+	}
+	return h.err
+}
+
+// serviceHandler answers the service control manager while run serves
+type serviceHandler struct {
+	run     func() error
+	signals chan<- os.Signal
+	err     error
+}
+
+// Execute implements svc.Handler
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptParamChange
+	status <- svc.Status{State: svc.StartPending}
+	done := make(chan error, 1)
+	go func() { done <- h.run() }()
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+	stopping := false
+	for {
+		select {
+		case err := <-done:
+			h.err = err
+			status <- svc.Status{State: svc.Stopped}
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				// StopPending is reported once, and Stopped when run returns
+				if !stopping {
+					stopping = true
+					status <- svc.Status{State: svc.StopPending}
+				}
+				h.signal(syscall.SIGTERM)
+			case svc.ParamChange:
+				h.signal(syscall.SIGHUP)
+			}
+		}
+	}
+}
+
+// signal passes sig on unless one is already waiting to be handled
+func (h *serviceHandler) signal(sig os.Signal) {
+	select {
+	case h.signals <- sig:
+	default:
+	}
+}
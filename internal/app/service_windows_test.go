@@ -0,0 +1,62 @@
+//go:build windows
+
+package app
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestServiceHandler_Execute(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	received := make(chan os.Signal, 2)
+	h := &serviceHandler{signals: signals, run: func() error {
+		for sig := range signals {
+			received <- sig
+			if sig == syscall.SIGTERM {
+				return nil
+			}
+		}
+		return nil
+	}}
+
+	requests := make(chan svc.ChangeRequest)
+	status := make(chan svc.Status, 10)
+	result := make(chan uint32, 1)
+	go func() {
+		_, code := h.Execute(nil, requests, status)
+		result <- code
+	}()
+
+	requests <- svc.ChangeRequest{Cmd: svc.ParamChange}
+	if sig := <-received; sig != syscall.SIGHUP {
+		t.Fatalf("expected paramchange to reload, got %v", sig)
+	}
+	requests <- svc.ChangeRequest{Cmd: svc.Stop}
+	if sig := <-received; sig != syscall.SIGTERM {
+		t.Fatalf("expected stop to shut down, got %v", sig)
+	}
+
+	select {
+	case code := <-result:
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("service did not stop")
+	}
+	close(status)
+	var states []svc.State
+	for st := range status {
+		states = append(states, st.State)
+	}
+	want := []svc.State{svc.StartPending, svc.Running, svc.StopPending, svc.Stopped}
+	if !reflect.DeepEqual(states, want) {
+		t.Fatalf("expected the service to report %v, got %v", want, states)
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+// startupCheckTimeout bounds each self-check request, including configured response delays
+const startupCheckTimeout = 30 * time.Second
+
+// runStartupChecks sends each check's request to baseURL and verifies the response.
+// It returns an error describing every failed check.
+func runStartupChecks(baseURL string, checks []config.StartupCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	client := &http.Client{
+		Timeout: startupCheckTimeout,
+		// The checks target this server, whose certificate may be self-signed
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	var failures []string
+	for _, check := range checks {
+		if err := runStartupCheck(client, baseURL, check); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", check.Name, err))
+			continue
+		}
+		log.Printf("Startup check passed: %s", check.Name)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d startup checks failed:\n  %s", len(failures), len(checks), strings.Join(failures, "\n  "))
+	}
+	log.Printf("All %d startup checks passed", len(checks))
+	return nil
+}
+
+func runStartupCheck(client *http.Client, baseURL string, check config.StartupCheck) error {
+	req, err := http.NewRequest(check.Request.Method, baseURL+check.Request.Path, strings.NewReader(check.Request.Body))
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	for name, value := range check.Request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != check.Expect.Status {
+		return fmt.Errorf("expected status %d, got %d", check.Expect.Status, resp.StatusCode)
+	}
+	for name, pattern := range check.Expect.Headers {
+		value := resp.Header.Get(name)
+		if !regexp.MustCompile(pattern).MatchString(value) {
+			return fmt.Errorf("expected header %s to match %q, got %q", name, pattern, value)
+		}
+	}
+	if check.Expect.Body != "" && !regexp.MustCompile(check.Expect.Body).Match(body) {
+		return fmt.Errorf("expected body to match %q", check.Expect.Body)
+	}
+	return nil
+}
+
+// goldenFailures returns an error describing the responses the startup checks
+// rendered that didn't match their golden files
+func goldenFailures(mismatches []handler.GoldenMismatch) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+	failures := make([]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		failure := fmt.Sprintf("%s (rule %s): %s", m.File, m.Rule, m.Reason)
+		if m.Line > 0 {
+			failure += fmt.Sprintf(" at line %d: expected %q, got %q", m.Line, m.Expected, m.Actual)
+		}
+		failures = append(failures, failure)
+	}
+	return fmt.Errorf("%d golden files don't match their responses:\n  %s", len(mismatches), strings.Join(failures, "\n  "))
+}
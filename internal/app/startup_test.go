@@ -0,0 +1,98 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+func newCheckServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users" || r.URL.Query().Get("page") != "1" || r.Header.Get("Accept") != "application/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"users": [{"id": 1}]}`))
+	}))
+}
+
+func TestRunStartupChecks_Pass(t *testing.T) {
+	srv := newCheckServer()
+	defer srv.Close()
+
+	checks := []config.StartupCheck{
+		{
+			Name:    "users",
+			Request: config.CheckRequest{Method: "GET", Path: "/users?page=1", Headers: map[string]string{"Accept": "application/json"}},
+			Expect:  config.CheckExpectation{Status: 200, Headers: map[string]string{"Content-Type": "^application/json$"}, Body: `"id": 1`},
+		},
+		{
+			Name:    "unknown path",
+			Request: config.CheckRequest{Method: "GET", Path: "/missing"},
+			Expect:  config.CheckExpectation{Status: 404},
+		},
+	}
+
+	if err := runStartupChecks(srv.URL, checks); err != nil {
+		t.Fatalf("expected checks to pass, got %v", err)
+	}
+}
+
+func TestRunStartupChecks_ReportsEveryFailure(t *testing.T) {
+	srv := newCheckServer()
+	defer srv.Close()
+
+	checks := []config.StartupCheck{
+		{
+			Name:    "wrong status",
+			Request: config.CheckRequest{Method: "GET", Path: "/missing"},
+			Expect:  config.CheckExpectation{Status: 200},
+		},
+		{
+			Name:    "wrong body",
+			Request: config.CheckRequest{Method: "GET", Path: "/users?page=1", Headers: map[string]string{"Accept": "application/json"}},
+			Expect:  config.CheckExpectation{Status: 200, Body: `"id": 2`},
+		},
+		{
+			Name:    "wrong header",
+			Request: config.CheckRequest{Method: "GET", Path: "/users?page=1", Headers: map[string]string{"Accept": "application/json"}},
+			Expect:  config.CheckExpectation{Status: 200, Headers: map[string]string{"Content-Type": "xml"}},
+		},
+	}
+
+	err := runStartupChecks(srv.URL, checks)
+	if err == nil {
+		t.Fatal("expected failures, got nil")
+	}
+	for _, want := range []string{
+		"3 of 3 startup checks failed",
+		"wrong status: expected status 200, got 404",
+		`wrong body: expected body to match "\"id\": 2"`,
+		"wrong header: expected header Content-Type",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestGoldenFailures(t *testing.T) {
+	if err := goldenFailures(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := goldenFailures([]handler.GoldenMismatch{
+		{Rule: "GET /a", File: "golden/a.json", Reason: "body differs from golden file", Line: 3, Expected: "x", Actual: "y"},
+		{Rule: "GET /b", File: "golden/b.json", Reason: "golden file does not exist"},
+	})
+	want := "2 golden files don't match their responses:\n" +
+		"  golden/a.json (rule GET /a): body differs from golden file at line 3: expected \"x\", got \"y\"\n" +
+		"  golden/b.json (rule GET /b): golden file does not exist"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+}
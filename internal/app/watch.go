@@ -0,0 +1,97 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"http-mock-server/internal/config"
+)
+
+// watchDebounce is how long the watcher waits for a burst of file events to settle,
+// since editors often write a file in several steps
+const watchDebounce = 250 * time.Millisecond
+
+// configWatcher reports changes to a configuration file, or to the configuration
+// files in a directory
+type configWatcher struct {
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+	match   func(name string) bool
+}
+
+// watchConfig starts watching the configuration at source. The directory holding a
+// file is watched rather than the file itself, so files replaced by renaming them
+// over the original, as many editors save, are still followed.
+func watchConfig(source string) (*configWatcher, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not watch the configuration: %w", err)
+	}
+	if source, err = filepath.Abs(source); err != nil {
+		return nil, err
+	}
+
+	dir, match := filepath.Dir(source), func(name string) bool { return filepath.Clean(name) == source }
+	if info.IsDir() {
+		dir, match = source, func(name string) bool {
+			return filepath.Dir(filepath.Clean(name)) == source && config.IsConfigFile(name)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("could not watch %s: %w", source, err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("could not watch %s: %w", source, err)
+	}
+
+	w := &configWatcher{watcher: watcher, changes: make(chan struct{}, 1), match: match}
+	go w.run()
+	return w, nil
+}
+
+// Changes receives a value once the watched configuration has changed and its
+// events have settled
+func (w *configWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching
+func (w *configWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+func (w *configWatcher) run() {
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !w.match(event.Name) {
+				continue
+			}
+			timer.Reset(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Watching the configuration failed: %v", err)
+		case <-timer.C:
+			select {
+			case w.changes <- struct{}{}:
+			default: // A reload is already pending
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitForChange(t *testing.T, w *configWatcher, want bool) {
+	t.Helper()
+	select {
+	case <-w.Changes():
+		if !want {
+			t.Fatal("unexpected change")
+		}
+	case <-time.After(4 * watchDebounce):
+		if want {
+			t.Fatal("expected a change")
+		}
+	}
+}
+
+func TestWatchConfig_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("requests: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := watchConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, w, false)
+
+	// Several writes in a row are reported once
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(path, []byte("requests:\n  - path: /x\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForChange(t, w, true)
+	waitForChange(t, w, false)
+
+	// Replacing the file by renaming another over it is followed
+	tmp := filepath.Join(dir, ".config.yaml.swp")
+	if err := os.WriteFile(tmp, []byte("requests: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, w, true)
+}
+
+func TestWatchConfig_Directory(t *testing.T) {
+	dir := t.TempDir()
+	w, err := watchConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, w, false)
+
+	if err := os.WriteFile(filepath.Join(dir, "payments.toml"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, w, true)
+}
+
+func TestWatchConfig_Missing(t *testing.T) {
+	if _, err := watchConfig("embedded:config"); err == nil {
+		t.Fatal("expected an error for a source that isn't on disk")
+	}
+}
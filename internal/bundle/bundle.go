@@ -0,0 +1,223 @@
+// Package bundle packages a configuration and the files it references into a
+// single gzipped tar archive that can be run elsewhere
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"http-mock-server/internal/config"
+)
+
+// ConfigFile is the name of the configuration inside a bundle
+const ConfigFile = "config.yaml"
+
+// filesDir holds the referenced files inside a bundle
+const filesDir = "files"
+
+// maxFileBytes limits the size of each file extracted from a bundle
+const maxFileBytes = 512 * 1024 * 1024
+
+// fileKeys are the configuration keys whose values are file paths
+var fileKeys = map[string]bool{
+	"bodySchema":   true,
+	"bodyFile":     true,
+	"certFile":     true,
+	"keyFile":      true,
+	"clientCAFile": true,
+	"file":         true, // Secret references
+}
+
+// Create writes a bundle of the configuration at configPath to w. Referenced files
+// are copied into the bundle and the configuration is rewritten to point at the
+// copies. It returns the referenced paths as written in the configuration.
+func Create(w io.Writer, configPath string) ([]string, error) {
+	// Refuse configurations that wouldn't load, including ones with missing files
+	cfg, err := config.LoadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	// Body files in configured storage are keys, not paths that could be copied
+	if cfg.Server.Storage != nil {
+		return nil, fmt.Errorf("%s configures server.storage; bundles only support configurations that read files from the working directory", configPath)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := config.DecodeDocument(data, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite each distinct path to a unique name under filesDir
+	files := make(map[string]string)
+	var order []string
+	rewrite := func(n *yaml.Node) {
+		if n.Kind != yaml.ScalarNode || n.Value == "" {
+			return
+		}
+		name, ok := files[n.Value]
+		if !ok {
+			name = path.Join(filesDir, fmt.Sprintf("%d-%s", len(order)+1, filepath.Base(n.Value)))
+			files[n.Value] = name
+			order = append(order, n.Value)
+		}
+		n.Value = name
+	}
+	if len(doc.Content) > 0 {
+		walk(doc.Content[0], true, rewrite)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, ConfigFile, out); err != nil {
+		return nil, err
+	}
+	for _, src := range order {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeEntry(tw, files[src], content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// walk calls fn for every value node that holds a file path
+func walk(n *yaml.Node, top bool, fn func(*yaml.Node)) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i].Value, n.Content[i+1]
+			switch {
+			case fileKeys[key]:
+				fn(value)
+			case key == "schemaBody":
+				if value.Kind == yaml.MappingNode {
+					value = lookup(value, "schema")
+				}
+				if value != nil {
+					withoutFragment(value, fn)
+				}
+			case top && (key == "datasets" || key == "partials") && value.Kind == yaml.MappingNode:
+				for j := 1; j < len(value.Content); j += 2 {
+					fn(value.Content[j])
+				}
+			default:
+				walk(value, false, fn)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			walk(item, false, fn)
+		}
+	}
+}
+
+// lookup returns the value of key in a mapping, or nil
+func lookup(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// withoutFragment calls fn with the path of a reference such as
+// "openapi.yaml#/components/schemas/User", keeping the fragment
+func withoutFragment(n *yaml.Node, fn func(*yaml.Node)) {
+	if n.Kind != yaml.ScalarNode {
+		return
+	}
+	path, fragment, found := strings.Cut(n.Value, "#")
+	file := &yaml.Node{Kind: yaml.ScalarNode, Value: path}
+	fn(file)
+	n.Value = file.Value
+	if found {
+		n.Value += "#" + fragment
+	}
+}
+
+func writeEntry(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// Extract unpacks the bundle at archivePath into dir
+func Extract(archivePath, dir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a bundle: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("bundle entry %q is outside the bundle", hdr.Name)
+		}
+		if hdr.Size > maxFileBytes {
+			return fmt.Errorf("bundle entry %q is too large", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxFileBytes))
+		if err != nil {
+			return fmt.Errorf("reading bundle entry %q: %w", hdr.Name, err)
+		}
+		if err := os.WriteFile(target, content, 0o600); err != nil {
+			return err
+		}
+		found = found || name == ConfigFile
+	}
+	if !found {
+		return fmt.Errorf("%s has no %s", archivePath, ConfigFile)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestCreateAndExtract(t *testing.T) {
+	src := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(src, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	users := write("users.json", `[{"name":"ada"}]`)
+	schema := write("order.json", `{"type":"object","required":["id"]}`)
+	secret := write("webhook.key", "s3cret\n")
+	envelope := write("envelope.tmpl", `{"data": {{ . }}}`)
+	spec := write("openapi.yaml", "components:\n  schemas:\n    Pet: {type: object, required: [name]}\n")
+	configPath := write("config.yaml", `
+server:
+  keys:
+    webhook: {file: `+secret+`}
+datasets:
+  users: `+users+`
+partials:
+  envelope: `+envelope+`
+definitions:
+  orders:
+    bodySchema: `+schema+`
+requests:
+  - path: /orders
+    method: POST
+    use: orders
+    response: {status-code: 201}
+  - path: /orders/bulk
+    method: POST
+    bodySchema: `+schema+`
+  - path: /users
+    response: {dataset: users}
+  - path: /pets
+    response:
+      schemaBody: {schema: `+spec+`#/components/schemas/Pet, random: true}
+`)
+
+	var buf bytes.Buffer
+	files, err := Create(&buf, configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 5 {
+		t.Fatalf("expected 5 distinct files, got %v", files)
+	}
+
+	archive := filepath.Join(t.TempDir(), "mocks.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := Extract(archive, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bundled, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bundled), src) {
+		t.Fatalf("bundled config still references the source directory:\n%s", bundled)
+	}
+
+	// The bundled configuration loads from the extracted directory
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFile(ConfigFile)
+	if err != nil {
+		t.Fatalf("bundled config failed to load: %v", err)
+	}
+	if cfg.Server.Keys["webhook"].Value() != "s3cret" || cfg.Requests[0].Schema == nil || cfg.DatasetData["users"] == nil ||
+		cfg.PartialSet.Lookup("envelope") == nil || cfg.Requests[3].Response.SchemaBody.Compiled == nil {
+		t.Fatalf("bundled files were not loaded: %+v", cfg)
+	}
+}
+
+func TestCreate_InvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("datasets:\n  users: /missing.json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(&bytes.Buffer{}, path); err == nil {
+		t.Fatal("expected error for config with a missing file")
+	}
+}
+
+func TestExtract_RejectsEscapingEntries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, map[string]string{"../evil": "x", ConfigFile: ""}); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Extract(archive, t.TempDir()); err == nil || !strings.Contains(err.Error(), "outside the bundle") {
+		t.Fatalf("expected escaping entry error, got %v", err)
+	}
+}
+
+func writeArchive(buf *bytes.Buffer, entries map[string]string) error {
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		if err := writeEntry(tw, name, []byte(content)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,20 @@
+package config
+
+import "fmt"
+
+// Admin controls what the admin API accepts. Reload and validate take a configuration
+// in the request body only when AcceptConfigs is set, since whoever submits one
+// controls every response the server sends.
+type Admin struct {
+	AcceptConfigs bool   `yaml:"acceptConfigs"` // Take configurations in reload and validate request bodies
+	Token         Secret `yaml:"token"`         // Bearer token required to submit a configuration
+}
+
+// validate requires submitted configurations to be restricted to known clients,
+// by the token or by server.accessControl allow entries
+func (a *Admin) validate(ac *AccessControl) error {
+	if a.AcceptConfigs && a.Token.Value() == "" && (ac == nil || ac.Allow.Empty()) {
+		return fmt.Errorf("acceptConfigs requires a token or server.accessControl allow entries")
+	}
+	return nil
+}
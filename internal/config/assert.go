@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+
+	"http-mock-server/internal/jsonschema"
+)
+
+// Actions taken when a response fails its assertions
+const (
+	AssertLog  = "log"  // Log the violations and send the response anyway
+	AssertDeny = "deny" // Log the violations and answer 500 instead
+)
+
+// ResponseAssertions are checks a rule's responses must pass before they are sent,
+// catching rules broken by template or dataset changes
+type ResponseAssertions struct {
+	Status     []int              `yaml:"status"`     // Allowed status codes; any when empty
+	Headers    []string           `yaml:"headers"`    // Headers the response must set
+	BodySchema string             `yaml:"bodySchema"` // Path to a JSON Schema file the response body must satisfy
+	Schema     *jsonschema.Schema `yaml:"-"`          // Loaded from BodySchema during config loading
+	OnFailure  string             `yaml:"onFailure"`  // "log" (default) or "deny"
+}
+
+func (a *ResponseAssertions) setDefaults() {
+	if a.OnFailure == "" {
+		a.OnFailure = AssertLog
+	}
+}
+
+// load validates the assertions and reads the body schema
+func (a *ResponseAssertions) load() error {
+	if a.OnFailure != AssertLog && a.OnFailure != AssertDeny {
+		return fmt.Errorf("assert onFailure must be one of: log, deny")
+	}
+	for _, s := range a.Status {
+		if s < 100 || s > 599 {
+			return fmt.Errorf("assert status has invalid status %d", s)
+		}
+	}
+	for _, h := range a.Headers {
+		if h == "" {
+			return fmt.Errorf("assert headers cannot be empty")
+		}
+	}
+	if a.BodySchema == "" {
+		return nil
+	}
+	schema, err := jsonschema.Load(a.BodySchema)
+	if err != nil {
+		return fmt.Errorf("assert bodySchema: %w", err)
+	}
+	a.Schema = schema
+	return nil
+}
+
+// Check returns the ways a response violates the assertions
+func (a *ResponseAssertions) Check(status int, header http.Header, body []byte) []string {
+	var violations []string
+	if len(a.Status) > 0 {
+		allowed := false
+		for _, s := range a.Status {
+			if s == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("status %d is not one of %v", status, a.Status))
+		}
+	}
+	for _, h := range a.Headers {
+		if header.Get(h) == "" {
+			violations = append(violations, fmt.Sprintf("header %s is missing", http.CanonicalHeaderKey(h)))
+		}
+	}
+	if a.Schema != nil {
+		for _, e := range a.Schema.ValidateJSON(body) {
+			violations = append(violations, "body "+e.String())
+		}
+	}
+	return violations
+}
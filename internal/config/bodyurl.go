@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BodyURL serves a body fetched from a URL, such as a fixture in an artifact store,
+// from memory. It is shorthand for a cached proxyBody fetched when the rules load.
+// It is written as the URL alone, or as a mapping with refresh options.
+type BodyURL struct {
+	URL     string `yaml:"url"`
+	TTL     int    `yaml:"ttl"`     // Seconds before the body is fetched again; 0 keeps it until the rules reload
+	Lazy    bool   `yaml:"lazy"`    // Fetch the body on the first request instead of when the rules load
+	Timeout int    `yaml:"timeout"` // Milliseconds to wait for the server to answer, defaults to 30000
+}
+
+// bodyURLSpec is the mapping form of BodyURL
+type bodyURLSpec BodyURL
+
+// UnmarshalYAML accepts the URL alone or the mapping form
+func (b *BodyURL) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*b = BodyURL{URL: node.Value}
+		return nil
+	}
+	var spec bodyURLSpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+	*b = BodyURL(spec)
+	return nil
+}
+
+// MarshalYAML renders the body source in the form it was written
+func (b BodyURL) MarshalYAML() (interface{}, error) {
+	if b.TTL == 0 && !b.Lazy && b.Timeout == 0 {
+		return b.URL, nil
+	}
+	return bodyURLSpec(b), nil
+}
+
+// proxyBody returns the cached proxyBody the body URL stands for
+func (b *BodyURL) proxyBody() *ProxyBody {
+	pb := &ProxyBody{URL: b.URL, Cache: true, CacheTTL: b.TTL, Prefetch: !b.Lazy, Timeout: b.Timeout}
+	pb.setDefaults()
+	return pb
+}
+
+// applyBodyURL serves the body URL as a proxyBody, unless the response sets one itself
+func (s *ResponseSpec) applyBodyURL() {
+	if s.BodyURL != nil && s.ProxyBody == nil {
+		s.ProxyBody = s.BodyURL.proxyBody()
+	}
+}
+
+func (s *ResponseSpec) validateBodyURL() error {
+	b := s.BodyURL
+	if b.URL == "" {
+		return fmt.Errorf("bodyUrl needs a url")
+	}
+	if b.TTL < 0 {
+		return fmt.Errorf("bodyUrl ttl cannot be negative")
+	}
+	if b.Timeout < 0 {
+		return fmt.Errorf("bodyUrl timeout cannot be negative")
+	}
+	if s.ProxyBody == nil || *s.ProxyBody != *b.proxyBody() {
+		return fmt.Errorf("bodyUrl cannot be combined with proxyBody")
+	}
+	if s.Body != nil || s.BodyBase64 != "" || s.BodyFile != "" || s.RandomBody != nil || s.GenerateBody != nil || s.Dataset != "" {
+		return fmt.Errorf("bodyUrl cannot be combined with body, bodyBase64, bodyFile, randomBody, generateBody, or dataset")
+	}
+	return nil
+}
@@ -1,32 +1,439 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
+	"net/http"
+	"net/netip"
 	"os"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"http-mock-server/internal/expr"
+	"http-mock-server/internal/jsonschema"
+	"http-mock-server/internal/templating"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig  `yaml:"server"`
-	Requests []RequestRule `yaml:"requests"`
+	Server        ServerConfig           `yaml:"server"`
+	Requests      []RequestRule          `yaml:"requests"`
+	StartupChecks []StartupCheck         `yaml:"startupChecks"`
+	Scenarios     map[string]string      `yaml:"scenarios"` // Initial state of scenarios that don't start in Started
+	Datasets      map[string]string      `yaml:"datasets"`  // Dataset names to JSON or YAML files
+	DatasetData   map[string]interface{} `yaml:"-"`         // Parsed from Datasets during config loading
+	Partials      map[string]string      `yaml:"partials"`  // Names of templates responses can include to template files
+	PartialSet    *template.Template     `yaml:"-"`         // Parsed from Partials during config loading
+	Source        string                 `yaml:"-"`         // File the configuration was loaded from, if any
+	RuleLoadTimes []time.Duration        `yaml:"-"`         // Time spent validating and compiling each request rule
+}
+
+// StartupCheck is a request the server sends to itself after starting, failing startup
+// if the response doesn't meet the expectation
+type StartupCheck struct {
+	Name    string           `yaml:"name"`
+	Request CheckRequest     `yaml:"request"`
+	Expect  CheckExpectation `yaml:"expect"`
+}
+
+// CheckRequest describes the request sent by a startup check
+type CheckRequest struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"` // Path including an optional query string
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+// CheckExpectation describes the response a startup check requires
+type CheckExpectation struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"` // Header name to regex pattern
+	Body    string            `yaml:"body"`    // Regex pattern the body must contain a match for
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port uint `yaml:"port"`
+	Port           uint              `yaml:"port"`
+	PortRange      string            `yaml:"portRange"` // Listen on the first free port in a range such as "8080-8090" instead of Port
+	BindRetry      *BindRetry        `yaml:"bindRetry"` // Retry binding while the port is in use
+	MatchMode      string            `yaml:"matchMode"` // Default regex anchoring for all rules: "full" (the default) or "partial"
+	AccessControl  *AccessControl    `yaml:"accessControl"`
+	Admin          *Admin            `yaml:"admin"` // What the admin API accepts
+	TLS            *TLSConfig        `yaml:"tls"`
+	Limits         *Limits           `yaml:"limits"`
+	Audiences      *Audiences        `yaml:"audiences"`      // How requests select the consumer audience rules respond to
+	Mirror         *Mirror           `yaml:"mirror"`         // Copy mock traffic to a secondary target
+	GRPCHealth     *GRPCHealth       `yaml:"grpcHealth"`     // Answer the gRPC health checking protocol
+	Journal        *Journal          `yaml:"journal"`        // Record mock requests for inspection and anonymized export
+	Metrics        *Metrics          `yaml:"metrics"`        // Serve per-rule Prometheus metrics
+	Freshness      *Freshness        `yaml:"freshness"`      // Warn about rules that have gone unmatched for days
+	Storage        *Storage          `yaml:"storage"`        // Where body files, journal exports, and recordings live
+	Warmup         *Warmup           `yaml:"warmup"`         // Prepare every rule before serving and report how long each took
+	Keys           map[string]Secret `yaml:"keys"`           // Named PEM private keys or HMAC secrets for template signing helpers
+	VariantHeader  string            `yaml:"variantHeader"`  // Request header forcing a named response, defaults to X-Mock-Variant
+	StrictPatterns bool              `yaml:"strictPatterns"` // Reject invalid matcher regexes instead of matching them exactly
+	UntypedBodies  bool              `yaml:"untypedBodies"`  // Don't add a Content-Type to inline bodies and datasets that set none
+	ErrorResponse  *ErrorResponse    `yaml:"errorResponse"`  // Sent when the server fails to produce a rule's response
+	TemplateLimits TemplateLimits    `yaml:"templateLimits"` // Output size, run time, and functions allowed to templates
+}
+
+// TLSConfig serves the mocks over HTTPS, optionally asking clients for certificates
+type TLSConfig struct {
+	CertFile     string      `yaml:"certFile"`     // PEM server certificate chain
+	KeyFile      string      `yaml:"keyFile"`      // PEM server private key
+	ClientCAFile string      `yaml:"clientCAFile"` // PEM CA bundle for verifying client certificates
+	ClientAuth   string      `yaml:"clientAuth"`   // none, request, require, verify, or require-verify
+	Config       *tls.Config `yaml:"-"`            // Built from the fields above during config loading
+}
+
+// Client certificate policies
+const (
+	ClientAuthNone          = "none"           // Don't ask for a certificate
+	ClientAuthRequest       = "request"        // Ask for a certificate but don't verify it
+	ClientAuthRequire       = "require"        // Require a certificate but don't verify it
+	ClientAuthVerify        = "verify"         // Verify a certificate against clientCAFile if one is sent
+	ClientAuthRequireVerify = "require-verify" // Require a certificate verified against clientCAFile
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	ClientAuthNone:          tls.NoClientCert,
+	ClientAuthRequest:       tls.RequestClientCert,
+	ClientAuthRequire:       tls.RequireAnyClientCert,
+	ClientAuthVerify:        tls.VerifyClientCertIfGiven,
+	ClientAuthRequireVerify: tls.RequireAndVerifyClientCert,
+}
+
+// load reads the certificates and builds the TLS configuration
+func (t *TLSConfig) load() error {
+	if t.CertFile == "" || t.KeyFile == "" {
+		return fmt.Errorf("certFile and keyFile are required")
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return fmt.Errorf("could not load certificate: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if t.ClientCAFile != "" {
+		pem, err := os.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("could not read clientCAFile: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("clientCAFile %s contains no PEM certificates", t.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+	}
+
+	authType, ok := clientAuthTypes[t.ClientAuth]
+	if !ok {
+		return fmt.Errorf("clientAuth must be one of: none, request, require, verify, require-verify")
+	}
+	if (t.ClientAuth == ClientAuthVerify || t.ClientAuth == ClientAuthRequireVerify) && cfg.ClientCAs == nil {
+		return fmt.Errorf("clientAuth %s requires clientCAFile", t.ClientAuth)
+	}
+	cfg.ClientAuth = authType
+	t.Config = cfg
+	return nil
+}
+
+// KeyValues returns the resolved values of the configured keys
+func (s ServerConfig) KeyValues() map[string]string {
+	values := make(map[string]string, len(s.Keys))
+	for name, key := range s.Keys {
+		values[name] = key.Value()
+	}
+	return values
+}
+
+// BindRetry retries binding the listening port while another process holds it
+type BindRetry struct {
+	Attempts int `yaml:"attempts"` // Total bind attempts, defaults to 5
+	Backoff  int `yaml:"backoff"`  // Milliseconds before the first retry, doubling after each one; defaults to 500
+}
+
+// ParsePortRange parses a port range such as "8080-8090". A single port is a range of one.
+func ParsePortRange(s string) (first, last uint, err error) {
+	lo, hi, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		hi = lo
+	}
+	a, err := strconv.ParseUint(strings.TrimSpace(lo), 10, 16)
+	if err != nil || a == 0 {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	b, err := strconv.ParseUint(strings.TrimSpace(hi), 10, 16)
+	if err != nil || b < a {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return uint(a), uint(b), nil
+}
+
+// Limits caps the resources mock traffic may hold at once. Requests over a limit are
+// rejected with 503 Service Unavailable, or the overload status, instead of queueing.
+type Limits struct {
+	MaxInFlight   int       `yaml:"maxInFlight"`   // Mock requests served at once, including those held by delays; 0 means unlimited
+	MaxBodyMemory string    `yaml:"maxBodyMemory"` // Total size of request bodies buffered at once, e.g. "64MB"
+	MaxBodyBytes  int       `yaml:"-"`             // Parsed from MaxBodyMemory, 0 when unlimited
+	RetryAfter    int       `yaml:"retryAfter"`    // Seconds suggested to rejected clients, defaults to 1
+	Overload      *Overload `yaml:"overload"`      // Shed requests while the server itself is overloaded
+}
+
+// Overload sheds mock traffic while the server's own CPU use or scheduling lag is
+// over a threshold, so load tests see explicit rejections instead of slowdowns
+type Overload struct {
+	MaxCPU   float64 `yaml:"maxCPU"`   // Process CPU use as a fraction of the available cores, e.g. 0.9; 0 disables
+	MaxLag   int     `yaml:"maxLag"`   // Milliseconds the server may fall behind its own timers; 0 disables
+	Status   int     `yaml:"status"`   // 429 or 503, defaults to 503
+	Interval int     `yaml:"interval"` // Milliseconds between measurements, defaults to 500
+}
+
+// DefaultVariantHeader names the request header that forces one of a rule's named responses
+const DefaultVariantHeader = "X-Mock-Variant"
+
+// DefaultAudienceHeader names the request header that selects an audience
+const DefaultAudienceHeader = "X-Audience"
+
+// Audiences configures how a request selects its audience, for rules that only
+// serve some consumers. A header takes precedence over the port the request arrived on.
+type Audiences struct {
+	Header  string          `yaml:"header"`  // Request header naming the audience, defaults to X-Audience
+	Default string          `yaml:"default"` // Audience of requests that select none
+	Ports   map[string]uint `yaml:"ports"`   // Additional ports to listen on, each selecting an audience
+}
+
+// PortAudience returns the audience selected by the port a request arrived on
+func (a *Audiences) PortAudience(port uint) (string, bool) {
+	for name, p := range a.Ports {
+		if p == port {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// AccessControl restricts which clients may use the server
+type AccessControl struct {
+	APIKeyHeader string     `yaml:"apiKeyHeader"` // Header carrying the client API key, defaults to X-API-Key
+	Allow        AccessList `yaml:"allow"`        // If non-empty, clients must match at least one entry
+	Deny         AccessList `yaml:"deny"`         // Clients matching any entry are rejected
+}
+
+// AccessList identifies clients by source address or API key
+type AccessList struct {
+	CIDRs   []string `yaml:"cidrs"`   // IP ranges such as "10.0.0.0/8"; a bare IP matches that address only
+	APIKeys []Secret `yaml:"apiKeys"` // Accepted values of the API key header
+}
+
+// Empty reports whether the list has no entries
+func (l AccessList) Empty() bool {
+	return len(l.CIDRs) == 0 && len(l.APIKeys) == 0
+}
+
+// Regex anchoring modes for header, query parameter, and body matchers
+const (
+	MatchModeFull    = "full"    // The pattern must match the entire value
+	MatchModePartial = "partial" // The pattern may match any substring of the value
+)
+
+// ClientCertMatcher matches attributes of the TLS client certificate. Patterns use the
+// rule's match mode; a request without a client certificate never matches.
+type ClientCertMatcher struct {
+	Subject string `yaml:"subject"` // Regex for the subject common name
+	SAN     string `yaml:"san"`     // Regex at least one DNS, email, IP, or URI subject alternative name must match
+	Issuer  string `yaml:"issuer"`  // Regex for the issuer common name
+}
+
+// QueryParams maps query parameter names to their matchers
+type QueryParams map[string]ParamMatcher
+
+// ParamMatcher matches a query parameter value against a regex pattern, or checks only
+// whether the parameter is present. In YAML it is a pattern string or a mapping:
+//
+//	q: "shoes"                  # value must match the pattern
+//	debug: { present: true }    # parameter must be present, with any value including empty
+//	legacy: { present: false }  # parameter must be absent
+type ParamMatcher struct {
+	Pattern string
+	Present *bool
+}
+
+type paramMatcherSpec struct {
+	Pattern *string `yaml:"pattern"`
+	Present *bool   `yaml:"present"`
+}
+
+// UnmarshalYAML accepts the pattern and mapping forms
+func (m *ParamMatcher) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*m = ParamMatcher{Pattern: node.Value}
+		return nil
+	}
+	var spec paramMatcherSpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+	if spec.Present == nil && spec.Pattern == nil {
+		return fmt.Errorf("line %d: query parameter matcher must set present or pattern", node.Line)
+	}
+	if spec.Present != nil && !*spec.Present && spec.Pattern != nil {
+		return fmt.Errorf("line %d: query parameter matcher cannot require absence and a pattern", node.Line)
+	}
+	*m = ParamMatcher{Present: spec.Present}
+	if spec.Pattern != nil {
+		m.Pattern = *spec.Pattern
+	}
+	return nil
+}
+
+// MarshalYAML renders the matcher in the form it was written
+func (m ParamMatcher) MarshalYAML() (interface{}, error) {
+	if m.Present == nil {
+		return m.Pattern, nil
+	}
+	spec := paramMatcherSpec{Present: m.Present}
+	if m.Pattern != "" {
+		spec.Pattern = &m.Pattern
+	}
+	return spec, nil
+}
+
+func (m ParamMatcher) String() string {
+	switch {
+	case m.Present == nil:
+		return m.Pattern
+	case !*m.Present:
+		return "(absent)"
+	case m.Pattern != "":
+		return "(present) " + m.Pattern
+	default:
+		return "(present)"
+	}
+}
+
+// MatcherGroups combines matchers with boolean logic, in addition to a rule's own matchers
+type MatcherGroups struct {
+	AnyOf []Matcher `yaml:"anyOf"` // At least one must match
+	AllOf []Matcher `yaml:"allOf"` // Every one must match
+	Not   *Matcher  `yaml:"not"`   // Must not match
+}
+
+// Matcher is a set of request matchers that all must match. Patterns use the rule's match mode.
+type Matcher struct {
+	Headers     map[string]string `yaml:"headers"`
+	QueryParams QueryParams       `yaml:"queryParams"`
+	Body        string            `yaml:"body"`
+	When        string            `yaml:"when"`
+	Groups      MatcherGroups     `yaml:",inline"`
+}
+
+// Empty reports whether the matcher sets no conditions
+func (m *Matcher) Empty() bool {
+	return len(m.Headers) == 0 && len(m.QueryParams) == 0 && m.Body == "" && m.When == "" &&
+		len(m.Groups.AnyOf) == 0 && len(m.Groups.AllOf) == 0 && m.Groups.Not == nil
+}
+
+// Walk calls fn for every matcher in the groups, including nested ones
+func (g *MatcherGroups) Walk(fn func(m *Matcher) error) error {
+	var groups []*Matcher
+	for i := range g.AnyOf {
+		groups = append(groups, &g.AnyOf[i])
+	}
+	for i := range g.AllOf {
+		groups = append(groups, &g.AllOf[i])
+	}
+	if g.Not != nil {
+		groups = append(groups, g.Not)
+	}
+	for _, m := range groups {
+		if err := fn(m); err != nil {
+			return err
+		}
+		if err := m.Groups.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SizeRange bounds a size in bytes. Bounds use the same format as randomBody sizes, e.g. 512 or "1 MB".
+type SizeRange struct {
+	Min      string `yaml:"min"`
+	Max      string `yaml:"max"` // Unbounded when empty
+	MinBytes int    `yaml:"-"`   // Parsed from Min during config loading
+	MaxBytes int    `yaml:"-"`   // Parsed from Max during config loading, -1 when unbounded
+}
+
+// sizeRangeSpec is the YAML form of a SizeRange, which also accepts minBytes and maxBytes
+type sizeRangeSpec struct {
+	Min      string `yaml:"min"`
+	Max      string `yaml:"max"`
+	MinBytes string `yaml:"minBytes"`
+	MaxBytes string `yaml:"maxBytes"`
+}
+
+// UnmarshalYAML accepts min and max or their minBytes and maxBytes spellings
+func (r *SizeRange) UnmarshalYAML(node *yaml.Node) error {
+	var spec sizeRangeSpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+	if (spec.Min != "" && spec.MinBytes != "") || (spec.Max != "" && spec.MaxBytes != "") {
+		return fmt.Errorf("line %d: size range cannot set both min and minBytes or max and maxBytes", node.Line)
+	}
+	*r = SizeRange{Min: spec.Min + spec.MinBytes, Max: spec.Max + spec.MaxBytes}
+	return nil
+}
+
+// Contains reports whether n bytes falls within the range, bounds inclusive
+func (r *SizeRange) Contains(n int64) bool {
+	return n >= int64(r.MinBytes) && (r.MaxBytes < 0 || n <= int64(r.MaxBytes))
+}
+
+func (r *SizeRange) parse() error {
+	r.MinBytes, r.MaxBytes = 0, -1
+	var err error
+	if r.Min != "" {
+		if r.MinBytes, err = parseSize(r.Min); err != nil {
+			return fmt.Errorf("min: %w", err)
+		}
+	}
+	if r.Max != "" {
+		if r.MaxBytes, err = parseSize(r.Max); err != nil {
+			return fmt.Errorf("max: %w", err)
+		}
+		if r.MinBytes > r.MaxBytes {
+			return fmt.Errorf("min (%s) cannot exceed max (%s)", formatBytes(r.MinBytes), formatBytes(r.MaxBytes))
+		}
+	}
+	return nil
 }
 
-// ResponseDelay specifies the min/max delay before sending a response
-type ResponseDelay struct {
-	Min int `yaml:"min"` // Minimum delay in milliseconds
-	Max int `yaml:"max"` // Maximum delay in milliseconds
+// JSONMatchOptions relaxes JSON body comparison. Numbers always compare by value, so 1 equals 1.0.
+type JSONMatchOptions struct {
+	NumericStrings   bool `yaml:"numericStrings"`   // A string holding a number equals that number, e.g. "1" and 1
+	NullEqualsAbsent bool `yaml:"nullEqualsAbsent"` // An object field set to null equals a missing field
+	IgnoreArrayOrder bool `yaml:"ignoreArrayOrder"` // Arrays with the same elements in any order are equal
 }
 
+// Body schema modes
+const (
+	SchemaModeMatch   = "match"   // Requests that fail validation don't match the rule
+	SchemaModeEnforce = "enforce" // Requests that fail validation get a 400 listing the violations
+)
+
 // RandomBodySpec configures pre-generated random body content for a response
 type RandomBodySpec struct {
 	Type      string `yaml:"type"` // "plaintext", "json", or "xml"
@@ -36,207 +443,1426 @@ type RandomBodySpec struct {
 
 // RequestRule defines a single mock request matching rule
 type RequestRule struct {
-	Path          string            `yaml:"path"`
-	Headers       map[string]string `yaml:"headers"`
-	QueryParams   map[string]string `yaml:"queryParams"`
-	Method        string            `yaml:"method"`
-	Response      ResponseSpec      `yaml:"response"`
-	Body          string            `yaml:"body"`
-	ResponseDelay *ResponseDelay    `yaml:"responseDelay"`
+	Name           string              `yaml:"name"` // Optional identifier used in diffs and logs
+	Path           string              `yaml:"path"`
+	PathPattern    *PathPattern        `yaml:"-"`          // Parsed from Path when it has {name} parameters
+	PathPrefix     string              `yaml:"pathPrefix"` // Match every path starting with this prefix, instead of path
+	Headers        map[string]string   `yaml:"headers"`
+	QueryParams    QueryParams         `yaml:"queryParams"`
+	Method         string              `yaml:"method"`
+	Response       ResponseSpec        `yaml:"response"`
+	Body           string              `yaml:"body"`
+	ContentLength  *SizeRange          `yaml:"contentLength"`  // Range the declared Content-Length header must fall in
+	BodySize       *SizeRange          `yaml:"bodySize"`       // Range the actual body size must fall in
+	JSONBody       interface{}         `yaml:"jsonBody"`       // Expected JSON body, compared structurally rather than byte for byte
+	JSONBodyMatch  JSONMatchOptions    `yaml:"jsonBodyMatch"`  // Equivalences applied when comparing against JSONBody
+	BodySchema     string              `yaml:"bodySchema"`     // Path to a JSON Schema file the request body must satisfy
+	BodySchemaMode string              `yaml:"bodySchemaMode"` // "match" (default) or "enforce"
+	Schema         *jsonschema.Schema  `yaml:"-"`              // Loaded from BodySchema during config loading
+	MatchMode      string              `yaml:"matchMode"`      // Overrides server.matchMode for this rule
+	When           string              `yaml:"when"`           // Optional expression that must evaluate to true
+	ClientCert     *ClientCertMatcher  `yaml:"clientCert"`     // Attributes the TLS client certificate must have
+	Groups         MatcherGroups       `yaml:",inline"`        // anyOf, allOf, and not matcher combinators
+	Scenario       string              `yaml:"scenario"`       // Name of the scenario state machine the rule belongs to
+	RequiredState  string              `yaml:"requiredState"`  // Scenario state required for the rule to match
+	NewState       string              `yaml:"newState"`       // Scenario state to transition to after the rule matches
+	ActiveFrom     string              `yaml:"activeFrom"`     // RFC 3339 time before which the rule is inactive
+	ActiveUntil    string              `yaml:"activeUntil"`    // RFC 3339 time from which the rule is inactive
+	Schedule       string              `yaml:"schedule"`       // Cron expression; the rule is active during matching minutes
+	ActiveWindow   ActiveWindow        `yaml:"-"`              // Parsed from ActiveFrom, ActiveUntil, and Schedule during config loading
+	OnCall         int                 `yaml:"onCall"`         // Match only the Nth request that satisfies the other matchers
+	AfterCalls     int                 `yaml:"afterCalls"`     // Match only after N requests have satisfied the other matchers
+	Weight         int                 `yaml:"weight"`         // Relative weight for random selection among matching weighted rules
+	ResponseDelay  *ResponseDelay      `yaml:"responseDelay"`
+	RetryAfter     *RetryAfter         `yaml:"retryAfter"` // Make each client wait and retry before it gets the response
+	Resource       *Resource           `yaml:"resource"`   // Serve an in-memory collection instead of a fixed response
+	Webhook        *Webhook            `yaml:"webhook"`    // Outbound request sent after the response
+	Webhooks       []Webhook           `yaml:"webhooks"`   // Several outbound requests sent after the response, instead of webhook
+	Audiences      []string            `yaml:"audiences"`  // Consumers the rule serves; empty to serve all
+	Responses      []WeightedResponse  `yaml:"responses"`  // Responses chosen by condition or at random by weight, instead of response
+	Assert         *ResponseAssertions `yaml:"assert"`     // Checks responses must pass before they are sent
+	Labels         map[string]string   `yaml:"labels"`     // Metric labels such as team, api, or criticality
+	Hold           bool                `yaml:"hold"`       // In interactive mode, wait for the operator to choose the response
+	SourceFile     string              `yaml:"-"`          // File of a config directory that defined the rule
 }
 
-// ResponseSpec describes the response to return when a rule matches
-type ResponseSpec struct {
-	Body       interface{}       `yaml:"body"`
-	RandomBody *RandomBodySpec   `yaml:"randomBody"`
-	StatusCode int               `yaml:"status-code"`
-	Headers    map[string]string `yaml:"headers"`
+// WeightedResponse is one of several responses a rule chooses between. The first
+// response whose condition holds is sent; otherwise one of the responses without a
+// condition is picked at random by weight. The variant header overrides both by naming
+// the response to send.
+type WeightedResponse struct {
+	Name         string `yaml:"name"`     // Identifies the response for the variant header
+	OnDemand     bool   `yaml:"onDemand"` // Sent only when the variant header names it
+	When         string `yaml:"when"`     // Expression the request must satisfy for this response
+	Weight       int    `yaml:"weight"`   // Relative chance of a response without when, defaults to 1
+	ResponseSpec `yaml:",inline"`
 }
 
-// Load reads and parses the configuration file
-func Load() (*Config, error) {
-	configPaths := []string{"config.yaml", "config/config.yaml"}
+// AllWebhooks returns the rule's webhook or webhooks, in the order they are sent
+func (r *RequestRule) AllWebhooks() []*Webhook {
+	if r.Webhook != nil {
+		return []*Webhook{r.Webhook}
+	}
+	webhooks := make([]*Webhook, len(r.Webhooks))
+	for i := range r.Webhooks {
+		webhooks[i] = &r.Webhooks[i]
+	}
+	return webhooks
+}
 
-	var configData []byte
-	var configPath string
-	var err error
+// DisplayPath returns the rule's path, or its path prefix followed by *
+func (r *RequestRule) DisplayPath() string {
+	if r.PathPrefix != "" {
+		return r.PathPrefix + "*"
+	}
+	return r.Path
+}
 
-	for _, path := range configPaths {
-		configData, err = os.ReadFile(path)
-		if err == nil {
-			configPath = path
-			break
-		}
+// ResponseChoices returns the weighted responses of the rule, or its single response
+func (r *RequestRule) ResponseChoices() []*ResponseSpec {
+	if len(r.Responses) == 0 {
+		return []*ResponseSpec{&r.Response}
 	}
+	specs := make([]*ResponseSpec, len(r.Responses))
+	for i := range r.Responses {
+		specs[i] = &r.Responses[i].ResponseSpec
+	}
+	return specs
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("could not find config file in any of %v: %w", configPaths, err)
+// AllResponses returns every response the rule can send, including variants
+func (r *RequestRule) AllResponses() []*ResponseSpec {
+	var specs []*ResponseSpec
+	for _, spec := range r.ResponseChoices() {
+		specs = append(specs, spec.Responses()...)
 	}
+	return specs
+}
 
-	var config Config
-	if err := yaml.Unmarshal(configData, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file %s: %w", configPath, err)
+// ActiveWindow restricts when a rule can match. The zero value is always active.
+type ActiveWindow struct {
+	From     time.Time
+	Until    time.Time
+	Schedule *Schedule
+}
+
+// Active reports whether the window includes t
+func (w ActiveWindow) Active(t time.Time) bool {
+	if !w.From.IsZero() && t.Before(w.From) {
+		return false
+	}
+	if !w.Until.IsZero() && !t.Before(w.Until) {
+		return false
 	}
+	if w.Schedule != nil && !w.Schedule.Active(t) {
+		return false
+	}
+	return true
+}
 
-	// Set defaults and validate
-	if err := config.setDefaults(); err != nil {
-		return nil, fmt.Errorf("failed to set defaults: %w", err)
+// ResponseSpec describes the response to return when a rule matches
+type ResponseSpec struct {
+	Body            interface{}       `yaml:"body"`
+	BodyFormat      string            `yaml:"bodyFormat"` // How a structured body is written: json (default) or xml
+	XML             *XMLBody          `yaml:"xml"`        // Root element and namespaces of a bodyFormat xml body
+	BodyBase64      string            `yaml:"bodyBase64"` // Binary body, sent byte for byte after decoding
+	BodyBytes       []byte            `yaml:"-"`          // Decoded from BodyBase64, encoded from a bodyFormat xml body, or generated from a schemaBody, during config loading
+	BodyFile        string            `yaml:"bodyFile"`   // File served as the body, cached until it changes on disk
+	Download        *Download         `yaml:"download"`   // Serve the body as a file to save, with Content-Disposition
+	ProxyBody       *ProxyBody        `yaml:"proxyBody"`  // Body taken from another URL
+	BodyURL         *BodyURL          `yaml:"bodyUrl"`    // Body fetched from a URL and served from memory
+	Template        bool              `yaml:"template"`   // Render a string body as a Go template
+	Dataset         string            `yaml:"dataset"`    // Serve the named dataset as a JSON body
+	Paginate        *Paginate         `yaml:"paginate"`   // Serve the list dataset one page at a time
+	RandomBody      *RandomBodySpec   `yaml:"randomBody"`
+	GenerateBody    *GenerateBody     `yaml:"generateBody"` // Body generated while it is written, never held in memory
+	SchemaBody      *SchemaBody       `yaml:"schemaBody"`   // JSON body generated from a JSON Schema or OpenAPI component
+	Status          StatusValue       `yaml:"status-code"`  // A number, or a template when template is set
+	StatusCode      int               `yaml:"-"`            // Taken from Status during config loading; 0 when it is a template
+	Headers         Headers           `yaml:"headers"`
+	Cookies         []Cookie          `yaml:"cookies"`         // Each sent in its own Set-Cookie header
+	Trailers        Headers           `yaml:"trailers"`        // Announced in a Trailer header and sent after the body
+	Checksums       []string          `yaml:"checksums"`       // Integrity headers computed over the body, e.g. etag or sha256
+	Conditional     bool              `yaml:"conditional"`     // Send ETag and Last-Modified and answer conditional requests with 304
+	Ranges          bool              `yaml:"ranges"`          // Answer Range requests with 206 Partial Content
+	Variants        ResponseVariants  `yaml:"variants"`        // Alternative responses chosen by the request's Accept header
+	EarlyHints      []string          `yaml:"earlyHints"`      // Link header values sent in a 103 Early Hints response first
+	Interim         []InterimResponse `yaml:"interim"`         // Informational 1xx responses sent before the final one
+	SecurityHeaders string            `yaml:"securityHeaders"` // Preset of security headers to add: strict, api, or off
+	Fault           string            `yaml:"fault"`           // Break the connection instead of responding normally
+	SSE             *SSEStream        `yaml:"sse"`             // Stream server-sent events instead of a body
+	Proxy           *Proxy            `yaml:"proxy"`           // Forward the request to a real upstream instead of responding
+	Transformer     *Transformer      `yaml:"transformer"`     // Produce the response with a function from a Go plugin
+	Mode            string            `yaml:"mode"`            // echo to reflect the request back as JSON
+	Redirect        *Redirect         `yaml:"redirect"`        // Redirect the client, optionally through several hops or a loop
+	Compress        string            `yaml:"compress"`        // auto to follow Accept-Encoding, or gzip, deflate, or br to force a coding
+	Golden          string            `yaml:"golden"`          // File the rendered body is compared against, reporting mismatches
+	bodyNode        *yaml.Node        // Body as declared, kept for writing it as XML
+}
+
+// StatusValue is a status code in YAML: a number, or a template string such as
+// "{{ or .Query.force_status \"200\" }}" rendered for each request
+type StatusValue struct {
+	Code     int
+	Template string
+}
+
+// UnmarshalYAML accepts a number or a template string
+func (s *StatusValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: status-code must be a number or a template", node.Line)
+	}
+	if code, err := strconv.Atoi(node.Value); err == nil {
+		*s = StatusValue{Code: code}
+		return nil
 	}
+	if !strings.Contains(node.Value, "{{") {
+		return fmt.Errorf("line %d: status-code %q must be a number or a template", node.Line, node.Value)
+	}
+	*s = StatusValue{Template: node.Value}
+	return nil
+}
 
-	if err := config.validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+// MarshalYAML writes the number or template back
+func (s StatusValue) MarshalYAML() (interface{}, error) {
+	if s.Template != "" {
+		return s.Template, nil
 	}
+	return s.Code, nil
+}
 
-	// Log each rule details
-	log.Printf("Loaded configuration from %s with %d request rules", configPath, len(config.Requests))
-	for i, rule := range config.Requests {
-		bodyDesc := "none"
-		if rb := rule.Response.RandomBody; rb != nil {
-			bodyDesc = fmt.Sprintf("random %s (%s)", rb.Type, formatBytes(rb.SizeBytes))
-		} else if rule.Response.Body != nil {
-			bodyDesc = "configured"
-		}
-		log.Printf(
-			"Rule %d: Path=%s, Method=%s, Headers=%v, QueryParams=%v, Body=%v, ResponseDelay=%v",
-			i+1, rule.Path, rule.Method, rule.Headers, rule.QueryParams, bodyDesc, rule.ResponseDelay,
-		)
+// Response compression modes
+const (
+	CompressAuto    = "auto" // Use the best coding the request's Accept-Encoding allows
+	CompressGzip    = "gzip"
+	CompressDeflate = "deflate"
+	CompressBrotli  = "br"
+)
+
+var validCompress = map[string]bool{CompressAuto: true, CompressGzip: true, CompressDeflate: true, CompressBrotli: true}
+
+// ResponseModeEcho reflects the request method, headers, query, and body back as JSON
+const ResponseModeEcho = "echo"
+
+// Responses returns the response followed by its variants
+func (s *ResponseSpec) Responses() []*ResponseSpec {
+	specs := []*ResponseSpec{s}
+	for i := range s.Variants {
+		specs = append(specs, &s.Variants[i].Response)
 	}
-	return &config, nil
+	return specs
 }
 
-func (c *Config) setDefaults() error {
-	if c.Server.Port == 0 {
-		c.Server.Port = 8080
+// ResponseVariant is a response served for one media type
+type ResponseVariant struct {
+	MediaType string
+	Response  ResponseSpec
+}
+
+// ResponseVariants maps media types to responses, keeping the order they were declared in
+type ResponseVariants []ResponseVariant
+
+// UnmarshalYAML decodes a mapping from media type to response
+func (v *ResponseVariants) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: variants must map media types to responses", node.Line)
 	}
+	variants := make(ResponseVariants, 0, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		variant := ResponseVariant{MediaType: node.Content[i].Value}
+		if err := node.Content[i+1].Decode(&variant.Response); err != nil {
+			return err
+		}
+		variants = append(variants, variant)
+	}
+	*v = variants
+	return nil
+}
 
-	for i := range c.Requests {
-		rule := &c.Requests[i]
-		if rule.Method == "" {
-			rule.Method = "GET"
+// MarshalYAML renders the variants as a mapping in declaration order
+func (v ResponseVariants) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, variant := range v {
+		var value yaml.Node
+		if err := value.Encode(variant.Response); err != nil {
+			return nil, err
 		}
-		rule.Method = strings.ToUpper(rule.Method)
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: variant.MediaType}, &value)
+	}
+	return node, nil
+}
 
-		if rule.Response.StatusCode == 0 {
-			rule.Response.StatusCode = 200
+// inheritVariants fills in each variant's status code, headers, and checksums from
+// the base response, and sets Content-Type to the variant's media type
+func (s *ResponseSpec) inheritVariants() {
+	for i := range s.Variants {
+		v := &s.Variants[i]
+		if v.Response.StatusCode == 0 && v.Response.Status.Template == "" {
+			v.Response.StatusCode = s.StatusCode
+			v.Response.Status.Template = s.Status.Template
+		}
+		if v.Response.Checksums == nil {
+			v.Response.Checksums = s.Checksums
+		}
+		if v.Response.Compress == "" {
+			v.Response.Compress = s.Compress
+		}
+		if v.Response.Cookies == nil {
+			v.Response.Cookies = s.Cookies
+		}
+		if v.Response.Trailers == nil {
+			v.Response.Trailers = s.Trailers
+		}
+		headers := make(map[string]string, len(s.Headers)+len(v.Response.Headers)+1)
+		for name, value := range s.Headers {
+			headers[name] = value
 		}
+		headers["Content-Type"] = v.MediaType
+		for name, value := range v.Response.Headers {
+			headers[name] = value
+		}
+		v.Response.Headers = headers
 	}
+}
 
-	return nil
+// Faults a response can inject instead of a well-formed response
+const (
+	FaultConnectionReset = "connectionReset" // Close the connection with a TCP reset
+	FaultEmptyResponse   = "emptyResponse"   // Close the connection without sending anything
+	FaultMalformedChunk  = "malformedChunk"  // Send the headers, then a chunk with an invalid size
+	FaultRandomGarbage   = "randomGarbage"   // Send random bytes instead of HTTP, then close
+)
+
+var validFaults = map[string]bool{
+	FaultConnectionReset: true, FaultEmptyResponse: true, FaultMalformedChunk: true, FaultRandomGarbage: true,
 }
 
-// MaxRandomBodySizeBytes is the maximum allowed random body size (2 GB) to support large payload and streaming testing.
-const MaxRandomBodySizeBytes = 2 * 1024 * 1024 * 1024
+// Checksum headers a response can carry
+const (
+	ChecksumContentMD5 = "content-md5" // Content-MD5: base64 MD5
+	ChecksumETag       = "etag"        // ETag: quoted hex MD5
+	ChecksumDigest     = "digest"      // Digest: sha-256=<base64>
+	ChecksumSHA1       = "sha1"        // x-amz-checksum-sha1
+	ChecksumSHA256     = "sha256"      // x-amz-checksum-sha256
+	ChecksumCRC32      = "crc32"       // x-amz-checksum-crc32
+	ChecksumCRC32C     = "crc32c"      // x-amz-checksum-crc32c
+)
 
-// parseSize parses a human-readable size string into bytes.
-// The numeric part is an integer; the optional unit suffix (case-insensitive)
-// may be b, k/kb, m/mb, or g/gb. No suffix means bytes.
-// Examples: "512", "1000b", "10k", "10kb", "2 MB", "1 GB"
-func parseSize(s string) (int, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, fmt.Errorf("size cannot be empty")
+var validChecksums = map[string]bool{
+	ChecksumContentMD5: true, ChecksumETag: true, ChecksumDigest: true,
+	ChecksumSHA1: true, ChecksumSHA256: true, ChecksumCRC32: true, ChecksumCRC32C: true,
+}
+
+// PathEnv is the environment variable naming the configuration file, so containers
+// can keep it anywhere
+const PathEnv = "MOCK_SERVER_CONFIG"
+
+// LoadFrom reads and parses the configuration file or directory at path, or the
+// file Load finds when path is empty
+func LoadFrom(path string) (*Config, error) {
+	if path == "" {
+		return Load()
 	}
-	if s[0] == '-' {
-		return 0, fmt.Errorf("size cannot be negative")
+	return loadPath(path)
+}
+
+// Load reads and parses the configuration file or directory named by MOCK_SERVER_CONFIG, or else
+// the first of DefaultPaths that exists. Binaries with an embedded configuration fall
+// back to it when no file is found.
+func Load() (*Config, error) {
+	if path := os.Getenv(PathEnv); path != "" {
+		return loadPath(path)
 	}
+	var configPath string
+	var err error
 
-	// Split at the boundary between digits and the unit suffix.
-	i := 0
-	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
-		i++
+	for _, path := range DefaultPaths {
+		if _, err = os.Stat(path); err == nil {
+			configPath = path
+			break
+		}
 	}
-	if i == 0 {
-		return 0, fmt.Errorf("size %q must start with a number", s)
+
+	if err != nil {
+		if HasEmbedded() {
+			return LoadSource(embeddedPrefix + EmbeddedName)
+		}
+		return nil, fmt.Errorf("could not find config file in any of %v: %w", DefaultPaths, err)
 	}
 
-	n, err := strconv.Atoi(s[:i])
+	return LoadFile(configPath)
+}
+
+// LoadSource reads and parses the configuration a Config.Source refers to,
+// either a file or directory path or a configuration embedded in the binary
+func LoadSource(source string) (*Config, error) {
+	if !strings.HasPrefix(source, embeddedPrefix) {
+		return loadPath(source)
+	}
+	data, err := readEmbedded(source)
 	if err != nil {
-		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+		return nil, err
 	}
+	return load(data, source)
+}
 
-	unit := strings.ToLower(strings.TrimSpace(s[i:]))
-	switch unit {
-	case "", "b":
-		return n, nil
-	case "k", "kb":
-		return n * 1024, nil
-	case "m", "mb":
-		return n * 1024 * 1024, nil
-	case "g", "gb":
-		return n * 1024 * 1024 * 1024, nil
-	default:
-		return 0, fmt.Errorf("size %q has unknown unit %q, use b, kb, mb, or gb", s, unit)
+// LoadFile reads and parses the configuration file at path
+func LoadFile(path string) (*Config, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file %s: %w", path, err)
 	}
+	return load(configData, path)
 }
 
-func formatBytes(n int) string {
-	switch {
-	case n >= 1024*1024*1024:
-		return fmt.Sprintf("%.1f GB", float64(n)/(1024*1024*1024))
-	case n >= 1024*1024:
-		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
-	case n >= 1024:
-		return fmt.Sprintf("%.1f KB", float64(n)/1024)
-	default:
-		return fmt.Sprintf("%d B", n)
+// loadPath loads the directory at path with LoadDir, or else the file
+func loadPath(path string) (*Config, error) {
+	if isDir(path) {
+		return LoadDir(path)
 	}
+	return LoadFile(path)
 }
 
-func (c *Config) validate() error {
-	if c.Server.Port == 0 {
-		return fmt.Errorf("server port is required")
+func load(data []byte, source string) (*Config, error) {
+	config, err := Parse(data, source)
+	if err != nil {
+		return nil, err
 	}
+	config.Source = source
 
-	for i, rule := range c.Requests {
-		if rule.Path == "" {
-			return fmt.Errorf("request rule %d: path is required", i)
-		}
+	log.Printf("Loaded configuration from %s with %d request rules", source, len(config.Requests))
+	return config, nil
+}
+
+// Parse parses, defaults, and validates configuration data.
+// The source names the origin of the data in error messages, and its
+// extension selects the format.
+func Parse(data []byte, source string) (*Config, error) {
+	doc, err := DecodeDocument(data, source)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(doc, source)
+}
+
+// parseDocument decodes, defaults, and validates a parsed configuration document
+func parseDocument(doc *yaml.Node, source string) (*Config, error) {
+	vaultCache.reset()
+	if err := expandDefinitions(doc); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+	}
+	if err := expandResponseRefs(doc); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+	}
+
+	var config Config
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&config); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+	}
+
+	// Set defaults and validate
+	if err := config.setDefaults(); err != nil {
+		return nil, fmt.Errorf("failed to set defaults: %w", err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return &config, nil
+}
+
+func (c *Config) setDefaults() error {
+	if c.Server.Port == 0 {
+		c.Server.Port = 8080
+	}
+	if ac := c.Server.AccessControl; ac != nil && ac.APIKeyHeader == "" {
+		ac.APIKeyHeader = "X-API-Key"
+	}
+	if b := c.Server.BindRetry; b != nil {
+		if b.Attempts == 0 {
+			b.Attempts = 5
+		}
+		if b.Backoff == 0 {
+			b.Backoff = 500
+		}
+	}
+	if c.Server.VariantHeader == "" {
+		c.Server.VariantHeader = DefaultVariantHeader
+	}
+	if e := c.Server.ErrorResponse; e != nil {
+		e.setDefaults()
+	}
+	c.Server.TemplateLimits.setDefaults()
+	if a := c.Server.Audiences; a != nil && a.Header == "" {
+		a.Header = DefaultAudienceHeader
+	}
+	if l := c.Server.Limits; l != nil && l.RetryAfter == 0 {
+		l.RetryAfter = 1
+	}
+	if o := c.Server.Limits; o != nil && o.Overload != nil {
+		if o.Overload.Status == 0 {
+			o.Overload.Status = http.StatusServiceUnavailable
+		}
+		if o.Overload.Interval == 0 {
+			o.Overload.Interval = 500
+		}
+	}
+	if m := c.Server.Mirror; m != nil {
+		m.setDefaults()
+	}
+	if g := c.Server.GRPCHealth; g != nil {
+		g.setDefaults()
+	}
+	if j := c.Server.Journal; j != nil {
+		j.setDefaults()
+	}
+	if m := c.Server.Metrics; m != nil {
+		m.setDefaults()
+	}
+	if f := c.Server.Freshness; f != nil {
+		f.setDefaults()
+	}
+	if s := c.Server.Storage; s != nil {
+		s.setDefaults()
+	}
+	if w := c.Server.Warmup; w != nil {
+		w.setDefaults()
+	}
+	if t := c.Server.TLS; t != nil && t.ClientAuth == "" {
+		// Ask for certificates by default so clientCert matchers work
+		t.ClientAuth = ClientAuthRequest
+		if t.ClientCAFile != "" {
+			t.ClientAuth = ClientAuthVerify
+		}
+	}
+
+	for i := range c.Requests {
+		rule := &c.Requests[i]
 		if rule.Method == "" {
-			return fmt.Errorf("request rule %d: method is required", i)
+			rule.Method = "GET"
+			if rule.Resource != nil {
+				rule.Method = AnyMethod
+			}
+		}
+		rule.Method = strings.ToUpper(rule.Method)
+		if rule.Resource != nil {
+			rule.Resource.setDefaults()
+		}
+
+		for j := range rule.Responses {
+			if wr := &rule.Responses[j]; wr.Weight == 0 && wr.When == "" && !wr.OnDemand {
+				wr.Weight = 1
+			}
+		}
+		for _, spec := range rule.AllResponses() {
+			if spec.StatusCode == 0 {
+				spec.StatusCode = spec.Status.Code
+			}
+		}
+		for _, spec := range rule.ResponseChoices() {
+			if spec.StatusCode == 0 && spec.Status.Template == "" {
+				spec.StatusCode = 200
+			}
+			spec.applySecurityHeaders()
+			spec.inheritVariants()
+		}
+		for _, spec := range rule.AllResponses() {
+			spec.applyDownload()
+			spec.applyBodyURL()
+			if spec.SSE != nil {
+				spec.SSE.setDefaults()
+			}
+			if spec.Proxy != nil {
+				spec.Proxy.setDefaults()
+			}
+			if spec.Transformer != nil {
+				spec.Transformer.setDefaults()
+			}
+			if spec.Paginate != nil {
+				spec.Paginate.setDefaults()
+			}
+			if spec.ProxyBody != nil {
+				spec.ProxyBody.setDefaults()
+			}
+			if spec.GenerateBody != nil {
+				spec.GenerateBody.setDefaults()
+			}
+			if spec.Redirect != nil {
+				spec.Redirect.setDefaults()
+				if spec.Status.Code == 0 && spec.Status.Template == "" {
+					spec.StatusCode = spec.Redirect.Status
+				}
+			}
+		}
+		if rule.ResponseDelay != nil {
+			rule.ResponseDelay.setDefaults()
+		}
+		if rule.RetryAfter != nil {
+			rule.RetryAfter.setDefaults()
+		}
+		for _, wh := range rule.AllWebhooks() {
+			wh.setDefaults()
+		}
+		if rule.Assert != nil {
+			rule.Assert.setDefaults()
+		}
+	}
+
+	for i := range c.StartupChecks {
+		check := &c.StartupChecks[i]
+		if check.Request.Method == "" {
+			check.Request.Method = "GET"
+		}
+		check.Request.Method = strings.ToUpper(check.Request.Method)
+		if check.Name == "" {
+			check.Name = check.Request.Method + " " + check.Request.Path
+		}
+		if check.Expect.Status == 0 {
+			check.Expect.Status = 200
+		}
+	}
+
+	return nil
+}
+
+// MaxRandomBodySizeBytes is the maximum allowed random body size (2 GB) to support large payload and streaming testing.
+const MaxRandomBodySizeBytes = 2 * 1024 * 1024 * 1024
+
+// parseSize parses a human-readable size string into bytes.
+// The numeric part is an integer; the optional unit suffix (case-insensitive)
+// may be b, k/kb, m/mb, or g/gb. No suffix means bytes.
+// Examples: "512", "1000b", "10k", "10kb", "2 MB", "1 GB"
+func parseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("size cannot be empty")
+	}
+	if s[0] == '-' {
+		return 0, fmt.Errorf("size cannot be negative")
+	}
+
+	// Split at the boundary between digits and the unit suffix.
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("size %q must start with a number", s)
+	}
+
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(s[i:]))
+	switch unit {
+	case "", "b":
+		return n, nil
+	case "k", "kb":
+		return n * 1024, nil
+	case "m", "mb":
+		return n * 1024 * 1024, nil
+	case "g", "gb":
+		return n * 1024 * 1024 * 1024, nil
+	default:
+		return 0, fmt.Errorf("size %q has unknown unit %q, use b, kb, mb, or gb", s, unit)
+	}
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1024*1024*1024))
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// validate checks the whole configuration and returns ValidationErrors listing every
+// problem found, so a broken configuration can be fixed in one pass
+func (c *Config) validate() error {
+	var errs ValidationErrors
+	errs.add(-1, nil, c.validateListener())
+	errs.add(-1, nil, c.validateLimits())
+	if m := c.Server.Mirror; m != nil {
+		if err := m.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server mirror: %w", err))
+		}
+	}
+	if g := c.Server.GRPCHealth; g != nil {
+		if err := g.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server grpcHealth: %w", err))
+		}
+	}
+	if j := c.Server.Journal; j != nil {
+		if err := j.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server journal: %w", err))
+		}
+	}
+	if m := c.Server.Metrics; m != nil {
+		if err := m.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server metrics: %w", err))
+		}
+	}
+	if f := c.Server.Freshness; f != nil {
+		if err := f.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server freshness: %w", err))
+		}
+	}
+	if s := c.Server.Storage; s != nil {
+		if err := s.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server storage: %w", err))
+		}
+	}
+	if w := c.Server.Warmup; w != nil {
+		if err := w.validate(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server warmup: %w", err))
+		}
+	}
+	errs.add(-1, nil, c.validateAudiences())
+	if t := c.Server.TLS; t != nil {
+		if err := t.load(); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server tls: %w", err))
+		}
+	}
+
+	keys, err := templating.NewKeyring(c.Server.KeyValues())
+	if err != nil {
+		// Templates can't be checked without their keys
+		errs.add(-1, nil, fmt.Errorf("server keys: %w", err))
+		return errs
+	}
+	if err := c.Server.TemplateLimits.validate(); err != nil {
+		errs.add(-1, nil, fmt.Errorf("server templateLimits: %w", err))
+	}
+	opts := templating.Options{Keys: keys, Denied: c.Server.TemplateLimits.DeniedFunctions}
+	if len(c.Partials) > 0 {
+		if c.PartialSet, err = c.loadPartials(opts); err != nil {
+			// Templates can't be checked without the partials they include
+			errs.add(-1, nil, err)
+			return errs
+		}
+		opts.Partials = c.PartialSet
+	}
+	if e := c.Server.ErrorResponse; e != nil {
+		if err := e.validate(opts); err != nil {
+			errs.add(-1, nil, fmt.Errorf("server errorResponse: %w", err))
+		}
+	}
+
+	c.DatasetData = make(map[string]interface{}, len(c.Datasets))
+	for _, name := range sortedKeys(c.Datasets) {
+		data, err := LoadDataset(c.Datasets[name])
+		if err != nil {
+			errs.add(-1, nil, fmt.Errorf("dataset %q: %w", name, err))
+			continue
+		}
+		c.DatasetData[name] = data
+	}
+
+	for name, state := range c.Scenarios {
+		if strings.TrimSpace(name) == "" || strings.TrimSpace(state) == "" {
+			errs.add(-1, nil, fmt.Errorf("scenarios: names and initial states cannot be empty"))
+			break
+		}
+	}
+
+	c.RuleLoadTimes = make([]time.Duration, len(c.Requests))
+	for i := range c.Requests {
+		start := time.Now()
+		errs.add(i, &c.Requests[i], c.validateRule(i, &c.Requests[i], opts))
+		c.RuleLoadTimes[i] = time.Since(start)
+	}
+
+	for i, check := range c.StartupChecks {
+		errs.add(-1, nil, validateStartupCheck(i, check))
+	}
+	return errs.err()
+}
+
+// validateListener checks how the server listens and who it lets in
+func (c *Config) validateListener() error {
+	if c.Server.Port == 0 {
+		return fmt.Errorf("server port is required")
+	}
+	if c.Server.PortRange != "" {
+		if _, _, err := ParsePortRange(c.Server.PortRange); err != nil {
+			return fmt.Errorf("server portRange: %w", err)
+		}
+	}
+	if b := c.Server.BindRetry; b != nil && (b.Attempts < 1 || b.Backoff < 0) {
+		return fmt.Errorf("server bindRetry: attempts must be positive and backoff cannot be negative")
+	}
+	if !validMatchMode(c.Server.MatchMode) {
+		return fmt.Errorf("server matchMode must be one of: full, partial")
+	}
+	if ac := c.Server.AccessControl; ac != nil {
+		for _, cidr := range append(append([]string{}, ac.Allow.CIDRs...), ac.Deny.CIDRs...) {
+			if _, err := ParsePrefix(cidr); err != nil {
+				return fmt.Errorf("server accessControl: %w", err)
+			}
+		}
+	}
+	if a := c.Server.Admin; a != nil {
+		if err := a.validate(c.Server.AccessControl); err != nil {
+			return fmt.Errorf("server admin: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateLimits checks server.limits and parses its sizes
+func (c *Config) validateLimits() error {
+	if l := c.Server.Limits; l != nil {
+		if l.MaxInFlight < 0 {
+			return fmt.Errorf("server limits: maxInFlight cannot be negative")
+		}
+		if l.RetryAfter < 0 {
+			return fmt.Errorf("server limits: retryAfter cannot be negative")
+		}
+		if o := l.Overload; o != nil {
+			if o.MaxCPU <= 0 && o.MaxLag <= 0 {
+				return fmt.Errorf("server limits: overload requires maxCPU or maxLag")
+			}
+			if o.MaxCPU < 0 || o.MaxCPU > 1 || o.MaxLag < 0 || o.Interval < 0 {
+				return fmt.Errorf("server limits: overload maxCPU must be between 0 and 1, and maxLag and interval cannot be negative")
+			}
+			if o.Status != http.StatusTooManyRequests && o.Status != http.StatusServiceUnavailable {
+				return fmt.Errorf("server limits: overload status must be 429 or 503")
+			}
+		}
+		if l.MaxBodyMemory != "" {
+			size, err := parseSize(l.MaxBodyMemory)
+			if err != nil {
+				return fmt.Errorf("server limits: maxBodyMemory: %w", err)
+			}
+			l.MaxBodyBytes = size
+		}
+	}
+	return nil
+}
+
+// validateAudiences checks that every audience port is valid and unused
+func (c *Config) validateAudiences() error {
+	if a := c.Server.Audiences; a != nil {
+		ports := map[uint]string{c.Server.Port: "server port"}
+		for name, port := range a.Ports {
+			if name == "" || port == 0 || port > 65535 {
+				return fmt.Errorf("server audiences: invalid port %d for audience %q", port, name)
+			}
+			if other, ok := ports[port]; ok {
+				return fmt.Errorf("server audiences: port %d of audience %q is already used by %s", port, name, other)
+			}
+			ports[port] = fmt.Sprintf("audience %q", name)
+		}
+	}
+	return nil
+}
+
+// validateRule checks request rule i, stopping at its first problem
+func (c *Config) validateRule(i int, rule *RequestRule, opts templating.Options) error {
+	if rule.PathPrefix != "" {
+		if rule.Path != "" {
+			return fmt.Errorf("request rule %d: path and pathPrefix are mutually exclusive", i)
+		}
+		if !strings.HasPrefix(rule.PathPrefix, "/") {
+			return fmt.Errorf("request rule %d: pathPrefix must start with /", i)
+		}
+	} else if rule.Path == "" {
+		return fmt.Errorf("request rule %d: path is required unless pathPrefix is set", i)
+	}
+	pattern, err := ParsePathPattern(rule.Path)
+	if err != nil {
+		return fmt.Errorf("request rule %d: %w", i, err)
+	}
+	rule.PathPattern = pattern
+	if rule.Method == "" {
+		return fmt.Errorf("request rule %d: method is required", i)
+	}
+	if !validMatchMode(rule.MatchMode) {
+		return fmt.Errorf("request rule %d: matchMode must be one of: full, partial", i)
+	}
+	if rule.Resource != nil {
+		if err := c.validateResource(rule); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	}
+	if err := c.normalizePatterns(i, rule); err != nil {
+		return err
+	}
+	if rule.Scenario == "" && (rule.RequiredState != "" || rule.NewState != "") {
+		return fmt.Errorf("request rule %d: requiredState and newState require a scenario", i)
+	}
+	if rule.Weight < 0 {
+		return fmt.Errorf("request rule %d: weight cannot be negative", i)
+	}
+	if rule.OnCall < 0 || rule.AfterCalls < 0 {
+		return fmt.Errorf("request rule %d: onCall and afterCalls cannot be negative", i)
+	}
+	if rule.OnCall > 0 && rule.AfterCalls > 0 {
+		return fmt.Errorf("request rule %d: onCall and afterCalls are mutually exclusive", i)
+	}
+	if err := rule.parseActiveWindow(); err != nil {
+		return fmt.Errorf("request rule %d: %w", i, err)
+	}
+	if rule.ContentLength != nil {
+		if err := rule.ContentLength.parse(); err != nil {
+			return fmt.Errorf("request rule %d: contentLength %w", i, err)
+		}
+	}
+	if rule.BodySize != nil {
+		if err := rule.BodySize.parse(); err != nil {
+			return fmt.Errorf("request rule %d: bodySize %w", i, err)
 		}
-		if rule.Response.StatusCode < 100 || rule.Response.StatusCode > 599 {
-			return fmt.Errorf("request rule %d: invalid status code %d", i, rule.Response.StatusCode)
+	}
+	if rule.JSONBody != nil {
+		normalized, err := normalizeJSON(rule.JSONBody)
+		if err != nil {
+			return fmt.Errorf("request rule %d: jsonBody: %w", i, err)
 		}
-		if delay := rule.ResponseDelay; delay != nil {
-			if delay.Min < 0 {
-				return fmt.Errorf("request rule %d: responseDelay min cannot be negative", i)
+		rule.JSONBody = normalized
+	}
+	if err := rule.loadSchema(); err != nil {
+		return fmt.Errorf("request rule %d: %w", i, err)
+	}
+	if err := validateMetricLabels(rule.Labels); err != nil {
+		return fmt.Errorf("request rule %d: %w", i, err)
+	}
+	if rule.Assert != nil {
+		for _, spec := range rule.AllResponses() {
+			if pb := spec.ProxyBody; pb != nil && !pb.Cache && rule.Assert.BodySchema != "" {
+				return fmt.Errorf("request rule %d: assert bodySchema can't check a streamed proxyBody, set cache", i)
 			}
-			if delay.Max < 0 {
-				return fmt.Errorf("request rule %d: responseDelay max cannot be negative", i)
+			if spec.GenerateBody != nil && rule.Assert.BodySchema != "" {
+				return fmt.Errorf("request rule %d: assert bodySchema can't check a generateBody", i)
 			}
-			if delay.Min > delay.Max {
-				return fmt.Errorf("request rule %d: responseDelay min (%d) cannot exceed max (%d)", i, delay.Min, delay.Max)
+			if spec.BodyFormat == BodyFormatXML && rule.Assert.BodySchema != "" {
+				return fmt.Errorf("request rule %d: assert bodySchema can't check a bodyFormat xml body", i)
 			}
 		}
-		if rb := rule.Response.RandomBody; rb != nil {
-			if rule.Response.Body != nil {
-				return fmt.Errorf("request rule %d: body and randomBody are mutually exclusive", i)
+		if err := rule.Assert.load(); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	}
+	if rule.When != "" {
+		if _, err := expr.Compile(rule.When); err != nil {
+			return fmt.Errorf("request rule %d: when: %w", i, err)
+		}
+	}
+	err = rule.Groups.Walk(func(m *Matcher) error {
+		if m.Empty() {
+			return fmt.Errorf("anyOf, allOf, and not entries must set at least one matcher")
+		}
+		if m.When != "" {
+			if _, err := expr.Compile(m.When); err != nil {
+				return fmt.Errorf("when: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("request rule %d: %w", i, err)
+	}
+	for _, audience := range rule.Audiences {
+		if strings.TrimSpace(audience) == "" {
+			return fmt.Errorf("request rule %d: audiences cannot contain empty names", i)
+		}
+	}
+	if delay := rule.ResponseDelay; delay != nil {
+		if err := delay.validate(); err != nil {
+			return fmt.Errorf("request rule %d: responseDelay %w", i, err)
+		}
+	}
+	if len(rule.Responses) == 0 {
+		if err := c.validateResponseVariants(&rule.Response, opts); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	} else if !reflect.DeepEqual(rule.Response, ResponseSpec{}) {
+		return fmt.Errorf("request rule %d: response and responses are mutually exclusive", i)
+	}
+	defaults := 0
+	names := make(map[string]bool)
+	for j := range rule.Responses {
+		wr := &rule.Responses[j]
+		if wr.Weight < 0 {
+			return fmt.Errorf("request rule %d: responses[%d]: weight cannot be negative", i, j)
+		}
+		if wr.Name != "" {
+			if names[wr.Name] {
+				return fmt.Errorf("request rule %d: responses[%d]: duplicate name %q", i, j, wr.Name)
 			}
-			switch rb.Type {
-			case "plaintext", "json", "xml":
-				// valid
-			default:
-				return fmt.Errorf("request rule %d: randomBody type must be one of: plaintext, json, xml", i)
+			names[wr.Name] = true
+		}
+		if wr.OnDemand {
+			if wr.Name == "" || wr.When != "" || wr.Weight != 0 {
+				return fmt.Errorf("request rule %d: responses[%d]: onDemand needs a name and no when or weight", i, j)
 			}
-			n, err := parseSize(rb.Size)
+		} else if wr.When == "" {
+			defaults++
+		} else if wr.Weight != 0 {
+			return fmt.Errorf("request rule %d: responses[%d]: weight only applies to responses without when", i, j)
+		} else if _, err := expr.Compile(wr.When); err != nil {
+			return fmt.Errorf("request rule %d: responses[%d]: when: %w", i, j, err)
+		}
+		if err := c.validateResponseVariants(&wr.ResponseSpec, opts); err != nil {
+			return fmt.Errorf("request rule %d: responses[%d]: %w", i, j, err)
+		}
+	}
+	if len(rule.Responses) > 0 && defaults == 0 {
+		return fmt.Errorf("request rule %d: responses need a default response without when", i)
+	}
+	if ra := rule.RetryAfter; ra != nil {
+		if err := ra.validate(opts); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	}
+	if wh := rule.Webhook; wh != nil {
+		if len(rule.Webhooks) > 0 {
+			return fmt.Errorf("request rule %d: webhook and webhooks are mutually exclusive", i)
+		}
+		if err := wh.validate(opts); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	}
+	for j := range rule.Webhooks {
+		if err := rule.Webhooks[j].validate(opts); err != nil {
+			return fmt.Errorf("request rule %d: webhooks[%d]: %w", i, j, err)
+		}
+	}
+	return nil
+}
+
+// validateStartupCheck checks startup check i
+func validateStartupCheck(i int, check StartupCheck) error {
+	if !strings.HasPrefix(check.Request.Path, "/") {
+		return fmt.Errorf("startup check %d: request path must start with /", i)
+	}
+	if check.Expect.Status < 100 || check.Expect.Status > 599 {
+		return fmt.Errorf("startup check %d: invalid expected status %d", i, check.Expect.Status)
+	}
+	patterns := []string{check.Expect.Body}
+	for _, p := range check.Expect.Headers {
+		patterns = append(patterns, p)
+	}
+	for _, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("startup check %d: invalid pattern %q: %w", i, p, err)
+		}
+	}
+	return nil
+}
+
+// validateResponseVariants checks a response and its variants
+func (c *Config) validateResponseVariants(spec *ResponseSpec, opts templating.Options) error {
+	if err := c.validateResponse(spec, opts); err != nil {
+		return err
+	}
+	for _, v := range spec.Variants {
+		if _, _, err := mime.ParseMediaType(v.MediaType); err != nil || !strings.Contains(v.MediaType, "/") {
+			return fmt.Errorf("invalid variant media type %q", v.MediaType)
+		}
+		if len(v.Response.Variants) > 0 {
+			return fmt.Errorf("variant %s cannot have variants", v.MediaType)
+		}
+		if v.Response.SecurityHeaders != "" {
+			return fmt.Errorf("variant %s cannot have securityHeaders, they come from the enclosing response", v.MediaType)
+		}
+		if len(v.Response.EarlyHints) > 0 {
+			return fmt.Errorf("variant %s cannot have earlyHints, they are sent before negotiation", v.MediaType)
+		}
+		if len(v.Response.Interim) > 0 {
+			return fmt.Errorf("variant %s cannot have interim responses, they are sent before negotiation", v.MediaType)
+		}
+		if err := c.validateResponse(&v.Response, opts); err != nil {
+			return fmt.Errorf("variant %s: %w", v.MediaType, err)
+		}
+	}
+	return nil
+}
+
+// validateResponse checks a response or response variant
+func (c *Config) validateResponse(spec *ResponseSpec, opts templating.Options) error {
+	if t := spec.Status.Template; t != "" {
+		if !spec.Template {
+			return fmt.Errorf("status-code %q is a template, which needs template: true", t)
+		}
+		if spec.Fault != "" {
+			return fmt.Errorf("a templated status-code cannot be combined with fault")
+		}
+		if _, err := templating.Parse("status-code", t, opts); err != nil {
+			return fmt.Errorf("status-code: %w", err)
+		}
+	} else if spec.StatusCode < 100 || spec.StatusCode > 599 {
+		return fmt.Errorf("invalid status code %d", spec.StatusCode)
+	}
+	for _, checksum := range spec.Checksums {
+		if !validChecksums[checksum] {
+			return fmt.Errorf("unknown checksum %q, use content-md5, etag, digest, sha1, sha256, crc32, or crc32c", checksum)
+		}
+	}
+	if spec.Fault != "" && !validFaults[spec.Fault] {
+		return fmt.Errorf("unknown fault %q, use connectionReset, emptyResponse, malformedChunk, or randomGarbage", spec.Fault)
+	}
+	if _, ok := securityHeaderPresets[spec.SecurityHeaders]; !ok && spec.SecurityHeaders != "" {
+		return fmt.Errorf("securityHeaders must be one of: strict, api, off")
+	}
+	for _, link := range spec.EarlyHints {
+		if !strings.HasPrefix(strings.TrimSpace(link), "<") || strings.ContainsAny(link, "\r\n") {
+			return fmt.Errorf("invalid earlyHints link %q, expected a Link header value such as </app.css>; rel=preload", link)
+		}
+	}
+	if len(spec.Trailers) > 0 {
+		if spec.Proxy != nil || spec.Fault != "" || spec.SSE != nil {
+			return fmt.Errorf("trailers cannot be combined with proxy, fault, or sse")
+		}
+		if err := validateTrailers(spec.Trailers); err != nil {
+			return err
+		}
+	}
+	for j := range spec.Cookies {
+		if err := spec.Cookies[j].load(); err != nil {
+			return err
+		}
+	}
+	for j := range spec.Interim {
+		if err := spec.Interim[j].validate(); err != nil {
+			return fmt.Errorf("interim[%d]: %w", j, err)
+		}
+	}
+	if spec.BodyBase64 != "" {
+		if spec.Body != nil || spec.RandomBody != nil || spec.Dataset != "" || spec.Template {
+			return fmt.Errorf("bodyBase64 cannot be combined with body, randomBody, dataset, or template")
+		}
+		b, err := decodeBase64(spec.BodyBase64)
+		if err != nil {
+			return fmt.Errorf("bodyBase64: %w", err)
+		}
+		spec.BodyBytes = b
+	}
+	if err := spec.encodeXMLBody(); err != nil {
+		return err
+	}
+	if spec.Compress != "" {
+		if !validCompress[spec.Compress] {
+			return fmt.Errorf("unknown compress %q, use auto, gzip, deflate, or br", spec.Compress)
+		}
+		if spec.SSE != nil || spec.Fault != "" {
+			return fmt.Errorf("compress cannot be combined with sse or fault")
+		}
+	}
+	if sse := spec.SSE; sse != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" || spec.Fault != "" {
+			return fmt.Errorf("sse cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, or fault")
+		}
+		if err := sse.validate(); err != nil {
+			return err
+		}
+	}
+	if p := spec.Proxy; p != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Template || spec.Compress != "" || len(spec.Headers) > 0 || len(spec.Cookies) > 0 || len(spec.Checksums) > 0 {
+			return fmt.Errorf("proxy cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, fault, sse, template, compress, headers, cookies, or checksums")
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	if spec.Conditional && (spec.Proxy != nil || spec.SSE != nil || spec.Fault != "") {
+		return fmt.Errorf("conditional cannot be combined with proxy, sse, or fault")
+	}
+	if spec.Ranges && (spec.Proxy != nil || spec.SSE != nil || spec.Fault != "" || spec.Compress != "" || len(spec.Trailers) > 0 || (spec.ProxyBody != nil && !spec.ProxyBody.Cache)) {
+		return fmt.Errorf("ranges cannot be combined with proxy, sse, fault, compress, trailers, or a proxyBody without cache")
+	}
+	if spec.Golden != "" && (spec.Proxy != nil || spec.SSE != nil || spec.Fault != "" || (spec.ProxyBody != nil && !spec.ProxyBody.Cache) || spec.GenerateBody != nil) {
+		return fmt.Errorf("golden cannot be combined with proxy, sse, fault, generateBody, or a proxyBody without cache")
+	}
+	if spec.Mode != "" {
+		if spec.Mode != ResponseModeEcho {
+			return fmt.Errorf("unknown mode %q, use echo", spec.Mode)
+		}
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Transformer != nil || spec.Template {
+			return fmt.Errorf("mode echo cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, fault, sse, proxy, transformer, or template")
+		}
+	}
+	if rd := spec.Redirect; rd != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Transformer != nil || spec.Mode != "" || spec.Template {
+			return fmt.Errorf("redirect cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, fault, sse, proxy, transformer, mode, or template")
+		}
+		if spec.Status.Code != 0 || spec.Status.Template != "" {
+			return fmt.Errorf("redirect sets the status code with redirect status, not status-code")
+		}
+		if err := rd.validate(); err != nil {
+			return err
+		}
+	}
+	if t := spec.Transformer; t != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Template {
+			return fmt.Errorf("transformer cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, fault, sse, proxy, or template")
+		}
+		if err := t.validate(); err != nil {
+			return err
+		}
+	}
+	if spec.BodyURL != nil {
+		if err := spec.validateBodyURL(); err != nil {
+			return err
+		}
+	}
+	if spec.SchemaBody != nil {
+		if err := spec.loadSchemaBody(); err != nil {
+			return err
+		}
+	}
+	if pb := spec.ProxyBody; pb != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Transformer != nil || spec.Mode != "" || spec.Redirect != nil {
+			return fmt.Errorf("proxyBody cannot be combined with body, bodyBase64, bodyFile, randomBody, dataset, fault, sse, proxy, transformer, mode, or redirect")
+		}
+		if !pb.Cache && (len(spec.Checksums) > 0 || spec.Compress != "" || spec.Conditional) {
+			return fmt.Errorf("proxyBody streams the body, so checksums, compress, and conditional need cache")
+		}
+		if err := pb.validate(); err != nil {
+			return err
+		}
+	}
+	if spec.Download != nil {
+		if err := spec.validateDownload(); err != nil {
+			return err
+		}
+	}
+	if gb := spec.GenerateBody; gb != nil {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.ProxyBody != nil || spec.RandomBody != nil || spec.Dataset != "" ||
+			spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Transformer != nil || spec.Mode != "" || spec.Redirect != nil || spec.Template {
+			return fmt.Errorf("generateBody cannot be combined with body, bodyBase64, bodyFile, proxyBody, randomBody, dataset, fault, sse, proxy, transformer, mode, redirect, or template")
+		}
+		if len(spec.Checksums) > 0 || spec.Compress != "" || spec.Conditional {
+			return fmt.Errorf("generateBody is never held in memory, so it cannot be combined with checksums, compress, or conditional")
+		}
+		if err := gb.validate(); err != nil {
+			return err
+		}
+	}
+	if spec.BodyFile != "" {
+		if spec.Body != nil || spec.BodyBase64 != "" || spec.RandomBody != nil || spec.Dataset != "" {
+			return fmt.Errorf("bodyFile cannot be combined with body, bodyBase64, randomBody, or dataset")
+		}
+		// Remote storage is only reached when the file is first served
+		if !c.Server.Storage.Remote() {
+			info, err := os.Stat(c.Server.Storage.LocalPath(spec.BodyFile))
 			if err != nil {
-				return fmt.Errorf("request rule %d: randomBody size: %w", i, err)
+				return fmt.Errorf("bodyFile: %w", err)
 			}
-			rb.SizeBytes = n
-			if rb.SizeBytes > MaxRandomBodySizeBytes {
-				return fmt.Errorf("request rule %d: randomBody size (%s) exceeds maximum allowed (%s)", i, formatBytes(rb.SizeBytes), formatBytes(MaxRandomBodySizeBytes))
+			if info.IsDir() {
+				return fmt.Errorf("bodyFile %s is a directory", spec.BodyFile)
 			}
-			if rb.Type == "json" && rb.SizeBytes < 2 {
-				return fmt.Errorf("request rule %d: randomBody size for json must be at least 2", i)
+		}
+	}
+	if spec.Template {
+		if spec.Body != nil {
+			body, ok := spec.Body.(string)
+			if !ok {
+				return fmt.Errorf("template requires a string body")
 			}
-			if rb.Type == "json" && rb.SizeBytes > 2 && rb.SizeBytes < 7 {
-				return fmt.Errorf("request rule %d: randomBody size for json must be 2 or at least 7", i)
+			if _, err := templating.Parse("body", body, opts); err != nil {
+				return err
 			}
-			if rb.Type == "xml" && rb.SizeBytes < 7 {
-				return fmt.Errorf("request rule %d: randomBody size for xml must be at least 7", i)
+		}
+		for name, value := range spec.Headers {
+			if _, err := templating.Parse(name, value, opts); err != nil {
+				return fmt.Errorf("header %s: %w", name, err)
 			}
 		}
 	}
+	if name := spec.Dataset; name != "" {
+		if _, ok := c.Datasets[name]; !ok {
+			return fmt.Errorf("unknown dataset %q", name)
+		}
+		if spec.Body != nil || spec.RandomBody != nil {
+			return fmt.Errorf("dataset cannot be combined with body or randomBody")
+		}
+	}
+	if p := spec.Paginate; p != nil {
+		if spec.Dataset == "" {
+			return fmt.Errorf("paginate requires a dataset")
+		}
+		if err := p.validate(c.DatasetData[spec.Dataset]); err != nil {
+			return err
+		}
+	}
+	if rb := spec.RandomBody; rb != nil {
+		if spec.Body != nil {
+			return fmt.Errorf("body and randomBody are mutually exclusive")
+		}
+		switch rb.Type {
+		case "plaintext", "json", "xml":
+			// valid
+		default:
+			return fmt.Errorf("randomBody type must be one of: plaintext, json, xml")
+		}
+		n, err := parseSize(rb.Size)
+		if err != nil {
+			return fmt.Errorf("randomBody size: %w", err)
+		}
+		rb.SizeBytes = n
+		if rb.SizeBytes > MaxRandomBodySizeBytes {
+			return fmt.Errorf("randomBody size (%s) exceeds maximum allowed (%s)", formatBytes(rb.SizeBytes), formatBytes(MaxRandomBodySizeBytes))
+		}
+		if rb.Type == "json" && rb.SizeBytes < 2 {
+			return fmt.Errorf("randomBody size for json must be at least 2")
+		}
+		if rb.Type == "json" && rb.SizeBytes > 2 && rb.SizeBytes < 7 {
+			return fmt.Errorf("randomBody size for json must be 2 or at least 7")
+		}
+		if rb.Type == "xml" && rb.SizeBytes < 7 {
+			return fmt.Errorf("randomBody size for xml must be at least 7")
+		}
+	}
+	return nil
+}
+
+// decodeBase64 decodes standard base64 with or without padding. Whitespace is
+// ignored so long values can be wrapped in YAML block scalars.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func (r *RequestRule) parseActiveWindow() error {
+	var w ActiveWindow
+	var err error
+	if r.ActiveFrom != "" {
+		if w.From, err = time.Parse(time.RFC3339, r.ActiveFrom); err != nil {
+			return fmt.Errorf("activeFrom must be an RFC 3339 timestamp: %w", err)
+		}
+	}
+	if r.ActiveUntil != "" {
+		if w.Until, err = time.Parse(time.RFC3339, r.ActiveUntil); err != nil {
+			return fmt.Errorf("activeUntil must be an RFC 3339 timestamp: %w", err)
+		}
+	}
+	if !w.From.IsZero() && !w.Until.IsZero() && !w.From.Before(w.Until) {
+		return fmt.Errorf("activeFrom must be before activeUntil")
+	}
+	if r.Schedule != "" {
+		if w.Schedule, err = ParseSchedule(r.Schedule); err != nil {
+			return err
+		}
+	}
+	r.ActiveWindow = w
+	return nil
+}
 
+func (r *RequestRule) loadSchema() error {
+	switch r.BodySchemaMode {
+	case "", SchemaModeMatch, SchemaModeEnforce:
+	default:
+		return fmt.Errorf("bodySchemaMode must be one of: match, enforce")
+	}
+	if r.BodySchema == "" {
+		if r.BodySchemaMode != "" {
+			return fmt.Errorf("bodySchemaMode requires bodySchema")
+		}
+		return nil
+	}
+	schema, err := jsonschema.Load(r.BodySchema)
+	if err != nil {
+		return fmt.Errorf("bodySchema: %w", err)
+	}
+	r.Schema = schema
 	return nil
 }
+
+// normalizeJSON converts a YAML-decoded value to the types encoding/json produces,
+// so it compares directly against decoded request bodies.
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("not representable as JSON: %w", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// loadPartials reads and parses the partial template files
+func (c *Config) loadPartials(opts templating.Options) (*template.Template, error) {
+	texts := make(map[string]string, len(c.Partials))
+	for _, name := range sortedKeys(c.Partials) {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("partials: names cannot be empty")
+		}
+		data, err := os.ReadFile(c.Partials[name])
+		if err != nil {
+			return nil, fmt.Errorf("partial %q: could not read template: %w", name, err)
+		}
+		texts[name] = string(data)
+	}
+	return templating.ParsePartials(texts, opts)
+}
+
+// LoadDataset reads a JSON or YAML dataset file
+func LoadDataset(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read dataset: %w", err)
+	}
+	return ParseDataset(data)
+}
+
+// ParseDataset parses JSON or YAML dataset content into JSON-compatible values
+func ParseDataset(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+	if v == nil {
+		return nil, fmt.Errorf("invalid dataset: content is empty")
+	}
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+	return normalized, nil
+}
+
+// ParsePrefix parses a CIDR range, accepting a bare IP address as a single-address range.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", s, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", s, err)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// validMatchMode reports whether mode is a known match mode or empty (inherit).
+func validMatchMode(mode string) bool {
+	switch mode {
+	case "", MatchModeFull, MatchModePartial:
+		return true
+	default:
+		return false
+	}
+}
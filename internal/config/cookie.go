@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookie is sent in its own Set-Cookie header, so a response can set several
+type Cookie struct {
+	Name     string       `yaml:"name"`
+	Value    string       `yaml:"value"`
+	Path     string       `yaml:"path"`
+	Domain   string       `yaml:"domain"`
+	Expires  string       `yaml:"expires"` // RFC 3339 timestamp
+	MaxAge   int          `yaml:"maxAge"`  // Seconds the cookie lives; negative deletes it at once
+	Secure   bool         `yaml:"secure"`
+	HTTPOnly bool         `yaml:"httpOnly"`
+	SameSite string       `yaml:"sameSite"` // lax, strict, or none
+	HTTP     *http.Cookie `yaml:"-"`        // Built from the fields above during config loading
+}
+
+var sameSiteModes = map[string]http.SameSite{
+	"":       http.SameSiteDefaultMode,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// load validates the cookie and builds the http.Cookie sent with the response
+func (c *Cookie) load() error {
+	if c.Name == "" {
+		return fmt.Errorf("cookie name is required")
+	}
+	sameSite, ok := sameSiteModes[strings.ToLower(c.SameSite)]
+	if !ok {
+		return fmt.Errorf("cookie %s: sameSite must be lax, strict, or none", c.Name)
+	}
+	if sameSite == http.SameSiteNoneMode && !c.Secure {
+		return fmt.Errorf("cookie %s: sameSite none requires secure, or browsers reject it", c.Name)
+	}
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+		SameSite: sameSite,
+	}
+	if c.Expires != "" {
+		t, err := time.Parse(time.RFC3339, c.Expires)
+		if err != nil {
+			return fmt.Errorf("cookie %s: expires must be an RFC 3339 timestamp: %w", c.Name, err)
+		}
+		cookie.Expires = t
+	}
+	if err := cookie.Valid(); err != nil {
+		return fmt.Errorf("cookie %s: %w", c.Name, err)
+	}
+	c.HTTP = cookie
+	return nil
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expandDefinitions resolves `use` references in request rules against the top-level
+// definitions section. A definition is a partial rule; the named definitions are merged
+// in order, then the rule's own fields. Mappings such as headers and response merge
+// key by key, while any other value set later replaces the earlier one. Definitions
+// may themselves `use` other definitions.
+func expandDefinitions(doc *yaml.Node) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	top := doc.Content[0]
+
+	defs := make(map[string]*yaml.Node)
+	if node := removeKey(top, "definitions"); node != nil {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: definitions must be a mapping of names to partial rules", node.Line)
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			defs[node.Content[i].Value] = node.Content[i+1]
+		}
+	}
+
+	r := &definitionResolver{defs: defs, resolved: make(map[string]*yaml.Node), resolving: make(map[string]bool)}
+
+	requests := lookupKey(top, "requests")
+	if requests == nil || requests.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for i, rule := range requests.Content {
+		if rule.Kind != yaml.MappingNode {
+			continue
+		}
+		expanded, err := r.expand(rule)
+		if err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+		requests.Content[i] = expanded
+	}
+	return nil
+}
+
+type definitionResolver struct {
+	defs      map[string]*yaml.Node
+	resolved  map[string]*yaml.Node
+	resolving map[string]bool
+}
+
+// expand returns node merged over the definitions it uses
+func (r *definitionResolver) expand(node *yaml.Node) (*yaml.Node, error) {
+	node = copyMapping(node)
+	use := removeKey(node, "use")
+	if use == nil {
+		return node, nil
+	}
+
+	var names []*yaml.Node
+	switch use.Kind {
+	case yaml.ScalarNode:
+		names = []*yaml.Node{use}
+	case yaml.SequenceNode:
+		names = use.Content
+	default:
+		return nil, fmt.Errorf("line %d: use must be a definition name or a list of names", use.Line)
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: node.Line, Column: node.Column}
+	for _, name := range names {
+		def, err := r.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeNodes(merged, def)
+	}
+	return mergeNodes(merged, node), nil
+}
+
+func (r *definitionResolver) resolve(name *yaml.Node) (*yaml.Node, error) {
+	if name.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("line %d: definition names must be strings", name.Line)
+	}
+	if def, ok := r.resolved[name.Value]; ok {
+		return def, nil
+	}
+	def, ok := r.defs[name.Value]
+	if !ok {
+		return nil, fmt.Errorf("line %d: unknown definition %q", name.Line, name.Value)
+	}
+	if def.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: definition %q must be a mapping", def.Line, name.Value)
+	}
+	if r.resolving[name.Value] {
+		return nil, fmt.Errorf("line %d: definition %q uses itself", name.Line, name.Value)
+	}
+
+	r.resolving[name.Value] = true
+	expanded, err := r.expand(def)
+	delete(r.resolving, name.Value)
+	if err != nil {
+		return nil, fmt.Errorf("definition %q: %w", name.Value, err)
+	}
+	r.resolved[name.Value] = expanded
+	return expanded, nil
+}
+
+// mergeNodes overlays override onto base without modifying either
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+	merged := copyMapping(base)
+	merged.Line, merged.Column = override.Line, override.Column
+	for i := 0; i < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		if j := keyIndex(merged, key.Value); j >= 0 {
+			merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+		} else {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
+
+func copyMapping(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = append([]*yaml.Node(nil), node.Content...)
+	return &c
+}
+
+func keyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func lookupKey(mapping *yaml.Node, key string) *yaml.Node {
+	if i := keyIndex(mapping, key); i >= 0 {
+		return mapping.Content[i+1]
+	}
+	return nil
+}
+
+// removeKey deletes key from the mapping and returns its value, or nil if absent
+func removeKey(mapping *yaml.Node, key string) *yaml.Node {
+	i := keyIndex(mapping, key)
+	if i < 0 {
+		return nil
+	}
+	value := mapping.Content[i+1]
+	mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+	return value
+}
+
+// expandResponseRefs resolves `ref` keys in the response and responses of request
+// rules. A ref names another rule whose response is merged under the referencing
+// response, the same way definitions merge, so the response can override parts of it.
+// It runs after definitions are expanded, so a referenced response may come from them.
+func expandResponseRefs(doc *yaml.Node) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	requests := lookupKey(doc.Content[0], "requests")
+	if requests == nil || requests.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	r := &refResolver{rules: make(map[string][]*yaml.Node), resolved: make(map[string]*yaml.Node), resolving: make(map[string]bool)}
+	for _, rule := range requests.Content {
+		if rule.Kind != yaml.MappingNode {
+			continue
+		}
+		if name := lookupKey(rule, "name"); name != nil && name.Kind == yaml.ScalarNode {
+			r.rules[name.Value] = append(r.rules[name.Value], rule)
+		}
+	}
+
+	for i, rule := range requests.Content {
+		if rule.Kind != yaml.MappingNode {
+			continue
+		}
+		if j := keyIndex(rule, "response"); j >= 0 {
+			expanded, err := r.expand(rule.Content[j+1])
+			if err != nil {
+				return fmt.Errorf("request rule %d: response: %w", i, err)
+			}
+			rule.Content[j+1] = expanded
+		}
+		if responses := lookupKey(rule, "responses"); responses != nil && responses.Kind == yaml.SequenceNode {
+			for j, entry := range responses.Content {
+				expanded, err := r.expand(entry)
+				if err != nil {
+					return fmt.Errorf("request rule %d: responses[%d]: %w", i, j, err)
+				}
+				responses.Content[j] = expanded
+			}
+		}
+	}
+	return nil
+}
+
+type refResolver struct {
+	rules     map[string][]*yaml.Node // Rules by name
+	resolved  map[string]*yaml.Node   // Expanded responses by rule name
+	resolving map[string]bool
+}
+
+// expand returns the response merged over the response it references
+func (r *refResolver) expand(response *yaml.Node) (*yaml.Node, error) {
+	if response.Kind != yaml.MappingNode {
+		return response, nil
+	}
+	response = copyMapping(response)
+	ref := removeKey(response, "ref")
+	if ref == nil {
+		return response, nil
+	}
+	if ref.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("line %d: ref must be a rule name", ref.Line)
+	}
+	base, err := r.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return mergeNodes(base, response), nil
+}
+
+func (r *refResolver) resolve(name *yaml.Node) (*yaml.Node, error) {
+	if response, ok := r.resolved[name.Value]; ok {
+		return response, nil
+	}
+	rules := r.rules[name.Value]
+	switch {
+	case len(rules) == 0:
+		return nil, fmt.Errorf("line %d: ref names no rule %q", name.Line, name.Value)
+	case len(rules) > 1:
+		return nil, fmt.Errorf("line %d: ref %q is ambiguous, %d rules have that name", name.Line, name.Value, len(rules))
+	}
+	response := lookupKey(rules[0], "response")
+	if response == nil || response.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: rule %q has no response to reference", name.Line, name.Value)
+	}
+	if r.resolving[name.Value] {
+		return nil, fmt.Errorf("line %d: response of rule %q references itself", name.Line, name.Value)
+	}
+
+	r.resolving[name.Value] = true
+	expanded, err := r.expand(response)
+	delete(r.resolving, name.Value)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", name.Value, err)
+	}
+	r.resolved[name.Value] = expanded
+	return expanded, nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_Definitions(t *testing.T) {
+	cfg, err := Parse([]byte(`
+definitions:
+  jsonHeaders:
+    response:
+      headers:
+        Content-Type: application/json
+  authenticated:
+    headers:
+      Authorization: "Bearer .+"
+  notFound:
+    use: jsonHeaders
+    response:
+      status-code: 404
+      body: {error: not found}
+requests:
+  - path: /users
+    use: [authenticated, jsonHeaders]
+    headers:
+      X-Tenant: ".+"
+    response:
+      headers:
+        Cache-Control: no-store
+      body: []
+  - path: /users/missing
+    use: notFound
+    response:
+      body: {error: user not found}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	users := cfg.Requests[0]
+	if users.Headers["Authorization"] != "Bearer .+" || users.Headers["X-Tenant"] != ".+" {
+		t.Fatalf("expected merged matcher headers, got %v", users.Headers)
+	}
+	if users.Response.Headers["Content-Type"] != "application/json" || users.Response.Headers["Cache-Control"] != "no-store" {
+		t.Fatalf("expected merged response headers, got %v", users.Response.Headers)
+	}
+	if users.Response.StatusCode != 200 {
+		t.Fatalf("expected default status 200, got %d", users.Response.StatusCode)
+	}
+
+	missing := cfg.Requests[1]
+	if missing.Response.StatusCode != 404 || missing.Response.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected nested definition to apply, got %+v", missing.Response)
+	}
+	body, _ := missing.Response.Body.(map[string]interface{})
+	if body["error"] != "user not found" {
+		t.Fatalf("expected rule body to override definition, got %v", missing.Response.Body)
+	}
+}
+
+func TestParse_DefinitionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "unknown",
+			yaml:    "requests:\n  - path: /x\n    use: missing\n",
+			wantErr: `request rule 0: line 3: unknown definition "missing"`,
+		},
+		{
+			name:    "cycle",
+			yaml:    "definitions:\n  a: {use: b}\n  b: {use: a}\nrequests:\n  - path: /x\n    use: a\n",
+			wantErr: "uses itself",
+		},
+		{
+			name:    "not a mapping",
+			yaml:    "definitions:\n  a: text\nrequests:\n  - path: /x\n    use: a\n",
+			wantErr: `definition "a" must be a mapping`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml), "test")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParse_ResponseRefs(t *testing.T) {
+	cfg, err := Parse([]byte(`
+requests:
+  - name: shared-error-500
+    path: /__errors/500
+    response:
+      status-code: 500
+      headers:
+        Content-Type: application/json
+      body: {error: internal}
+  - name: orders-error
+    path: /orders
+    method: POST
+    response:
+      ref: shared-error-500
+      headers:
+        Retry-After: "5"
+  - path: /payments
+    method: POST
+    response:
+      ref: orders-error
+      body: {error: payments down}
+  - path: /users
+    responses:
+      - name: ok
+        body: []
+      - name: error-500
+        onDemand: true
+        ref: shared-error-500
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orders := cfg.Requests[1].Response
+	if orders.StatusCode != 500 || orders.Headers["Content-Type"] != "application/json" || orders.Headers["Retry-After"] != "5" {
+		t.Fatalf("expected referenced response with merged headers, got %+v", orders)
+	}
+	payments := cfg.Requests[2].Response
+	body, _ := payments.Body.(map[string]interface{})
+	if payments.StatusCode != 500 || payments.Headers["Retry-After"] != "5" || body["error"] != "payments down" {
+		t.Fatalf("expected chained reference with overridden body, got %+v", payments)
+	}
+	forced := cfg.Requests[3].Responses[1]
+	if forced.StatusCode != 500 || forced.Name != "error-500" || !forced.OnDemand {
+		t.Fatalf("expected referenced response in responses entry, got %+v", forced)
+	}
+}
+
+func TestParse_ResponseRefErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "unknown",
+			yaml:    "requests:\n  - path: /x\n    response:\n      ref: missing\n",
+			wantErr: `request rule 0: response: line 4: ref names no rule "missing"`,
+		},
+		{
+			name:    "ambiguous",
+			yaml:    "requests:\n  - {name: a, path: /a, response: {body: a}}\n  - {name: a, path: /b, response: {body: b}}\n  - path: /x\n    response: {ref: a}\n",
+			wantErr: `ref "a" is ambiguous, 2 rules have that name`,
+		},
+		{
+			name:    "cycle",
+			yaml:    "requests:\n  - {name: a, path: /a, response: {ref: b}}\n  - {name: b, path: /b, response: {ref: a}}\n",
+			wantErr: "references itself",
+		},
+		{
+			name:    "no single response",
+			yaml:    "requests:\n  - {name: a, path: /a, responses: [{body: a}]}\n  - path: /x\n    response: {ref: a}\n",
+			wantErr: `rule "a" has no response to reference`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml), "test")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Response delay distributions
+const (
+	DelayUniform   = "uniform"   // Any delay between min and max, equally likely
+	DelayFixed     = "fixed"     // Always p50
+	DelayNormal    = "normal"    // Bell curve around p50
+	DelayLognormal = "lognormal" // Skewed toward a long tail above p50, like most real latencies
+)
+
+// Standard normal quantiles of the percentiles a delay distribution can be fitted to
+var delayQuantiles = map[string]float64{"p90": 1.2815516, "p95": 1.6448536, "p99": 2.3263479}
+
+// ResponseDelay specifies the delay before sending a response
+type ResponseDelay struct {
+	Distribution string  `yaml:"distribution"` // uniform (default), fixed, normal, or lognormal
+	Min          int     `yaml:"min"`          // Minimum delay in milliseconds
+	Max          int     `yaml:"max"`          // Maximum delay in milliseconds; no limit for normal and lognormal when 0
+	P50          int     `yaml:"p50"`          // Median delay in milliseconds for fixed, normal, and lognormal
+	P90          int     `yaml:"p90"`          // One tail percentile in milliseconds fits the spread of normal and lognormal
+	P95          int     `yaml:"p95"`
+	P99          int     `yaml:"p99"`
+	Sigma        float64 `yaml:"-"` // Standard deviation fitted to the tail percentile: in milliseconds for normal, of the log for lognormal
+}
+
+// String describes the delay for the configuration log
+func (d *ResponseDelay) String() string {
+	switch d.Distribution {
+	case DelayFixed:
+		return fmt.Sprintf("%dms", d.P50)
+	case DelayNormal, DelayLognormal:
+		return fmt.Sprintf("%s p50=%dms sigma=%.3g", d.Distribution, d.P50, d.Sigma)
+	default:
+		return fmt.Sprintf("%d-%dms", d.Min, d.Max)
+	}
+}
+
+func (d *ResponseDelay) setDefaults() {
+	if d.Distribution == "" {
+		d.Distribution = DelayUniform
+	}
+}
+
+// validate checks the delay and fits Sigma to its tail percentile
+func (d *ResponseDelay) validate() error {
+	if d.Min < 0 {
+		return fmt.Errorf("min cannot be negative")
+	}
+	if d.Max < 0 {
+		return fmt.Errorf("max cannot be negative")
+	}
+	percentiles := map[string]int{"p90": d.P90, "p95": d.P95, "p99": d.P99}
+	switch d.Distribution {
+	case "", DelayUniform:
+		if d.Min > d.Max {
+			return fmt.Errorf("min (%d) cannot exceed max (%d)", d.Min, d.Max)
+		}
+		if d.P50 != 0 || d.P90 != 0 || d.P95 != 0 || d.P99 != 0 {
+			return fmt.Errorf("percentiles need distribution fixed, normal, or lognormal")
+		}
+	case DelayFixed:
+		if d.P50 <= 0 {
+			return fmt.Errorf("fixed distribution needs p50")
+		}
+	case DelayNormal, DelayLognormal:
+		if d.Max != 0 && d.Min > d.Max {
+			return fmt.Errorf("min (%d) cannot exceed max (%d)", d.Min, d.Max)
+		}
+		if d.P50 <= 0 {
+			return fmt.Errorf("%s distribution needs p50", d.Distribution)
+		}
+		tail := ""
+		for name, value := range percentiles {
+			if value == 0 {
+				continue
+			}
+			if tail != "" {
+				return fmt.Errorf("set only one of p90, p95, and p99")
+			}
+			if value <= d.P50 {
+				return fmt.Errorf("%s (%d) must exceed p50 (%d)", name, value, d.P50)
+			}
+			tail = name
+		}
+		if tail == "" {
+			return fmt.Errorf("%s distribution needs one of p90, p95, or p99", d.Distribution)
+		}
+		z := delayQuantiles[tail]
+		if d.Distribution == DelayNormal {
+			d.Sigma = float64(percentiles[tail]-d.P50) / z
+		} else {
+			d.Sigma = math.Log(float64(percentiles[tail])/float64(d.P50)) / z
+		}
+	default:
+		return fmt.Errorf("unknown distribution %q, use uniform, fixed, normal, or lognormal", d.Distribution)
+	}
+	return nil
+}
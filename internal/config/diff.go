@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleDiff describes how one rule set differs from another
+type RuleDiff struct {
+	Added         []string `json:"added"`
+	Removed       []string `json:"removed"`
+	Changed       []string `json:"changed"`
+	Unchanged     int      `json:"unchanged"`
+	ServerChanged bool     `json:"serverChanged"`
+}
+
+// Empty reports whether the two rule sets are identical
+func (d RuleDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && !d.ServerChanged
+}
+
+// Diff compares the rules of two configurations. Rules are identified by name when
+// set, otherwise by method and path, numbered when the same pair occurs more than once.
+func Diff(old, updated *Config) RuleDiff {
+	diff := RuleDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	oldKeys, oldRules := indexRules(old.Requests)
+	newKeys, newRules := indexRules(updated.Requests)
+
+	for _, key := range oldKeys {
+		newRule, ok := newRules[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		if bytes.Equal(canonicalYAML(oldRules[key]), canonicalYAML(newRule)) {
+			diff.Unchanged++
+		} else {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for _, key := range newKeys {
+		if _, ok := oldRules[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+
+	diff.ServerChanged = !bytes.Equal(canonicalYAML(old.Server), canonicalYAML(updated.Server))
+	return diff
+}
+
+// RuleKey returns the identifier used for a rule in diffs
+func RuleKey(rule *RequestRule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return rule.Method + " " + rule.DisplayPath()
+}
+
+// RuleKeys returns a unique identifier for each rule, numbering repeated keys
+func RuleKeys(rules []RequestRule) []string {
+	keys := make([]string, 0, len(rules))
+	seen := make(map[string]int)
+	for i := range rules {
+		key := RuleKey(&rules[i])
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s #%d", key, n)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func indexRules(rules []RequestRule) ([]string, map[string]*RequestRule) {
+	keys := RuleKeys(rules)
+	byKey := make(map[string]*RequestRule, len(rules))
+	for i, key := range keys {
+		byKey[key] = &rules[i]
+	}
+	return keys, byKey
+}
+
+// RuleHash returns a short hash of a rule's definition, which changes whenever the rule does
+func RuleHash(rule *RequestRule) string {
+	sum := sha256.Sum256(canonicalYAML(rule))
+	return hex.EncodeToString(sum[:6])
+}
+
+func canonicalYAML(v interface{}) []byte {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return []byte(fmt.Sprintf("%#v", v))
+	}
+	return data
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadDir reads every YAML, JSON, and TOML file directly inside dir, in name order, and merges them
+// into one configuration, so each mocked service can keep its rules in its own file.
+// The requests and startupChecks of the files are concatenated. Other mappings, such
+// as server, datasets, and definitions, are merged key by key, and a key set by two
+// files is an error rather than silently overridden.
+func LoadDir(dir string) (*Config, error) {
+	files, err := configFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	owners := make(map[string]string) // Merged key path to the file that set it
+	var ruleFiles []string            // File of each merged request rule
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config file %s: %w", file, err)
+		}
+		doc, err := DecodeDocument(data, file)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		top := doc.Content[0]
+		if top.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("error parsing config file %s: line %d: expected a mapping", file, top.Line)
+		}
+		if err := mergeFile(merged, top, "", file, owners); err != nil {
+			return nil, err
+		}
+		if i := keyIndex(top, "requests"); i >= 0 && top.Content[i+1].Kind == yaml.SequenceNode {
+			for range top.Content[i+1].Content {
+				ruleFiles = append(ruleFiles, file)
+			}
+		}
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{merged}}
+	config, err := parseDocument(doc, dir)
+	if err != nil {
+		return nil, err
+	}
+	config.Source = dir
+	if len(ruleFiles) == len(config.Requests) {
+		for i := range config.Requests {
+			config.Requests[i].SourceFile = ruleFiles[i]
+		}
+	}
+
+	log.Printf("Loaded configuration from %d files in %s with %d request rules", len(files), dir, len(config.Requests))
+	return config, nil
+}
+
+// configFiles lists the configuration files LoadDir reads from dir, in name order
+func configFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config directory %s: %w", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !IsConfigFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("config directory %s contains no configuration files", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeFile merges the top-level mapping of one file into merged. Lists of rules and
+// checks are appended; any other value may only be set by one file.
+func mergeFile(merged, top *yaml.Node, path, file string, owners map[string]string) error {
+	for i := 0; i < len(top.Content); i += 2 {
+		key, value := top.Content[i], top.Content[i+1]
+		keyPath := key.Value
+		if path != "" {
+			keyPath = path + "." + key.Value
+		}
+
+		j := keyIndex(merged, key.Value)
+		if j < 0 {
+			merged.Content = append(merged.Content, key, value)
+			owners[keyPath] = file
+			continue
+		}
+		existing := merged.Content[j+1]
+		switch {
+		case path == "" && (key.Value == "requests" || key.Value == "startupChecks") &&
+			existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			combined := *existing
+			combined.Content = append(append([]*yaml.Node(nil), existing.Content...), value.Content...)
+			merged.Content[j+1] = &combined
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			combined := copyMapping(existing)
+			merged.Content[j+1] = combined
+			if err := mergeFile(combined, value, keyPath, file, owners); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("config file %s: line %d: %s is already set in %s", file, key.Line, keyPath, ownerOf(owners, keyPath))
+		}
+	}
+	return nil
+}
+
+// ownerOf returns the file that set keyPath, or the mapping containing it
+func ownerOf(owners map[string]string, keyPath string) string {
+	for {
+		if file, ok := owners[keyPath]; ok {
+			return file
+		}
+		i := strings.LastIndex(keyPath, ".")
+		if i < 0 {
+			return ""
+		}
+		keyPath = keyPath[:i]
+	}
+}
+
+// isDir reports whether path names an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("payments.yaml", "server:\n  port: 9090\nrequests:\n  - path: /charges\nscenarios:\n  checkout: Paid\n")
+	write("users.yml", "server:\n  matchMode: partial\nrequests:\n  - path: /users\n  - path: /users/1\nscenarios:\n  signup: Verified\n")
+	write("notes.txt", "not a configuration")
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var paths []string
+	for _, r := range cfg.Requests {
+		paths = append(paths, r.Path)
+	}
+	if got := strings.Join(paths, ","); got != "/charges,/users,/users/1" {
+		t.Errorf("expected the rules of both files in name order, got %s", got)
+	}
+	if cfg.Server.Port != 9090 || cfg.Server.MatchMode != MatchModePartial {
+		t.Errorf("expected server settings from both files, got %+v", cfg.Server)
+	}
+	if cfg.Scenarios["checkout"] != "Paid" || cfg.Scenarios["signup"] != "Verified" {
+		t.Errorf("expected scenarios from both files, got %v", cfg.Scenarios)
+	}
+	if cfg.Source != dir {
+		t.Errorf("expected the directory as source, got %s", cfg.Source)
+	}
+
+	// Reloads read the directory again
+	if cfg, err := LoadSource(dir); err != nil || len(cfg.Requests) != 3 {
+		t.Fatalf("expected the directory to reload, got %v", err)
+	}
+
+	write("zz-override.yaml", "server:\n  port: 9091\n")
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "server.port is already set in "+filepath.Join(dir, "payments.yaml")) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+
+	if _, err := LoadDir(filepath.Join(dir, "nested")); err == nil || !strings.Contains(err.Error(), "contains no configuration files") {
+		t.Fatalf("expected an empty directory error, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Download serves a response as a file to save, setting Content-Disposition and a
+// Content-Type that matches the file name
+type Download struct {
+	Filename string `yaml:"filename"` // Name the client saves the file as
+	File     string `yaml:"file"`     // Served as the bodyFile; without it the body comes from the response
+	Inline   bool   `yaml:"inline"`   // Let browsers display the file instead of saving it
+}
+
+// applyDownload serves the download's file and adds the Content-Disposition and
+// Content-Type headers the response doesn't set itself
+func (s *ResponseSpec) applyDownload() {
+	d := s.Download
+	if d == nil {
+		return
+	}
+	if s.BodyFile == "" {
+		s.BodyFile = d.File
+	}
+	set := make(map[string]bool, len(s.Headers))
+	for name := range s.Headers {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	if s.Headers == nil {
+		s.Headers = make(map[string]string, 2)
+	}
+	if !set["Content-Disposition"] {
+		disposition := "attachment"
+		if d.Inline {
+			disposition = "inline"
+		}
+		s.Headers["Content-Disposition"] = mime.FormatMediaType(disposition, map[string]string{"filename": d.Filename})
+	}
+	if !set["Content-Type"] {
+		contentType := mime.TypeByExtension(filepath.Ext(d.Filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		s.Headers["Content-Type"] = contentType
+	}
+}
+
+func (s *ResponseSpec) validateDownload() error {
+	d := s.Download
+	if d.Filename == "" {
+		return fmt.Errorf("download needs a filename")
+	}
+	if strings.ContainsAny(d.Filename, `/\`) || d.Filename == "." || d.Filename == ".." {
+		return fmt.Errorf("download filename %q cannot contain a path", d.Filename)
+	}
+	if d.File != "" && s.BodyFile != d.File {
+		return fmt.Errorf("download file cannot be combined with bodyFile")
+	}
+	if d.File != "" && (s.Body != nil || s.BodyBase64 != "" || s.ProxyBody != nil || s.RandomBody != nil || s.GenerateBody != nil || s.Dataset != "") {
+		return fmt.Errorf("download file cannot be combined with body, bodyBase64, proxyBody, randomBody, generateBody, or dataset")
+	}
+	if s.Proxy != nil || s.SSE != nil || s.Fault != "" || s.Redirect != nil {
+		return fmt.Errorf("download cannot be combined with proxy, sse, fault, or redirect")
+	}
+	return nil
+}
+
+// Streamed reports whether the body is written as it is produced rather than built
+// in memory first, so its length isn't known up front
+func (s *ResponseSpec) Streamed() bool {
+	return s.GenerateBody != nil || (s.ProxyBody != nil && !s.ProxyBody.Cache)
+}
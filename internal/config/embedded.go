@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// EmbeddedName selects the configuration embedded from embedded/<name>.yaml in
+// binaries built with -tags embedconfig. Override it with
+// -ldflags "-X http-mock-server/internal/config.EmbeddedName=<name>".
+var EmbeddedName = "config"
+
+// embeddedPrefix marks configuration sources that are compiled into the binary
+const embeddedPrefix = "embedded:"
+
+// embeddedConfigs holds the embedded configurations, nil without the embedconfig tag
+var embeddedConfigs fs.FS
+
+// HasEmbedded reports whether the binary carries an embedded default configuration
+func HasEmbedded() bool {
+	return embeddedConfigs != nil
+}
+
+func readEmbedded(source string) ([]byte, error) {
+	if embeddedConfigs == nil {
+		return nil, fmt.Errorf("no configuration is embedded in this binary")
+	}
+	name := strings.TrimPrefix(source, embeddedPrefix)
+	data, err := fs.ReadFile(embeddedConfigs, "embedded/"+name+".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("could not read embedded config %q: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,12 @@
+//go:build embedconfig
+
+package config
+
+import "embed"
+
+//go:embed embedded/*.yaml
+var embeddedFiles embed.FS
+
+func init() {
+	embeddedConfigs = embeddedFiles
+}
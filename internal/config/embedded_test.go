@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadSource_Embedded(t *testing.T) {
+	saved := embeddedConfigs
+	t.Cleanup(func() { embeddedConfigs = saved })
+
+	embeddedConfigs = nil
+	if _, err := LoadSource("embedded:config"); err == nil || !strings.Contains(err.Error(), "no configuration is embedded") {
+		t.Fatalf("expected error without embedded configs, got %v", err)
+	}
+
+	embeddedConfigs = fstest.MapFS{
+		"embedded/payments.yaml": {Data: []byte("requests:\n  - path: /charges\n")},
+	}
+	cfg, err := LoadSource("embedded:payments")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Source != "embedded:payments" || cfg.Requests[0].Path != "/charges" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if _, err := LoadSource("embedded:missing"); err == nil || !strings.Contains(err.Error(), `embedded config "missing"`) {
+		t.Fatalf("expected missing config error, got %v", err)
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, path string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte("requests:\n  - path: "+path+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	flagged, fromEnv := write("flag.yaml", "/flag"), write("env.yaml", "/env")
+	t.Setenv(PathEnv, fromEnv)
+
+	cfg, err := LoadFrom(flagged)
+	if err != nil || cfg.Requests[0].Path != "/flag" || cfg.Source != flagged {
+		t.Fatalf("expected the given path to win, got %+v, %v", cfg, err)
+	}
+	cfg, err = LoadFrom("")
+	if err != nil || cfg.Requests[0].Path != "/env" || cfg.Source != fromEnv {
+		t.Fatalf("expected the %s path, got %+v, %v", PathEnv, cfg, err)
+	}
+
+	// A missing file named explicitly is an error, not a reason to search elsewhere
+	t.Setenv(PathEnv, filepath.Join(dir, "missing.yaml"))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Fatalf("expected the missing file to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+
+	"http-mock-server/internal/templating"
+)
+
+// ErrorResponse replaces the plain-text error the server sends when it fails to produce
+// a rule's response, for example when a template fails or a body file is gone
+type ErrorResponse struct {
+	StatusCode int     `yaml:"status-code"` // Defaults to 500
+	Headers    Headers `yaml:"headers"`
+	Body       string  `yaml:"body"` // Template with .Error, .Rule, .Method, and .Path
+}
+
+func (e *ErrorResponse) setDefaults() {
+	if e.StatusCode == 0 {
+		e.StatusCode = http.StatusInternalServerError
+	}
+}
+
+func (e *ErrorResponse) validate(opts templating.Options) error {
+	if e.StatusCode < 400 || e.StatusCode > 599 {
+		return fmt.Errorf("status-code must be an error status, got %d", e.StatusCode)
+	}
+	if _, err := templating.Parse("body", e.Body, opts); err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats, chosen by file extension
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// formatExtensions maps configuration file extensions to their format
+var formatExtensions = map[string]string{
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".json": FormatJSON,
+	".toml": FormatTOML,
+}
+
+// DefaultPaths are the configuration files the server looks for, in order, when
+// none is named
+var DefaultPaths = []string{
+	"config.yaml", "config.json", "config.toml",
+	"config/config.yaml", "config/config.json", "config/config.toml",
+}
+
+// FormatOf returns the format of the configuration at source by its extension.
+// Sources without a known extension, such as request bodies, are YAML.
+func FormatOf(source string) string {
+	if format, ok := formatExtensions[strings.ToLower(filepath.Ext(source))]; ok {
+		return format
+	}
+	return FormatYAML
+}
+
+// IsConfigFile reports whether name has the extension of a configuration format,
+// as the files LoadDir reads from a directory do
+func IsConfigFile(name string) bool {
+	_, ok := formatExtensions[strings.ToLower(filepath.Ext(name))]
+	return ok && !strings.HasPrefix(filepath.Base(name), ".")
+}
+
+// DecodeDocument parses configuration data in the format of source into a YAML
+// document, so every format shares the same definitions, refs, and validation.
+// JSON keeps the order of its keys; TOML tables have none, so their keys are sorted.
+func DecodeDocument(data []byte, source string) (*yaml.Node, error) {
+	var doc yaml.Node
+	switch FormatOf(source) {
+	case FormatJSON:
+		// YAML accepts JSON, but JSON's own parser reports its mistakes more clearly
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+	case FormatTOML:
+		var v map[string]interface{}
+		if err := toml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+		var top yaml.Node
+		if err := top.Encode(v); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&top}}
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", source, err)
+		}
+	}
+	return &doc, nil
+}
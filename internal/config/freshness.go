@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Freshness warns about rules that go unmatched for days while other rules keep
+// serving traffic, to find stubs nobody uses anymore in long-lived deployments
+type Freshness struct {
+	StaleAfterDays int    `yaml:"staleAfterDays"` // Days without a match before a rule is stale, defaults to 30
+	StateKey       string `yaml:"stateKey"`       // Storage key the match history is saved under, so it survives restarts
+}
+
+// StaleAfter returns how long a rule can go unmatched before it is stale
+func (f *Freshness) StaleAfter() time.Duration {
+	return time.Duration(f.StaleAfterDays) * 24 * time.Hour
+}
+
+func (f *Freshness) setDefaults() {
+	if f.StaleAfterDays == 0 {
+		f.StaleAfterDays = 30
+	}
+}
+
+func (f *Freshness) validate() error {
+	if f.StaleAfterDays < 0 {
+		return fmt.Errorf("staleAfterDays cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import "fmt"
+
+// Generated body patterns
+const (
+	GeneratePatternRandom = "random" // Random bytes
+	GeneratePatternZeros  = "zeros"  // Zero bytes
+	GeneratePatternLorem  = "lorem"  // Lorem ipsum text, repeated
+)
+
+// GenerateBody produces a body of the given size as it is written, so large downloads
+// can be tested without fixtures or holding the body in memory
+type GenerateBody struct {
+	SizeBytes int    `yaml:"sizeBytes"`
+	Pattern   string `yaml:"pattern"` // random (default), zeros, or lorem
+}
+
+func (g *GenerateBody) setDefaults() {
+	if g.Pattern == "" {
+		g.Pattern = GeneratePatternRandom
+	}
+}
+
+func (g *GenerateBody) validate() error {
+	if g.SizeBytes < 0 {
+		return fmt.Errorf("generateBody sizeBytes cannot be negative")
+	}
+	switch g.Pattern {
+	case GeneratePatternRandom, GeneratePatternZeros, GeneratePatternLorem:
+		return nil
+	}
+	return fmt.Errorf("unknown generateBody pattern %q, use random, zeros, or lorem", g.Pattern)
+}
@@ -0,0 +1,38 @@
+package config
+
+import "fmt"
+
+// Serving statuses of the gRPC health checking protocol (grpc.health.v1)
+const (
+	HealthServing    = "SERVING"
+	HealthNotServing = "NOT_SERVING"
+)
+
+// GRPCHealth answers the standard gRPC health checking protocol, so clients and
+// probes that check a backend's health before calling it can use the mock
+type GRPCHealth struct {
+	Services map[string]string `yaml:"services"` // Status of each service; "" is the server as a whole, SERVING unless set
+}
+
+func (g *GRPCHealth) setDefaults() {
+	if g.Services == nil {
+		g.Services = make(map[string]string)
+	}
+	if _, ok := g.Services[""]; !ok {
+		g.Services[""] = HealthServing
+	}
+}
+
+func (g *GRPCHealth) validate() error {
+	for service, status := range g.Services {
+		if !ValidHealthStatus(status) {
+			return fmt.Errorf("service %q: status must be SERVING or NOT_SERVING", service)
+		}
+	}
+	return nil
+}
+
+// ValidHealthStatus reports whether status can be configured for a service
+func ValidHealthStatus(status string) bool {
+	return status == HealthServing || status == HealthNotServing
+}
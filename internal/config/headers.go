@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Headers are response headers by name. A header can be declared with a list of
+// values, such as Vary: [Accept, Origin], to send one header line per value. The
+// values are kept joined by newlines, which header values can't otherwise contain;
+// HeaderValues separates them again.
+type Headers map[string]string
+
+// UnmarshalYAML accepts a single value or a list of values for each header
+func (h *Headers) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	headers := make(Headers, len(raw))
+	for name, value := range raw {
+		if value.Kind == yaml.AliasNode {
+			value = *value.Alias
+		}
+		if value.Kind != yaml.SequenceNode {
+			var s string
+			if err := value.Decode(&s); err != nil {
+				return fmt.Errorf("line %d: header %s must be a value or a list of values", value.Line, name)
+			}
+			headers[name] = s
+			continue
+		}
+		if len(value.Content) == 0 {
+			return fmt.Errorf("line %d: header %s has an empty list of values", value.Line, name)
+		}
+		values := make([]string, len(value.Content))
+		for i, item := range value.Content {
+			if err := item.Decode(&values[i]); err != nil {
+				return fmt.Errorf("line %d: header %s values must be scalars", item.Line, name)
+			}
+		}
+		headers[name] = strings.Join(values, "\n")
+	}
+	*h = headers
+	return nil
+}
+
+// MarshalYAML writes headers with several values as lists
+func (h Headers) MarshalYAML() (interface{}, error) {
+	out := make(map[string]interface{}, len(h))
+	for name, value := range h {
+		if values := HeaderValues(value); len(values) > 1 {
+			out[name] = values
+		} else {
+			out[name] = value
+		}
+	}
+	return out, nil
+}
+
+// HeaderValues returns the values of a header declared in Headers
+func HeaderValues(value string) []string {
+	return strings.Split(value, "\n")
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// InterimResponse is an informational 1xx response sent before the final response
+type InterimResponse struct {
+	StatusCode int     `yaml:"status-code"` // 100 to 199, except 101 Switching Protocols
+	Headers    Headers `yaml:"headers"`
+	Delay      int     `yaml:"delay"` // Milliseconds to wait before sending it
+}
+
+func (i *InterimResponse) validate() error {
+	if i.StatusCode < 100 || i.StatusCode > 199 {
+		return fmt.Errorf("status-code must be an informational 1xx status, got %d", i.StatusCode)
+	}
+	if i.StatusCode == http.StatusSwitchingProtocols {
+		return fmt.Errorf("status-code cannot be 101, the connection would switch protocols")
+	}
+	if i.Delay < 0 {
+		return fmt.Errorf("delay cannot be negative")
+	}
+	for name, value := range i.Headers {
+		if strings.ContainsAny(name, "\r\n") || strings.Contains(value, "\r") {
+			return fmt.Errorf("header %s cannot contain line breaks", name)
+		}
+	}
+	return nil
+}
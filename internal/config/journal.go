@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRedactedHeaders are always redacted from journal exports
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// Journal records requests to the mocks, matched or not, so they can be inspected and exported
+type Journal struct {
+	MaxEntries int           `yaml:"maxEntries"` // Most recent requests kept, defaults to 1000
+	Export     JournalExport `yaml:"export"`     // Anonymization applied by the export endpoint
+}
+
+// JournalExport anonymizes journal exports so captured traffic can be shared
+// without leaking personal data. The raw journal is never changed.
+type JournalExport struct {
+	RedactHeaders     []string      `yaml:"redactHeaders"`     // Replaced with [REDACTED], in addition to DefaultRedactedHeaders
+	RedactQueryParams []string      `yaml:"redactQueryParams"` // Replaced with [REDACTED]
+	RedactFields      []string      `yaml:"redactFields"`      // JSON or form body fields, at any depth, replaced with [REDACTED]
+	HashFields        []string      `yaml:"hashFields"`        // Body fields and query params replaced with a stable hash of their value
+	HashPathIDs       bool          `yaml:"hashPathIds"`       // Hash path segments that look like IDs: numbers, UUIDs, and long hex strings
+	TimeBucket        string        `yaml:"timeBucket"`        // Round timestamps down to this duration, e.g. "1h"
+	Bucket            time.Duration `yaml:"-"`                 // Parsed from TimeBucket
+	Salt              Secret        `yaml:"salt"`              // Keys the hashes; random per start when unset, so exports can't be linked across runs
+}
+
+func (j *Journal) setDefaults() {
+	if j.MaxEntries == 0 {
+		j.MaxEntries = 1000
+	}
+}
+
+func (j *Journal) validate() error {
+	if j.MaxEntries < 0 {
+		return fmt.Errorf("maxEntries cannot be negative")
+	}
+	e := &j.Export
+	for _, list := range [][]string{e.RedactHeaders, e.RedactQueryParams, e.RedactFields, e.HashFields} {
+		for _, name := range list {
+			if name == "" {
+				return fmt.Errorf("export field and header names cannot be empty")
+			}
+		}
+	}
+	if e.TimeBucket != "" {
+		d, err := time.ParseDuration(e.TimeBucket)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("export timeBucket %q must be a positive duration", e.TimeBucket)
+		}
+		e.Bucket = d
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Labels every mock request series carries, which rules cannot set themselves
+var reservedMetricLabels = map[string]bool{"rule": true, "status": true, "le": true}
+
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Metrics serves per-rule request counts and durations in the Prometheus text format
+type Metrics struct {
+	Path      string `yaml:"path"`      // Endpoint path, defaults to /metrics
+	MaxSeries int    `yaml:"maxSeries"` // Distinct series kept before new ones are aggregated into one, defaults to 1000
+}
+
+func (m *Metrics) setDefaults() {
+	if m.Path == "" {
+		m.Path = "/metrics"
+	}
+	if m.MaxSeries == 0 {
+		m.MaxSeries = 1000
+	}
+}
+
+func (m *Metrics) validate() error {
+	if !strings.HasPrefix(m.Path, "/") || m.Path == "/" || m.Path == "/health" || strings.HasPrefix(m.Path, "/__admin/") {
+		return fmt.Errorf("path %q must start with / and not be /, /health, or below /__admin/", m.Path)
+	}
+	if m.MaxSeries < 0 {
+		return fmt.Errorf("maxSeries cannot be negative")
+	}
+	return nil
+}
+
+// validateMetricLabels checks the label names of a rule
+func validateMetricLabels(labels map[string]string) error {
+	for name := range labels {
+		if !metricLabelName.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("labels: invalid label name %q", name)
+		}
+		if reservedMetricLabels[name] {
+			return fmt.Errorf("labels: %q is reserved", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Mirror copies mock traffic to a secondary target in the background. The copies
+// don't affect the mock responses, and their results are only logged.
+type Mirror struct {
+	URL         string            `yaml:"url"`         // Base URL; the request path and query are appended
+	SampleRate  float64           `yaml:"sampleRate"`  // Fraction of requests mirrored, from 0 to 1, defaults to 1
+	Timeout     int               `yaml:"timeout"`     // Milliseconds to wait for the target, defaults to 5000
+	MaxInFlight int               `yaml:"maxInFlight"` // Copies pending at once before new ones are dropped, defaults to 100
+	Headers     map[string]string `yaml:"headers"`     // Added to each copy, replacing request headers of the same name
+}
+
+func (m *Mirror) setDefaults() {
+	if m.SampleRate == 0 {
+		m.SampleRate = 1
+	}
+	if m.Timeout == 0 {
+		m.Timeout = 5000
+	}
+	if m.MaxInFlight == 0 {
+		m.MaxInFlight = 100
+	}
+}
+
+func (m *Mirror) validate() error {
+	u, err := url.Parse(m.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", m.URL)
+	}
+	if m.SampleRate < 0 || m.SampleRate > 1 {
+		return fmt.Errorf("sampleRate must be between 0 and 1")
+	}
+	if m.Timeout < 0 || m.MaxInFlight < 0 {
+		return fmt.Errorf("timeout and maxInFlight cannot be negative")
+	}
+	return nil
+}
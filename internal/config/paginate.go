@@ -0,0 +1,56 @@
+package config
+
+import "fmt"
+
+// Pagination styles
+const (
+	PaginatePage   = "page"   // page and limit query parameters
+	PaginateCursor = "cursor" // Opaque cursor and limit query parameters
+)
+
+// Paginate serves a list dataset one page at a time, with X-Total-Count and Link headers
+type Paginate struct {
+	Style        string `yaml:"style"`        // page (default) or cursor
+	DefaultLimit int    `yaml:"defaultLimit"` // Items per page without a limit parameter, defaults to 20 or maxLimit
+	MaxLimit     int    `yaml:"maxLimit"`     // Largest limit a client can ask for, defaults to 100
+	PageParam    string `yaml:"pageParam"`    // Defaults to page
+	LimitParam   string `yaml:"limitParam"`   // Defaults to limit
+	CursorParam  string `yaml:"cursorParam"`  // Defaults to cursor
+}
+
+func (p *Paginate) setDefaults() {
+	if p.Style == "" {
+		p.Style = PaginatePage
+	}
+	if p.MaxLimit == 0 {
+		p.MaxLimit = 100
+	}
+	if p.DefaultLimit == 0 {
+		p.DefaultLimit = min(20, p.MaxLimit)
+	}
+	if p.PageParam == "" {
+		p.PageParam = "page"
+	}
+	if p.LimitParam == "" {
+		p.LimitParam = "limit"
+	}
+	if p.CursorParam == "" {
+		p.CursorParam = "cursor"
+	}
+}
+
+func (p *Paginate) validate(dataset interface{}) error {
+	if p.Style != PaginatePage && p.Style != PaginateCursor {
+		return fmt.Errorf("paginate style must be page or cursor, got %q", p.Style)
+	}
+	if p.DefaultLimit < 0 || p.MaxLimit < 0 {
+		return fmt.Errorf("paginate limits cannot be negative")
+	}
+	if p.DefaultLimit > p.MaxLimit {
+		return fmt.Errorf("paginate defaultLimit %d exceeds maxLimit %d", p.DefaultLimit, p.MaxLimit)
+	}
+	if _, ok := dataset.([]interface{}); !ok {
+		return fmt.Errorf("paginate needs a dataset that is a list")
+	}
+	return nil
+}
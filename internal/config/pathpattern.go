@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathPattern matches request paths containing {name} segments, which match one
+// non-empty path segment, and an optional final {name...} segment, which matches
+// the rest of the path
+type PathPattern struct {
+	segments []string // Literal segments, or parameter names wrapped in braces
+	rest     string   // Name of the final {name...} parameter, if any
+}
+
+// ParsePathPattern parses a rule path. It returns nil for paths without parameters.
+func ParsePathPattern(path string) (*PathPattern, error) {
+	if !strings.Contains(path, "{") {
+		return nil, nil
+	}
+	p := &PathPattern{}
+	seen := make(map[string]bool)
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if !strings.ContainsAny(part, "{}") {
+			p.segments = append(p.segments, part)
+			continue
+		}
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("path parameter must be a whole segment: %q", part)
+		}
+		name := part[1 : len(part)-1]
+		rest := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		if name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("invalid path parameter %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate path parameter %q", name)
+		}
+		seen[name] = true
+		if rest {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("path parameter %q must be the last segment", part)
+			}
+			p.rest = name
+			continue
+		}
+		p.segments = append(p.segments, part)
+	}
+	return p, nil
+}
+
+// Match reports whether path matches the pattern and returns the parameter values
+func (p *PathPattern) Match(path string) (map[string]string, bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) < len(p.segments) || (p.rest == "" && len(parts) != len(p.segments)) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range p.segments {
+		if !strings.HasPrefix(segment, "{") {
+			if parts[i] != segment {
+				return nil, false
+			}
+			continue
+		}
+		if parts[i] == "" {
+			return nil, false
+		}
+		params[segment[1:len(segment)-1]] = parts[i]
+	}
+	if p.rest != "" {
+		params[p.rest] = strings.Join(parts[len(p.segments):], "/")
+	}
+	return params, true
+}
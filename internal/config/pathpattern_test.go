@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    map[string]string // Nil when the path shouldn't match
+	}{
+		{"/users/{id}", "/users/42", map[string]string{"id": "42"}},
+		{"/users/{id}", "/users/42/orders", nil},
+		{"/users/{id}", "/users/", nil},
+		{"/users/{id}/orders/{order}", "/users/1/orders/7", map[string]string{"id": "1", "order": "7"}},
+		{"/files/{path...}", "/files/a/b/c.txt", map[string]string{"path": "a/b/c.txt"}},
+		{"/files/{path...}", "/files/", map[string]string{"path": ""}},
+		{"/files/{path...}", "/other/a", nil},
+	}
+	for _, tt := range tests {
+		p, err := ParsePathPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.pattern, err)
+		}
+		got, ok := p.Match(tt.path)
+		if ok != (tt.want != nil) || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s on %s: got %v, %v", tt.pattern, tt.path, got, ok)
+		}
+	}
+
+	if p, err := ParsePathPattern("/plain/path"); p != nil || err != nil {
+		t.Fatalf("expected no pattern for a literal path, got %v, %v", p, err)
+	}
+	for _, bad := range []string{"/users/id-{id}", "/users/{}", "/users/{id}/{id}", "/files/{rest...}/x"} {
+		if _, err := ParsePathPattern(bad); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// LiteralPrefix marks a matcher pattern that is compared exactly instead of as a regex
+const LiteralPrefix = "literal:"
+
+// normalizePatterns rewrites the regex matchers of a rule so the handler only sees valid
+// patterns. A literal: pattern becomes an escaped regex matching the whole value. An
+// invalid pattern is an error with server.strictPatterns, and otherwise is matched
+// exactly as well, with a warning naming the rule and the matcher.
+func (c *Config) normalizePatterns(i int, rule *RequestRule) error {
+	fix := func(what string, pattern *string) error {
+		if *pattern == "" {
+			return nil
+		}
+		if rest, ok := strings.CutPrefix(*pattern, LiteralPrefix); ok {
+			*pattern = literalPattern(rest)
+			return nil
+		}
+		_, err := regexp.Compile(*pattern)
+		if err == nil {
+			return nil
+		}
+		if c.Server.StrictPatterns {
+			return fmt.Errorf("request rule %d: %s: invalid pattern %q: %v; prefix it with %s to match it exactly", i, what, *pattern, err, LiteralPrefix)
+		}
+		log.Printf("WARNING: request rule %d (%s): %s pattern %q is not a valid regex (%v), so it only matches the exact value %q. Prefix it with %s to make that explicit, or set server.strictPatterns to reject it",
+			i, RuleKey(rule), what, *pattern, err, *pattern, LiteralPrefix)
+		*pattern = literalPattern(*pattern)
+		return nil
+	}
+
+	fixMatcher := func(where string, headers map[string]string, params QueryParams, body *string) error {
+		for _, name := range sortedKeys(headers) {
+			value := headers[name]
+			if err := fix(where+"header "+name, &value); err != nil {
+				return err
+			}
+			headers[name] = value
+		}
+		for _, name := range sortedKeys(params) {
+			m := params[name]
+			if err := fix(where+"query parameter "+name, &m.Pattern); err != nil {
+				return err
+			}
+			params[name] = m
+		}
+		return fix(where+"body", body)
+	}
+
+	if err := fixMatcher("", rule.Headers, rule.QueryParams, &rule.Body); err != nil {
+		return err
+	}
+	if err := rule.Groups.Walk(func(m *Matcher) error {
+		return fixMatcher("matcher group ", m.Headers, m.QueryParams, &m.Body)
+	}); err != nil {
+		return err
+	}
+	if cc := rule.ClientCert; cc != nil {
+		if err := fix("clientCert subject", &cc.Subject); err != nil {
+			return err
+		}
+		if err := fix("clientCert san", &cc.SAN); err != nil {
+			return err
+		}
+		if err := fix("clientCert issuer", &cc.Issuer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// literalPattern returns a regex matching exactly s
+func literalPattern(s string) string {
+	return "^" + regexp.QuoteMeta(s) + "$"
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Proxy forwards the request to a real upstream and relays its response, so only some
+// endpoints need to be mocked
+type Proxy struct {
+	Target      string            `yaml:"target"`      // Base URL of the upstream; the request path and query are appended
+	StripPrefix string            `yaml:"stripPrefix"` // Removed from the request path before it is appended
+	Timeout     int               `yaml:"timeout"`     // Milliseconds to wait for the upstream response, defaults to 30000
+	Headers     map[string]string `yaml:"headers"`     // Added to the forwarded request, replacing request headers of the same name
+	TargetURL   *url.URL          `yaml:"-"`           // Parsed from Target during config loading
+}
+
+func (p *Proxy) setDefaults() {
+	if p.Timeout == 0 {
+		p.Timeout = 30000
+	}
+}
+
+func (p *Proxy) validate() error {
+	u, err := url.Parse(p.Target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("proxy target %q must be an absolute http or https URL", p.Target)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("proxy target %q cannot have a query or fragment", p.Target)
+	}
+	if p.StripPrefix != "" && !strings.HasPrefix(p.StripPrefix, "/") {
+		return fmt.Errorf("proxy stripPrefix must start with /")
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("proxy timeout cannot be negative")
+	}
+	p.TargetURL = u
+	return nil
+}
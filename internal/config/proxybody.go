@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProxyBody takes the response body from another URL, while the status code and
+// headers still come from the rule. It is written as the URL alone, or as a mapping
+// with caching options.
+type ProxyBody struct {
+	URL      string `yaml:"url"`
+	Cache    bool   `yaml:"cache"`    // Fetch the body once and serve it from memory instead of streaming it each time
+	CacheTTL int    `yaml:"cacheTTL"` // Seconds a cached body is kept before it is fetched again; 0 keeps it until the rules reload
+	Prefetch bool   `yaml:"prefetch"` // Fetch a cached body when the rules load instead of on the first request
+	Timeout  int    `yaml:"timeout"`  // Milliseconds to wait for the upstream to answer, defaults to 30000
+}
+
+// proxyBodySpec is the mapping form of ProxyBody
+type proxyBodySpec ProxyBody
+
+// UnmarshalYAML accepts the URL alone or the mapping form
+func (p *ProxyBody) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = ProxyBody{URL: node.Value}
+		return nil
+	}
+	var spec proxyBodySpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+	*p = ProxyBody(spec)
+	return nil
+}
+
+// MarshalYAML renders the body source in the form it was written
+func (p ProxyBody) MarshalYAML() (interface{}, error) {
+	if !p.Cache && p.CacheTTL == 0 && !p.Prefetch && (p.Timeout == 0 || p.Timeout == 30000) {
+		return p.URL, nil
+	}
+	return proxyBodySpec(p), nil
+}
+
+func (p *ProxyBody) setDefaults() {
+	if p.Timeout == 0 {
+		p.Timeout = 30000
+	}
+}
+
+func (p *ProxyBody) validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("proxyBody url %q must be an absolute http or https URL", p.URL)
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("proxyBody timeout cannot be negative")
+	}
+	if p.CacheTTL < 0 {
+		return fmt.Errorf("proxyBody cacheTTL cannot be negative")
+	}
+	if p.CacheTTL > 0 && !p.Cache {
+		return fmt.Errorf("proxyBody cacheTTL requires cache")
+	}
+	if p.Prefetch && !p.Cache {
+		return fmt.Errorf("proxyBody prefetch requires cache")
+	}
+	return nil
+}
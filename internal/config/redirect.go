@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultRedirectHopParam is the query parameter numbering the hops of a redirect chain
+const DefaultRedirectHopParam = "hop"
+
+// Redirect answers with a redirect, optionally through a chain of hops or a loop, to
+// test how clients follow redirects and detect loops
+type Redirect struct {
+	To       string `yaml:"to"`       // Location of the last hop, required unless loop is set
+	Status   int    `yaml:"status"`   // 301, 302, 303, 307, or 308, defaults to 302
+	Hops     int    `yaml:"hops"`     // Redirects before the client reaches to, defaults to 1
+	Loop     bool   `yaml:"loop"`     // Send the last hop back to the first instead of to
+	HopParam string `yaml:"hopParam"` // Query parameter numbering the hops, defaults to hop
+}
+
+var redirectStatuses = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+func (r *Redirect) setDefaults() {
+	if r.Status == 0 {
+		r.Status = http.StatusFound
+	}
+	if r.Hops == 0 {
+		r.Hops = 1
+	}
+	if r.HopParam == "" {
+		r.HopParam = DefaultRedirectHopParam
+	}
+}
+
+func (r *Redirect) validate() error {
+	if !redirectStatuses[r.Status] {
+		return fmt.Errorf("redirect status must be 301, 302, 303, 307, or 308, got %d", r.Status)
+	}
+	if r.Hops < 0 {
+		return fmt.Errorf("redirect hops cannot be negative")
+	}
+	switch {
+	case r.Loop && r.To != "":
+		return fmt.Errorf("redirect to cannot be combined with loop")
+	case !r.Loop && r.To == "":
+		return fmt.Errorf("redirect to is required unless loop is set")
+	}
+	return nil
+}
+
+// Describe summarizes where the redirect leads, e.g. "to /v2 after 3 hops" or "loop of 2 hops"
+func (r *Redirect) Describe() string {
+	switch {
+	case r.Loop:
+		return fmt.Sprintf("loop of %d hops", r.Hops)
+	case r.Hops > 1:
+		return fmt.Sprintf("to %s after %d hops", r.To, r.Hops)
+	default:
+		return "to " + r.To
+	}
+}
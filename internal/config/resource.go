@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnyMethod is the method of resource rules, which answer every method
+const AnyMethod = "*"
+
+// DefaultResourceIDField is the field holding the id of resource items unless the rule
+// names another
+const DefaultResourceIDField = "id"
+
+// Resource makes a rule serve an in-memory collection of JSON objects: list and create
+// at the rule's path, and get, replace, update, and delete at path/{id}
+type Resource struct {
+	IDField string `yaml:"idField"` // Field holding each item's id, defaults to id
+	Seed    string `yaml:"seed"`    // Dataset with the initial items, a list of objects
+}
+
+func (res *Resource) setDefaults() {
+	if res.IDField == "" {
+		res.IDField = DefaultResourceIDField
+	}
+}
+
+func (c *Config) validateResource(rule *RequestRule) error {
+	res := rule.Resource
+	if rule.Method != AnyMethod {
+		return fmt.Errorf("resource rules answer every method, so method cannot be set")
+	}
+	if rule.PathPrefix != "" || rule.PathPattern != nil || strings.HasSuffix(rule.Path, "/") {
+		return fmt.Errorf("resource rules need a path without parameters or a trailing slash")
+	}
+	if len(rule.Responses) > 0 || rule.Assert != nil || rule.Hold {
+		return fmt.Errorf("resource cannot be combined with responses, assert, or hold")
+	}
+	spec := &rule.Response
+	if spec.Body != nil || spec.BodyBase64 != "" || spec.BodyFile != "" || spec.RandomBody != nil || spec.Dataset != "" ||
+		spec.Fault != "" || spec.SSE != nil || spec.Proxy != nil || spec.Transformer != nil || spec.Template ||
+		spec.Status.Code != 0 || len(spec.Variants) > 0 {
+		return fmt.Errorf("the response of a resource rule can only set headers")
+	}
+	if res.Seed == "" {
+		return nil
+	}
+	data, ok := c.DatasetData[res.Seed]
+	if !ok {
+		return fmt.Errorf("resource seed names unknown dataset %q", res.Seed)
+	}
+	items, ok := data.([]interface{})
+	if !ok {
+		return fmt.Errorf("resource seed dataset %q must be a list of objects", res.Seed)
+	}
+	seen := make(map[string]bool, len(items))
+	for j, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("resource seed dataset %q: item %d is not an object", res.Seed, j)
+		}
+		id, ok := obj[res.IDField]
+		if !ok {
+			return fmt.Errorf("resource seed dataset %q: item %d has no %s", res.Seed, j, res.IDField)
+		}
+		key := fmt.Sprint(id)
+		if seen[key] {
+			return fmt.Errorf("resource seed dataset %q: duplicate %s %s", res.Seed, res.IDField, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"http-mock-server/internal/templating"
+)
+
+// RetryAfter throttles each client before it gets the rule's response: the first request
+// is answered with a Retry-After header, and only a retry after that many seconds gets
+// the response. Retries that come too soon get the early response instead.
+type RetryAfter struct {
+	Seconds          string            `yaml:"seconds"` // Number of seconds, or a template rendered for each throttled request
+	ThrottleResponse `yaml:",inline"`  // Defaults to 503 Service Unavailable
+	Early            *ThrottleResponse `yaml:"early"`        // Sent to retries within the window; defaults to the throttle response
+	ClientHeader     string            `yaml:"clientHeader"` // Identifies clients by this header instead of their IP address
+}
+
+// ThrottleResponse is a response sent instead of the rule's response while a client
+// has to wait
+type ThrottleResponse struct {
+	StatusCode int         `yaml:"status-code"`
+	Headers    Headers     `yaml:"headers"`
+	Body       interface{} `yaml:"body"`
+}
+
+// Template reports whether Seconds is rendered for each request
+func (r *RetryAfter) Template() bool {
+	return strings.Contains(r.Seconds, "{{")
+}
+
+func (r *RetryAfter) setDefaults() {
+	if r.StatusCode == 0 {
+		r.StatusCode = http.StatusServiceUnavailable
+	}
+	if r.Early != nil && r.Early.StatusCode == 0 {
+		r.Early.StatusCode = r.StatusCode
+	}
+}
+
+func (r *RetryAfter) validate(opts templating.Options) error {
+	switch {
+	case r.Seconds == "":
+		return fmt.Errorf("retryAfter seconds is required")
+	case r.Template():
+		if _, err := templating.Parse("retryAfter seconds", r.Seconds, opts); err != nil {
+			return fmt.Errorf("retryAfter seconds: %w", err)
+		}
+	default:
+		if n, err := strconv.Atoi(r.Seconds); err != nil || n <= 0 {
+			return fmt.Errorf("retryAfter seconds must be a positive number or a template, got %q", r.Seconds)
+		}
+	}
+	if r.StatusCode != http.StatusServiceUnavailable && r.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("retryAfter status-code must be 503 or 429, got %d", r.StatusCode)
+	}
+	if r.Early != nil && (r.Early.StatusCode < 400 || r.Early.StatusCode > 599) {
+		return fmt.Errorf("retryAfter early status-code must be an error status, got %d", r.Early.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteRoutes prints a table of the rules in the order they are tried, with the
+// method, path, a summary of the other matchers, and the response of each
+func (c *Config) WriteRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tMETHOD\tPATH\tMATCHERS\tRESPONSE")
+	for i := range c.Requests {
+		rule := &c.Requests[i]
+		response := responseSummary(rule)
+		if rule.ResponseDelay != nil {
+			response += ", delay " + rule.ResponseDelay.String()
+		}
+		if rule.RetryAfter != nil {
+			response = fmt.Sprintf("%d retry after %ss, then %s", rule.RetryAfter.StatusCode, rule.RetryAfter.Seconds, response)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, rule.Method, rule.DisplayPath(), matcherSummary(rule), response)
+	}
+	return tw.Flush()
+}
+
+// matcherSummary names the matchers of a rule besides its method and path
+func matcherSummary(rule *RequestRule) string {
+	var parts []string
+	for _, name := range sortedKeys(rule.Headers) {
+		parts = append(parts, "header "+name)
+	}
+	for _, name := range sortedKeys(rule.QueryParams) {
+		parts = append(parts, "query "+name)
+	}
+	if rule.Body != "" {
+		parts = append(parts, "body")
+	}
+	if rule.JSONBody != nil {
+		parts = append(parts, "json body")
+	}
+	if rule.BodySchema != "" {
+		parts = append(parts, "schema "+rule.BodySchema)
+	}
+	if rule.ContentLength != nil || rule.BodySize != nil {
+		parts = append(parts, "body size")
+	}
+	if rule.When != "" {
+		parts = append(parts, "when")
+	}
+	if rule.ClientCert != nil {
+		parts = append(parts, "client cert")
+	}
+	if len(rule.Groups.AnyOf) > 0 {
+		parts = append(parts, "anyOf")
+	}
+	if len(rule.Groups.AllOf) > 0 {
+		parts = append(parts, "allOf")
+	}
+	if rule.Groups.Not != nil {
+		parts = append(parts, "not")
+	}
+	if rule.RequiredState != "" {
+		parts = append(parts, fmt.Sprintf("state %s=%s", rule.Scenario, rule.RequiredState))
+	}
+	if len(rule.Audiences) > 0 {
+		parts = append(parts, "audience "+strings.Join(rule.Audiences, ","))
+	}
+	if rule.OnCall > 0 {
+		parts = append(parts, fmt.Sprintf("call %d", rule.OnCall))
+	}
+	if rule.AfterCalls > 0 {
+		parts = append(parts, fmt.Sprintf("after %d calls", rule.AfterCalls))
+	}
+	if rule.ActiveFrom != "" || rule.ActiveUntil != "" || rule.Schedule != "" {
+		parts = append(parts, "scheduled")
+	}
+	if rule.Weight > 0 {
+		parts = append(parts, fmt.Sprintf("weight %d", rule.Weight))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// responseSummary describes the response of a rule, or the statuses of its responses
+func responseSummary(rule *RequestRule) string {
+	if res := rule.Resource; res != nil {
+		if res.Seed != "" {
+			return "resource seeded from " + res.Seed
+		}
+		return "resource"
+	}
+	if len(rule.Responses) == 0 {
+		return describeSpec(&rule.Response)
+	}
+	var statuses []string
+	seen := make(map[string]bool)
+	for i := range rule.Responses {
+		status := describeStatus(&rule.Responses[i].ResponseSpec)
+		if !seen[status] {
+			seen[status] = true
+			statuses = append(statuses, status)
+		}
+	}
+	return fmt.Sprintf("%s (%d responses)", strings.Join(statuses, "/"), len(rule.Responses))
+}
+
+func describeStatus(spec *ResponseSpec) string {
+	switch {
+	case spec.Proxy != nil:
+		return "proxy"
+	case spec.Fault != "":
+		return "fault"
+	case spec.Status.Template != "":
+		return "templated"
+	default:
+		return fmt.Sprint(spec.StatusCode)
+	}
+}
+
+func describeSpec(spec *ResponseSpec) string {
+	switch {
+	case spec.Proxy != nil:
+		return "proxy " + spec.Proxy.Target
+	case spec.Fault != "":
+		return "fault " + spec.Fault
+	}
+	desc := describeStatus(spec)
+	switch {
+	case spec.SSE != nil:
+		desc += " event stream"
+	case spec.BodyFile != "":
+		desc += " file " + spec.BodyFile
+	case spec.Transformer != nil:
+		desc += " transformer " + spec.Transformer.Plugin
+	case spec.Mode != "":
+		desc += " " + spec.Mode
+	case spec.Redirect != nil:
+		desc += " redirect " + spec.Redirect.Describe()
+	case spec.Dataset != "":
+		desc += " dataset " + spec.Dataset
+	case spec.RandomBody != nil:
+		desc += fmt.Sprintf(" random %s (%s)", spec.RandomBody.Type, formatBytes(spec.RandomBody.SizeBytes))
+	case spec.SchemaBody != nil:
+		desc += " schema " + spec.SchemaBody.Schema
+	case spec.BodyFormat == BodyFormatXML:
+		desc += " xml"
+	case spec.BodyBytes != nil:
+		desc += fmt.Sprintf(" binary (%s)", formatBytes(len(spec.BodyBytes)))
+	}
+	if len(spec.Variants) > 0 {
+		desc += fmt.Sprintf(", %d variants", len(spec.Variants))
+	}
+	return desc
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
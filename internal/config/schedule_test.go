@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Active(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"TZ=UTC * * * * *", utc(3, 5, 10, 17), true},
+		{"TZ=UTC 0 * * * *", utc(3, 5, 10, 0), true},
+		{"TZ=UTC 0 * * * *", utc(3, 5, 10, 1), false},
+		{"TZ=UTC */15 * * * *", utc(3, 5, 10, 45), true},
+		{"TZ=UTC */15 * * * *", utc(3, 5, 10, 46), false},
+		{"TZ=UTC * 9-17 * * MON-FRI", utc(3, 5, 12, 0), true},  // Wednesday
+		{"TZ=UTC * 9-17 * * MON-FRI", utc(3, 8, 12, 0), false}, // Saturday
+		{"TZ=UTC * 9-17 * * MON-FRI", utc(3, 5, 18, 0), false},
+		{"TZ=UTC * * * * 7", utc(3, 9, 0, 0), true}, // Sunday written as 7
+		{"TZ=UTC * * 1,15 jan *", utc(1, 15, 8, 0), true},
+		{"TZ=UTC * * 1,15 jan *", utc(2, 15, 8, 0), false},
+		{"TZ=UTC 30 8-18/2 * * *", utc(3, 5, 10, 30), true},
+		{"TZ=UTC 30 8-18/2 * * *", utc(3, 5, 11, 30), false},
+		{"TZ=UTC * * 1 * MON", utc(3, 3, 0, 0), true}, // Monday, not the 1st
+		{"TZ=UTC * * 1 * MON", utc(3, 4, 0, 0), false},
+		{"TZ=Asia/Tokyo * 9 * * *", utc(3, 5, 0, 30), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.at.Format(time.RFC3339), func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) unexpected error: %v", tt.expr, err)
+			}
+			if got := s.Active(tt.at); got != tt.want {
+				t.Fatalf("Active(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"* * * *", "must have 5 fields"},
+		{"60 * * * *", "invalid value"},
+		{"* 24 * * *", "invalid value"},
+		{"* * 0 * *", "invalid value"},
+		{"* * * foo *", "invalid value"},
+		{"*/0 * * * *", "invalid step"},
+		{"5-1 * * * *", "invalid range"},
+		{"TZ=Nowhere/City * * * * *", "unknown time zone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseSchedule(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateActiveWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    RequestRule
+		wantErr string
+	}{
+		{name: "valid window", rule: RequestRule{ActiveFrom: "2025-01-01T00:00:00Z", ActiveUntil: "2025-01-02T00:00:00+01:00", Schedule: "* 2 * * *"}},
+		{name: "bad from", rule: RequestRule{ActiveFrom: "yesterday"}, wantErr: "activeFrom must be an RFC 3339 timestamp"},
+		{name: "bad until", rule: RequestRule{ActiveUntil: "2025-01-01"}, wantErr: "activeUntil must be an RFC 3339 timestamp"},
+		{name: "inverted", rule: RequestRule{ActiveFrom: "2025-02-01T00:00:00Z", ActiveUntil: "2025-01-01T00:00:00Z"}, wantErr: "activeFrom must be before activeUntil"},
+		{name: "bad schedule", rule: RequestRule{Schedule: "every day"}, wantErr: "must have 5 fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.Path, rule.Method, rule.Response.StatusCode = "/x", "GET", 200
+			cfg := &Config{Server: ServerConfig{Port: 8080}, Requests: []RequestRule{rule}}
+
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				w := cfg.Requests[0].ActiveWindow
+				if w.From.IsZero() || w.Until.IsZero() || w.Schedule == nil {
+					t.Fatalf("expected parsed active window, got %+v", w)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
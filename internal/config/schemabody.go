@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"http-mock-server/internal/jsonschema"
+)
+
+// schemaBodySeed seeds bodies generated once, so they stay the same across reloads
+const schemaBodySeed = 1
+
+// SchemaBody generates a JSON body from a JSON Schema, so endpoints known only from
+// a contract still answer with plausible data. The schema may be a schema inside a
+// larger document, such as an OpenAPI component. It is written as the schema path
+// alone, or as a mapping with options.
+type SchemaBody struct {
+	Schema   string             `yaml:"schema"` // Schema file, JSON or YAML, optionally followed by #/pointer/to/the/schema
+	Random   bool               `yaml:"random"` // Generate a new body for every request instead of once when the rules load
+	Compiled *jsonschema.Schema `yaml:"-"`      // Loaded from Schema during config loading
+}
+
+// schemaBodySpec is the mapping form of SchemaBody
+type schemaBodySpec SchemaBody
+
+// UnmarshalYAML accepts the schema path alone or the mapping form
+func (b *SchemaBody) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*b = SchemaBody{Schema: node.Value}
+		return nil
+	}
+	var spec schemaBodySpec
+	if err := node.Decode(&spec); err != nil {
+		return err
+	}
+	*b = SchemaBody(spec)
+	return nil
+}
+
+// MarshalYAML renders the body source in the form it was written
+func (b SchemaBody) MarshalYAML() (interface{}, error) {
+	if !b.Random {
+		return b.Schema, nil
+	}
+	return schemaBodySpec{Schema: b.Schema, Random: b.Random}, nil
+}
+
+// Generate returns a new JSON body from the schema, drawing its choices from r
+func (b *SchemaBody) Generate(r *rand.Rand) ([]byte, error) {
+	_, pointer, _ := strings.Cut(b.Schema, "#")
+	if pointer != "" {
+		pointer = "#" + pointer
+	}
+	v, err := b.Compiled.Generate(pointer, r)
+	if err != nil {
+		return nil, fmt.Errorf("schemaBody %s: %w", b.Schema, err)
+	}
+	return json.Marshal(v)
+}
+
+// loadSchemaBody reads the schema and, unless a body is generated per request,
+// generates the body served for every request into BodyBytes
+func (s *ResponseSpec) loadSchemaBody() error {
+	b := s.SchemaBody
+	path, _, _ := strings.Cut(b.Schema, "#")
+	if path == "" {
+		return fmt.Errorf("schemaBody needs a schema file")
+	}
+	if s.Body != nil || s.BodyBase64 != "" || s.BodyFile != "" || s.ProxyBody != nil || s.RandomBody != nil || s.GenerateBody != nil || s.Dataset != "" ||
+		s.Fault != "" || s.SSE != nil || s.Proxy != nil || s.Transformer != nil || s.Mode != "" || s.Redirect != nil || s.Template {
+		return fmt.Errorf("schemaBody cannot be combined with body, bodyBase64, bodyFile, proxyBody, bodyUrl, randomBody, generateBody, dataset, fault, sse, proxy, transformer, mode, redirect, or template")
+	}
+	if s.BodyFormat == BodyFormatXML {
+		return fmt.Errorf("schemaBody is always JSON and cannot be combined with bodyFormat xml")
+	}
+	if b.Random && s.Golden != "" {
+		return fmt.Errorf("golden cannot be combined with a random schemaBody")
+	}
+
+	schema, err := jsonschema.Load(path)
+	if err != nil {
+		return fmt.Errorf("schemaBody: %w", err)
+	}
+	b.Compiled = schema
+	// Generating once also checks that the pointer and references resolve
+	body, err := b.Generate(rand.New(rand.NewSource(schemaBodySeed)))
+	if err != nil {
+		return err
+	}
+	if !b.Random {
+		s.BodyBytes = body
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret is a sensitive configuration value. In YAML it is either an inline
+// string or a mapping naming where to load the value from:
+//
+//	apiKey: "inline-value"
+//	apiKey: { file: /run/secrets/api-key }
+//	apiKey: { env: API_KEY }                 # falls back to the file named by API_KEY_FILE
+//	apiKey: { vault: { path: secret/data/mock, field: apiKey } }
+//
+// Values are resolved once while the configuration is loaded, and again on reload.
+type Secret struct {
+	value  string
+	source string
+}
+
+// NewSecret creates a secret with an inline value
+func NewSecret(value string) Secret {
+	return Secret{value: value, source: "inline"}
+}
+
+// Value returns the resolved secret value
+func (s Secret) Value() string {
+	return s.value
+}
+
+// String describes where the secret came from without revealing it
+func (s Secret) String() string {
+	return s.source
+}
+
+// secretRef is the mapping form of a Secret
+type secretRef struct {
+	File  string    `yaml:"file"`
+	Env   string    `yaml:"env"`
+	Vault *vaultRef `yaml:"vault"`
+}
+
+type vaultRef struct {
+	Path  string `yaml:"path"`  // API path below /v1/, e.g. secret/data/mock
+	Field string `yaml:"field"` // Key within the secret's data
+}
+
+// UnmarshalYAML resolves a secret from its inline or reference form
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = NewSecret(node.Value)
+		return nil
+	}
+
+	var ref secretRef
+	if err := node.Decode(&ref); err != nil {
+		return err
+	}
+
+	sources := 0
+	for _, set := range []bool{ref.File != "", ref.Env != "", ref.Vault != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("line %d: secret must set exactly one of file, env, or vault", node.Line)
+	}
+
+	var err error
+	switch {
+	case ref.File != "":
+		s.source = "file:" + ref.File
+		s.value, err = readSecretFile(ref.File)
+	case ref.Env != "":
+		s.source = "env:" + ref.Env
+		s.value, err = readSecretEnv(ref.Env)
+	default:
+		s.source = "vault:" + ref.Vault.Path + "#" + ref.Vault.Field
+		s.value, err = readVaultSecret(ref.Vault)
+	}
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
+
+// MarshalYAML renders the secret as its source and a short digest, so that
+// configuration diffs detect changed values without exposing them.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	sum := sha256.Sum256([]byte(s.value))
+	return s.source + " sha256:" + hex.EncodeToString(sum[:])[:12], nil
+}
+
+// readSecretFile reads a secret from a file, trimming the trailing newline most editors add.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readSecretEnv reads a secret from an environment variable. Following the common
+// container convention, NAME_FILE may point to a file holding the value instead.
+func readSecretEnv(name string) (string, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	if path, ok := os.LookupEnv(name + "_FILE"); ok {
+		return readSecretFile(path)
+	}
+	return "", fmt.Errorf("environment variable %s (or %s_FILE) is not set", name, name)
+}
+
+// vaultCache shares the secrets fetched from Vault between the references of one
+// configuration load, so a secret with several fields is requested once. Each load
+// starts with an empty cache, so reloads pick up rotated secrets.
+var vaultCache = &vaultSecrets{entries: make(map[string]*vaultEntry)}
+
+type vaultSecrets struct {
+	mu      sync.Mutex
+	entries map[string]*vaultEntry
+}
+
+// vaultEntry is one fetched secret; done is closed once data and err are set
+type vaultEntry struct {
+	done chan struct{}
+	data map[string]interface{}
+	err  error
+}
+
+// reset forgets the secrets fetched by earlier loads
+func (c *vaultSecrets) reset() {
+	c.mu.Lock()
+	c.entries = make(map[string]*vaultEntry)
+	c.mu.Unlock()
+}
+
+// get returns the secret at path, fetching it unless the current load already has.
+// The lock only guards the map, so a slow Vault never blocks unrelated lookups.
+func (c *vaultSecrets) get(addr, token, path string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	if !ok {
+		entry = &vaultEntry{done: make(chan struct{})}
+		c.entries[path] = entry
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		entry.data, entry.err = fetchVaultSecret(addr, token, path)
+		close(entry.done)
+	}
+	<-entry.done
+	return entry.data, entry.err
+}
+
+// readVaultSecret reads a field from a HashiCorp Vault secret using VAULT_ADDR and
+// VAULT_TOKEN (or VAULT_TOKEN_FILE). Both KV version 1 and 2 responses are supported.
+func readVaultSecret(ref *vaultRef) (string, error) {
+	if ref.Path == "" || ref.Field == "" {
+		return "", fmt.Errorf("vault secret requires path and field")
+	}
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token, err := readSecretEnv("VAULT_TOKEN")
+	if err != nil {
+		return "", err
+	}
+
+	data, err := vaultCache.get(addr, token, ref.Path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %q", ref.Path, ref.Field)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s field %q is not a string", ref.Path, ref.Field)
+	}
+	return s, nil
+}
+
+func fetchVaultSecret(addr, token, path string) (map[string]interface{}, error) {
+	url := strings.TrimRight(addr, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %w", err)
+	}
+
+	// KV version 2 nests the secret under data.data alongside metadata
+	if nested, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := body.Data["metadata"]; hasMeta {
+			return nested, nil
+		}
+	}
+	return body.Data, nil
+}
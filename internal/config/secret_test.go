@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeSecret(t *testing.T, src string) (Secret, error) {
+	t.Helper()
+	var holder struct {
+		Key Secret `yaml:"key"`
+	}
+	t.Cleanup(vaultCache.reset)
+	err := yaml.Unmarshal([]byte(src), &holder)
+	return holder.Key, err
+}
+
+func TestSecret_Inline(t *testing.T) {
+	s, err := decodeSecret(t, `key: "plain"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Value() != "plain" {
+		t.Fatalf("Value() = %q, want %q", s.Value(), "plain")
+	}
+}
+
+func TestSecret_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := decodeSecret(t, "key: { file: "+path+" }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Value() != "from-file" {
+		t.Fatalf("Value() = %q, want %q", s.Value(), "from-file")
+	}
+	if s.String() != "file:"+path {
+		t.Fatalf("String() = %q, want source description", s.String())
+	}
+}
+
+func TestSecret_Env(t *testing.T) {
+	t.Setenv("MOCK_TEST_SECRET", "from-env")
+
+	s, err := decodeSecret(t, "key: { env: MOCK_TEST_SECRET }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Value() != "from-env" {
+		t.Fatalf("Value() = %q, want %q", s.Value(), "from-env")
+	}
+}
+
+func TestSecret_EnvFileConvention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("from-env-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MOCK_TEST_FILE_SECRET_FILE", path)
+
+	s, err := decodeSecret(t, "key: { env: MOCK_TEST_FILE_SECRET }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Value() != "from-env-file" {
+		t.Fatalf("Value() = %q, want %q", s.Value(), "from-env-file")
+	}
+}
+
+func TestSecret_Vault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.URL.Path != "/v1/secret/data/mock-vault-test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"apiKey": "from-vault"}, "metadata": {"version": 1}}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	s, err := decodeSecret(t, "key: { vault: { path: secret/data/mock-vault-test, field: apiKey } }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Value() != "from-vault" {
+		t.Fatalf("Value() = %q, want %q", s.Value(), "from-vault")
+	}
+}
+
+func TestSecret_VaultReload(t *testing.T) {
+	var mu sync.Mutex
+	requests, version := 0, "v1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		_, _ = fmt.Fprintf(w, `{"data": {"signing": "sign-%s", "hmac": "hmac-%s"}}`, version, version)
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	src := []byte(`
+server:
+  keys:
+    signing: { vault: { path: secret/rotating, field: signing } }
+    hmac: { vault: { path: secret/rotating, field: hmac } }
+`)
+	cfg, err := Parse(src, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Server.Keys["hmac"].Value(); got != "hmac-v1" || requests != 1 {
+		t.Fatalf("expected one request for both fields, got %d requests and %q", requests, got)
+	}
+
+	// Each load fetches the secret again, so a reload sees the rotated value
+	mu.Lock()
+	version = "v2"
+	mu.Unlock()
+	cfg, err = Parse(src, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Server.Keys["signing"].Value(); got != "sign-v2" || requests != 2 {
+		t.Fatalf("expected the rotated secret after reload, got %d requests and %q", requests, got)
+	}
+}
+
+func TestSecret_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"no source", "key: {}", "exactly one of"},
+		{"two sources", "key: { env: A, file: /b }", "exactly one of"},
+		{"missing env", "key: { env: MOCK_TEST_UNSET_SECRET }", "is not set"},
+		{"missing file", "key: { file: /nonexistent/secret }", "could not read secret file"},
+		{"vault without field", "key: { vault: { path: secret/x } }", "requires path and field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeSecret(t, tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSecret_MarshalDoesNotLeakValue(t *testing.T) {
+	out, err := yaml.Marshal(NewSecret("super-secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "super-secret") {
+		t.Fatalf("marshaled secret leaks value: %s", out)
+	}
+
+	other, _ := yaml.Marshal(NewSecret("different"))
+	if string(out) == string(other) {
+		t.Fatal("expected different values to marshal differently")
+	}
+}
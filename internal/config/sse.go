@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SSEStream is a response sent as a text/event-stream of server-sent events
+type SSEStream struct {
+	Events []SSEEvent `yaml:"events"`
+	Repeat int        `yaml:"repeat"` // Times to send the events, defaults to 1; -1 repeats until the client disconnects
+}
+
+// SSEEvent is one server-sent event
+type SSEEvent struct {
+	ID    string      `yaml:"id"`
+	Event string      `yaml:"event"` // Event type; clients treat events without one as "message"
+	Data  interface{} `yaml:"data"`  // Strings are sent as is, other values as JSON
+	Retry int         `yaml:"retry"` // Reconnection time in milliseconds suggested to the client
+	Delay int         `yaml:"delay"` // Milliseconds to wait before sending the event
+}
+
+func (s *SSEStream) setDefaults() {
+	if s.Repeat == 0 {
+		s.Repeat = 1
+	}
+}
+
+func (s *SSEStream) validate() error {
+	if len(s.Events) == 0 {
+		return fmt.Errorf("sse requires at least one event")
+	}
+	if s.Repeat < -1 {
+		return fmt.Errorf("sse repeat must be -1 or a positive number of times")
+	}
+	for i, e := range s.Events {
+		if strings.ContainsAny(e.ID+e.Event, "\r\n") {
+			return fmt.Errorf("sse event %d: id and event cannot contain line breaks", i)
+		}
+		if e.Retry < 0 || e.Delay < 0 {
+			return fmt.Errorf("sse event %d: retry and delay cannot be negative", i)
+		}
+	}
+	return nil
+}
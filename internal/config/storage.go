@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Storage types
+const (
+	StorageLocal  = "local"
+	StorageMemory = "memory"
+	StorageS3     = "s3"
+)
+
+// Storage is where body files are read from and journal exports and recordings are
+// written to, so ephemeral containers can keep fixtures and results in a bucket
+type Storage struct {
+	Type         string `yaml:"type"`         // local (default), memory, or s3
+	Dir          string `yaml:"dir"`          // Directory local keys are relative to, defaults to the working directory
+	Bucket       string `yaml:"bucket"`       // S3 bucket
+	Prefix       string `yaml:"prefix"`       // Prepended to every S3 key, e.g. ci/run-42/
+	Region       string `yaml:"region"`       // S3 region, defaults to us-east-1
+	Endpoint     string `yaml:"endpoint"`     // S3-compatible endpoint such as MinIO, addressed path-style
+	AccessKey    Secret `yaml:"accessKey"`    // Defaults to AWS_ACCESS_KEY_ID
+	SecretKey    Secret `yaml:"secretKey"`    // Defaults to AWS_SECRET_ACCESS_KEY
+	SessionToken Secret `yaml:"sessionToken"` // Defaults to AWS_SESSION_TOKEN
+}
+
+// Remote reports whether keys live somewhere other than the local file system
+func (s *Storage) Remote() bool {
+	return s != nil && s.Type != StorageLocal
+}
+
+// LocalPath returns the file a key refers to in local storage. Without storage
+// configured, keys are plain file paths.
+func (s *Storage) LocalPath(key string) string {
+	if s == nil || s.Dir == "" || filepath.IsAbs(key) {
+		return key
+	}
+	return filepath.Join(s.Dir, key)
+}
+
+func (s *Storage) setDefaults() {
+	if s.Type == "" {
+		s.Type = StorageLocal
+	}
+	if s.Type == StorageS3 && s.Region == "" {
+		s.Region = "us-east-1"
+	}
+}
+
+func (s *Storage) validate() error {
+	switch s.Type {
+	case StorageLocal:
+		if s.Bucket != "" || s.Prefix != "" || s.Endpoint != "" {
+			return fmt.Errorf("bucket, prefix, and endpoint need type s3")
+		}
+	case StorageMemory:
+		if s.Dir != "" || s.Bucket != "" || s.Prefix != "" || s.Endpoint != "" {
+			return fmt.Errorf("memory storage takes no dir, bucket, prefix, or endpoint")
+		}
+	case StorageS3:
+		if s.Bucket == "" {
+			return fmt.Errorf("s3 storage needs a bucket")
+		}
+		if s.Dir != "" {
+			return fmt.Errorf("dir needs type local")
+		}
+		if s.Endpoint != "" {
+			u, err := url.Parse(s.Endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("endpoint %q must be an absolute http or https URL", s.Endpoint)
+			}
+		}
+		if strings.HasPrefix(s.Prefix, "/") {
+			return fmt.Errorf("prefix cannot start with /")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, use local, memory, or s3", s.Type)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/templating"
+)
+
+// Template limit defaults
+const (
+	DefaultTemplateMaxOutput = "10MB"
+	DefaultTemplateTimeout   = 1000
+)
+
+// TemplateLimits bounds what one template execution may do, so a bad rule can't hang a
+// worker or produce an endless response
+type TemplateLimits struct {
+	MaxOutput       string   `yaml:"maxOutput"`       // Largest rendered output, e.g. "1MB", defaults to 10MB
+	MaxOutputBytes  int      `yaml:"-"`               // Parsed from MaxOutput
+	Timeout         int      `yaml:"timeout"`         // Milliseconds one execution may run, defaults to 1000
+	DeniedFunctions []string `yaml:"deniedFunctions"` // Helpers and builtins templates may not call
+}
+
+func (t *TemplateLimits) setDefaults() {
+	if t.MaxOutput == "" {
+		t.MaxOutput = DefaultTemplateMaxOutput
+	}
+	if t.Timeout == 0 {
+		t.Timeout = DefaultTemplateTimeout
+	}
+}
+
+func (t *TemplateLimits) validate() error {
+	if t.MaxOutput != "" {
+		n, err := parseSize(t.MaxOutput)
+		if err != nil {
+			return fmt.Errorf("maxOutput: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("maxOutput must be positive")
+		}
+		t.MaxOutputBytes = n
+	}
+	if t.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	for _, name := range t.DeniedFunctions {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("deniedFunctions cannot contain an empty name")
+		}
+	}
+	return nil
+}
+
+// Limits returns the execution limits for the templating package
+func (t *TemplateLimits) Limits() templating.Limits {
+	return templating.Limits{
+		MaxOutputBytes: t.MaxOutputBytes,
+		Timeout:        time.Duration(t.Timeout) * time.Millisecond,
+	}
+}
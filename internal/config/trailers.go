@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// forbiddenTrailers can't be sent after the body: they frame or route the message,
+// or clients need them before reading it
+var forbiddenTrailers = map[string]bool{
+	"Authorization":     true,
+	"Cache-Control":     true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Range":     true,
+	"Content-Type":      true,
+	"Host":              true,
+	"Set-Cookie":        true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+}
+
+// validateTrailers checks the trailers of a response
+func validateTrailers(trailers Headers) error {
+	for name, value := range trailers {
+		canonical := http.CanonicalHeaderKey(name)
+		if forbiddenTrailers[canonical] {
+			return fmt.Errorf("trailer %s is not allowed after the body", canonical)
+		}
+		if strings.ContainsAny(name, "\r\n: ") || strings.Contains(value, "\r") {
+			return fmt.Errorf("invalid trailer %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"plugin"
+	"strings"
+
+	"http-mock-server/pkg/transform"
+)
+
+// DefaultTransformerSymbol is the function a transformer plugin exports unless the
+// rule names another
+const DefaultTransformerSymbol = "Transform"
+
+// Transformer produces the response with a function loaded from a Go plugin, for
+// behavior too complex for templates
+type Transformer struct {
+	Plugin  string            `yaml:"plugin"`  // Path of the .so file built with -buildmode=plugin
+	Symbol  string            `yaml:"symbol"`  // Exported function, defaults to Transform
+	Options map[string]string `yaml:"options"` // Passed to the function with every request
+}
+
+func (t *Transformer) setDefaults() {
+	if t.Symbol == "" {
+		t.Symbol = DefaultTransformerSymbol
+	}
+}
+
+func (t *Transformer) validate() error {
+	if t.Plugin == "" {
+		return fmt.Errorf("transformer plugin is required")
+	}
+	if strings.HasSuffix(t.Plugin, ".wasm") {
+		return fmt.Errorf("transformer plugin %s: WASM modules are not supported, build the transformer as a Go plugin", t.Plugin)
+	}
+	return nil
+}
+
+// Open loads the plugin and looks up the transformer function. Opening a plugin runs
+// its init code, so it is left to the handler building the rules rather than done
+// during validation, and only configurations read from disk may name plugins.
+func (t *Transformer) Open() (transform.Func, error) {
+	p, err := plugin.Open(t.Plugin)
+	if err != nil {
+		return nil, fmt.Errorf("transformer plugin %s: %w", t.Plugin, err)
+	}
+	sym, err := p.Lookup(t.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("transformer plugin %s: %w", t.Plugin, err)
+	}
+	switch fn := sym.(type) {
+	case func(*transform.Request) (*transform.Response, error):
+		return fn, nil
+	case *transform.Func:
+		return *fn, nil
+	}
+	return nil, fmt.Errorf("transformer plugin %s: %s is a %T, not a transform.Func", t.Plugin, t.Symbol, sym)
+}
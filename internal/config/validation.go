@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationError is one problem found while validating a configuration
+type ValidationError struct {
+	Rule    int    `json:"rule"`              // Index of the request rule, -1 for problems outside the rules
+	RuleKey string `json:"ruleKey,omitempty"` // Method and path of the rule, or its id
+	Field   string `json:"field,omitempty"`   // Path of the offending field within the rule or server section, when known
+	Reason  string `json:"reason"`
+	Message string `json:"message"` // The whole problem, as the server logs it
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors are all the problems of a configuration
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Message
+	}
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = "\n  " + e.Message
+	}
+	return fmt.Sprintf("%d problems:%s", len(errs), strings.Join(messages, ""))
+}
+
+// fieldToken matches the field names that lead error messages, such as "responses[0]"
+var fieldToken = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*(\[\d+\])?$`)
+
+// add records err, if any, for the rule with index i, or outside the rules when i is -1
+func (errs *ValidationErrors) add(i int, rule *RequestRule, err error) {
+	if err == nil {
+		return
+	}
+	e := &ValidationError{Rule: i, Message: err.Error()}
+	rest := e.Message
+	var fields []string
+	if rule != nil {
+		e.RuleKey = RuleKey(rule)
+		rest = strings.TrimPrefix(rest, fmt.Sprintf("request rule %d: ", i))
+	} else if section, after, ok := strings.Cut(rest, ": "); ok && strings.HasPrefix(section, "server ") && fieldToken.MatchString(section[len("server "):]) {
+		fields, rest = []string{"server", section[len("server "):]}, after
+	}
+	for {
+		token, after, ok := strings.Cut(rest, ": ")
+		if !ok || !fieldToken.MatchString(token) {
+			break
+		}
+		fields, rest = append(fields, token), after
+	}
+	e.Field, e.Reason = strings.Join(fields, "."), rest
+	*errs = append(*errs, e)
+}
+
+// err returns the collected problems as an error, nil when there are none
+func (errs ValidationErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Warmup prepares every rule before the server answers requests, loading body files
+// ahead of time, and logs how long each rule took so slow startups of large
+// configurations can be traced to specific rules
+type Warmup struct {
+	Slowest   int `yaml:"slowest"`   // Rules listed in the timing report, slowest first, defaults to 10
+	WarnAfter int `yaml:"warnAfter"` // Milliseconds a rule can take before a warning names it, defaults to 100
+}
+
+// WarnAfterDuration returns how long a rule can take to prepare before it is reported as slow
+func (w *Warmup) WarnAfterDuration() time.Duration {
+	return time.Duration(w.WarnAfter) * time.Millisecond
+}
+
+func (w *Warmup) setDefaults() {
+	if w.Slowest == 0 {
+		w.Slowest = 10
+	}
+	if w.WarnAfter == 0 {
+		w.WarnAfter = 100
+	}
+}
+
+func (w *Warmup) validate() error {
+	if w.Slowest < 0 {
+		return fmt.Errorf("slowest cannot be negative")
+	}
+	if w.WarnAfter < 0 {
+		return fmt.Errorf("warnAfter cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"http-mock-server/internal/templating"
+)
+
+// Webhook is an outbound request sent after a rule responds
+type Webhook struct {
+	URL      string            `yaml:"url"`
+	Method   string            `yaml:"method"` // Defaults to POST
+	Headers  map[string]string `yaml:"headers"`
+	Body     interface{}       `yaml:"body"`     // Strings are sent as is, other values as JSON
+	Template bool              `yaml:"template"` // Render the URL, string body, and header values as Go templates
+	Delay    int               `yaml:"delay"`    // Milliseconds to wait before the first attempt
+	Timeout  int               `yaml:"timeout"`  // Milliseconds to wait for each attempt, defaults to 5000
+	Retry    WebhookRetry      `yaml:"retry"`
+}
+
+// WebhookRetry controls redelivery of a webhook the receiver did not accept
+type WebhookRetry struct {
+	MaxAttempts int     `yaml:"maxAttempts"` // Total attempts including the first, defaults to 1
+	Backoff     int     `yaml:"backoff"`     // Milliseconds before the first retry, defaults to 1000
+	Multiplier  float64 `yaml:"multiplier"`  // Growth of the backoff after each retry, defaults to 2
+	MaxBackoff  int     `yaml:"maxBackoff"`  // Upper bound for the backoff in milliseconds, 0 for none
+	RetryOn     []int   `yaml:"retryOn"`     // Statuses that trigger a retry; any non-2xx when empty
+}
+
+// Retryable reports whether an attempt answered with status should be retried.
+// Connection failures and timeouts, reported as status 0, are always retryable.
+func (r *WebhookRetry) Retryable(status int) bool {
+	if status == 0 {
+		return true
+	}
+	if len(r.RetryOn) == 0 {
+		return status < 200 || status > 299
+	}
+	for _, s := range r.RetryOn {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// BackoffMillis returns the wait in milliseconds after the given failed attempt
+func (r *WebhookRetry) BackoffMillis(attempt int) int {
+	backoff := float64(r.Backoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= r.Multiplier
+		if r.MaxBackoff > 0 && backoff >= float64(r.MaxBackoff) {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 && backoff > float64(r.MaxBackoff) {
+		backoff = float64(r.MaxBackoff)
+	}
+	return int(backoff)
+}
+
+func (w *Webhook) setDefaults() {
+	if w.Method == "" {
+		w.Method = http.MethodPost
+	}
+	w.Method = strings.ToUpper(w.Method)
+	if w.Timeout == 0 {
+		w.Timeout = 5000
+	}
+	if w.Retry.MaxAttempts == 0 {
+		w.Retry.MaxAttempts = 1
+	}
+	if w.Retry.Backoff == 0 {
+		w.Retry.Backoff = 1000
+	}
+	if w.Retry.Multiplier == 0 {
+		w.Retry.Multiplier = 2
+	}
+}
+
+func (w *Webhook) validate(opts templating.Options) error {
+	if w.URL == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+	if w.Template {
+		if _, err := templating.Parse("url", w.URL, opts); err != nil {
+			return fmt.Errorf("webhook url: %w", err)
+		}
+		if body, ok := w.Body.(string); ok {
+			if _, err := templating.Parse("body", body, opts); err != nil {
+				return fmt.Errorf("webhook body: %w", err)
+			}
+		} else if w.Body != nil {
+			return fmt.Errorf("webhook template requires a string body")
+		}
+		for name, value := range w.Headers {
+			if _, err := templating.Parse(name, value, opts); err != nil {
+				return fmt.Errorf("webhook header %s: %w", name, err)
+			}
+		}
+	} else {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook url %q must be an absolute http or https URL", w.URL)
+		}
+	}
+	if w.Delay < 0 || w.Timeout < 0 {
+		return fmt.Errorf("webhook delay and timeout cannot be negative")
+	}
+	r := w.Retry
+	if r.MaxAttempts < 0 || r.Backoff < 0 || r.MaxBackoff < 0 {
+		return fmt.Errorf("webhook retry maxAttempts, backoff, and maxBackoff cannot be negative")
+	}
+	if r.Multiplier < 1 {
+		return fmt.Errorf("webhook retry multiplier must be at least 1")
+	}
+	for _, s := range r.RetryOn {
+		if s < 100 || s > 599 {
+			return fmt.Errorf("webhook retryOn has invalid status %d", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response body formats for structured bodies
+const (
+	BodyFormatJSON = "json" // Default
+	BodyFormatXML  = "xml"
+)
+
+// XMLBody controls how a structured body is written with bodyFormat xml
+type XMLBody struct {
+	Root       string            `yaml:"root"`       // Element the body's fields are written into; defaults to the body's single key
+	Namespaces map[string]string `yaml:"namespaces"` // Prefixes to namespace URIs declared on the root, "" for the default namespace
+}
+
+// xmlName matches an element or attribute name, with an optional namespace prefix
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*(:[A-Za-z_][A-Za-z0-9_.-]*)?$`)
+
+// UnmarshalYAML decodes the response and keeps the body's node, so an XML body is
+// written in the order its fields were declared
+func (s *ResponseSpec) UnmarshalYAML(node *yaml.Node) error {
+	type plain ResponseSpec
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "body" {
+				s.bodyNode = node.Content[i+1]
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes the weighted response's own fields next to the inlined
+// ResponseSpec, whose promoted UnmarshalYAML would otherwise skip them
+func (w *WeightedResponse) UnmarshalYAML(node *yaml.Node) error {
+	var own struct {
+		Name     string `yaml:"name"`
+		OnDemand bool   `yaml:"onDemand"`
+		When     string `yaml:"when"`
+		Weight   int    `yaml:"weight"`
+	}
+	if err := node.Decode(&own); err != nil {
+		return err
+	}
+	if err := w.ResponseSpec.UnmarshalYAML(node); err != nil {
+		return err
+	}
+	w.Name, w.OnDemand, w.When, w.Weight = own.Name, own.OnDemand, own.When, own.Weight
+	return nil
+}
+
+// encodeXMLBody checks the body format and writes a bodyFormat xml body to BodyBytes
+func (s *ResponseSpec) encodeXMLBody() error {
+	switch s.BodyFormat {
+	case "", BodyFormatJSON:
+		if s.XML != nil {
+			return fmt.Errorf("xml needs bodyFormat: xml")
+		}
+		return nil
+	case BodyFormatXML:
+	default:
+		return fmt.Errorf("unknown bodyFormat %q, use json or xml", s.BodyFormat)
+	}
+	if _, ok := s.Body.(map[string]interface{}); !ok || s.bodyNode == nil {
+		return fmt.Errorf("bodyFormat xml needs a body mapping element names to values")
+	}
+	if s.Template {
+		return fmt.Errorf("bodyFormat xml cannot be combined with template")
+	}
+	opts := s.XML
+	if opts == nil {
+		opts = &XMLBody{}
+	}
+	body, err := encodeXML(resolveAlias(s.bodyNode), opts)
+	if err != nil {
+		return fmt.Errorf("bodyFormat xml: %w", err)
+	}
+	s.BodyBytes = body
+	return nil
+}
+
+// encodeXML writes a YAML mapping as an XML document. Keys become elements in
+// declaration order, "@name" keys attributes, and a "#text" key the element's text.
+// A list repeats its element for every item.
+func encodeXML(body *yaml.Node, opts *XMLBody) ([]byte, error) {
+	root, fields := opts.Root, body
+	if root == "" {
+		if len(body.Content) != 2 {
+			return nil, fmt.Errorf("body needs a single root element, or set xml root")
+		}
+		root, fields = body.Content[0].Value, resolveAlias(body.Content[1])
+	}
+	if !xmlName.MatchString(root) || strings.HasPrefix(root, "@") {
+		return nil, fmt.Errorf("invalid element name %q", root)
+	}
+
+	var attrs []string
+	prefixes := make([]string, 0, len(opts.Namespaces))
+	for prefix := range opts.Namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		name := "xmlns"
+		if prefix != "" {
+			if strings.Contains(prefix, ":") || !xmlName.MatchString(prefix) {
+				return nil, fmt.Errorf("invalid namespace prefix %q", prefix)
+			}
+			name += ":" + prefix
+		}
+		attrs = append(attrs, fmt.Sprintf(` %s="%s"`, name, escapeXML(opts.Namespaces[prefix])))
+	}
+
+	e := &xmlEncoder{namespaces: opts.Namespaces}
+	e.buf.WriteString(xml.Header)
+	if err := e.element(root, fields, attrs); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type xmlEncoder struct {
+	buf        bytes.Buffer
+	namespaces map[string]string
+}
+
+// element writes one element with the given value, which is a mapping of children,
+// attributes, and text, or a scalar holding the text
+func (e *xmlEncoder) element(name string, value *yaml.Node, attrs []string) error {
+	if !xmlName.MatchString(name) {
+		return fmt.Errorf("invalid element name %q", name)
+	}
+	if err := e.checkPrefix(name); err != nil {
+		return err
+	}
+
+	var children []*yaml.Node
+	var text string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag != "!!null" {
+			text = value.Value
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, child := value.Content[i].Value, resolveAlias(value.Content[i+1])
+			switch {
+			case key == "#text":
+				if child.Kind != yaml.ScalarNode {
+					return fmt.Errorf("%s: #text must be a scalar", name)
+				}
+				text = child.Value
+			case strings.HasPrefix(key, "@"):
+				attr := key[1:]
+				if !xmlName.MatchString(attr) || child.Kind != yaml.ScalarNode {
+					return fmt.Errorf("%s: attribute %q must be a name with a scalar value", name, key)
+				}
+				if err := e.checkPrefix(attr); err != nil {
+					return err
+				}
+				attrs = append(attrs, fmt.Sprintf(` %s="%s"`, attr, escapeXML(child.Value)))
+			default:
+				children = append(children, value.Content[i], child)
+			}
+		}
+	default:
+		return fmt.Errorf("%s: a list must be the value of an element name", name)
+	}
+
+	e.buf.WriteString("<" + name + strings.Join(attrs, ""))
+	if text == "" && len(children) == 0 {
+		e.buf.WriteString("/>")
+		return nil
+	}
+	e.buf.WriteString(">" + escapeXML(text))
+	for i := 0; i < len(children); i += 2 {
+		key, child := children[i].Value, children[i+1]
+		items := []*yaml.Node{child}
+		if child.Kind == yaml.SequenceNode {
+			items = child.Content
+		}
+		for _, item := range items {
+			if err := e.element(key, resolveAlias(item), nil); err != nil {
+				return err
+			}
+		}
+	}
+	e.buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// checkPrefix reports a namespace prefix that isn't declared in xml namespaces
+func (e *xmlEncoder) checkPrefix(name string) error {
+	prefix, _, ok := strings.Cut(name, ":")
+	if !ok || prefix == "xml" || prefix == "xmlns" {
+		return nil
+	}
+	if _, declared := e.namespaces[prefix]; !declared {
+		return fmt.Errorf("namespace prefix %q of %s is not declared in xml namespaces", prefix, name)
+	}
+	return nil
+}
+
+// resolveAlias follows YAML aliases to the node they refer to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,124 @@
+// Package expr compiles and evaluates the CEL (Common Expression Language)
+// expressions of request conditions, using cel-go. Expressions see the request as
+// the variables method, path, headers, query, body, and json, plus the header(name)
+// and query(name) lookups, and can use the standard CEL functions and macros and the
+// string extensions such as lowerAscii().
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+	"github.com/google/cel-go/interpreter"
+)
+
+// Vars holds the request variables an expression is evaluated against: method,
+// path, and body as strings, headers and query as maps of strings, and json as the
+// decoded body, or nil when the body is not valid JSON
+type Vars map[string]interface{}
+
+// Program is a compiled expression that can be evaluated repeatedly
+type Program struct {
+	source  string
+	program cel.Program
+}
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+)
+
+// conditionEnv returns the CEL environment of request conditions, created once
+func conditionEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("method", cel.StringType),
+			cel.Variable("path", cel.StringType),
+			cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("query", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("body", cel.StringType),
+			cel.Variable("json", cel.DynType),
+			ext.Strings(),
+			cel.CrossTypeNumericComparisons(true),
+			// header(name) and query(name) read the headers and query variables,
+			// returning "" for absent values
+			cel.Macros(lookupMacro("header", "headers"), lookupMacro("query", "query")),
+			cel.Function("@header",
+				cel.Overload("header_lookup", []*cel.Type{cel.MapType(cel.StringType, cel.StringType), cel.StringType}, cel.StringType,
+					cel.BinaryBinding(func(headers, name ref.Val) ref.Val {
+						return lookup(headers, strings.ToLower(string(name.(types.String))))
+					}))),
+			cel.Function("@query",
+				cel.Overload("query_lookup", []*cel.Type{cel.MapType(cel.StringType, cel.StringType), cel.StringType}, cel.StringType,
+					cel.BinaryBinding(func(query, name ref.Val) ref.Val {
+						return lookup(query, string(name.(types.String)))
+					}))),
+		)
+	})
+	return env, envErr
+}
+
+// lookupMacro expands name(key) into a lookup of key in the variable vars. The
+// function it calls can't be named in expressions, so the variable is always the
+// request's.
+func lookupMacro(name, vars string) cel.Macro {
+	return cel.GlobalMacro(name, 1, func(eh cel.MacroExprFactory, _ ast.Expr, args []ast.Expr) (ast.Expr, *cel.Error) {
+		return eh.NewCall("@"+name, eh.NewIdent(vars), args[0]), nil
+	})
+}
+
+// lookup returns the value of key in m, or "" when it is absent
+func lookup(m ref.Val, key string) ref.Val {
+	if v, found := m.(traits.Mapper).Find(types.String(key)); found {
+		return v
+	}
+	return types.String("")
+}
+
+// Compile parses and type-checks an expression, which must evaluate to a bool. The
+// patterns of matches() calls written as string literals are compiled here too, so
+// they are checked once and not compiled again on every evaluation.
+func Compile(source string) (*Program, error) {
+	env, err := conditionEnv()
+	if err != nil {
+		return nil, err
+	}
+	checked, issues := env.Compile(source)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, issues.Err())
+	}
+	if t := checked.OutputType(); !t.IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("invalid expression %q: returns %s, want bool", source, t)
+	}
+	program, err := env.Program(checked, cel.OptimizeRegex(interpreter.MatchesRegexOptimization))
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	return &Program{source: source, program: program}, nil
+}
+
+// String returns the source of the expression
+func (p *Program) String() string {
+	return p.source
+}
+
+// EvalBool evaluates the expression against the request variables
+func (p *Program) EvalBool(vars Vars) (bool, error) {
+	out, _, err := p.program.Eval(map[string]interface{}(vars))
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q returned %s, want bool", p.source, out.Type())
+	}
+	return b, nil
+}
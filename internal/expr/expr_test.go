@@ -0,0 +1,134 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func testVars() Vars {
+	return Vars{
+		"method":  "POST",
+		"path":    "/orders",
+		"headers": map[string]string{"x-retry": "1"},
+		"query":   map[string]string{"page": "12", "q": "admin"},
+		"body":    `{"amount": 150}`,
+		"json": map[string]interface{}{
+			"amount": 150.0,
+			"items":  []interface{}{"a", "b"},
+			"customer": map[string]interface{}{
+				"tier": "gold",
+			},
+		},
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`method == "POST"`, true},
+		{`method != "POST"`, false},
+		{`json.amount > 100`, true},
+		{`json.amount == 150`, true},
+		{`json.amount <= 100`, false},
+		{`json.customer.tier == "gold"`, true},
+		{`json.items[1] == "b"`, true},
+		{`json["amount"] == 150.0`, true},
+		{`has(json.customer)`, true},
+		{`has(json.missing)`, false},
+		{`"a" in json.items`, true},
+		{`"amount" in json`, true},
+		{`method in ["GET", "HEAD"]`, false},
+		{`size(json.items) == 2`, true},
+		{`json.items.size() == 2`, true},
+		{`path.startsWith("/ord") && path.endsWith("ers") && path.contains("rde")`, true},
+		{`path.matches("^/orders$")`, true},
+		{`method.lowerAscii() == "post"`, true},
+		{`int(query.page) + 1 == 13`, true},
+		{`7 / 2 == 3`, true},
+		{`headers["x-retry"] == "1"`, true},
+		{`header('X-Retry') != '' && json.amount > 100`, true},
+		{`header('X-Other') != '' || false`, false},
+		{`int(query('page')) > 10 || query('q').startsWith('admin')`, true},
+		{`query('missing') == ""`, true},
+		{`false && json.missing == 1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			p, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile(%q) unexpected error: %v", tt.source, err)
+			}
+			got, err := p.EvalBool(testVars())
+			if err != nil {
+				t.Fatalf("EvalBool(%q) unexpected error: %v", tt.source, err)
+			}
+			if got != tt.want {
+				t.Fatalf("EvalBool(%q) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalBool_Errors(t *testing.T) {
+	tests := []struct {
+		source  string
+		wantErr string
+	}{
+		{`json.missing == 1`, "no such key"},
+		{`json.items[5] == "c"`, "index out of bounds"},
+		{`int(query.q) > 1`, "type conversion error"},
+		{`json.amount`, "want bool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			p, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile(%q) unexpected error: %v", tt.source, err)
+			}
+			_, err = p.EvalBool(testVars())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("EvalBool(%q) error = %v, want containing %q", tt.source, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		source  string
+		wantErr string
+	}{
+		{``, "invalid expression"},
+		{`1 +`, "invalid expression"},
+		{`method ==== "GET"`, "invalid expression"},
+		{`undefined == 1`, "undeclared reference"},
+		{`nosuch(1)`, "undeclared reference"},
+		{`query.page > 1`, "no matching overload"},
+		{`method`, "want bool"},
+		{`path.matches("[unclosed")`, "missing closing ]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if _, err := Compile(tt.source); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Compile(%q) error = %v, want containing %q", tt.source, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalBool_NullJSON(t *testing.T) {
+	p, err := Compile(`json == null || json.amount > 100`)
+	if err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	vars := testVars()
+	vars["json"] = nil
+	if ok, err := p.EvalBool(vars); err != nil || !ok {
+		t.Fatalf("expected a body that isn't JSON to read as null, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"http-mock-server/internal/config"
+)
+
+// accessList is an AccessList with its CIDRs parsed
+type accessList struct {
+	prefixes []netip.Prefix
+	apiKeys  map[string]struct{}
+}
+
+func newAccessList(l config.AccessList) accessList {
+	al := accessList{apiKeys: make(map[string]struct{}, len(l.APIKeys))}
+	for _, cidr := range l.CIDRs {
+		prefix, err := config.ParsePrefix(cidr)
+		if err != nil {
+			log.Printf("Ignoring access control entry: %v", err)
+			continue
+		}
+		al.prefixes = append(al.prefixes, prefix)
+	}
+	for _, key := range l.APIKeys {
+		al.apiKeys[key.Value()] = struct{}{}
+	}
+	return al
+}
+
+func (al accessList) empty() bool {
+	return len(al.prefixes) == 0 && len(al.apiKeys) == 0
+}
+
+func (al accessList) matches(addr netip.Addr, apiKey string) bool {
+	if addr.IsValid() {
+		for _, prefix := range al.prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+	}
+	if apiKey != "" {
+		if _, ok := al.apiKeys[apiKey]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessControlMiddleware rejects requests with 403 Forbidden unless the client is
+// permitted by ac. Deny entries take precedence; when allow entries exist, the client
+// must match one of them by source address or API key. A nil ac permits everyone.
+func AccessControlMiddleware(ac *config.AccessControl, next http.Handler) http.Handler {
+	if ac == nil {
+		return next
+	}
+	allow := newAccessList(ac.Allow)
+	deny := newAccessList(ac.Deny)
+	header := ac.APIKeyHeader
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			addr := clientAddr(r)
+			apiKey := r.Header.Get(header)
+
+			if deny.matches(addr, apiKey) || (!allow.empty() && !allow.matches(addr, apiKey)) {
+				log.Printf("Access denied for %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// clientAddr returns the IP address of the connection's remote end
+func clientAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func performAccessRequest(h http.Handler, remoteAddr, apiKey string) int {
+	req := httptest.NewRequest(http.MethodGet, "/anything", nil)
+	req.RemoteAddr = remoteAddr
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestAccessControl_NilAllowsAll(t *testing.T) {
+	h := AccessControlMiddleware(nil, okHandler())
+	if code := performAccessRequest(h, "203.0.113.7:1234", ""); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestAccessControl_AllowList(t *testing.T) {
+	ac := &config.AccessControl{
+		APIKeyHeader: "X-API-Key",
+		Allow: config.AccessList{
+			CIDRs:   []string{"10.0.0.0/8", "192.168.1.5"},
+			APIKeys: []config.Secret{config.NewSecret("secret-key")},
+		},
+	}
+	h := AccessControlMiddleware(ac, okHandler())
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		apiKey     string
+		want       int
+	}{
+		{"address in range", "10.1.2.3:5000", "", http.StatusOK},
+		{"single address", "192.168.1.5:5000", "", http.StatusOK},
+		{"neighbouring address", "192.168.1.6:5000", "", http.StatusForbidden},
+		{"outside range", "203.0.113.7:5000", "", http.StatusForbidden},
+		{"outside range with key", "203.0.113.7:5000", "secret-key", http.StatusOK},
+		{"outside range with wrong key", "203.0.113.7:5000", "guess", http.StatusForbidden},
+		{"ipv4-mapped ipv6", "[::ffff:10.0.0.1]:5000", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := performAccessRequest(h, tt.remoteAddr, tt.apiKey); code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestAccessControl_DenyTakesPrecedence(t *testing.T) {
+	ac := &config.AccessControl{
+		APIKeyHeader: "X-API-Key",
+		Allow:        config.AccessList{CIDRs: []string{"10.0.0.0/8"}},
+		Deny:         config.AccessList{CIDRs: []string{"10.0.0.13"}, APIKeys: []config.Secret{config.NewSecret("revoked")}},
+	}
+	h := AccessControlMiddleware(ac, okHandler())
+
+	if code := performAccessRequest(h, "10.0.0.13:5000", ""); code != http.StatusForbidden {
+		t.Fatalf("expected denied address to get %d, got %d", http.StatusForbidden, code)
+	}
+	if code := performAccessRequest(h, "10.0.0.14:5000", "revoked"); code != http.StatusForbidden {
+		t.Fatalf("expected denied key to get %d, got %d", http.StatusForbidden, code)
+	}
+	if code := performAccessRequest(h, "10.0.0.14:5000", ""); code != http.StatusOK {
+		t.Fatalf("expected allowed address to get %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestAccessControl_DenyOnly(t *testing.T) {
+	ac := &config.AccessControl{
+		APIKeyHeader: "X-API-Key",
+		Deny:         config.AccessList{CIDRs: []string{"2001:db8::/32"}},
+	}
+	h := AccessControlMiddleware(ac, okHandler())
+
+	if code := performAccessRequest(h, "[2001:db8::1]:5000", ""); code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, code)
+	}
+	if code := performAccessRequest(h, "203.0.113.7:5000", ""); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"http-mock-server/internal/config"
+)
+
+// redacted replaces values removed from journal exports
+const redacted = "[REDACTED]"
+
+// pathIDPattern matches path segments that look like identifiers
+var pathIDPattern = regexp.MustCompile(`^(?:[0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// anonymizer applies the journal export settings to entries
+type anonymizer struct {
+	cfg     config.JournalExport
+	headers []string
+	key     []byte
+}
+
+// ExportJournal returns the recorded requests anonymized by server.journal.export:
+// sensitive headers, query params, and body fields are redacted or hashed, client
+// addresses are always hashed, and bodies that are neither JSON nor form data are omitted
+func (h *MockHandler) ExportJournal() []JournalEntry {
+	var cfg config.JournalExport
+	if j := h.current().config.Server.Journal; j != nil {
+		cfg = j.Export
+	}
+	a := &anonymizer{
+		cfg:     cfg,
+		headers: append(append([]string(nil), config.DefaultRedactedHeaders...), cfg.RedactHeaders...),
+		key:     h.journal.salt,
+	}
+	if salt := cfg.Salt.Value(); salt != "" {
+		a.key = []byte(salt)
+	}
+	entries := h.journal.snapshot()
+	for i := range entries {
+		a.apply(&entries[i])
+	}
+	return entries
+}
+
+func (a *anonymizer) apply(e *JournalEntry) {
+	if a.cfg.Bucket > 0 {
+		e.Time = e.Time.UTC().Truncate(a.cfg.Bucket)
+	}
+	if e.Client != "" {
+		e.Client = a.hash(e.Client)
+	}
+	if a.cfg.HashPathIDs {
+		segments := strings.Split(e.Path, "/")
+		for i, s := range segments {
+			if pathIDPattern.MatchString(s) {
+				segments[i] = a.hash(s)
+			}
+		}
+		e.Path = strings.Join(segments, "/")
+	}
+
+	header := http.Header(e.Headers).Clone()
+	for _, name := range a.headers {
+		if values := header.Values(name); len(values) > 0 {
+			header[http.CanonicalHeaderKey(name)] = []string{redacted}
+		}
+	}
+	e.Headers = header
+
+	if e.Query != nil {
+		q := cloneValues(e.Query)
+		a.fields(q, a.cfg.RedactQueryParams, a.cfg.HashFields)
+		e.Query = q
+	}
+	e.Body = a.body(e.Body, header.Get("Content-Type"))
+}
+
+// body anonymizes JSON and form bodies and omits any other kind
+func (a *anonymizer) body(body, contentType string) string {
+	if body == "" {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(body); err == nil {
+			a.fields(form, a.cfg.RedactFields, a.cfg.HashFields)
+			return form.Encode()
+		}
+	}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(a.json(v)); err == nil {
+			return strings.TrimSuffix(buf.String(), "\n")
+		}
+	}
+	return fmt.Sprintf("(omitted, %d bytes that are not JSON or form data)", len(body))
+}
+
+// json redacts and hashes the named fields of a decoded JSON value at any depth
+func (a *anonymizer) json(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch {
+			case containsFold(a.cfg.RedactFields, key):
+				v[key] = redacted
+			case containsFold(a.cfg.HashFields, key):
+				if s, ok := value.(string); ok {
+					v[key] = a.hash(s)
+				} else {
+					raw, _ := json.Marshal(value)
+					v[key] = a.hash(string(raw))
+				}
+			default:
+				v[key] = a.json(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = a.json(v[i])
+		}
+	}
+	return v
+}
+
+// fields redacts and hashes query params or form fields in place
+func (a *anonymizer) fields(values url.Values, redact, hash []string) {
+	for key, vs := range values {
+		switch {
+		case containsFold(redact, key):
+			values[key] = []string{redacted}
+		case containsFold(hash, key):
+			hashed := make([]string, len(vs))
+			for i, s := range vs {
+				hashed[i] = a.hash(s)
+			}
+			values[key] = hashed
+		}
+	}
+}
+
+// hash returns a keyed hash of the value, stable within one export key
+func (a *anonymizer) hash(value string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func cloneValues(v url.Values) url.Values {
+	c := make(url.Values, len(v))
+	for key, vs := range v {
+		c[key] = append([]string(nil), vs...)
+	}
+	return c
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
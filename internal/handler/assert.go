@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"http-mock-server/internal/config"
+)
+
+// denyResponse answers with 500 and the violations when a response fails assertions
+// with onFailure deny. Violations are always logged. It reports whether a response
+// was written in place of the rule's.
+func denyResponse(w http.ResponseWriter, rs *ruleSet, rule *config.RequestRule, status int, header http.Header, body []byte) bool {
+	a := rule.Assert
+	if a == nil {
+		return false
+	}
+	violations := a.Check(status, header, body)
+	if len(violations) == 0 {
+		return false
+	}
+	log.Printf("Response assertion failed for %s: %s", rs.ruleKey(rule), strings.Join(violations, "; "))
+	if a.OnFailure != config.AssertDeny {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      "mock response failed its assertions",
+		"rule":       rs.ruleKey(rule),
+		"violations": violations,
+	})
+	if err != nil {
+		log.Printf("Error writing assertion failure response: %v", err)
+	}
+	return true
+}
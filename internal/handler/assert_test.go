@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_ResponseAssertions(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "user.json")
+	schema := `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`
+	if err := os.WriteFile(schemaPath, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+requests:
+  - name: checked
+    path: /users/{id}
+    response:
+      status-code: 200
+      template: true
+      headers:
+        Content-Type: application/json
+      body: '{"id": {{ .Path.id }}}'
+    assert:
+      status: [200]
+      headers: [content-type]
+      bodySchema: %[1]s
+      onFailure: deny
+  - name: logged
+    path: /logged
+    response:
+      status-code: 201
+      body: 'not json'
+    assert:
+      status: [200]
+      bodySchema: %[1]s
+`, schemaPath)), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/users/7", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != `{"id": 7}` {
+		t.Fatalf("expected the valid response, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	// The template renders a string id, which the schema rejects
+	rr = performRequest(h, http.MethodGet, "/users/abc", nil, nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+	var denied struct {
+		Rule       string   `json:"rule"`
+		Violations []string `json:"violations"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &denied); err != nil {
+		t.Fatalf("invalid denial body %q: %v", rr.Body.String(), err)
+	}
+	if denied.Rule != "checked" || len(denied.Violations) != 1 || !strings.Contains(denied.Violations[0], "invalid JSON") {
+		t.Fatalf("unexpected denial %+v", denied)
+	}
+	if rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected JSON denial, got %q", rr.Header().Get("Content-Type"))
+	}
+
+	// Violations of log-only assertions are reported but the response is sent
+	rr = performRequest(h, http.MethodGet, "/logged", nil, nil)
+	if rr.Code != http.StatusCreated || rr.Body.String() != "not json" {
+		t.Fatalf("expected the rule's response, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestResponseAssertions_Check(t *testing.T) {
+	a := &config.ResponseAssertions{Status: []int{200, 204}, Headers: []string{"etag", "Cache-Control"}}
+	header := http.Header{}
+	header.Set("ETag", `"v1"`)
+
+	got := a.Check(500, header, nil)
+	want := []string{"status 500 is not one of [200 204]", "header Cache-Control is missing"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	header.Set("Cache-Control", "no-store")
+	if got := a.Check(204, header, nil); len(got) != 0 {
+		t.Fatalf("expected no violations, got %q", got)
+	}
+}
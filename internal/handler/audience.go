@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"http-mock-server/internal/config"
+)
+
+// requestAudience returns the audience a request selected with the audience header,
+// or else with the port it arrived on, falling back to the configured default
+func requestAudience(r *http.Request, a *config.Audiences) string {
+	header := config.DefaultAudienceHeader
+	if a != nil {
+		header = a.Header
+	}
+	if v := strings.TrimSpace(r.Header.Get(header)); v != "" {
+		return v
+	}
+	if a == nil {
+		return ""
+	}
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok {
+		if name, ok := a.PortAudience(uint(addr.Port)); ok {
+			return name
+		}
+	}
+	return a.Default
+}
+
+// matchesAudience reports whether a rule serves the audience. Rules without
+// audiences serve every request, including those that select no audience.
+func matchesAudience(audiences []string, audience string) bool {
+	if len(audiences) == 0 {
+		return true
+	}
+	for _, a := range audiences {
+		if strings.EqualFold(a, audience) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_Audiences(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  port: 8080
+  audiences:
+    header: X-Client
+    default: web
+    ports:
+      mobile: 8081
+requests:
+  - path: /profile
+    audiences: [mobile]
+    response:
+      body: '{"name":"Ada"}'
+  - path: /profile
+    audiences: [web, partner]
+    response:
+      template: true
+      body: '{"name":"Ada","email":"ada@example.com","audience":"{{ .Audience }}"}'
+  - path: /error
+    audiences: [mobile]
+    response:
+      status-code: 400
+      body: '{"code":"BAD_INPUT"}'
+  - path: /error
+    response:
+      status-code: 400
+      body: '{"errors":[{"detail":"Bad input"}]}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	request := func(path string, headers map[string]string, port int) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if port != 0 {
+			addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+			req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		port    int
+		want    string
+	}{
+		{"default audience", "/profile", nil, 8080, `{"name":"Ada","email":"ada@example.com","audience":"web"}`},
+		{"port selects audience", "/profile", nil, 8081, `{"name":"Ada"}`},
+		{"header selects audience", "/profile", map[string]string{"X-Client": "Mobile"}, 0, `{"name":"Ada"}`},
+		{"header overrides port", "/profile", map[string]string{"X-Client": "partner"}, 8081, `{"name":"Ada","email":"ada@example.com","audience":"partner"}`},
+		{"unknown audience", "/profile", map[string]string{"X-Client": "tv"}, 0, "404 page not found\n"},
+		{"audience-specific error format", "/error", nil, 8081, `{"code":"BAD_INPUT"}`},
+		{"shared rule for other audiences", "/error", nil, 8080, `{"errors":[{"detail":"Bad input"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := request(tt.path, tt.headers, tt.port); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestAudience_WithoutConfig(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := requestAudience(req, nil); got != "" {
+		t.Fatalf("expected no audience, got %q", got)
+	}
+	req.Header.Set(config.DefaultAudienceHeader, "web")
+	if got := requestAudience(req, nil); got != "web" {
+		t.Fatalf("expected audience from the default header, got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_BodylessStatuses(t *testing.T) {
+	rules := `
+requests:
+  - path: /deleted
+    method: DELETE
+    response:
+      status-code: 204
+      body: {deleted: true}
+  - path: /cached
+    response:
+      status-code: 304
+      headers:
+        etag: '"v1"'
+      body: stale
+  - path: /events
+    response:
+      status-code: 204
+      sse:
+        events:
+          - data: hi
+`
+	cfg, err := config.Parse([]byte(rules), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodDelete, "/deleted"},
+		{http.MethodGet, "/cached"},
+		{http.MethodGet, "/events"},
+	} {
+		rr := performRequest(h, tt.method, tt.path, nil, nil)
+		if rr.Body.Len() != 0 {
+			t.Fatalf("%s: expected no body, got %q", tt.path, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Length"); got != "" {
+			t.Fatalf("%s: expected no Content-Length, got %q", tt.path, got)
+		}
+	}
+	if got := performRequest(h, http.MethodGet, "/cached", nil, nil).Header().Get("ETag"); got != `"v1"` {
+		t.Fatalf("expected the 304 to keep its headers, got ETag %q", got)
+	}
+}
+
+func TestMockHandler_HeadMatchesGetRules(t *testing.T) {
+	rules := `
+requests:
+  - path: /users
+    response:
+      headers:
+        x-total: "2"
+      body: [{id: 1}, {id: 2}]
+  - path: /probe
+    response:
+      body: from GET
+  - path: /probe
+    method: HEAD
+    response:
+      status-code: 204
+  - path: /events
+    response:
+      sse:
+        repeat: -1
+        events:
+          - data: tick
+            delay: 10
+`
+	cfg, err := config.Parse([]byte(rules), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	get := performRequest(h, http.MethodGet, "/users", nil, nil)
+	head := performRequest(h, http.MethodHead, "/users", nil, nil)
+	if head.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", head.Code)
+	}
+	if head.Body.Len() != 0 {
+		t.Fatalf("expected no body, got %q", head.Body.String())
+	}
+	if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+		t.Fatalf("expected Content-Length %q, got %q", want, got)
+	}
+	if got := head.Header().Get("X-Total"); got != "2" {
+		t.Fatalf("expected the GET rule's headers, got X-Total %q", got)
+	}
+
+	if rr := performRequest(h, http.MethodHead, "/probe", nil, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected the HEAD rule to take precedence, got %d", rr.Code)
+	}
+
+	// An endless event stream still answers HEAD right away
+	rr := performRequest(h, http.MethodHead, "/events", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Fatalf("expected 200 without a body, got %d %q", rr.Code, rr.Body.String())
+	}
+}
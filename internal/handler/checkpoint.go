@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkpointStore holds named copies of the mock's state, so tests can return to a
+// known baseline. Checkpoints survive configuration reloads.
+type checkpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]*checkpoint
+}
+
+// checkpoint is a copy of the scenario states, resource collections, uploaded
+// datasets, call counts, and seq counters at one moment
+type checkpoint struct {
+	createdAt time.Time
+	scenarios map[string]string
+	resources map[string]*collection
+	datasets  map[string]uploadedDataset
+	calls     map[string]int64 // Keyed by rule key
+	sequences map[string]int64
+}
+
+// CheckpointInfo describes a saved checkpoint
+type CheckpointInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Scenarios int       `json:"scenarios"` // Scenarios moved away from their initial state
+	Resources int       `json:"resources"` // Resource collections in use
+	Datasets  int       `json:"datasets"`  // Uploaded datasets
+}
+
+func newCheckpointStore() *checkpointStore {
+	return &checkpointStore{checkpoints: make(map[string]*checkpoint)}
+}
+
+func (c *checkpoint) info(name string) CheckpointInfo {
+	return CheckpointInfo{
+		Name:      name,
+		CreatedAt: c.createdAt,
+		Scenarios: len(c.scenarios),
+		Resources: len(c.resources),
+		Datasets:  len(c.datasets),
+	}
+}
+
+// SaveCheckpoint copies the current state under the name, replacing any checkpoint of
+// that name, and reports whether one was replaced
+func (h *MockHandler) SaveCheckpoint(name string) (CheckpointInfo, bool, error) {
+	rs := h.current()
+	cp := &checkpoint{createdAt: h.now(), calls: make(map[string]int64, len(rs.calls)), sequences: h.sequences.Values()}
+
+	h.scenarios.mu.Lock()
+	cp.scenarios = make(map[string]string, len(h.scenarios.states))
+	for scenario, state := range h.scenarios.states {
+		cp.scenarios[scenario] = state
+	}
+	h.scenarios.mu.Unlock()
+
+	h.resources.mu.Lock()
+	cp.resources = make(map[string]*collection, len(h.resources.collections))
+	for path, c := range h.resources.collections {
+		copied, err := c.clone()
+		if err != nil {
+			h.resources.mu.Unlock()
+			return CheckpointInfo{}, false, fmt.Errorf("resource %s: %w", path, err)
+		}
+		cp.resources[path] = copied
+	}
+	h.resources.mu.Unlock()
+
+	// Uploads are replaced, never changed in place, so sharing them is safe
+	h.datasets.mu.RLock()
+	cp.datasets = make(map[string]uploadedDataset, len(h.datasets.uploads))
+	for dataset, u := range h.datasets.uploads {
+		cp.datasets[dataset] = u
+	}
+	h.datasets.mu.RUnlock()
+
+	for i := range rs.config.Requests {
+		if n := rs.calls[&rs.config.Requests[i]].Load(); n > 0 {
+			cp.calls[rs.ruleKeys[i]] = n
+		}
+	}
+
+	h.checkpoints.mu.Lock()
+	defer h.checkpoints.mu.Unlock()
+	_, replaced := h.checkpoints.checkpoints[name]
+	h.checkpoints.checkpoints[name] = cp
+	return cp.info(name), replaced, nil
+}
+
+// RestoreCheckpoint returns the state to the named checkpoint, reporting whether it
+// exists. Call counts are restored for rules that still have the same key.
+func (h *MockHandler) RestoreCheckpoint(name string) (bool, error) {
+	h.checkpoints.mu.Lock()
+	cp, ok := h.checkpoints.checkpoints[name]
+	h.checkpoints.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	// Copy again so the checkpoint can be restored any number of times
+	resources := make(map[string]*collection, len(cp.resources))
+	for path, c := range cp.resources {
+		copied, err := c.clone()
+		if err != nil {
+			return true, fmt.Errorf("resource %s: %w", path, err)
+		}
+		resources[path] = copied
+	}
+	h.resources.mu.Lock()
+	h.resources.collections = resources
+	h.resources.mu.Unlock()
+
+	h.scenarios.Replace(cp.scenarios)
+	h.sequences.Set(cp.sequences)
+
+	h.datasets.mu.Lock()
+	h.datasets.uploads = make(map[string]uploadedDataset, len(cp.datasets))
+	for dataset, u := range cp.datasets {
+		h.datasets.uploads[dataset] = u
+	}
+	h.datasets.mu.Unlock()
+
+	rs := h.current()
+	for i := range rs.config.Requests {
+		rs.calls[&rs.config.Requests[i]].Store(cp.calls[rs.ruleKeys[i]])
+	}
+	return true, nil
+}
+
+// DeleteCheckpoint removes the named checkpoint, reporting whether it existed
+func (h *MockHandler) DeleteCheckpoint(name string) bool {
+	h.checkpoints.mu.Lock()
+	defer h.checkpoints.mu.Unlock()
+	_, ok := h.checkpoints.checkpoints[name]
+	delete(h.checkpoints.checkpoints, name)
+	return ok
+}
+
+// CheckpointInfo lists the saved checkpoints, sorted by name
+func (h *MockHandler) CheckpointInfo() []CheckpointInfo {
+	h.checkpoints.mu.Lock()
+	defer h.checkpoints.mu.Unlock()
+	infos := make([]CheckpointInfo, 0, len(h.checkpoints.checkpoints))
+	for name, cp := range h.checkpoints.checkpoints {
+		infos = append(infos, cp.info(name))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// clone returns a deep copy of the collection
+func (c *collection) clone() (*collection, error) {
+	raw, err := json.Marshal(c.items)
+	if err != nil {
+		return nil, err
+	}
+	copied := &collection{nextID: c.nextID}
+	if err := json.Unmarshal(raw, &copied.items); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestCheckpoint_SaveAndRestore(t *testing.T) {
+	h := newTestHandler(t, resourceConfig(t))
+	h.Scenarios().SetState("checkout", "paid")
+	h.Datasets().Set("extra", []interface{}{"x"}, []byte(`["x"]`), h.now())
+	if rr := performRequest(h, http.MethodPost, "/users", nil, []byte(`{"name": "cy"}`)); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+
+	info, replaced, err := h.SaveCheckpoint("baseline")
+	if err != nil || replaced || info.Scenarios != 1 || info.Resources != 1 || info.Datasets != 1 {
+		t.Fatalf("unexpected checkpoint %+v %v %v", info, replaced, err)
+	}
+
+	// Move on from the baseline, twice, restoring in between
+	for i := 0; i < 2; i++ {
+		h.Scenarios().SetState("checkout", "refunded")
+		h.Scenarios().SetState("login", "locked")
+		h.Datasets().Delete("extra")
+		performRequest(h, http.MethodDelete, "/users/1", nil, nil)
+		performRequest(h, http.MethodPost, "/users", nil, []byte(`{"name": "dee"}`))
+
+		if ok, err := h.RestoreCheckpoint("baseline"); !ok || err != nil {
+			t.Fatalf("expected the checkpoint to be restored, got %v %v", ok, err)
+		}
+		if got := h.Scenarios().State("checkout"); got != "paid" {
+			t.Fatalf("expected checkout paid, got %s", got)
+		}
+		if got := h.Scenarios().State("login"); got != ScenarioStarted {
+			t.Fatalf("expected login back at its initial state, got %s", got)
+		}
+		if _, err := h.Dataset("extra"); err != nil {
+			t.Fatalf("expected the uploaded dataset back, got %v", err)
+		}
+		rr := performRequest(h, http.MethodGet, "/users", nil, nil)
+		if want := `[{"id":1,"name":"ada","role":"admin"},{"id":2,"name":"bob","role":"user"},{"id":3,"name":"cy"}]`; rr.Body.String() != want {
+			t.Fatalf("expected %s, got %s", want, rr.Body.String())
+		}
+		// Ids continue from the checkpoint
+		if rr := performRequest(h, http.MethodPost, "/users", nil, []byte(`{"name": "eve"}`)); rr.Body.String() != `{"id":4,"name":"eve"}` {
+			t.Fatalf("unexpected created item %s", rr.Body.String())
+		}
+		h.RestoreCheckpoint("baseline")
+	}
+
+	if _, replaced, _ := h.SaveCheckpoint("baseline"); !replaced {
+		t.Fatal("expected the checkpoint to be replaced")
+	}
+	if ok, _ := h.RestoreCheckpoint("missing"); ok {
+		t.Fatal("expected no checkpoint named missing")
+	}
+	if !h.DeleteCheckpoint("baseline") || h.DeleteCheckpoint("baseline") || len(h.CheckpointInfo()) != 0 {
+		t.Fatal("expected the checkpoint to be deleted once")
+	}
+}
+
+func TestCheckpoint_RestoresCallCounts(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /flaky
+    onCall: 3
+    response:
+      status-code: 503
+  - path: /flaky
+    response:
+      status-code: 200
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	performRequest(h, http.MethodGet, "/flaky", nil, nil)
+	performRequest(h, http.MethodGet, "/flaky", nil, nil)
+	if _, _, err := h.SaveCheckpoint("two-calls"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if rr := performRequest(h, http.MethodGet, "/flaky", nil, nil); rr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected the third call to fail, got %d", rr.Code)
+		}
+		h.RestoreCheckpoint("two-calls")
+	}
+}
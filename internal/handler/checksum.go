@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"net/http"
+
+	"http-mock-server/internal/config"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// setChecksumHeaders adds the requested integrity headers computed over body
+func setChecksumHeaders(header http.Header, checksums []string, body []byte) {
+	for _, checksum := range checksums {
+		switch checksum {
+		case config.ChecksumContentMD5:
+			sum := md5.Sum(body)
+			header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		case config.ChecksumETag:
+			// Like S3, the ETag of a single-part object is its quoted hex MD5
+			sum := md5.Sum(body)
+			header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		case config.ChecksumDigest:
+			sum := sha256.Sum256(body)
+			header.Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		case config.ChecksumSHA1:
+			sum := sha1.Sum(body)
+			header.Set("X-Amz-Checksum-Sha1", base64.StdEncoding.EncodeToString(sum[:]))
+		case config.ChecksumSHA256:
+			sum := sha256.Sum256(body)
+			header.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+		case config.ChecksumCRC32:
+			header.Set("X-Amz-Checksum-Crc32", crcHeader(crc32.ChecksumIEEE(body)))
+		case config.ChecksumCRC32C:
+			header.Set("X-Amz-Checksum-Crc32c", crcHeader(crc32.Checksum(body, crc32cTable)))
+		}
+	}
+}
+
+// crcHeader encodes a CRC as base64 of its big-endian bytes, as S3 does
+func crcHeader(sum uint32) string {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], sum)
+	return base64.StdEncoding.EncodeToString(b[:])
+}
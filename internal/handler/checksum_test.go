@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_ChecksumHeaders(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:   "/file",
+				Method: "GET",
+				Response: config.ResponseSpec{
+					StatusCode: 200,
+					Body:       "hello world",
+					Checksums: []string{
+						config.ChecksumContentMD5, config.ChecksumETag, config.ChecksumDigest,
+						config.ChecksumSHA1, config.ChecksumSHA256, config.ChecksumCRC32, config.ChecksumCRC32C,
+					},
+				},
+			},
+			{
+				Path:     "/plain",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 200, Body: "hello world"},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/file", nil, nil)
+	want := map[string]string{
+		"Content-MD5":           "XrY7u+Ae7tCTyyK7j1rNww==",
+		"ETag":                  `"5eb63bbbe01eeed093cb22bb8f5acdc3"`,
+		"Digest":                "sha-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=",
+		"X-Amz-Checksum-Sha1":   "Kq5sNclPz7QV2+lfQIuc6R7oRu0=",
+		"X-Amz-Checksum-Sha256": "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=",
+		"X-Amz-Checksum-Crc32":  "DUoRhQ==",
+		"X-Amz-Checksum-Crc32c": "yZRlqg==",
+	}
+	for name, value := range want {
+		if got := rr.Header().Get(name); got != value {
+			t.Errorf("expected %s %q, got %q", name, value, got)
+		}
+	}
+
+	rr = performRequest(h, http.MethodGet, "/plain", nil, nil)
+	if rr.Header().Get("ETag") != "" {
+		t.Fatalf("expected no checksum headers without checksums, got ETag %q", rr.Header().Get("ETag"))
+	}
+}
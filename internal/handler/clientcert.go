@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"http-mock-server/internal/config"
+)
+
+// matchesClientCert checks the leaf certificate the client presented over TLS
+func (rs *ruleSet) matchesClientCert(m *config.ClientCertMatcher, r *http.Request, full bool) bool {
+	if m == nil {
+		return true
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	cert := r.TLS.PeerCertificates[0]
+
+	if m.Subject != "" && !rs.matchPattern(m.Subject, cert.Subject.CommonName, full) {
+		return false
+	}
+	if m.Issuer != "" && !rs.matchPattern(m.Issuer, cert.Issuer.CommonName, full) {
+		return false
+	}
+	if m.SAN != "" {
+		var sans []string
+		sans = append(sans, cert.DNSNames...)
+		sans = append(sans, cert.EmailAddresses...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		for _, uri := range cert.URIs {
+			sans = append(sans, uri.String())
+		}
+		for _, san := range sans {
+			if rs.matchPattern(m.SAN, san, full) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
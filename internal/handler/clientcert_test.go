@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_ClientCert(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /partners
+    clientCert:
+      subject: partner-.+
+      issuer: Partner CA
+      san: .+\.partner\.example
+    response:
+      status-code: 200
+  - path: /spiffe
+    clientCert:
+      san: spiffe://example/.+
+    response:
+      status-code: 200
+  - path: /partners
+    response:
+      status-code: 403
+  - path: /spiffe
+    response:
+      status-code: 403
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	partner := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "partner-acme"},
+		Issuer:   pkix.Name{CommonName: "Partner CA"},
+		DNSNames: []string{"localhost", "api.partner.example"},
+	}
+	workload := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "workload"},
+		URIs:    []*url.URL{{Scheme: "spiffe", Host: "example", Path: "/billing"}},
+	}
+	otherIssuer := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "partner-acme"},
+		Issuer:   pkix.Name{CommonName: "Other CA"},
+		DNSNames: []string{"api.partner.example"},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		cert *x509.Certificate
+		want int
+	}{
+		{name: "all attributes", path: "/partners", cert: partner, want: http.StatusOK},
+		{name: "wrong issuer", path: "/partners", cert: otherIssuer, want: http.StatusForbidden},
+		{name: "no certificate", path: "/partners", want: http.StatusForbidden},
+		{name: "uri san", path: "/spiffe", cert: workload, want: http.StatusOK},
+		{name: "no matching san", path: "/spiffe", cert: partner, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.cert != nil {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}}
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
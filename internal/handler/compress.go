@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"http-mock-server/internal/config"
+)
+
+// codingPreference orders the supported codings for clients that accept several equally
+var codingPreference = []string{config.CompressBrotli, config.CompressGzip, config.CompressDeflate}
+
+// compressResponse encodes the body with the coding the response forces, or with the
+// best one the request accepts in auto mode, and sets Content-Encoding to match.
+// Empty bodies are sent as is.
+func compressResponse(r *http.Request, mode string, header http.Header, body []byte) ([]byte, error) {
+	coding := mode
+	if mode == config.CompressAuto {
+		header.Add("Vary", "Accept-Encoding")
+		coding = acceptedCoding(r.Header.Values("Accept-Encoding"))
+	}
+	if coding == "" || len(body) == 0 {
+		return body, nil
+	}
+	encoded, err := encodeCoding(coding, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s compression failed: %w", coding, err)
+	}
+	header.Set("Content-Encoding", coding)
+	header.Del("Content-Length")
+	return encoded, nil
+}
+
+// acceptedCoding picks the supported coding with the highest quality in the
+// Accept-Encoding values, or "" when the client accepts none of them
+func acceptedCoding(values []string) string {
+	quality := make(map[string]float64)
+	wildcard := -1.0
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			q := 1.0
+			if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+			if name == "*" {
+				wildcard = q
+			} else if name != "" {
+				quality[name] = q
+			}
+		}
+	}
+	best, bestQ := "", 0.0
+	for _, coding := range codingPreference {
+		q, ok := quality[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+func encodeCoding(coding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case config.CompressBrotli:
+		w = brotli.NewWriter(&buf)
+	case config.CompressGzip:
+		w = gzip.NewWriter(&buf)
+	case config.CompressDeflate:
+		// HTTP's deflate coding is the zlib format
+		w = zlib.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported coding")
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
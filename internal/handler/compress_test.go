@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"http-mock-server/internal/config"
+)
+
+func TestAcceptedCoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"deflate, gzip;q=0", "deflate"},
+		{"*", "br"},
+		{"*, br;q=0", "gzip"},
+		{"identity", ""},
+		{"zstd", ""},
+	}
+	for _, tt := range tests {
+		var values []string
+		if tt.accept != "" {
+			values = []string{tt.accept}
+		}
+		if got := acceptedCoding(values); got != tt.want {
+			t.Fatalf("%q: expected %q, got %q", tt.accept, tt.want, got)
+		}
+	}
+}
+
+func TestEncodeCoding_Brotli(t *testing.T) {
+	body := strings.Repeat(`{"id": 1, "name": "widget"}`, 200)
+	encoded, err := encodeCoding(config.CompressBrotli, []byte(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(encoded) >= len(body)/10 {
+		t.Fatalf("expected a repetitive body to compress well, got %d bytes from %d", len(encoded), len(body))
+	}
+	decoded, err := io.ReadAll(brotli.NewReader(bytes.NewReader(encoded)))
+	if err != nil || string(decoded) != body {
+		t.Fatalf("round trip failed: %v", err)
+	}
+}
+
+func TestMockHandler_Compress(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /auto
+    response:
+      compress: auto
+      body: hello hello hello
+  - path: /forced
+    response:
+      compress: deflate
+      body: hello hello hello
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/auto", map[string]string{"Accept-Encoding": "gzip"}, nil)
+	if rr.Header().Get("Content-Encoding") != "gzip" || rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("unexpected headers %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != "hello hello hello" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	rr = performRequest(h, http.MethodGet, "/auto", nil, nil)
+	if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != "hello hello hello" {
+		t.Fatalf("expected an identity response, got %q %q", rr.Header().Get("Content-Encoding"), rr.Body.String())
+	}
+
+	// Forced codings ignore Accept-Encoding
+	rr = performRequest(h, http.MethodGet, "/forced", map[string]string{"Accept-Encoding": "identity"}, nil)
+	if rr.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expected deflate, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	fr, err := zlib.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("invalid deflate body: %v", err)
+	}
+	if body, _ := io.ReadAll(fr); string(body) != "hello hello hello" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"strconv"
+	"sync"
+)
+
+// concurrencyBuckets are the upper bounds of the in-flight histogram buckets
+var concurrencyBuckets = []int{1, 2, 4, 8, 16, 32, 64, 128}
+
+// concurrencyTracker records how many requests a rule is serving at once
+type concurrencyTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	total    int64
+	counts   []int64 // Per bucket, plus a final overflow bucket
+}
+
+func newConcurrencyTracker() *concurrencyTracker {
+	return &concurrencyTracker{counts: make([]int64, len(concurrencyBuckets)+1)}
+}
+
+// begin registers a request and records the concurrency level it observed, including itself
+func (c *concurrencyTracker) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight++
+	c.total++
+	if c.inFlight > c.max {
+		c.max = c.inFlight
+	}
+	i := 0
+	for i < len(concurrencyBuckets) && c.inFlight > concurrencyBuckets[i] {
+		i++
+	}
+	c.counts[i]++
+}
+
+func (c *concurrencyTracker) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+}
+
+func (c *concurrencyTracker) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max = c.inFlight
+	c.total = 0
+	c.counts = make([]int64, len(concurrencyBuckets)+1)
+}
+
+// ConcurrencyStats summarizes the concurrency observed for one rule
+type ConcurrencyStats struct {
+	Rule      string            `json:"rule"`
+	InFlight  int               `json:"inFlight"`
+	Max       int               `json:"max"`
+	Requests  int64             `json:"requests"`
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+// HistogramBucket counts requests that started while at most Le requests of the
+// rule were in flight (cumulative, as in Prometheus histograms)
+type HistogramBucket struct {
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+func (c *concurrencyTracker) stats(rule string) ConcurrencyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := ConcurrencyStats{
+		Rule:      rule,
+		InFlight:  c.inFlight,
+		Max:       c.max,
+		Requests:  c.total,
+		Histogram: make([]HistogramBucket, 0, len(c.counts)),
+	}
+	var cumulative int64
+	for i, n := range c.counts {
+		cumulative += n
+		le := "+Inf"
+		if i < len(concurrencyBuckets) {
+			le = strconv.Itoa(concurrencyBuckets[i])
+		}
+		s.Histogram = append(s.Histogram, HistogramBucket{Le: le, Count: cumulative})
+	}
+	return s
+}
+
+// ConcurrencyStats returns the observed concurrency of every rule in configuration order.
+// Statistics start over when the rules are reloaded.
+func (h *MockHandler) ConcurrencyStats() []ConcurrencyStats {
+	rs := h.current()
+	stats := make([]ConcurrencyStats, 0, len(rs.config.Requests))
+	for i, key := range rs.ruleKeys {
+		stats = append(stats, rs.concurrency[&rs.config.Requests[i]].stats(key))
+	}
+	return stats
+}
+
+// ResetConcurrencyStats clears the recorded maxima and histograms
+func (h *MockHandler) ResetConcurrencyStats() {
+	for _, tracker := range h.current().concurrency {
+		tracker.reset()
+	}
+}
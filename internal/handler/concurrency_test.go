@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestConcurrencyStats_TracksMaxInFlight(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Name:          "slow",
+				Path:          "/slow",
+				Method:        "GET",
+				ResponseDelay: &config.ResponseDelay{Min: 200, Max: 200},
+				Response:      config.ResponseSpec{StatusCode: 200},
+			},
+			{
+				Path:     "/idle",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	const concurrency = 10
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			performRequest(h, http.MethodGet, "/slow", nil, nil)
+		}()
+	}
+	wg.Wait()
+
+	stats := h.ConcurrencyStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected stats for 2 rules, got %d", len(stats))
+	}
+
+	slow := stats[0]
+	if slow.Rule != "slow" {
+		t.Fatalf("expected rule name slow, got %q", slow.Rule)
+	}
+	if slow.Max != concurrency {
+		t.Fatalf("expected max in flight %d, got %d", concurrency, slow.Max)
+	}
+	if slow.InFlight != 0 {
+		t.Fatalf("expected no requests in flight, got %d", slow.InFlight)
+	}
+	if slow.Requests != concurrency {
+		t.Fatalf("expected %d requests, got %d", concurrency, slow.Requests)
+	}
+	last := slow.Histogram[len(slow.Histogram)-1]
+	if last.Le != "+Inf" || last.Count != concurrency {
+		t.Fatalf("expected cumulative +Inf bucket %d, got %+v", concurrency, last)
+	}
+	if first := slow.Histogram[0]; first.Le != "1" || first.Count != 1 {
+		t.Fatalf("expected exactly one request to start alone, got %+v", first)
+	}
+
+	if idle := stats[1]; idle.Rule != "GET /idle" || idle.Max != 0 || idle.Requests != 0 {
+		t.Fatalf("expected untouched idle rule, got %+v", idle)
+	}
+
+	h.ResetConcurrencyStats()
+	if got := h.ConcurrencyStats()[0]; got.Max != 0 || got.Requests != 0 {
+		t.Fatalf("expected reset stats, got %+v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/expr"
+)
+
+// compileConditions parses every `when` expression of rule i, its matcher groups,
+// and its responses once at startup. A condition that fails to compile never matches.
+func (rs *ruleSet) compileConditions(i int) {
+	rule := &rs.config.Requests[i]
+	rs.compileCondition(i, rule.When)
+	for _, wr := range rule.Responses {
+		rs.compileCondition(i, wr.When)
+	}
+	_ = rule.Groups.Walk(func(m *config.Matcher) error {
+		rs.compileCondition(i, m.When)
+		return nil
+	})
+}
+
+func (rs *ruleSet) compileCondition(i int, when string) {
+	if when == "" {
+		return
+	}
+	if _, ok := rs.conditions[when]; ok {
+		return
+	}
+	program, err := expr.Compile(when)
+	if err != nil {
+		log.Printf("request rule %d: when: %v, condition never matches", i, err)
+	}
+	rs.conditions[when] = program
+}
+
+func (rs *ruleSet) matchesWhen(when string, r *http.Request) bool {
+	if when == "" {
+		return true
+	}
+	program := rs.conditions[when]
+	if program == nil {
+		return false
+	}
+
+	ok, err := program.EvalBool(conditionVars(r))
+	if err != nil {
+		log.Printf("Error evaluating condition %q: %v", when, err)
+		return false
+	}
+	return ok
+}
+
+// conditionVarsKey carries a request's expression variables, built when the first
+// condition is evaluated and shared by the rest, so the body is read and parsed once
+// however many rules and responses have conditions
+type conditionVarsKey struct{}
+
+type sharedVars struct {
+	once sync.Once
+	vars expr.Vars
+}
+
+// withConditionVars prepares r to share one set of expression variables between conditions
+func withConditionVars(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), conditionVarsKey{}, &sharedVars{}))
+}
+
+// conditionVars returns the shared variables of r, or new ones when r has none
+func conditionVars(r *http.Request) expr.Vars {
+	shared, ok := r.Context().Value(conditionVarsKey{}).(*sharedVars)
+	if !ok {
+		return requestVars(r)
+	}
+	shared.once.Do(func() { shared.vars = requestVars(r) })
+	return shared.vars
+}
+
+// requestVars exposes the request to expressions as the variables method, path,
+// headers (lowercased names), query, body (raw string), and json (parsed body,
+// or null when the body is not valid JSON). The header(name) and query(name)
+// functions of expressions read headers and query.
+func requestVars(r *http.Request) expr.Vars {
+	body, _ := requestBody(r)
+
+	var parsed interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			parsed = nil
+		}
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		headers[strings.ToLower(name)] = values[0]
+	}
+
+	query := make(map[string]string)
+	for name, values := range r.URL.Query() {
+		query[name] = values[0]
+	}
+
+	return expr.Vars{
+		"method":  strings.ToUpper(r.Method),
+		"path":    r.URL.Path,
+		"headers": headers,
+		"query":   query,
+		"body":    string(body),
+		"json":    parsed,
+	}
+}
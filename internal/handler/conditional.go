@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// validators remembers the ETag a conditional response last had and since when, so its
+// Last-Modified only moves when the body changes
+type validators struct {
+	mu       sync.Mutex
+	etag     string
+	modified time.Time
+}
+
+// setValidators adds ETag and Last-Modified to a conditional response, unless its
+// headers already have them. The ETag is the quoted hex MD5 of the body.
+func (rs *ruleSet) setValidators(spec *config.ResponseSpec, header http.Header, body []byte, now time.Time) {
+	v := rs.validators[spec]
+	if header.Get("ETag") == "" {
+		sum := md5.Sum(body)
+		header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	}
+	etag := header.Get("ETag")
+
+	v.mu.Lock()
+	if v.etag != etag {
+		v.etag, v.modified = etag, now
+	}
+	modified := v.modified
+	v.mu.Unlock()
+
+	if header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestConditionalResponse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /profile
+    response:
+      conditional: true
+      template: true
+      body: 'name={{ or .Query.name "ada" }}'
+  - path: /fixed
+    response:
+      conditional: true
+      headers:
+        ETag: '"v1"'
+      body: fixed
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	rr := performRequest(h, http.MethodGet, "/profile", nil, nil)
+	etag, modified := rr.Header().Get("ETag"), rr.Header().Get("Last-Modified")
+	if rr.Code != http.StatusOK || etag == "" || modified != "Wed, 01 May 2024 10:00:00 GMT" {
+		t.Fatalf("expected validators, got %d %v", rr.Code, rr.Header())
+	}
+
+	now = now.Add(time.Hour)
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"matching etag", "/profile", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak etag", "/profile", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"other etag", "/profile", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", "/profile", map[string]string{"If-Modified-Since": modified}, http.StatusNotModified},
+		{"changed body", "/profile?name=bob", map[string]string{"If-None-Match": etag}, http.StatusOK},
+		{"configured etag", "/fixed", map[string]string{"If-None-Match": `"v1"`}, http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, tt.path, tt.headers, nil)
+		if rr.Code != tt.status {
+			t.Fatalf("%s: expected %d, got %d", tt.name, tt.status, rr.Code)
+		}
+		if tt.status == http.StatusNotModified && rr.Body.Len() != 0 {
+			t.Fatalf("%s: expected no body, got %q", tt.name, rr.Body.String())
+		}
+	}
+
+	// The body went back to name=ada after name=bob, so it was modified an hour ago
+	rr = performRequest(h, http.MethodGet, "/profile", map[string]string{"If-Modified-Since": modified}, nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("Last-Modified") != "Wed, 01 May 2024 11:00:00 GMT" {
+		t.Fatalf("expected a new Last-Modified after the body changed, got %d %v", rr.Code, rr.Header())
+	}
+}
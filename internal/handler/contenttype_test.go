@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_AutoContentType(t *testing.T) {
+	rules := `
+requests:
+  - path: /object
+    response:
+      body: {id: 1}
+  - path: /list
+    response:
+      body: [1, 2]
+  - path: /text
+    response:
+      body: hello
+  - path: /html
+    response:
+      body: "<!DOCTYPE html><p>hi</p>"
+  - path: /typed
+    response:
+      headers:
+        content-type: application/vnd.api+json
+      body: {id: 1}
+  - path: /gzipped
+    response:
+      compress: gzip
+      body: {id: 1}
+  - path: /base64
+    response:
+      bodyBase64: iVBORw0KGgo=
+`
+	cfg, err := config.Parse([]byte(rules), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	for path, want := range map[string]string{
+		"/object":  "application/json; charset=utf-8",
+		"/list":    "application/json; charset=utf-8",
+		"/text":    "text/plain; charset=utf-8",
+		"/html":    "text/html; charset=utf-8",
+		"/typed":   "application/vnd.api+json",
+		"/gzipped": "application/json; charset=utf-8",
+		"/base64":  "", // Left to the server's sniffing, as before
+	} {
+		rr := performRequest(h, http.MethodGet, path, nil, nil)
+		if got := rr.Header().Get("Content-Type"); got != want {
+			t.Errorf("%s: expected Content-Type %q, got %q", path, want, got)
+		}
+	}
+
+	cfg, err = config.Parse([]byte("server:\n  untypedBodies: true\n"+rules[1:]), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h = NewMockHandler(cfg)
+	if got := performRequest(h, http.MethodGet, "/object", nil, nil).Header().Get("Content-Type"); got != "" {
+		t.Errorf("expected untypedBodies to leave the type to sniffing, got %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestResponseCookies(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /login
+    method: POST
+    response:
+      headers:
+        Set-Cookie: legacy=1
+      cookies:
+        - name: session
+          value: abc123
+          path: /
+          maxAge: 3600
+          secure: true
+          httpOnly: true
+          sameSite: strict
+        - name: theme
+          value: dark
+          expires: 2030-01-02T03:04:05Z
+        - name: tracking
+          maxAge: -1
+  - path: /whoami
+    response:
+      template: true
+      body: 'session={{ .Cookies.session }}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/login", nil, nil)
+	want := []string{
+		"legacy=1",
+		"session=abc123; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+		"theme=dark; Expires=Wed, 02 Jan 2030 03:04:05 GMT",
+		"tracking=; Max-Age=0",
+	}
+	got := rr.Header().Values("Set-Cookie")
+	if len(got) != len(want) {
+		t.Fatalf("expected %d Set-Cookie headers, got %q", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Set-Cookie %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	rr = performRequest(h, http.MethodGet, "/whoami", map[string]string{"Cookie": "theme=dark; session=abc123"}, nil)
+	if rr.Body.String() != "session=abc123" {
+		t.Fatalf("expected the session cookie in the template, got %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package handler
+
+import "time"
+
+// processCPUTime is only implemented on Unix
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DatasetStore holds datasets uploaded at runtime. An upload replaces the configured
+// file of the same name until it is deleted, and survives configuration reloads.
+type DatasetStore struct {
+	mu      sync.RWMutex
+	uploads map[string]uploadedDataset
+	changed func(name string) // Called after an upload is set or deleted
+}
+
+type uploadedDataset struct {
+	data      interface{}
+	raw       []byte // Content as uploaded, served to bodyFile references
+	updatedAt time.Time
+}
+
+// DatasetInfo describes a dataset and where its current content came from
+type DatasetInfo struct {
+	Name      string     `json:"name"`
+	Source    string     `json:"source"`              // "file:<path>" or "upload"
+	Size      int        `json:"size,omitempty"`      // Uploaded size in bytes
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"` // Time of the upload
+}
+
+// NewDatasetStore creates an empty dataset store
+func NewDatasetStore() *DatasetStore {
+	return &DatasetStore{uploads: make(map[string]uploadedDataset)}
+}
+
+// Set stores uploaded dataset content, parsed and as it was uploaded
+func (s *DatasetStore) Set(name string, data interface{}, raw []byte, now time.Time) {
+	s.mu.Lock()
+	s.uploads[name] = uploadedDataset{data: data, raw: raw, updatedAt: now}
+	s.mu.Unlock()
+	if s.changed != nil {
+		s.changed(name)
+	}
+}
+
+// Delete removes an upload, reporting whether there was one
+func (s *DatasetStore) Delete(name string) bool {
+	s.mu.Lock()
+	_, ok := s.uploads[name]
+	delete(s.uploads, name)
+	s.mu.Unlock()
+	if ok && s.changed != nil {
+		s.changed(name)
+	}
+	return ok
+}
+
+func (s *DatasetStore) get(name string) (interface{}, bool) {
+	u, ok := s.upload(name)
+	return u.data, ok
+}
+
+func (s *DatasetStore) upload(name string) (uploadedDataset, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.uploads[name]
+	return u, ok
+}
+
+// Datasets returns the upload store
+func (h *MockHandler) Datasets() *DatasetStore {
+	return h.datasets
+}
+
+// dataset returns the current content of a dataset: the upload if there is one,
+// otherwise the file from the rule set's configuration.
+func (h *MockHandler) dataset(rs *ruleSet, name string) (interface{}, error) {
+	if data, ok := h.datasets.get(name); ok {
+		return data, nil
+	}
+	if data, ok := rs.config.DatasetData[name]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown dataset %q", name)
+}
+
+// Dataset returns the current content of a dataset
+func (h *MockHandler) Dataset(name string) (interface{}, error) {
+	return h.dataset(h.current(), name)
+}
+
+// uploadedFile returns the upload replacing the dataset configured with the file at
+// path, so a bodyFile naming a dataset's file serves its current content
+func (h *MockHandler) uploadedFile(rs *ruleSet, path string) (*cachedFile, bool) {
+	for name, file := range rs.config.Datasets {
+		if filepath.Clean(file) != filepath.Clean(path) {
+			continue
+		}
+		u, ok := h.datasets.upload(name)
+		if !ok {
+			return nil, false
+		}
+		return &cachedFile{
+			data:    u.raw,
+			modTime: u.updatedAt,
+			size:    int64(len(u.raw)),
+			etag:    fmt.Sprintf(`"%x-%x"`, u.updatedAt.UnixNano(), len(u.raw)),
+		}, true
+	}
+	return nil, false
+}
+
+// reseedResources drops the collections of resources seeded from a dataset, so they
+// are seeded from its new content on next use
+func (h *MockHandler) reseedResources(name string) {
+	rs := h.current()
+	h.resources.mu.Lock()
+	defer h.resources.mu.Unlock()
+	for i := range rs.config.Requests {
+		if res := rs.config.Requests[i].Resource; res != nil && res.Seed == name {
+			delete(h.resources.collections, rs.config.Requests[i].Path)
+		}
+	}
+}
+
+// DatasetInfo lists configured and uploaded datasets, sorted by name
+func (h *MockHandler) DatasetInfo() []DatasetInfo {
+	cfg := h.current().config
+	infos := make(map[string]DatasetInfo)
+	for name, path := range cfg.Datasets {
+		infos[name] = DatasetInfo{Name: name, Source: "file:" + path}
+	}
+
+	h.datasets.mu.RLock()
+	for name, u := range h.datasets.uploads {
+		updatedAt := u.updatedAt
+		infos[name] = DatasetInfo{Name: name, Source: "upload", Size: len(u.raw), UpdatedAt: &updatedAt}
+	}
+	h.datasets.mu.RUnlock()
+
+	list := make([]DatasetInfo, 0, len(infos))
+	for _, info := range infos {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecompressionMiddleware returns middleware that transparently decodes request bodies
+// sent with Content-Encoding gzip or deflate, so body matchers and the request log see
+// the original content. Bodies with other encodings are passed through unchanged.
+// Behind LimitsMiddleware with maxBodyMemory, the body is decoded up front within the
+// remaining budget, and a body that expands past it is shed like an oversized one.
+func DecompressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			encodings := contentEncodings(r.Header)
+			if len(encodings) == 0 || r.Body == nil || r.Body == http.NoBody || !supportedEncodings(encodings) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Encodings are listed in the order they were applied, so undo them in reverse
+			body := io.ReadCloser(r.Body)
+			for i := len(encodings) - 1; i >= 0; i-- {
+				decoded, err := decodeBody(encodings[i], body)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s request body: %v", encodings[i], err), http.StatusBadRequest)
+					return
+				}
+				body = decoded
+			}
+			if budget := requestBodyBudget(r); budget != nil {
+				data, ok, err := budget.read(body)
+				_ = body.Close()
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid %s request body: %v", strings.Join(encodings, ", "), err), http.StatusBadRequest)
+					return
+				}
+				if !ok {
+					budget.shedder.shedBody.Add(1)
+					budget.shedder.shed(w, r, http.StatusServiceUnavailable, "decompressed request body memory limit reached")
+					return
+				}
+				body = io.NopCloser(bytes.NewReader(data))
+			}
+			r.Body = body
+			// The declared length described the encoded body
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// contentEncodings lists the request's content codings, ignoring identity
+func contentEncodings(header http.Header) []string {
+	var encodings []string
+	for _, value := range header.Values("Content-Encoding") {
+		for _, enc := range strings.Split(value, ",") {
+			enc = strings.ToLower(strings.TrimSpace(enc))
+			if enc != "" && enc != "identity" {
+				encodings = append(encodings, enc)
+			}
+		}
+	}
+	return encodings
+}
+
+func supportedEncodings(encodings []string) bool {
+	for _, enc := range encodings {
+		switch enc {
+		case "gzip", "x-gzip", "deflate":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func decodeBody(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	if encoding == "deflate" {
+		return decodeDeflate(body)
+	}
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{zr, body}, nil
+}
+
+// decodeDeflate accepts both zlib-wrapped data, as the HTTP spec requires,
+// and the raw deflate streams some clients send instead.
+func decodeDeflate(body io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return readCloser{zr, body}, nil
+	}
+	return readCloser{flate.NewReader(br), body}, nil
+}
+
+// readCloser reads from a decoder and closes the underlying body
+type readCloser struct {
+	io.Reader
+	body io.Closer
+}
+
+func (rc readCloser) Close() error {
+	return rc.body.Close()
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func compress(t *testing.T, newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressionMiddleware(t *testing.T) {
+	const payload = `{"hello":"world"}`
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zlibWriter := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+	flateWriter := func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantCode int
+		wantBody string
+	}{
+		{name: "gzip", encoding: "gzip", body: compress(t, gzipWriter, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "deflate zlib", encoding: "deflate", body: compress(t, zlibWriter, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "deflate raw", encoding: "Deflate", body: compress(t, flateWriter, payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "stacked", encoding: "deflate, gzip", body: compress(t, gzipWriter, string(compress(t, zlibWriter, payload))), wantCode: http.StatusOK, wantBody: payload},
+		{name: "identity", encoding: "identity", body: []byte(payload), wantCode: http.StatusOK, wantBody: payload},
+		{name: "unsupported passes through", encoding: "br", body: []byte("opaque"), wantCode: http.StatusOK, wantBody: "opaque"},
+		{name: "corrupt gzip", encoding: "gzip", body: []byte("not gzip"), wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []byte
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = io.ReadAll(r.Body)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			rr := httptest.NewRecorder()
+			DecompressionMiddleware(next).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rr.Code)
+			}
+			if tt.wantCode == http.StatusOK && string(got) != tt.wantBody {
+				t.Fatalf("expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+func TestDecompressionMiddleware_BodyMatcher(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /events
+    method: POST
+    body: '\{"type":"click"\}'
+    response:
+      status-code: 202
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := DecompressionMiddleware(NewMockHandler(cfg))
+
+	body := compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, `{"type":"click"}`)
+	rr := performRequest(h, http.MethodPost, "/events", map[string]string{"Content-Encoding": "gzip"}, body)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, rr.Code)
+	}
+}
+
+func TestDecompressionMiddleware_SizeMatchers(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /events
+    method: POST
+    contentLength: {maxBytes: 100}
+    bodySize: {minBytes: 1kb}
+    response:
+      status-code: 202
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := DecompressionMiddleware(NewMockHandler(cfg))
+
+	// contentLength sees the compressed size, bodySize the decompressed one
+	body := compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, strings.Repeat("a", 2048))
+	headers := map[string]string{"Content-Encoding": "gzip", "Content-Length": strconv.Itoa(len(body))}
+	rr := performRequest(h, http.MethodPost, "/events", headers, body)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, rr.Code)
+	}
+}
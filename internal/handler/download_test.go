@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_Download(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.7 fake"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /report
+    response:
+      download: {filename: report.pdf, file: `+path+`}
+  - path: /export
+    response:
+      download: {filename: "Relatório 2026.csv"}
+      body: "id,total\n1,42\n"
+  - path: /preview
+    response:
+      headers:
+        Content-Type: image/png
+      download: {filename: chart.bin, inline: true}
+      bodyBase64: iVBORw0KGgo=
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/report", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "%PDF-1.7 fake" ||
+		rr.Header().Get("Content-Disposition") != `attachment; filename=report.pdf` ||
+		rr.Header().Get("Content-Type") != "application/pdf" || rr.Header().Get("Content-Length") != "13" {
+		t.Fatalf("unexpected file download %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	rr = performRequest(h, http.MethodGet, "/export", nil, nil)
+	if rr.Header().Get("Content-Disposition") != `attachment; filename*=utf-8''Relat%C3%B3rio%202026.csv` ||
+		rr.Header().Get("Content-Type") != "text/csv; charset=utf-8" || rr.Header().Get("Content-Length") != "14" {
+		t.Fatalf("unexpected body download %v", rr.Header())
+	}
+
+	// HEAD requests get the headers of a download without its body
+	rr = performRequest(h, http.MethodHead, "/export", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 || rr.Header().Get("Content-Length") != "14" {
+		t.Fatalf("unexpected HEAD response %d %v", rr.Code, rr.Header())
+	}
+
+	rr = performRequest(h, http.MethodGet, "/preview", nil, nil)
+	if rr.Header().Get("Content-Disposition") != "inline; filename=chart.bin" || rr.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected an inline download keeping the configured Content-Type, got %v", rr.Header())
+	}
+}
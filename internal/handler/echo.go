@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"http-mock-server/internal/config"
+)
+
+// echoedRequest is the body of an echo response
+type echoedRequest struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Path       string              `json:"path"`
+	Query      map[string][]string `json:"query"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	BodyBase64 string              `json:"bodyBase64,omitempty"` // Set instead of body when it isn't UTF-8
+	JSON       interface{}         `json:"json,omitempty"`       // Parsed body, when it is JSON
+}
+
+// echoResponse reflects the request back as JSON, with the status code and headers of spec
+func echoResponse(r *http.Request, spec *config.ResponseSpec) ([]byte, int, map[string]string, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("reading request body: %w", err)
+	}
+	echo := echoedRequest{
+		Method:  r.Method,
+		URL:     r.URL.RequestURI(),
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: r.Header.Clone(),
+	}
+	if r.Host != "" {
+		echo.Headers["Host"] = []string{r.Host}
+	}
+	if utf8.Valid(body) {
+		echo.Body = string(body)
+	} else {
+		echo.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	if len(body) > 0 && json.Unmarshal(body, &echo.JSON) != nil {
+		echo.JSON = nil
+	}
+	data, err := json.MarshalIndent(echo, "", "  ")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	for name, value := range spec.Headers {
+		headers[name] = value
+	}
+	return data, spec.StatusCode, headers, nil
+}
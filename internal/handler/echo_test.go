@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestEchoResponse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - pathPrefix: /echo/
+    method: POST
+    response:
+      mode: echo
+      status-code: 202
+      headers:
+        X-Mock: echo
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/echo/orders?id=1&id=2", map[string]string{"X-Trace": "abc"}, []byte(`{"qty": 3}`))
+	if rr.Code != http.StatusAccepted || rr.Header().Get("X-Mock") != "echo" || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected 202 with the rule headers, got %d %v", rr.Code, rr.Header())
+	}
+	var got echoedRequest
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("expected JSON, got %s", rr.Body.String())
+	}
+	if got.Method != http.MethodPost || got.Path != "/echo/orders" || got.URL != "/echo/orders?id=1&id=2" {
+		t.Fatalf("unexpected request line: %+v", got)
+	}
+	if len(got.Query["id"]) != 2 || got.Headers["X-Trace"][0] != "abc" || got.Headers["Host"][0] != "example.com" {
+		t.Fatalf("unexpected query or headers: %+v", got)
+	}
+	if got.Body != `{"qty": 3}` || got.JSON.(map[string]interface{})["qty"] != float64(3) {
+		t.Fatalf("unexpected body: %+v", got)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/echo/bin", nil, []byte{0xff, 0xfe})
+	var binary echoedRequest
+	if err := json.Unmarshal(rr.Body.Bytes(), &binary); err != nil || binary.BodyBase64 != "//4=" || binary.JSON != nil {
+		t.Fatalf("expected a base64 body, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"http-mock-server/internal/config"
+)
+
+// errorData is what the server.errorResponse body template can use
+type errorData struct {
+	Error  string
+	Rule   string
+	Method string
+	Path   string
+}
+
+// writeMockError answers a request the server failed to produce a response for. It sends
+// server.errorResponse when configured, and the error as plain text otherwise.
+func writeMockError(w http.ResponseWriter, r *http.Request, rs *ruleSet, rule *config.RequestRule, err error) {
+	e := rs.config.Server.ErrorResponse
+	if e == nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var body []byte
+	if rs.errorBody != nil {
+		data := errorData{Error: err.Error(), Rule: rs.ruleKey(rule), Method: r.Method, Path: r.URL.Path}
+		var renderErr error
+		if body, renderErr = rs.render(rs.errorBody, data); renderErr != nil {
+			log.Printf("Error rendering server errorResponse: %v", renderErr)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	setHeaders(w.Header(), e.Headers)
+	w.WriteHeader(e.StatusCode)
+	w.Write(body)
+}
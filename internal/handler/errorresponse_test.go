@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestErrorResponse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(file, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules := `
+requests:
+  - path: /broken
+    response:
+      template: true
+      body: '{{ sign "missing" "x" }}'
+  - path: /file
+    response:
+      bodyFile: ` + file + `
+`
+	tests := []struct {
+		name   string
+		server string
+		path   string
+		status int
+		want   string
+		header string
+	}{
+		{name: "default", path: "/broken", status: http.StatusInternalServerError},
+		{
+			name:   "template",
+			server: "server:\n  errorResponse:\n    status-code: 502\n    headers:\n      Content-Type: application/json\n    body: '{\"mockError\":{{ toJson .Error }},\"rule\":\"{{ .Rule }}\"}'\n",
+			path:   "/broken", status: http.StatusBadGateway, header: "application/json",
+			want: `{"mockError":"response template failed: template: GET /broken:1:3: executing \"GET /broken\" at \u003csign \"missing\" \"x\"\u003e: error calling sign: unknown key \"missing\"","rule":"GET /broken"}`,
+		},
+		{
+			name:   "missing file",
+			server: "server:\n  errorResponse:\n    body: 'mock error on {{ .Method }} {{ .Path }}'\n",
+			path:   "/file", status: http.StatusInternalServerError, want: "mock error on GET /file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Parse([]byte(tt.server+rules), "test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := NewMockHandler(cfg)
+			if tt.path == "/file" {
+				if err := os.Rename(file, file+".gone"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				defer os.Rename(file+".gone", file)
+			}
+			rr := performRequest(h, http.MethodGet, tt.path, nil, nil)
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d, got %d %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.want != "" && rr.Body.String() != tt.want {
+				t.Fatalf("expected body %s, got %s", tt.want, rr.Body.String())
+			}
+			if tt.header != "" && rr.Header().Get("Content-Type") != tt.header {
+				t.Fatalf("expected Content-Type %s, got %s", tt.header, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
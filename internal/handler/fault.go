@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+
+	"http-mock-server/internal/config"
+)
+
+// garbageSize is the number of random bytes sent by the randomGarbage fault
+const garbageSize = 2048
+
+// writeFault breaks the connection in the way the response's fault describes.
+// Faults need the raw connection; where it can't be taken over, as with HTTP/2,
+// the request is aborted instead.
+func (h *MockHandler) writeFault(w http.ResponseWriter, r *http.Request, spec *config.ResponseSpec) {
+	conn, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		log.Printf("Fault %s needs an HTTP/1.x connection (%v); aborting %s %s instead", spec.Fault, err, r.Method, r.URL.Path)
+		panic(http.ErrAbortHandler)
+	}
+	defer conn.Close()
+	log.Printf("Injecting fault %s into %s %s", spec.Fault, r.Method, r.URL.Path)
+
+	switch spec.Fault {
+	case config.FaultConnectionReset:
+		// Discarding unsent data on close makes the kernel answer with RST instead of FIN
+		if tcp, ok := netConn(conn).(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+	case config.FaultEmptyResponse:
+		// Close without writing anything
+	case config.FaultMalformedChunk:
+		writeStatusLine(buf, spec)
+		buf.WriteString("Transfer-Encoding: chunked\r\n\r\n")
+		// A valid first chunk, then a size line that isn't hexadecimal
+		buf.WriteString("5\r\nhello\r\nzz\r\nnot a chunk\r\n")
+		_ = buf.Flush()
+	case config.FaultRandomGarbage:
+		garbage := make([]byte, garbageSize)
+		h.randMu.Lock()
+		h.rand.Read(garbage)
+		h.randMu.Unlock()
+		_, _ = buf.Write(garbage)
+		_ = buf.Flush()
+	}
+}
+
+// writeStatusLine writes the status line and configured headers of a response
+func writeStatusLine(buf *bufio.ReadWriter, spec *config.ResponseSpec) {
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", spec.StatusCode, http.StatusText(spec.StatusCode))
+	names := make([]string, 0, len(spec.Headers))
+	for name := range spec.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s: %s\r\n", http.CanonicalHeaderKey(name), spec.Headers[name])
+	}
+}
+
+// netConn returns the connection beneath TLS, if any
+func netConn(conn net.Conn) net.Conn {
+	if t, ok := conn.(*tls.Conn); ok {
+		return t.NetConn()
+	}
+	return conn
+}
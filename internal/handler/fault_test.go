@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_Faults(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /reset
+    response: {fault: connectionReset}
+  - path: /empty
+    response: {fault: emptyResponse}
+  - path: /chunk
+    response:
+      fault: malformedChunk
+      status-code: 202
+      headers:
+        content-type: text/plain
+  - path: /garbage
+    response: {fault: randomGarbage}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Faults must reach the connection through the logging middleware's writer
+	srv := httptest.NewServer(LoggingMiddleware(NewMockHandler(cfg)))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/reset", "reset"},
+		{"/empty", "EOF"},
+		{"/garbage", "malformed HTTP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get(srv.URL + tt.path)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("expected an error, got %s", resp.Status)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("/chunk", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/chunk")
+		if err != nil {
+			t.Fatalf("expected headers to arrive, got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted || resp.Header.Get("Content-Type") != "text/plain" {
+			t.Fatalf("unexpected response %s %v", resp.Status, resp.Header)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err == nil || !strings.Contains(err.Error(), "chunk") {
+			t.Fatalf("expected a chunk error after %q, got %v", body, err)
+		}
+	})
+}
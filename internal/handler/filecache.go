@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedFile is the content of a bodyFile as of its modification time
+type cachedFile struct {
+	data    []byte
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// fileCache keeps bodyFile contents in memory, re-reading a file only when its
+// modification time or size changes. It outlives configuration reloads.
+type fileCache struct {
+	mu    sync.Mutex
+	files map[string]*cachedFile
+}
+
+func newFileCache() *fileCache {
+	return &fileCache{files: make(map[string]*cachedFile)}
+}
+
+// get returns the current content of the file at path
+func (c *fileCache) get(path string) (*cachedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("bodyFile: %w", err)
+	}
+	c.mu.Lock()
+	f, ok := c.files[path]
+	c.mu.Unlock()
+	if ok && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("bodyFile: %w", err)
+	}
+	f = &cachedFile{
+		data:    data,
+		modTime: info.ModTime(),
+		size:    int64(len(data)),
+		etag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), len(data)),
+	}
+	c.mu.Lock()
+	c.files[path] = f
+	c.mu.Unlock()
+	return f, nil
+}
+
+// withValidators returns the headers with the file's ETag and Last-Modified added,
+// unless the response configures its own
+func (f *cachedFile) withValidators(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers)+2)
+	for name, value := range headers {
+		out[name] = value
+	}
+	if !hasHeader(headers, "ETag") {
+		out["ETag"] = f.etag
+	}
+	if !hasHeader(headers, "Last-Modified") {
+		out["Last-Modified"] = f.modTime.UTC().Format(http.TimeFormat)
+	}
+	return out
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified evaluates the request's If-None-Match and If-Modified-Since headers
+// against the validators of a 200 response to a GET or HEAD request
+func notModified(r *http.Request, status int, header http.Header) bool {
+	if status != http.StatusOK || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	// If-None-Match takes precedence over If-Modified-Since
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := header.Get("ETag")
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || (etag != "" && strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/")) {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(ims)
+}
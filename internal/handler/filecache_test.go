@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_BodyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+requests:
+  - path: /report
+    response:
+      headers:
+        Content-Type: text/csv
+      bodyFile: %s
+`, path)), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/report", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("expected the file, got %d %q", rr.Code, rr.Body.String())
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" || rr.Header().Get("Last-Modified") != "Wed, 01 May 2024 12:00:00 GMT" || rr.Header().Get("Content-Length") != "8" {
+		t.Fatalf("unexpected headers %v", rr.Header())
+	}
+
+	// GET rules serving files answer HEAD with the headers only
+	rr = performRequest(h, http.MethodHead, "/report", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 || rr.Header().Get("Content-Length") != "8" || rr.Header().Get("ETag") != etag {
+		t.Fatalf("unexpected HEAD response %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	conditional := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"weak etag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Wed, 01 May 2024 12:00:00 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Tue, 30 Apr 2024 12:00:00 GMT"}, http.StatusOK},
+	}
+	for _, tt := range conditional {
+		rr := performRequest(h, http.MethodGet, "/report", tt.headers, nil)
+		if rr.Code != tt.want {
+			t.Fatalf("%s: expected %d, got %d", tt.name, tt.want, rr.Code)
+		}
+		if tt.want == http.StatusNotModified && rr.Body.Len() != 0 {
+			t.Fatalf("%s: expected no body, got %q", tt.name, rr.Body.String())
+		}
+	}
+
+	// Changes on disk replace the cached content and its validators
+	if err := os.WriteFile(path, []byte("a,b\n3,4\n5,6\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rr = performRequest(h, http.MethodGet, "/report", map[string]string{"If-None-Match": etag}, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "a,b\n3,4\n5,6\n" || rr.Header().Get("ETag") == etag {
+		t.Fatalf("expected the new content, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestFileCache_ReusesUnchangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newFileCache()
+	first, err := c.get(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := c.get(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected the cached entry to be reused")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.get(path); err == nil {
+		t.Fatalf("expected an error for a removed file")
+	}
+}
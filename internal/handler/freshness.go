@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/storage"
+)
+
+// freshnessCheckInterval is how often served requests look for newly stale rules
+const freshnessCheckInterval = time.Hour
+
+// freshnessStorageTimeout bounds loading the saved match history and each
+// background save
+const freshnessStorageTimeout = 30 * time.Second
+
+// RuleFreshness reports when a rule last changed and was last matched
+type RuleFreshness struct {
+	Rule        string     `json:"rule"`
+	Hash        string     `json:"hash"`      // Changes whenever the rule's definition does
+	ChangedAt   time.Time  `json:"changedAt"` // Modification time of the configuration file that defines the rule
+	TrackedAt   time.Time  `json:"trackedAt"` // When the server started watching this definition
+	LastMatched *time.Time `json:"lastMatched,omitempty"`
+	IdleDays    int        `json:"idleDays"` // Whole days since the last match, or since tracking started
+	Stale       bool       `json:"stale"`
+}
+
+// freshnessRecord is what the tracker knows about one rule definition
+type freshnessRecord struct {
+	hash        string
+	changedAt   time.Time
+	trackedAt   time.Time
+	lastMatched time.Time
+	warned      bool // Logged as stale since its last match
+}
+
+// idleSince returns when the rule was last matched, or when tracking started
+func (f *freshnessRecord) idleSince() time.Time {
+	if f.lastMatched.IsZero() {
+		return f.trackedAt
+	}
+	return f.lastMatched
+}
+
+// freshnessTracker follows when each rule was last matched, by rule key. Records
+// outlive configuration reloads and start over when a rule's definition changes.
+type freshnessTracker struct {
+	mu        sync.Mutex
+	keys      []string // Rule keys in configuration order
+	rules     map[string]*freshnessRecord
+	lastCheck time.Time
+
+	restore sync.Once     // Loads the saved history, see restoreFreshness
+	saver   sync.Once     // Starts the background saver, see scheduleFreshnessSave
+	saves   chan struct{} // Pending background save
+	saveMu  sync.Mutex    // Serializes writes of the saved history
+}
+
+func newFreshnessTracker() *freshnessTracker {
+	return &freshnessTracker{rules: make(map[string]*freshnessRecord), saves: make(chan struct{}, 1)}
+}
+
+// sync starts tracking the rules of a newly active configuration. Rules whose
+// definition changed are tracked afresh from the modification time of the file that
+// defines them: their own file in a config directory, or the configuration file.
+func (t *freshnessTracker) sync(rs *ruleSet, now time.Time) {
+	modTimes := make(map[string]time.Time)
+	changedAt := func(rule *config.RequestRule) time.Time {
+		path := rule.SourceFile
+		if path == "" {
+			path = rs.config.Source
+		}
+		if modTime, ok := modTimes[path]; ok {
+			return modTime
+		}
+		modTime := now
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		modTimes[path] = modTime
+		return modTime
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rules := make(map[string]*freshnessRecord, len(rs.ruleKeys))
+	for i, key := range rs.ruleKeys {
+		rule := &rs.config.Requests[i]
+		hash := config.RuleHash(rule)
+		if f := t.rules[key]; f != nil && f.hash == hash {
+			rules[key] = f
+			continue
+		}
+		rules[key] = &freshnessRecord{hash: hash, changedAt: changedAt(rule), trackedAt: now}
+	}
+	t.keys, t.rules = rs.ruleKeys, rules
+}
+
+// merge adds history saved by an earlier run to the tracked rules. Saved records
+// only apply while the rule's definition is unchanged.
+func (t *freshnessTracker) merge(saved []RuleFreshness) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, rf := range saved {
+		f := t.rules[rf.Rule]
+		if f == nil || f.hash != rf.Hash {
+			continue
+		}
+		f.changedAt = rf.ChangedAt
+		if rf.TrackedAt.Before(f.trackedAt) {
+			f.trackedAt = rf.TrackedAt
+		}
+		if rf.LastMatched != nil && rf.LastMatched.After(f.lastMatched) {
+			f.lastMatched = *rf.LastMatched
+		}
+	}
+}
+
+// matched records that a request matched the rule
+func (t *freshnessTracker) matched(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if f := t.rules[key]; f != nil {
+		f.lastMatched, f.warned = now, false
+	}
+}
+
+// warn logs the rules that became stale since the last check, at most once per
+// freshnessCheckInterval. It reports whether the check ran.
+func (t *freshnessTracker) warn(cfg *config.Freshness, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.lastCheck) < freshnessCheckInterval {
+		return false
+	}
+	t.lastCheck = now
+	for _, key := range t.keys {
+		f := t.rules[key]
+		if f.warned || !t.stale(f, cfg.StaleAfter(), now) {
+			continue
+		}
+		f.warned = true
+		log.Printf("WARNING: rule %s has not been matched for %d days while other rules are in use; it may be a stale stub", key, idleDays(f, now))
+	}
+	return true
+}
+
+// report returns the freshness of every rule in configuration order
+func (t *freshnessTracker) report(cfg *config.Freshness, now time.Time) []RuleFreshness {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]RuleFreshness, 0, len(t.keys))
+	for _, key := range t.keys {
+		f := t.rules[key]
+		rf := RuleFreshness{
+			Rule:      key,
+			Hash:      f.hash,
+			ChangedAt: f.changedAt,
+			TrackedAt: f.trackedAt,
+			IdleDays:  idleDays(f, now),
+			Stale:     t.stale(f, cfg.StaleAfter(), now),
+		}
+		if !f.lastMatched.IsZero() {
+			matched := f.lastMatched
+			rf.LastMatched = &matched
+		}
+		out = append(out, rf)
+	}
+	return out
+}
+
+// stale reports whether a rule has gone unmatched for staleAfter while another
+// rule was matched within that time. Without any recent traffic nothing is stale,
+// so an idle deployment doesn't flag every rule.
+func (t *freshnessTracker) stale(f *freshnessRecord, staleAfter time.Duration, now time.Time) bool {
+	if now.Sub(f.idleSince()) < staleAfter {
+		return false
+	}
+	for _, other := range t.rules {
+		if other != f && !other.lastMatched.IsZero() && now.Sub(other.lastMatched) < staleAfter {
+			return true
+		}
+	}
+	return false
+}
+
+func idleDays(f *freshnessRecord, now time.Time) int {
+	return int(now.Sub(f.idleSince()) / (24 * time.Hour))
+}
+
+// Freshness reports when each rule last changed and was last matched. It is nil
+// when server.freshness isn't configured.
+func (h *MockHandler) Freshness() []RuleFreshness {
+	cfg := h.current().config.Server.Freshness
+	if cfg == nil {
+		return nil
+	}
+	return h.freshness.report(cfg, h.now())
+}
+
+// freshnessState is the saved match history, in the format of the admin endpoint
+type freshnessState struct {
+	StaleAfterDays int             `json:"staleAfterDays"`
+	Rules          []RuleFreshness `json:"rules"`
+}
+
+// restoreFreshness loads the history saved under server.freshness.stateKey, once,
+// when a configuration first sets it
+func (h *MockHandler) restoreFreshness(rs *ruleSet) {
+	cfg := rs.config.Server.Freshness
+	if cfg == nil || cfg.StateKey == "" {
+		return
+	}
+	h.freshness.restore.Do(func() { h.loadFreshness(rs, cfg) })
+}
+
+// loadFreshness merges the history saved under cfg.StateKey into the tracker
+func (h *MockHandler) loadFreshness(rs *ruleSet, cfg *config.Freshness) {
+	if err := storage.CheckKey(cfg.StateKey); err != nil {
+		log.Printf("WARNING: freshness state: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), freshnessStorageTimeout)
+	defer cancel()
+	obj, err := rs.store.Get(ctx, cfg.StateKey)
+	if storage.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("WARNING: could not load freshness state %s from %s: %v", cfg.StateKey, rs.store, err)
+		return
+	}
+	var state freshnessState
+	if err := json.Unmarshal(obj.Data, &state); err != nil {
+		log.Printf("WARNING: could not load freshness state %s from %s: %v", cfg.StateKey, rs.store, err)
+		return
+	}
+	h.freshness.merge(state.Rules)
+	log.Printf("Loaded freshness history of %d rules from %s in %s", len(state.Rules), cfg.StateKey, rs.store)
+}
+
+// SaveFreshness writes the match history to server.freshness.stateKey, so a restart
+// picks up where this run left off. It does nothing without a state key.
+func (h *MockHandler) SaveFreshness(ctx context.Context) error {
+	rs := h.current()
+	cfg := rs.config.Server.Freshness
+	if cfg == nil || cfg.StateKey == "" {
+		return nil
+	}
+	if err := storage.CheckKey(cfg.StateKey); err != nil {
+		return fmt.Errorf("freshness state: %w", err)
+	}
+	h.freshness.saveMu.Lock()
+	defer h.freshness.saveMu.Unlock()
+	data, err := json.MarshalIndent(freshnessState{StaleAfterDays: cfg.StaleAfterDays, Rules: h.freshness.report(cfg, h.now())}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := rs.store.Put(ctx, cfg.StateKey, data); err != nil {
+		return fmt.Errorf("could not save freshness state %s to %s: %w", cfg.StateKey, rs.store, err)
+	}
+	return nil
+}
+
+// scheduleFreshnessSave asks the background saver to write the match history, so
+// requests never wait on storage. A save that is already pending covers the request.
+func (h *MockHandler) scheduleFreshnessSave() {
+	h.freshness.saver.Do(func() {
+		go func() {
+			for range h.freshness.saves {
+				ctx, cancel := context.WithTimeout(context.Background(), freshnessStorageTimeout)
+				if err := h.SaveFreshness(ctx); err != nil {
+					log.Printf("WARNING: %v", err)
+				}
+				cancel()
+			}
+		}()
+	})
+	select {
+	case h.freshness.saves <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestFreshness(t *testing.T) {
+	parse := func(body string) *config.Config {
+		cfg, err := config.Parse([]byte(`
+server:
+  freshness:
+    staleAfterDays: 7
+requests:
+  - path: /hot
+  - path: /cold
+    response:
+      body: `+body+`
+  - path: /quiet
+`), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cfg
+	}
+	h := NewMockHandler(parse("v1"))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	h.freshness = newFreshnessTracker()
+	h.freshness.sync(h.current(), now)
+
+	stale := func() map[string]bool {
+		m := map[string]bool{}
+		for _, rf := range h.Freshness() {
+			m[rf.Rule] = rf.Stale
+		}
+		return m
+	}
+
+	// Nothing is stale while no rule sees traffic
+	now = now.Add(10 * 24 * time.Hour)
+	if got := stale(); got["GET /cold"] || got["GET /quiet"] {
+		t.Fatalf("expected no stale rules without traffic, got %v", got)
+	}
+
+	performRequest(h, http.MethodGet, "/hot", nil, nil)
+	performRequest(h, http.MethodGet, "/quiet", nil, nil)
+	now = now.Add(24 * time.Hour)
+	performRequest(h, http.MethodGet, "/hot", nil, nil)
+	if got := stale(); got["GET /hot"] || !got["GET /cold"] || got["GET /quiet"] {
+		t.Fatalf("expected only /cold to be stale, got %v", got)
+	}
+	for _, rf := range h.Freshness() {
+		if rf.Rule == "GET /cold" && (rf.IdleDays != 11 || rf.LastMatched != nil || rf.Hash == "") {
+			t.Fatalf("unexpected freshness %+v", rf)
+		}
+	}
+
+	// Editing a rule tracks it afresh, while unchanged rules keep their history
+	if err := h.Reload(parse("v2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stale(); got["GET /cold"] {
+		t.Fatalf("expected the edited rule to be fresh, got %v", got)
+	}
+	now = now.Add(7 * 24 * time.Hour)
+	performRequest(h, http.MethodGet, "/hot", nil, nil)
+	if got := stale(); !got["GET /cold"] || !got["GET /quiet"] {
+		t.Fatalf("expected /cold and /quiet to be stale, got %v", got)
+	}
+}
+
+func TestFreshness_NotConfigured(t *testing.T) {
+	cfg, err := config.Parse([]byte("requests:\n  - path: /a\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := NewMockHandler(cfg).Freshness(); got != nil {
+		t.Fatalf("expected no report, got %v", got)
+	}
+}
+
+func TestFreshness_ConfigDirModTimes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]time.Time{
+		"a.yaml": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"b.yaml": time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for name, modTime := range files {
+		path := filepath.Join(dir, name)
+		content := "requests:\n  - path: /" + name[:1] + "\n"
+		if name == "a.yaml" {
+			content = "server:\n  freshness: {}\n" + content
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := config.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, rf := range NewMockHandler(cfg).Freshness() {
+		want := files[rf.Rule[len("GET /"):]+".yaml"]
+		if !rf.ChangedAt.Equal(want) {
+			t.Errorf("expected %s to change at %s, got %s", rf.Rule, want, rf.ChangedAt)
+		}
+	}
+}
+
+func TestFreshness_StateKey(t *testing.T) {
+	dir := t.TempDir()
+	parse := func(body string) *config.Config {
+		cfg, err := config.Parse([]byte(`
+server:
+  storage: {dir: `+dir+`}
+  freshness: {stateKey: freshness.json}
+requests:
+  - path: /a
+  - path: /b
+    response:
+      body: `+body+`
+`), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cfg
+	}
+	h := NewMockHandler(parse("v1"))
+	performRequest(h, http.MethodGet, "/a", nil, nil)
+	performRequest(h, http.MethodGet, "/b", nil, nil)
+	saved := h.Freshness()
+
+	// The first request schedules a background save, which a restart picks up. It
+	// keeps the history of unchanged rules and starts edited ones over.
+	var got []RuleFreshness
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if got = NewMockHandler(parse("v2")).Freshness(); got[0].LastMatched != nil {
+			break
+		}
+	}
+	if got[0].LastMatched == nil || !got[0].LastMatched.Equal(*saved[0].LastMatched) || !got[0].TrackedAt.Equal(saved[0].TrackedAt) {
+		t.Fatalf("expected /a to keep its history %+v, got %+v", saved[0], got[0])
+	}
+	if got[1].LastMatched != nil {
+		t.Errorf("expected the edited /b to start over, got %+v", got[1])
+	}
+
+	// A save on shutdown includes every match
+	if err := h.SaveFreshness(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got = NewMockHandler(parse("v1")).Freshness(); got[1].LastMatched == nil || !got[1].LastMatched.Equal(*saved[1].LastMatched) {
+		t.Errorf("expected /b to keep its history %+v, got %+v", saved[1], got[1])
+	}
+}
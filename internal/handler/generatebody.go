@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+
+	"http-mock-server/internal/config"
+)
+
+const loremIpsum = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor " +
+	"incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud " +
+	"exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat.\n"
+
+// generatedBody returns a reader of length bytes of the generated body, starting at
+// offset. A random body with ranges is the same on every request, so the parts of a
+// resumed download fit together; otherwise each request gets bytes of its own.
+func (h *MockHandler) generatedBody(spec *config.ResponseSpec, offset, length int64) io.Reader {
+	gb := spec.GenerateBody
+	var src io.Reader
+	switch gb.Pattern {
+	case config.GeneratePatternZeros:
+		src = zeroReader{}
+	case config.GeneratePatternLorem:
+		src = &repeatReader{text: loremIpsum, off: int(offset % int64(len(loremIpsum)))}
+	default:
+		seed := int64(gb.SizeBytes)
+		if !spec.Ranges {
+			h.randMu.Lock()
+			seed = h.rand.Int63()
+			h.randMu.Unlock()
+		}
+		src = newSeededReader(seed, offset)
+	}
+	return io.LimitReader(src, length)
+}
+
+// generatedBodyHeaders declares the length of a generated body, or of the part sent,
+// and a Content-Type unless the rule sets one
+func generatedBodyHeaders(header http.Header, gb *config.GenerateBody, length int64, trailers bool) {
+	if header.Get("Content-Type") == "" {
+		if gb.Pattern == config.GeneratePatternLorem {
+			header.Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+	}
+	if !trailers {
+		header.Set("Content-Length", strconv.FormatInt(length, 10))
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// repeatReader reads text over and over
+type repeatReader struct {
+	text string
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.text[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.text)
+	}
+	return n, nil
+}
+
+// seededBlockSize is how many random bytes are derived from each block's seed
+const seededBlockSize = 64 * 1024
+
+// seededReader reads random bytes that depend only on its seed and position, so a
+// body can be read from any offset. Each block has a source seeded from the block number.
+type seededReader struct {
+	seed  int64
+	off   int64
+	block int64 // Number of the block in buf, -1 before the first read
+	buf   []byte
+}
+
+func newSeededReader(seed, offset int64) *seededReader {
+	return &seededReader{seed: seed, off: offset, block: -1, buf: make([]byte, seededBlockSize)}
+}
+
+func (s *seededReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if block := s.off / seededBlockSize; block != s.block {
+			rand.New(rand.NewSource(s.seed ^ block*0x5851f42d4c957f2d)).Read(s.buf) //nolint:staticcheck // math/rand Read is fine for non-crypto use
+			s.block = block
+		}
+		c := copy(p[n:], s.buf[s.off%seededBlockSize:])
+		n += c
+		s.off += int64(c)
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_GenerateBody(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /random
+    response:
+      generateBody: {sizeBytes: 1048577}
+  - path: /zeros
+    response:
+      headers:
+        Content-Type: application/zip
+      generateBody: {sizeBytes: 70000, pattern: zeros}
+  - path: /lorem
+    response:
+      generateBody: {sizeBytes: 300, pattern: lorem}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/random", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 1048577 || rr.Header().Get("Content-Length") != "1048577" ||
+		rr.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("unexpected random response %d, %d bytes, %v", rr.Code, rr.Body.Len(), rr.Header())
+	}
+	first := rr.Body.Bytes()
+	if bytes.Equal(first[:64], make([]byte, 64)) {
+		t.Fatalf("expected random bytes, got zeros")
+	}
+	if again := performRequest(h, http.MethodGet, "/random", nil, nil); bytes.Equal(again.Body.Bytes(), first) {
+		t.Fatalf("expected each request to generate a different body")
+	}
+
+	rr = performRequest(h, http.MethodGet, "/zeros", nil, nil)
+	if !bytes.Equal(rr.Body.Bytes(), make([]byte, 70000)) || rr.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("unexpected zeros response, %d bytes, %v", rr.Body.Len(), rr.Header())
+	}
+
+	rr = performRequest(h, http.MethodGet, "/lorem", nil, nil)
+	if body := rr.Body.String(); len(body) != 300 || !strings.HasPrefix(body, "Lorem ipsum") ||
+		!strings.Contains(body[200:], "Lorem ipsum") || rr.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("unexpected lorem response %q, %v", body, rr.Header())
+	}
+
+	// HEAD requests get the size without generating anything
+	rr = performRequest(h, http.MethodHead, "/random", nil, nil)
+	if rr.Body.Len() != 0 || rr.Header().Get("Content-Length") != "1048577" {
+		t.Fatalf("unexpected HEAD response %d bytes, %v", rr.Body.Len(), rr.Header())
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// GoldenMismatch describes a response body that differs from its golden file
+type GoldenMismatch struct {
+	Rule     string    `json:"rule"`
+	File     string    `json:"file"`
+	Time     time.Time `json:"time"`
+	Reason   string    `json:"reason"`
+	Line     int       `json:"line,omitempty"` // First line that differs, counting from 1
+	Expected string    `json:"expected"`       // That line in the golden file
+	Actual   string    `json:"actual"`         // That line in the response body
+}
+
+// goldenStore keeps the latest mismatch of each golden file. A matching response
+// clears the file's mismatch. It outlives configuration reloads.
+type goldenStore struct {
+	mu         sync.Mutex
+	update     bool // Write rendered bodies to the golden files instead of comparing
+	mismatches map[string]GoldenMismatch
+}
+
+func newGoldenStore() *goldenStore {
+	return &goldenStore{mismatches: make(map[string]GoldenMismatch)}
+}
+
+// checkGolden compares a rendered body with the response's golden file, or writes it
+// to the file in update mode
+func (h *MockHandler) checkGolden(rs *ruleSet, rule *config.RequestRule, spec *config.ResponseSpec, body []byte) {
+	g := h.golden
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	path, key := spec.Golden, rs.ruleKey(rule)
+	if g.update {
+		if err := writeGolden(path, body); err != nil {
+			log.Printf("WARNING: rule %s: could not update golden file %s: %v", key, path, err)
+			return
+		}
+		delete(g.mismatches, path)
+		log.Printf("Updated golden file %s from rule %s", path, key)
+		return
+	}
+
+	m := GoldenMismatch{Rule: key, File: path, Time: h.now()}
+	want, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		m.Reason = "golden file does not exist; run with --update-golden to create it"
+	case err != nil:
+		m.Reason = err.Error()
+	case bytes.Equal(want, body):
+		delete(g.mismatches, path)
+		return
+	default:
+		m.Reason = "body differs from golden file"
+		m.Line, m.Expected, m.Actual = firstDifferentLine(want, body)
+	}
+	if _, seen := g.mismatches[path]; !seen {
+		log.Printf("WARNING: rule %s: %s (%s)", key, m.Reason, path)
+	}
+	g.mismatches[path] = m
+}
+
+func writeGolden(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, body, 0o644)
+}
+
+// firstDifferentLine returns the number of the first line that differs between two
+// bodies, and that line in each. A body that runs out of lines gives an empty line.
+func firstDifferentLine(want, got []byte) (int, string, string) {
+	wantLines, gotLines := bytes.Split(want, []byte("\n")), bytes.Split(got, []byte("\n"))
+	for i := 0; ; i++ {
+		var w, g []byte
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if !bytes.Equal(w, g) || i >= len(wantLines) || i >= len(gotLines) {
+			return i + 1, string(w), string(g)
+		}
+	}
+}
+
+// UpdateGolden makes responses with a golden file write their rendered body to it
+// instead of comparing. It must be called before the server starts.
+func (h *MockHandler) UpdateGolden() {
+	h.golden.mu.Lock()
+	defer h.golden.mu.Unlock()
+	h.golden.update = true
+}
+
+// GoldenMismatches returns the golden files the latest response didn't match, by file name
+func (h *MockHandler) GoldenMismatches() []GoldenMismatch {
+	h.golden.mu.Lock()
+	defer h.golden.mu.Unlock()
+	out := make([]GoldenMismatch, 0, len(h.golden.mismatches))
+	for _, m := range h.golden.mismatches {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].File < out[j].File })
+	return out
+}
+
+// GoldenUpdating reports whether golden files are being written instead of compared
+func (h *MockHandler) GoldenUpdating() bool {
+	h.golden.mu.Lock()
+	defer h.golden.mu.Unlock()
+	return h.golden.update
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestGolden(t *testing.T) {
+	dir := t.TempDir()
+	golden := filepath.Join(dir, "golden", "order.json")
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders/{id}
+    response:
+      template: true
+      golden: `+golden+`
+      body: "{\n  \"id\": \"{{ .Path.id }}\"\n}\n"
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/orders/1", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the response to be served, got %d", rr.Code)
+	}
+	if m := h.GoldenMismatches(); len(m) != 1 || m[0].Rule != "GET /orders/{id}" || m[0].Line != 0 {
+		t.Fatalf("expected a missing golden file to be reported, got %+v", m)
+	}
+
+	h.UpdateGolden()
+	performRequest(h, http.MethodGet, "/orders/1", nil, nil)
+	if data, err := os.ReadFile(golden); err != nil || string(data) != "{\n  \"id\": \"1\"\n}\n" {
+		t.Fatalf("expected the golden file to be written, got %q %v", data, err)
+	}
+	if m := h.GoldenMismatches(); len(m) != 0 {
+		t.Fatalf("expected no mismatches after updating, got %+v", m)
+	}
+
+	h.golden.update = false
+	performRequest(h, http.MethodGet, "/orders/2", nil, nil)
+	m := h.GoldenMismatches()
+	if len(m) != 1 || m[0].Line != 2 || m[0].Expected != `  "id": "1"` || m[0].Actual != `  "id": "2"` {
+		t.Fatalf("unexpected mismatch %+v", m)
+	}
+	performRequest(h, http.MethodGet, "/orders/1", nil, nil)
+	if m := h.GoldenMismatches(); len(m) != 0 {
+		t.Fatalf("expected a matching response to clear the mismatch, got %+v", m)
+	}
+}
+
+func TestFirstDifferentLine(t *testing.T) {
+	tests := []struct {
+		want, got string
+		line      int
+		expected  string
+		actual    string
+	}{
+		{"a\nb\n", "a\nc\n", 2, "b", "c"},
+		{"a\nb", "a", 2, "b", ""},
+		{"a\n", "a", 2, "", ""},
+		{"x", "y", 1, "x", "y"},
+	}
+	for _, tt := range tests {
+		line, expected, actual := firstDifferentLine([]byte(tt.want), []byte(tt.got))
+		if line != tt.line || expected != tt.expected || actual != tt.actual {
+			t.Errorf("%q vs %q: got %d %q %q", tt.want, tt.got, line, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"http-mock-server/internal/config"
+)
+
+// matchesGroups evaluates the anyOf, allOf, and not combinators
+func (rs *ruleSet) matchesGroups(g *config.MatcherGroups, r *http.Request, full bool) bool {
+	for i := range g.AllOf {
+		if !rs.matchesMatcher(&g.AllOf[i], r, full) {
+			return false
+		}
+	}
+	if len(g.AnyOf) > 0 {
+		matched := false
+		for i := range g.AnyOf {
+			if rs.matchesMatcher(&g.AnyOf[i], r, full) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if g.Not != nil && rs.matchesMatcher(g.Not, r, full) {
+		return false
+	}
+	return true
+}
+
+// matchesMatcher reports whether every condition set in m matches the request
+func (rs *ruleSet) matchesMatcher(m *config.Matcher, r *http.Request, full bool) bool {
+	return rs.matchesHeaders(m.Headers, r.Header, full) &&
+		rs.matchesQueryParams(m.QueryParams, r.URL.Query(), full) &&
+		rs.matchesBody(m.Body, r, full) &&
+		rs.matchesWhen(m.When, r) &&
+		rs.matchesGroups(&m.Groups, r, full)
+}
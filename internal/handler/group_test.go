@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_MatcherGroups(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /items
+    anyOf:
+      - headers: {X-Tenant: .+}
+      - queryParams: {tenant: .+}
+    not:
+      allOf:
+        - headers: {X-Tenant: blocked}
+        - when: "query('debug') == '1'"
+    response:
+      status-code: 200
+  - path: /items
+    response:
+      status-code: 400
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "header", path: "/items", headers: map[string]string{"X-Tenant": "acme"}, want: http.StatusOK},
+		{name: "query", path: "/items?tenant=acme", want: http.StatusOK},
+		{name: "neither", path: "/items", want: http.StatusBadRequest},
+		{name: "not partially matched", path: "/items?debug=1", headers: map[string]string{"X-Tenant": "acme"}, want: http.StatusOK},
+		{name: "not matched", path: "/items?debug=1", headers: map[string]string{"X-Tenant": "blocked"}, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(h, http.MethodGet, tt.path, tt.headers, nil)
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
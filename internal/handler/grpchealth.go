@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"http-mock-server/internal/config"
+)
+
+// GRPCHealthPrefix is the URL prefix of the grpc.health.v1.Health service
+const GRPCHealthPrefix = "/grpc.health.v1.Health/"
+
+// HealthCheckResponse.ServingStatus values
+const (
+	servingStatusUnknown        = 0
+	servingStatusServing        = 1
+	servingStatusNotServing     = 2
+	servingStatusServiceUnknown = 3 // Only sent by Watch
+)
+
+// gRPC status codes used by the health service
+const (
+	grpcOK            = 0
+	grpcInvalidArg    = 3
+	grpcNotFound      = 5
+	grpcUnimplemented = 12
+)
+
+// maxHealthRequestBytes bounds the HealthCheckRequest message, which only names a service
+const maxHealthRequestBytes = 64 * 1024
+
+// healthStore holds the serving status of each service and wakes watchers on changes.
+// It outlives configuration reloads, so statuses set at runtime stick.
+type healthStore struct {
+	mu       sync.Mutex
+	statuses map[string]string
+	changed  chan struct{} // Closed and replaced on every change
+}
+
+func newHealthStore(g *config.GRPCHealth) *healthStore {
+	s := &healthStore{statuses: make(map[string]string), changed: make(chan struct{})}
+	if g != nil {
+		for service, status := range g.Services {
+			s.statuses[service] = status
+		}
+	}
+	return s
+}
+
+// status returns the service's status and a channel closed on the next change
+func (s *healthStore) status(service string) (string, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[service]
+	return status, ok, s.changed
+}
+
+// GRPCHealth returns the serving status of every service, keyed by service name
+func (h *MockHandler) GRPCHealth() map[string]string {
+	s := h.health
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make(map[string]string, len(s.statuses))
+	for service, status := range s.statuses {
+		statuses[service] = status
+	}
+	return statuses
+}
+
+// SetGRPCHealth changes the serving status of a service, adding it if needed, and
+// notifies clients watching it
+func (h *MockHandler) SetGRPCHealth(service, status string) error {
+	if !config.ValidHealthStatus(status) {
+		return fmt.Errorf("status must be SERVING or NOT_SERVING")
+	}
+	s := h.health
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[service] = status
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return nil
+}
+
+// GRPCHealthHandler serves the Check and Watch methods of grpc.health.v1.Health.
+// gRPC needs HTTP/2, which the server offers over TLS and, from Go 1.24, over
+// plaintext connections with prior knowledge (h2c).
+func (h *MockHandler) GRPCHealthHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+				http.Error(w, "gRPC requests must be POST with Content-Type application/grpc", http.StatusUnsupportedMediaType)
+				return
+			}
+			w.Header().Set("Content-Type", "application/grpc")
+			service, err := readHealthRequest(r.Body)
+			if err != nil {
+				writeGRPCStatus(w, grpcInvalidArg, err.Error())
+				return
+			}
+			switch strings.TrimPrefix(r.URL.Path, GRPCHealthPrefix) {
+			case "Check":
+				h.healthCheck(w, service)
+			case "Watch":
+				h.healthWatch(w, r, service)
+			default:
+				writeGRPCStatus(w, grpcUnimplemented, "unknown method "+r.URL.Path)
+			}
+		},
+	)
+}
+
+func (h *MockHandler) healthCheck(w http.ResponseWriter, service string) {
+	status, ok, _ := h.health.status(service)
+	if !ok {
+		writeGRPCStatus(w, grpcNotFound, "unknown service "+service)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(healthResponse(servingStatus(status, ok)))
+	writeGRPCStatus(w, grpcOK, "")
+}
+
+// healthWatch streams the service's status, then every change, until the client goes away
+func (h *MockHandler) healthWatch(w http.ResponseWriter, r *http.Request, service string) {
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	last := -1
+	for {
+		status, ok, changed := h.health.status(service)
+		if s := servingStatus(status, ok); s != last {
+			if _, err := w.Write(healthResponse(s)); err != nil {
+				return
+			}
+			_ = rc.Flush()
+			last = s
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func servingStatus(status string, ok bool) int {
+	switch {
+	case !ok:
+		return servingStatusServiceUnknown
+	case status == config.HealthServing:
+		return servingStatusServing
+	case status == config.HealthNotServing:
+		return servingStatusNotServing
+	default:
+		return servingStatusUnknown
+	}
+}
+
+// writeGRPCStatus ends the call with a gRPC status in the trailers
+func writeGRPCStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	}
+}
+
+// readHealthRequest reads the length-prefixed HealthCheckRequest message and
+// returns its service field
+func readHealthRequest(body io.Reader) (string, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		return "", fmt.Errorf("missing request message")
+	}
+	if prefix[0] != 0 {
+		return "", fmt.Errorf("compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > maxHealthRequestBytes {
+		return "", fmt.Errorf("request message too large")
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		return "", fmt.Errorf("truncated request message")
+	}
+	return parseHealthRequest(msg)
+}
+
+var errMalformedMessage = errors.New("malformed request message")
+
+// parseHealthRequest decodes the protobuf HealthCheckRequest { string service = 1; },
+// skipping unknown fields
+func parseHealthRequest(msg []byte) (string, error) {
+	service := ""
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return "", errMalformedMessage
+		}
+		msg = msg[n:]
+		switch tag & 7 {
+		case 0: // Varint
+			if _, n = binary.Uvarint(msg); n <= 0 {
+				return "", errMalformedMessage
+			}
+			msg = msg[n:]
+		case 1: // 64-bit
+			if len(msg) < 8 {
+				return "", errMalformedMessage
+			}
+			msg = msg[8:]
+		case 2: // Length-delimited
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < size {
+				return "", errMalformedMessage
+			}
+			if tag>>3 == 1 {
+				service = string(msg[n : n+int(size)])
+			}
+			msg = msg[n+int(size):]
+		case 5: // 32-bit
+			if len(msg) < 4 {
+				return "", errMalformedMessage
+			}
+			msg = msg[4:]
+		default:
+			return "", errMalformedMessage
+		}
+	}
+	return service, nil
+}
+
+// healthResponse encodes a length-prefixed HealthCheckResponse { ServingStatus status = 1; }
+func healthResponse(status int) []byte {
+	return []byte{0, 0, 0, 0, 2, 0x08, byte(status)}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+// healthRequest encodes a length-prefixed HealthCheckRequest for service
+func healthRequest(service string) []byte {
+	msg := append([]byte{0x0a, byte(len(service))}, service...)
+	return append([]byte{0, 0, 0, 0, byte(len(msg))}, msg...)
+}
+
+func newHealthServer(t *testing.T) (*MockHandler, *httptest.Server) {
+	t.Helper()
+	cfg, err := config.Parse([]byte(`
+server:
+  grpcHealth:
+    services:
+      orders.v1.Orders: NOT_SERVING
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	srv := httptest.NewUnstartedServer(h.GRPCHealthHandler())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return h, srv
+}
+
+func callHealth(t *testing.T, srv *httptest.Server, method, service string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+GRPCHealthPrefix+method, bytes.NewReader(healthRequest(service)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+	return resp
+}
+
+func TestGRPCHealth_Check(t *testing.T) {
+	_, srv := newHealthServer(t)
+
+	tests := []struct {
+		service    string
+		wantBody   []byte
+		wantStatus string
+	}{
+		{"", healthResponse(servingStatusServing), "0"},
+		{"orders.v1.Orders", healthResponse(servingStatusNotServing), "0"},
+		{"missing.v1.Missing", nil, "5"},
+	}
+	for _, tt := range tests {
+		resp := callHealth(t, srv, "Check", tt.service)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !bytes.Equal(body, tt.wantBody) {
+			t.Fatalf("%q: expected body % x, got % x", tt.service, tt.wantBody, body)
+		}
+		if got := resp.Trailer.Get("Grpc-Status"); got != tt.wantStatus {
+			t.Fatalf("%q: expected grpc-status %s, got %q", tt.service, tt.wantStatus, got)
+		}
+	}
+}
+
+func TestGRPCHealth_WatchSeesChanges(t *testing.T) {
+	h, srv := newHealthServer(t)
+
+	resp := callHealth(t, srv, "Watch", "payments.v1.Payments")
+	defer resp.Body.Close()
+	read := func() []byte {
+		t.Helper()
+		msg := make([]byte, 7)
+		if _, err := io.ReadFull(resp.Body, msg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return msg
+	}
+
+	if msg := read(); !bytes.Equal(msg, healthResponse(servingStatusServiceUnknown)) {
+		t.Fatalf("expected SERVICE_UNKNOWN, got % x", msg)
+	}
+	if err := h.SetGRPCHealth("payments.v1.Payments", config.HealthServing); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg := read(); !bytes.Equal(msg, healthResponse(servingStatusServing)) {
+		t.Fatalf("expected SERVING, got % x", msg)
+	}
+	// Changes to other services don't produce messages
+	_ = h.SetGRPCHealth("orders.v1.Orders", config.HealthServing)
+	_ = h.SetGRPCHealth("payments.v1.Payments", config.HealthNotServing)
+	if msg := read(); !bytes.Equal(msg, healthResponse(servingStatusNotServing)) {
+		t.Fatalf("expected NOT_SERVING, got % x", msg)
+	}
+}
+
+func TestParseHealthRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", msg: nil, want: ""},
+		{name: "service", msg: []byte{0x0a, 0x03, 'a', 'p', 'i'}, want: "api"},
+		{name: "unknown fields", msg: []byte{0x10, 0x96, 0x01, 0x0a, 0x01, 'x', 0x1d, 1, 2, 3, 4}, want: "x"},
+		{name: "truncated", msg: []byte{0x0a, 0x05, 'a'}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseHealthRequest(tt.msg)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("%s: expected %q (error %v), got %q, %v", tt.name, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
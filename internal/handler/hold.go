@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// HeldRequest is a request to a rule with hold set, waiting for the operator to
+// choose its response in interactive mode
+type HeldRequest struct {
+	ID      int
+	Time    time.Time
+	Method  string
+	URL     string
+	Rule    string
+	Header  http.Header
+	Body    string
+	Choices []string // Descriptions of the responses the operator can pick, numbered from 1
+
+	specs    []*config.ResponseSpec
+	decision chan holdDecision
+}
+
+// holdDecision is the operator's answer to a held request
+type holdDecision struct {
+	spec   *config.ResponseSpec // Nil to choose the response as usual
+	status int                  // Replaces the response's status code when not 0
+}
+
+// ObservedRequest describes a request the mock answered, for the interactive console
+type ObservedRequest struct {
+	Method   string
+	URL      string
+	Rule     string // Empty when no rule matched
+	Status   int
+	Duration time.Duration
+}
+
+// holdQueue keeps requests to rules with hold set until the operator releases them.
+// Holding is off unless interactive mode enables it.
+type holdQueue struct {
+	mu      sync.Mutex
+	enabled bool
+	notify  func(HeldRequest)
+	nextID  int
+	pending map[int]*HeldRequest
+}
+
+func newHoldQueue() *holdQueue {
+	return &holdQueue{pending: make(map[int]*HeldRequest)}
+}
+
+// EnableInteractive turns on holding for rules with hold set and reports requests to the
+// console: held is called when a request starts waiting and observe after every request.
+// It must be called before the server starts.
+func (h *MockHandler) EnableInteractive(held func(HeldRequest), observe func(ObservedRequest)) {
+	h.holds.mu.Lock()
+	defer h.holds.mu.Unlock()
+	h.holds.enabled = true
+	h.holds.notify = held
+	h.observe = observe
+}
+
+// HeldRequests returns the requests waiting for the operator, oldest first
+func (h *MockHandler) HeldRequests() []HeldRequest {
+	q := h.holds
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	held := make([]HeldRequest, 0, len(q.pending))
+	for _, hr := range q.pending {
+		held = append(held, *hr)
+	}
+	sort.Slice(held, func(i, j int) bool { return held[i].ID < held[j].ID })
+	return held
+}
+
+// Release answers a held request with the response numbered choice in its Choices,
+// or as usual when choice is 0, and with status instead of the response's status
+// code when status is not 0
+func (h *MockHandler) Release(id, choice, status int) error {
+	q := h.holds
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	hr, ok := q.pending[id]
+	if !ok {
+		return fmt.Errorf("no held request %d", id)
+	}
+	if choice < 0 || choice > len(hr.specs) {
+		return fmt.Errorf("choice must be between 1 and %d", len(hr.specs))
+	}
+	if status != 0 && (status < 100 || status > 599) {
+		return fmt.Errorf("invalid status code %d", status)
+	}
+	d := holdDecision{status: status}
+	if choice > 0 {
+		d.spec = hr.specs[choice-1]
+	}
+	delete(q.pending, id)
+	hr.decision <- d
+	return nil
+}
+
+// hold waits for the operator to release the request, returning at once when holding
+// is off. It returns false when the client went away first.
+func (h *MockHandler) hold(r *http.Request, rs *ruleSet, rule *config.RequestRule) (holdDecision, bool) {
+	q := h.holds
+	body, _ := requestBody(r)
+	q.mu.Lock()
+	if !q.enabled {
+		q.mu.Unlock()
+		return holdDecision{}, true
+	}
+	q.nextID++
+	hr := &HeldRequest{
+		ID:       q.nextID,
+		Time:     h.now(),
+		Method:   r.Method,
+		URL:      r.URL.RequestURI(),
+		Rule:     rs.ruleKey(rule),
+		Header:   r.Header.Clone(),
+		Body:     journalBody(body),
+		specs:    rule.AllResponses(),
+		decision: make(chan holdDecision, 1),
+	}
+	for _, spec := range hr.specs {
+		hr.Choices = append(hr.Choices, describeResponse(spec))
+	}
+	q.pending[hr.ID] = hr
+	notify := q.notify
+	q.mu.Unlock()
+
+	if notify != nil {
+		notify(*hr)
+	}
+	select {
+	case d := <-hr.decision:
+		return d, true
+	case <-r.Context().Done():
+		q.mu.Lock()
+		delete(q.pending, hr.ID)
+		q.mu.Unlock()
+		return holdDecision{}, false
+	}
+}
+
+// describeResponse summarizes a response for the operator
+func describeResponse(spec *config.ResponseSpec) string {
+	parts := []string{fmt.Sprint(spec.StatusCode)}
+	if spec.Status.Template != "" {
+		parts[0] = spec.Status.Template
+	}
+	if ct := spec.Headers["Content-Type"]; ct != "" {
+		parts = append(parts, ct)
+	}
+	switch {
+	case spec.Fault != "":
+		parts = append(parts, "fault "+spec.Fault)
+	case spec.Proxy != nil:
+		parts = []string{"proxy " + spec.Proxy.Target}
+	case spec.Transformer != nil:
+		parts = append(parts, "transformer "+spec.Transformer.Symbol)
+	case spec.Mode != "":
+		parts = append(parts, spec.Mode)
+	case spec.Redirect != nil:
+		parts = append(parts, "redirect "+spec.Redirect.Describe())
+	case spec.SSE != nil:
+		parts = append(parts, fmt.Sprintf("%d events", len(spec.SSE.Events)))
+	case spec.BodyFile != "":
+		parts = append(parts, "file "+spec.BodyFile)
+	case spec.Dataset != "":
+		parts = append(parts, "dataset "+spec.Dataset)
+	case spec.RandomBody != nil:
+		parts = append(parts, "random "+spec.RandomBody.Type)
+	case spec.SchemaBody != nil:
+		parts = append(parts, "schema "+spec.SchemaBody.Schema)
+	case spec.GenerateBody != nil:
+		parts = append(parts, fmt.Sprintf("generated %d bytes of %s", spec.GenerateBody.SizeBytes, spec.GenerateBody.Pattern))
+	case spec.BodyFormat == config.BodyFormatXML:
+		parts = append(parts, "xml body")
+	case spec.BodyBytes != nil:
+		parts = append(parts, fmt.Sprintf("%d bytes", len(spec.BodyBytes)))
+	case spec.Body != nil:
+		if s, ok := spec.Body.(string); ok && len(s) <= 40 {
+			parts = append(parts, fmt.Sprintf("%q", s))
+		} else {
+			parts = append(parts, "body")
+		}
+	}
+	if len(spec.Variants) > 0 {
+		parts = append(parts, fmt.Sprintf("%d variants", len(spec.Variants)))
+	}
+	return strings.Join(parts, ", ")
+}
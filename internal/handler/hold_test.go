@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const holdConfig = `
+requests:
+  - path: /pay
+    method: POST
+    hold: true
+    responses:
+      - status-code: 201
+        body: created
+      - status-code: 402
+        body: declined
+`
+
+func TestHold_Release(t *testing.T) {
+	h := newTestHandler(t, holdConfig)
+	held := make(chan HeldRequest, 1)
+	var observed []ObservedRequest
+	h.EnableInteractive(func(hr HeldRequest) { held <- hr }, func(o ObservedRequest) { observed = append(observed, o) })
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- performRequest(h, http.MethodPost, "/pay", nil, []byte("amount=5")) }()
+
+	hr := <-held
+	if hr.ID != 1 || hr.Body != "amount=5" || len(hr.Choices) != 2 || hr.Choices[1] != `402, "declined"` {
+		t.Fatalf("unexpected held request %+v", hr)
+	}
+	if got := h.HeldRequests(); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("expected 1 held request, got %+v", got)
+	}
+	if err := h.Release(1, 3, 0); err == nil {
+		t.Fatal("expected an out of range choice to fail")
+	}
+	if err := h.Release(1, 0, 42); err == nil {
+		t.Fatal("expected an invalid status to fail")
+	}
+	if err := h.Release(1, 2, http.StatusTeapot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rr := <-done
+	if rr.Code != http.StatusTeapot || rr.Body.String() != "declined" {
+		t.Fatalf("expected the chosen response with the edited status, got %d %q", rr.Code, rr.Body.String())
+	}
+	if len(h.HeldRequests()) != 0 {
+		t.Fatal("expected no held requests after release")
+	}
+	if len(observed) != 1 || observed[0].Status != http.StatusTeapot || observed[0].Rule != "POST /pay" {
+		t.Fatalf("unexpected observed requests %+v", observed)
+	}
+}
+
+func TestHold_ClientGone(t *testing.T) {
+	h := newTestHandler(t, holdConfig)
+	held := make(chan HeldRequest, 1)
+	h.EnableInteractive(func(hr HeldRequest) { held <- hr }, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/pay", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-held
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the request to stop waiting when the client goes away")
+	}
+	if len(h.HeldRequests()) != 0 {
+		t.Fatal("expected the abandoned request to leave the queue")
+	}
+}
+
+func TestHold_Disabled(t *testing.T) {
+	h := newTestHandler(t, holdConfig)
+	rr := performRequest(h, http.MethodPost, "/pay", nil, nil)
+	if rr.Code != http.StatusCreated && rr.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected hold to be ignored outside interactive mode, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// writeInterim sends the informational responses in order, waiting each one's delay
+// first. It reports false when the client went away while waiting. HTTP/1.0 clients
+// don't understand informational responses and get none.
+func writeInterim(w http.ResponseWriter, r *http.Request, interim []config.InterimResponse) bool {
+	if len(interim) == 0 || !r.ProtoAtLeast(1, 1) {
+		return true
+	}
+	for _, ir := range interim {
+		if ir.Delay > 0 {
+			select {
+			case <-time.After(time.Duration(ir.Delay) * time.Millisecond):
+			case <-r.Context().Done():
+				return false
+			}
+		}
+		// The final response carries only the headers configured for it
+		saved := w.Header().Clone()
+		setHeaders(w.Header(), ir.Headers)
+		w.WriteHeader(ir.StatusCode)
+		for name := range ir.Headers {
+			w.Header().Del(name)
+		}
+		for name, values := range saved {
+			w.Header()[name] = values
+		}
+	}
+	return true
+}
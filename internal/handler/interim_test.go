@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestInterimResponses(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /report
+    response:
+      status-code: 201
+      headers:
+        X-Final: "yes"
+      body: done
+      earlyHints:
+        - </report.css>; rel=preload
+      interim:
+        - status-code: 102
+        - status-code: 102
+          delay: 30
+        - status-code: 199
+          headers:
+            X-Progress: "90%"
+          delay: 20
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(NewMockHandler(cfg))
+	defer srv.Close()
+
+	var codes []int
+	var headers []textproto.MIMEHeader
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			codes = append(codes, code)
+			headers = append(headers, header)
+			return nil
+		},
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/report", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	start := time.Now()
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the interim delays to add up to 50ms, took %s", elapsed)
+	}
+	if len(codes) != 4 || codes[0] != http.StatusEarlyHints || codes[1] != http.StatusProcessing || codes[2] != http.StatusProcessing || codes[3] != 199 {
+		t.Fatalf("expected 103, 102, 102, 199, got %v", codes)
+	}
+	if headers[3].Get("X-Progress") != "90%" || headers[1].Get("X-Progress") != "" {
+		t.Fatalf("unexpected interim headers %v", headers)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Final") != "yes" || resp.Header.Get("X-Progress") != "" || resp.Header.Get("Link") != "" {
+		t.Fatalf("expected final 201 with only its own headers, got %d %v", resp.StatusCode, resp.Header)
+	}
+}
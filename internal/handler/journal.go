@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"http-mock-server/internal/config"
+)
+
+// journalBodyLimit is the most request body bytes kept per journal entry
+const journalBodyLimit = 64 * 1024
+
+// JournalEntry is a request recorded in the journal
+type JournalEntry struct {
+	ID         string              `json:"id"`
+	Time       time.Time           `json:"time"`
+	Client     string              `json:"client"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Query      map[string][]string `json:"query,omitempty"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body,omitempty"`
+	Rule       string              `json:"rule,omitempty"` // Empty when no rule matched
+	Status     int                 `json:"status"`
+	DurationMs int64               `json:"durationMs"`
+}
+
+// requestJournal keeps the most recent requests. It outlives configuration reloads.
+type requestJournal struct {
+	salt []byte // Keys export hashes when the configuration sets no salt
+
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+func newRequestJournal() *requestJournal {
+	salt := make([]byte, 32)
+	_, _ = rand.Read(salt)
+	return &requestJournal{salt: salt}
+}
+
+func (j *requestJournal) add(e JournalEntry, max int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, e)
+	if n := len(j.entries) - max; n > 0 {
+		j.entries = append([]JournalEntry(nil), j.entries[n:]...)
+	}
+}
+
+func (j *requestJournal) snapshot() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JournalEntry{}, j.entries...)
+}
+
+func (j *requestJournal) reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+}
+
+// record adds a served request to the journal
+func (j *requestJournal) record(cfg *config.Journal, r *http.Request, body []byte, rs *ruleSet, rule *config.RequestRule, status int, start time.Time, elapsed time.Duration) {
+	e := JournalEntry{
+		ID:         newDeliveryID(),
+		Time:       start,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Headers:    r.Header.Clone(),
+		Body:       journalBody(body),
+		Status:     status,
+		DurationMs: elapsed.Milliseconds(),
+	}
+	if addr := clientAddr(r); addr.IsValid() {
+		e.Client = addr.String()
+	}
+	if q := r.URL.Query(); len(q) > 0 {
+		e.Query = q
+	}
+	if rule != nil {
+		e.Rule = rs.ruleKey(rule)
+	}
+	j.add(e, cfg.MaxEntries)
+}
+
+// journalBody keeps text bodies up to journalBodyLimit and describes the rest
+func journalBody(body []byte) string {
+	switch {
+	case !utf8.Valid(body):
+		return fmt.Sprintf("(%d bytes of binary data)", len(body))
+	case len(body) > journalBodyLimit:
+		return fmt.Sprintf("(omitted, body exceeds %d bytes)", journalBodyLimit)
+	default:
+		return string(body)
+	}
+}
+
+// Journal returns the recorded requests, oldest first
+func (h *MockHandler) Journal() []JournalEntry {
+	return h.journal.snapshot()
+}
+
+// ResetJournal clears the request journal
+func (h *MockHandler) ResetJournal() {
+	h.journal.reset()
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const journalConfig = `
+server:
+  journal:
+    maxEntries: 2
+    export:
+      redactHeaders: [X-Session]
+      redactQueryParams: [token]
+      redactFields: [password]
+      hashFields: [email, userId]
+      hashPathIds: true
+      timeBucket: 1h
+      salt: pepper
+requests:
+  - name: create-user
+    path: /users/{id}
+    method: POST
+    response:
+      status-code: 201
+`
+
+// journalNow is the fixed clock of journal tests
+func journalNow() time.Time { return time.Date(2024, 5, 1, 10, 42, 7, 0, time.UTC) }
+
+func TestJournal_RecordsRequests(t *testing.T) {
+	h := newTestHandler(t, journalConfig)
+	h.now = journalNow
+	performRequest(h, http.MethodGet, "/missing", nil, nil)
+	performRequest(h, http.MethodPost, "/users/42?token=abc", map[string]string{"Authorization": "Bearer x"}, []byte(`{"password":"hunter2"}`))
+
+	entries := h.Journal()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Status != http.StatusNotFound || entries[0].Rule != "" {
+		t.Fatalf("expected an unmatched 404 entry, got %+v", entries[0])
+	}
+	e := entries[1]
+	if e.Status != http.StatusCreated || e.Rule != "create-user" || e.Path != "/users/42" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e.Body != `{"password":"hunter2"}` || e.Query["token"][0] != "abc" || e.Headers["Authorization"][0] != "Bearer x" {
+		t.Fatalf("expected the raw journal to keep the request as received, got %+v", e)
+	}
+
+	performRequest(h, http.MethodGet, "/third", nil, nil)
+	if entries := h.Journal(); len(entries) != 2 || entries[1].Path != "/third" {
+		t.Fatalf("expected maxEntries to keep the 2 most recent entries, got %+v", entries)
+	}
+	h.ResetJournal()
+	if len(h.Journal()) != 0 {
+		t.Fatal("expected reset to clear the journal")
+	}
+}
+
+func TestJournal_Export(t *testing.T) {
+	h := newTestHandler(t, journalConfig)
+	h.now = journalNow
+	headers := map[string]string{
+		"Authorization": "Bearer x",
+		"X-Session":     "s1",
+		"Content-Type":  "application/json",
+		"Accept":        "application/json",
+	}
+	performRequest(h, http.MethodPost, "/users/42?token=abc&userId=7&page=2", headers,
+		[]byte(`{"user":{"email":"a@example.com","password":"hunter2","age":30},"tags":[{"userId":7}]}`))
+	performRequest(h, http.MethodPost, "/users/42", map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		[]byte("email=a%40example.com&password=hunter2&plan=pro"))
+	performRequest(h, http.MethodPost, "/users/42", map[string]string{"Content-Type": "text/plain"}, []byte("call me on 555-0100"))
+
+	entries := h.ExportJournal()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	hashed := (&anonymizer{key: []byte("pepper")}).hash
+	form, text := entries[0], entries[1]
+
+	// maxEntries evicted the JSON request; it is exported on its own below
+	if form.Path != "/users/"+hashed("42") {
+		t.Fatalf("expected the path ID to be hashed, got %q", form.Path)
+	}
+	if !form.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the time to be bucketed to the hour, got %v", form.Time)
+	}
+	if form.Client == "" || form.Client == h.Journal()[0].Client {
+		t.Fatalf("expected the client address to be hashed, got %q", form.Client)
+	}
+	if want := "email=" + hashed("a@example.com") + "&password=%5BREDACTED%5D&plan=pro"; form.Body != want {
+		t.Fatalf("expected form body %q, got %q", want, form.Body)
+	}
+	if !strings.HasPrefix(text.Body, "(omitted, 19 bytes") {
+		t.Fatalf("expected the text body to be omitted, got %q", text.Body)
+	}
+	if raw := h.Journal()[1]; raw.Body != "call me on 555-0100" || raw.Path != "/users/42" {
+		t.Fatalf("expected export to leave the journal untouched, got %+v", raw)
+	}
+
+	h.ResetJournal()
+	performRequest(h, http.MethodPost, "/users/42?token=abc&userId=7&page=2", headers,
+		[]byte(`{"user":{"email":"a@example.com","password":"hunter2","age":30},"tags":[{"userId":7}]}`))
+	e := h.ExportJournal()[0]
+	if e.Headers["Authorization"][0] != redacted || e.Headers["X-Session"][0] != redacted || e.Headers["Accept"][0] != "application/json" {
+		t.Fatalf("unexpected exported headers %v", e.Headers)
+	}
+	if e.Query["token"][0] != redacted || e.Query["userId"][0] != hashed("7") || e.Query["page"][0] != "2" {
+		t.Fatalf("unexpected exported query %v", e.Query)
+	}
+	want := `{"tags":[{"userId":"` + hashed("7") + `"}],"user":{"age":30,"email":"` + hashed("a@example.com") + `","password":"[REDACTED]"}}`
+	if e.Body != want {
+		t.Fatalf("expected JSON body %s, got %s", want, e.Body)
+	}
+}
+
+func TestJournal_Disabled(t *testing.T) {
+	h := newTestHandler(t, "requests:\n  - path: /a\n    response:\n      status-code: 200\n")
+	h.now = journalNow
+	performRequest(h, http.MethodGet, "/a", nil, nil)
+	if len(h.Journal()) != 0 {
+		t.Fatal("expected no journal without server.journal")
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"http-mock-server/internal/config"
+)
+
+// matchesJSONBody compares the request body with the rule's jsonBody as JSON values
+func matchesJSONBody(rule *config.RequestRule, r *http.Request) bool {
+	if rule.JSONBody == nil {
+		return true
+	}
+	body, err := requestBody(r)
+	if err != nil {
+		return false
+	}
+	var actual interface{}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return false
+	}
+	return jsonEqual(rule.JSONBody, actual, rule.JSONBodyMatch)
+}
+
+// jsonEqual reports whether two decoded JSON values are equivalent under opts
+func jsonEqual(expected, actual interface{}, opts config.JSONMatchOptions) bool {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		return ok && objectsEqual(e, a, opts)
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(e) != len(a) {
+			return false
+		}
+		if opts.IgnoreArrayOrder {
+			return unorderedEqual(e, a, opts)
+		}
+		for i := range e {
+			if !jsonEqual(e[i], a[i], opts) {
+				return false
+			}
+		}
+		return true
+	case float64:
+		n, ok := jsonNumber(actual, opts)
+		return ok && n == e
+	case string:
+		if a, ok := actual.(string); ok {
+			return a == e
+		}
+		if _, isNum := actual.(float64); isNum && opts.NumericStrings {
+			n, ok := jsonNumber(e, opts)
+			return ok && n == actual
+		}
+		return false
+	default:
+		// bool and null
+		return expected == actual
+	}
+}
+
+func objectsEqual(expected, actual map[string]interface{}, opts config.JSONMatchOptions) bool {
+	for key, ev := range expected {
+		av, present := actual[key]
+		if !present {
+			if opts.NullEqualsAbsent && ev == nil {
+				continue
+			}
+			return false
+		}
+		if !jsonEqual(ev, av, opts) {
+			return false
+		}
+	}
+	for key, av := range actual {
+		if _, present := expected[key]; !present && !(opts.NullEqualsAbsent && av == nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// unorderedEqual pairs each expected element with a distinct equal actual element
+func unorderedEqual(expected, actual []interface{}, opts config.JSONMatchOptions) bool {
+	used := make([]bool, len(actual))
+	for _, ev := range expected {
+		found := false
+		for i, av := range actual {
+			if !used[i] && jsonEqual(ev, av, opts) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonNumber returns v as a number, parsing numeric strings when opts allow it
+func jsonNumber(v interface{}, opts config.JSONMatchOptions) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		if !opts.NumericStrings {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
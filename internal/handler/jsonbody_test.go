@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		opts     config.JSONMatchOptions
+		want     bool
+	}{
+		{name: "identical", expected: `{"a":1,"b":[1,2]}`, actual: `{"b":[1,2],"a":1}`, want: true},
+		{name: "numeric formats", expected: `{"a":1}`, actual: `{"a":1.0}`, want: true},
+		{name: "exponent", expected: `100`, actual: `1e2`, want: true},
+		{name: "different values", expected: `{"a":1}`, actual: `{"a":2}`},
+		{name: "extra field", expected: `{"a":1}`, actual: `{"a":1,"b":2}`},
+		{name: "numeric string strict", expected: `{"a":1}`, actual: `{"a":"1"}`},
+		{name: "numeric string coerced", expected: `{"a":1}`, actual: `{"a":"1.0"}`, opts: config.JSONMatchOptions{NumericStrings: true}, want: true},
+		{name: "numeric string coerced reverse", expected: `{"a":"1"}`, actual: `{"a":1}`, opts: config.JSONMatchOptions{NumericStrings: true}, want: true},
+		{name: "non-numeric string coerced", expected: `{"a":1}`, actual: `{"a":"one"}`, opts: config.JSONMatchOptions{NumericStrings: true}},
+		{name: "null vs absent strict", expected: `{"a":1,"b":null}`, actual: `{"a":1}`},
+		{name: "null vs absent", expected: `{"a":1,"b":null}`, actual: `{"a":1}`, opts: config.JSONMatchOptions{NullEqualsAbsent: true}, want: true},
+		{name: "absent vs null", expected: `{"a":1}`, actual: `{"a":1,"b":null}`, opts: config.JSONMatchOptions{NullEqualsAbsent: true}, want: true},
+		{name: "array order strict", expected: `[1,2,3]`, actual: `[3,2,1]`},
+		{name: "array order ignored", expected: `[1,2,{"x":true}]`, actual: `[{"x":true},2,1]`, opts: config.JSONMatchOptions{IgnoreArrayOrder: true}, want: true},
+		{name: "array duplicates", expected: `[1,1,2]`, actual: `[1,2,2]`, opts: config.JSONMatchOptions{IgnoreArrayOrder: true}},
+		{name: "type mismatch", expected: `true`, actual: `"true"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expected, actual interface{}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.actual), &actual); err != nil {
+				t.Fatal(err)
+			}
+			if got := jsonEqual(expected, actual, tt.opts); got != tt.want {
+				t.Fatalf("jsonEqual(%s, %s) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockHandler_JSONBody(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders
+    method: POST
+    jsonBody:
+      id: 7
+      tags: [a, b]
+      note: null
+    jsonBodyMatch:
+      numericStrings: true
+      nullEqualsAbsent: true
+      ignoreArrayOrder: true
+    response:
+      status-code: 201
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/orders", nil, []byte(`{"tags":["b","a"],"id":"7.0"}`))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/orders", nil, []byte(`{"tags":["a"],"id":7}`))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/orders", nil, []byte(`not json`))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// loadShedder enforces the server limits and counts what it rejected
+type loadShedder struct {
+	maxInFlight  int64
+	maxBodyBytes int64
+	retryAfter   string
+	overload     *overloadMonitor // Nil unless overload shedding is configured
+
+	inFlight      atomic.Int64
+	bodyBytes     atomic.Int64
+	peakInFlight  atomic.Int64
+	peakBodyBytes atomic.Int64
+	shedInFlight  atomic.Int64
+	shedBody      atomic.Int64
+	shedOverload  atomic.Int64
+}
+
+func newLoadShedder(l *config.Limits) *loadShedder {
+	s := &loadShedder{retryAfter: "1"}
+	if l != nil {
+		s.maxInFlight = int64(l.MaxInFlight)
+		s.maxBodyBytes = int64(l.MaxBodyBytes)
+		s.retryAfter = strconv.Itoa(l.RetryAfter)
+		if l.Overload != nil {
+			s.overload = newOverloadMonitor(l.Overload)
+		}
+	}
+	return s
+}
+
+// reserve adds n to counter unless that would exceed max (0 means unlimited)
+func reserve(counter, peak *atomic.Int64, n, max int64) bool {
+	for {
+		cur := counter.Load()
+		if max > 0 && cur+n > max {
+			return false
+		}
+		if counter.CompareAndSwap(cur, cur+n) {
+			raisePeak(peak, cur+n)
+			return true
+		}
+	}
+}
+
+func raisePeak(peak *atomic.Int64, v int64) {
+	for {
+		p := peak.Load()
+		if v <= p || peak.CompareAndSwap(p, v) {
+			return
+		}
+	}
+}
+
+// bufferBody reads the request body into memory within the body budget. It returns
+// the number of bytes reserved, which the caller must release.
+func (s *loadShedder) bufferBody(r *http.Request) (int64, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return 0, true, nil
+	}
+	remaining := s.maxBodyBytes - s.bodyBytes.Load()
+	if r.ContentLength > remaining {
+		return 0, false, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, remaining+1))
+	if err != nil {
+		return 0, true, err
+	}
+	n := int64(len(data))
+	if n > remaining || !reserve(&s.bodyBytes, &s.peakBodyBytes, n, s.maxBodyBytes) {
+		return 0, false, nil
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return n, true, nil
+}
+
+// bodyBudgetKey carries the body budget of a request from LimitsMiddleware to
+// DecompressionMiddleware, so decoded bodies count against maxBodyMemory too
+type bodyBudgetKey struct{}
+
+// bodyBudget tracks the decoded body bytes reserved for one request
+type bodyBudget struct {
+	shedder  *loadShedder
+	reserved int64
+}
+
+func requestBodyBudget(r *http.Request) *bodyBudget {
+	b, _ := r.Context().Value(bodyBudgetKey{}).(*bodyBudget)
+	return b
+}
+
+// read reads body into memory within the remaining budget. It reports false, having
+// read at most one byte past the budget, when the body doesn't fit.
+func (b *bodyBudget) read(body io.Reader) ([]byte, bool, error) {
+	s := b.shedder
+	remaining := s.maxBodyBytes - s.bodyBytes.Load()
+	if remaining < 0 {
+		remaining = 0
+	}
+	data, err := io.ReadAll(io.LimitReader(body, remaining+1))
+	if err != nil {
+		return nil, true, err
+	}
+	n := int64(len(data))
+	if n > remaining || !reserve(&s.bodyBytes, &s.peakBodyBytes, n, s.maxBodyBytes) {
+		return nil, false, nil
+	}
+	b.reserved += n
+	return data, true, nil
+}
+
+func (s *loadShedder) shed(w http.ResponseWriter, r *http.Request, status int, reason string) {
+	log.Printf("Shedding %s %s from %s: %s", r.Method, r.URL.Path, r.RemoteAddr, reason)
+	w.Header().Set("Retry-After", s.retryAfter)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// LimitsMiddleware rejects requests with 503 Service Unavailable while the server
+// limits are exhausted: too many requests in flight, or too much request body data
+// buffered. Bodies are buffered as received, and DecompressionMiddleware counts the
+// decoded body against the same budget, so a small compressed body can't expand past
+// it. While the server is overloaded, requests get the overload status instead.
+func (h *MockHandler) LimitsMiddleware(next http.Handler) http.Handler {
+	s := h.limits
+	if s.overload != nil {
+		s.overload.run()
+	}
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if o := s.overload; o != nil && o.overloaded.Load() {
+				s.shedOverload.Add(1)
+				s.shed(w, r, o.status, "server overloaded")
+				return
+			}
+			if !reserve(&s.inFlight, &s.peakInFlight, 1, s.maxInFlight) {
+				s.shedInFlight.Add(1)
+				s.shed(w, r, http.StatusServiceUnavailable, "too many requests in flight")
+				return
+			}
+			defer s.inFlight.Add(-1)
+
+			if s.maxBodyBytes > 0 {
+				n, ok, err := s.bufferBody(r)
+				if err != nil {
+					http.Error(w, "failed to read request body", http.StatusBadRequest)
+					return
+				}
+				if !ok {
+					s.shedBody.Add(1)
+					s.shed(w, r, http.StatusServiceUnavailable, "request body memory limit reached")
+					return
+				}
+				budget := &bodyBudget{shedder: s}
+				r = r.WithContext(context.WithValue(r.Context(), bodyBudgetKey{}, budget))
+				defer func() { s.bodyBytes.Add(-n - budget.reserved) }()
+			}
+
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// LoadStats reports current usage against the server limits. Limits of 0 are unlimited.
+type LoadStats struct {
+	InFlight       int64 `json:"inFlight"`
+	PeakInFlight   int64 `json:"peakInFlight"`
+	MaxInFlight    int64 `json:"maxInFlight"`
+	BodyBytes      int64 `json:"bodyBytes"`
+	PeakBodyBytes  int64 `json:"peakBodyBytes"`
+	MaxBodyBytes   int64 `json:"maxBodyBytes"`
+	ShedInFlight   int64 `json:"shedInFlight"`   // Requests rejected by maxInFlight
+	ShedBodyMemory int64 `json:"shedBodyMemory"` // Requests rejected by maxBodyMemory
+
+	// Reported when overload shedding is configured
+	Overloaded   *bool    `json:"overloaded,omitempty"`
+	CPU          *float64 `json:"cpu,omitempty"`   // Process CPU use as a fraction of the available cores
+	LagMs        *int64   `json:"lagMs,omitempty"` // How late the server's timers last fired
+	ShedOverload int64    `json:"shedOverload"`    // Requests rejected while overloaded
+}
+
+// LoadStats returns the usage and rejection counters since startup
+func (h *MockHandler) LoadStats() LoadStats {
+	s := h.limits
+	stats := LoadStats{
+		InFlight:       s.inFlight.Load(),
+		PeakInFlight:   s.peakInFlight.Load(),
+		MaxInFlight:    s.maxInFlight,
+		BodyBytes:      s.bodyBytes.Load(),
+		PeakBodyBytes:  s.peakBodyBytes.Load(),
+		MaxBodyBytes:   s.maxBodyBytes,
+		ShedInFlight:   s.shedInFlight.Load(),
+		ShedBodyMemory: s.shedBody.Load(),
+		ShedOverload:   s.shedOverload.Load(),
+	}
+	if o := s.overload; o != nil {
+		overloaded, cpu, lag := o.overloaded.Load(), o.cpuFraction(), time.Duration(o.lag.Load()).Milliseconds()
+		stats.Overloaded, stats.CPU, stats.LagMs = &overloaded, &cpu, &lag
+	}
+	return stats
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestLimitsMiddleware_MaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	h := newTestHandler(t, "server:\n  limits:\n    maxInFlight: 2\n    retryAfter: 5\n")
+	limited := h.LimitsMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+		},
+	))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			performRequest(limited, http.MethodGet, "/slow", nil, nil)
+		}()
+	}
+	<-started
+	<-started
+
+	rr := performRequest(limited, http.MethodGet, "/slow", nil, nil)
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "5" {
+		t.Fatalf("expected 503 with Retry-After 5, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	wg.Wait()
+	stats := h.LoadStats()
+	if stats.InFlight != 0 || stats.PeakInFlight != 2 || stats.ShedInFlight != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimitsMiddleware_MaxBodyMemory(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var received string
+	h := newTestHandler(t, "server:\n  limits:\n    maxBodyMemory: 10B\n")
+	limited := h.LimitsMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.URL.Path == "/hold" {
+				started <- struct{}{}
+				<-release
+				return
+			}
+			received = string(body)
+		},
+	))
+
+	// Without a Content-Length the body is read up to the remaining budget
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("0123456789x"))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	limited.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for oversized body, got %d", rr.Code)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		performRequest(limited, http.MethodPost, "/hold", nil, []byte("123456"))
+	}()
+	<-started
+
+	if rr := performRequest(limited, http.MethodPost, "/echo", nil, []byte("12345")); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while budget is held, got %d", rr.Code)
+	}
+	if rr := performRequest(limited, http.MethodPost, "/echo", nil, []byte("1234")); rr.Code != http.StatusOK || received != "1234" {
+		t.Fatalf("expected body within budget to pass, got %d %q", rr.Code, received)
+	}
+
+	close(release)
+	<-done
+	stats := h.LoadStats()
+	if stats.BodyBytes != 0 || stats.PeakBodyBytes != 10 || stats.ShedBodyMemory != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimitsMiddleware_MaxBodyMemoryDecompressed(t *testing.T) {
+	var received string
+	h := newTestHandler(t, "server:\n  limits:\n    maxBodyMemory: 1KB\n")
+	limited := h.LimitsMiddleware(DecompressionMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+		},
+	)))
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	headers := map[string]string{"Content-Encoding": "gzip"}
+
+	// A megabyte of zeros compresses to about a kilobyte, well within the budget as sent
+	bomb := compress(t, gzipWriter, strings.Repeat("0", 1<<20))
+	rr := performRequest(limited, http.MethodPost, "/upload", headers, bomb)
+	if rr.Code != http.StatusServiceUnavailable || received != "" {
+		t.Fatalf("expected 503 for body expanding past the budget, got %d", rr.Code)
+	}
+
+	payload := strings.Repeat("a", 200)
+	rr = performRequest(limited, http.MethodPost, "/upload", headers, compress(t, gzipWriter, payload))
+	if rr.Code != http.StatusOK || received != payload {
+		t.Fatalf("expected decoded body within budget to pass, got %d %q", rr.Code, received)
+	}
+
+	stats := h.LoadStats()
+	if stats.BodyBytes != 0 || stats.ShedBodyMemory != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimitsMiddleware_Overload(t *testing.T) {
+	// A long interval keeps the background monitor from sampling during the test
+	h := newTestHandler(t, "server:\n  limits:\n    overload: {maxLag: 100, status: 429, interval: 60000}\n")
+	limited := h.LimitsMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+	))
+
+	if rr := performRequest(limited, http.MethodGet, "/a", nil, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 before overload, got %d", rr.Code)
+	}
+	h.limits.overload.sample(60250 * time.Millisecond)
+	if rr := performRequest(limited, http.MethodGet, "/a", nil, nil); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After while overloaded, got %d", rr.Code)
+	}
+	stats := h.LoadStats()
+	if stats.Overloaded == nil || !*stats.Overloaded || *stats.LagMs != 250 || stats.ShedOverload != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	h.limits.overload.sample(60010 * time.Millisecond)
+	if rr := performRequest(limited, http.MethodGet, "/a", nil, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 after recovery, got %d", rr.Code)
+	}
+}
+
+func TestOverloadMonitor_CPU(t *testing.T) {
+	m := newOverloadMonitor(&config.Overload{MaxCPU: 0.5, Status: http.StatusServiceUnavailable, Interval: 1000})
+	used := time.Duration(0)
+	m.cpuTime = func() (time.Duration, bool) { return used, true }
+	cores := time.Duration(runtime.GOMAXPROCS(0))
+
+	used += 900 * time.Millisecond * cores
+	m.sample(time.Second)
+	if !m.overloaded.Load() {
+		t.Fatalf("expected 90%% CPU to be overloaded, got %.2f", m.cpuFraction())
+	}
+	used += 200 * time.Millisecond * cores
+	m.sample(time.Second)
+	if m.overloaded.Load() || m.cpuFraction() < 0.19 || m.cpuFraction() > 0.21 {
+		t.Fatalf("expected 20%% CPU not to be overloaded, got %.2f", m.cpuFraction())
+	}
+}
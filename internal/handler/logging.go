@@ -9,6 +9,7 @@ import (
 	"mime"
 	"net/http"
 	"sort"
+	"unicode/utf8"
 )
 
 // logBodyLimit is the maximum number of bytes logged for request and response bodies.
@@ -106,6 +107,9 @@ func formatLogBody(body []byte, contentType string) string {
 	if len(body) == 0 {
 		return " (empty)"
 	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf(" (%d bytes of binary data)", len(body))
+	}
 	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/json" {
 		var pretty bytes.Buffer
 		if err := json.Indent(&pretty, body, "        ", "  "); err == nil {
@@ -127,6 +131,11 @@ func (lw *loggingResponseWriter) WriteHeader(code int) {
 	lw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap gives http.ResponseController access to the underlying writer, for hijacking and flushing
+func (lw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
+
 func (lw *loggingResponseWriter) Write(data []byte) (int, error) {
 	// Buffer up to logBodyLimit+1 bytes for logging; the full body is always sent to the client.
 	if lw.body.Len() <= logBodyLimit {
@@ -495,3 +495,28 @@ func TestLoggingMiddleware_CapturesRequestAndResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggingMiddleware_SummarizesBinaryResponseBody(t *testing.T) {
+	var buf bytes.Buffer
+	oldOut := log.Writer()
+	defer log.SetOutput(oldOut)
+	log.SetOutput(&buf)
+
+	binary := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0xff, 0xfe}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(binary)
+	})
+
+	handler := LoggingMiddleware(next)
+	req := httptest.NewRequest(http.MethodGet, "/logo.png", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	if strings.Contains(out, string(binary)) {
+		t.Fatal("expected binary response body to be left out of the log")
+	}
+	if !strings.Contains(out, "(10 bytes of binary data)") {
+		t.Fatalf("expected binary summary in log, got:\n%s", out)
+	}
+}
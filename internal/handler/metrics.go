@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// metricsBuckets are the request duration histogram bounds in seconds, as in the Prometheus clients
+var metricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// overflowLabel replaces every label value of the series requests fall into once maxSeries is reached
+const overflowLabel = "_other"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricSeries counts the requests with one set of label values
+type metricSeries struct {
+	labels  string // Formatted name="value" pairs
+	count   uint64
+	sum     float64  // Seconds
+	buckets []uint64 // Requests at or below each of metricsBuckets
+}
+
+// ruleMetrics aggregates mock requests per rule, status, and rule labels.
+// Counters outlive configuration reloads, as Prometheus expects.
+type ruleMetrics struct {
+	mu         sync.Mutex
+	series     map[string]*metricSeries
+	unmatched  uint64
+	overflowed uint64
+}
+
+func newRuleMetrics() *ruleMetrics {
+	return &ruleMetrics{series: make(map[string]*metricSeries)}
+}
+
+// observe counts a served request. Requests that would start a new series beyond
+// maxSeries are aggregated into a single series with every label set to _other.
+func (m *ruleMetrics) observe(cfg *config.Metrics, rs *ruleSet, rule *config.RequestRule, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rule == nil {
+		m.unmatched++
+		return
+	}
+	labels := formatLabels(rs.ruleKey(rule), strconv.Itoa(status), rule.Labels)
+	s := m.series[labels]
+	if s == nil {
+		if len(m.series) >= cfg.MaxSeries {
+			m.overflowed++
+			labels = formatLabels(overflowLabel, overflowLabel, nil)
+			s = m.series[labels]
+		}
+		if s == nil {
+			s = &metricSeries{labels: labels, buckets: make([]uint64, len(metricsBuckets))}
+			m.series[labels] = s
+		}
+	}
+
+	seconds := elapsed.Seconds()
+	s.count++
+	s.sum += seconds
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// formatLabels writes the rule and status labels followed by the rule's own labels in name order
+func formatLabels(rule, status string, extra map[string]string) string {
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, `rule="%s",status="%s"`, labelEscaper.Replace(rule), labelEscaper.Replace(status))
+	for _, name := range names {
+		fmt.Fprintf(&b, `,%s="%s"`, name, labelEscaper.Replace(extra[name]))
+	}
+	return b.String()
+}
+
+// write renders the metrics in the Prometheus text exposition format
+func (m *ruleMetrics) write(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := make([]*metricSeries, 0, len(m.series))
+	for _, s := range m.series {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].labels < series[j].labels })
+
+	buf.WriteString("# HELP mock_requests_total Requests answered by mock rules.\n")
+	buf.WriteString("# TYPE mock_requests_total counter\n")
+	for _, s := range series {
+		fmt.Fprintf(buf, "mock_requests_total{%s} %d\n", s.labels, s.count)
+	}
+
+	buf.WriteString("# HELP mock_request_duration_seconds Time mock rules took to answer, including delays.\n")
+	buf.WriteString("# TYPE mock_request_duration_seconds histogram\n")
+	for _, s := range series {
+		for i, bound := range metricsBuckets {
+			fmt.Fprintf(buf, "mock_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", s.labels, formatFloat(bound), s.buckets[i])
+		}
+		fmt.Fprintf(buf, "mock_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", s.labels, s.count)
+		fmt.Fprintf(buf, "mock_request_duration_seconds_sum{%s} %s\n", s.labels, formatFloat(s.sum))
+		fmt.Fprintf(buf, "mock_request_duration_seconds_count{%s} %d\n", s.labels, s.count)
+	}
+
+	buf.WriteString("# HELP mock_unmatched_requests_total Requests no mock rule matched.\n")
+	buf.WriteString("# TYPE mock_unmatched_requests_total counter\n")
+	fmt.Fprintf(buf, "mock_unmatched_requests_total %d\n", m.unmatched)
+
+	buf.WriteString("# HELP mock_metrics_overflow_total Requests counted in the _other series because maxSeries was reached.\n")
+	buf.WriteString("# TYPE mock_metrics_overflow_total counter\n")
+	fmt.Fprintf(buf, "mock_metrics_overflow_total %d\n", m.overflowed)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// MetricsHandler serves the per-rule metrics for Prometheus to scrape
+func (h *MockHandler) MetricsHandler() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var buf bytes.Buffer
+			h.metrics.write(&buf)
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+			_, _ = w.Write(buf.Bytes())
+		},
+	)
+}
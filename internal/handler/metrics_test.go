@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  metrics:
+    maxSeries: 2
+requests:
+  - name: list-orders
+    path: /orders
+    labels: {team: checkout, criticality: high}
+    response:
+      status-code: 200
+  - name: create-order
+    path: /orders
+    method: POST
+    labels: {team: 'check"out'}
+    response:
+      status-code: 201
+  - name: health
+    path: /ping
+    response:
+      status-code: 204
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	performRequest(h, http.MethodGet, "/orders", nil, nil)
+	performRequest(h, http.MethodGet, "/orders", nil, nil)
+	performRequest(h, http.MethodPost, "/orders", nil, nil)
+	performRequest(h, http.MethodGet, "/ping", nil, nil)
+	performRequest(h, http.MethodGet, "/missing", nil, nil)
+
+	rr := httptest.NewRecorder()
+	h.MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`mock_requests_total{rule="list-orders",status="200",criticality="high",team="checkout"} 2`,
+		`mock_requests_total{rule="create-order",status="201",team="check\"out"} 1`,
+		`mock_requests_total{rule="_other",status="_other"} 1`,
+		`mock_request_duration_seconds_bucket{rule="list-orders",status="200",criticality="high",team="checkout",le="+Inf"} 2`,
+		`mock_request_duration_seconds_count{rule="create-order",status="201",team="check\"out"} 1`,
+		"mock_unmatched_requests_total 1",
+		"mock_metrics_overflow_total 1",
+		"# TYPE mock_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected metrics to contain %s, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `rule="health"`) {
+		t.Fatalf("expected the third series to be aggregated, got:\n%s", body)
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// MirroredHeader marks mirrored copies so the target can tell them from its own traffic
+const MirroredHeader = "X-Mock-Mirrored"
+
+// hopHeaders describe a single connection and are not copied to mirrored requests
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// trafficMirror sends copies of mock requests in the background and counts the outcomes.
+// It outlives configuration reloads.
+type trafficMirror struct {
+	client   *http.Client
+	wg       sync.WaitGroup
+	inFlight atomic.Int64
+	peak     atomic.Int64
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+func newTrafficMirror() *trafficMirror {
+	return &trafficMirror{client: &http.Client{}}
+}
+
+// MirrorMiddleware copies a sample of mock requests to server.mirror without waiting
+// for the target. Copies carry the request as received, before any decompression.
+func (h *MockHandler) MirrorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			m := h.current().config.Server.Mirror
+			if m != nil && h.sampled(m.SampleRate) {
+				h.mirror.send(m, r)
+			}
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// sampled reports whether a request falls within the sample rate
+func (h *MockHandler) sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	h.randMu.Lock()
+	defer h.randMu.Unlock()
+	return h.rand.Float64() < rate
+}
+
+// send copies the request, restoring its body for the mock, and delivers the copy in
+// the background. Copies over the in-flight limit are dropped.
+func (t *trafficMirror) send(m *config.Mirror, r *http.Request) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		data, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			// The mock will see the same truncated body; don't mirror it
+			return
+		}
+		body = data
+	}
+
+	if !reserve(&t.inFlight, &t.peak, 1, int64(m.MaxInFlight)) {
+		t.dropped.Add(1)
+		return
+	}
+	target := strings.TrimSuffix(m.URL, "/") + r.URL.RequestURI()
+	header := r.Header.Clone()
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+	for name, value := range m.Headers {
+		header.Set(name, value)
+	}
+	header.Set(MirroredHeader, "true")
+	host := r.Host
+	timeout := time.Duration(m.Timeout) * time.Millisecond
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer t.inFlight.Add(-1)
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		req, err := http.NewRequestWithContext(ctx, r.Method, target, bytes.NewReader(body))
+		if err != nil {
+			t.failed.Add(1)
+			log.Printf("Mirroring %s %s failed: %v", r.Method, target, err)
+			return
+		}
+		req.Header = header
+		if _, ok := m.Headers["Host"]; !ok {
+			req.Host = host
+		}
+		resp, err := t.client.Do(req)
+		if err != nil {
+			t.failed.Add(1)
+			log.Printf("Mirroring %s %s failed: %v", r.Method, target, err)
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		t.sent.Add(1)
+	}()
+}
+
+// MirrorStats counts the copies sent to the mirror target since startup
+type MirrorStats struct {
+	InFlight     int64 `json:"inFlight"`
+	PeakInFlight int64 `json:"peakInFlight"`
+	Sent         int64 `json:"sent"`    // Copies the target answered, whatever the status
+	Failed       int64 `json:"failed"`  // Copies that could not be delivered or timed out
+	Dropped      int64 `json:"dropped"` // Copies skipped because maxInFlight were pending
+}
+
+// MirrorStats returns the traffic mirroring counters
+func (h *MockHandler) MirrorStats() MirrorStats {
+	t := h.mirror
+	return MirrorStats{
+		InFlight:     t.inFlight.Load(),
+		PeakInFlight: t.peak.Load(),
+		Sent:         t.sent.Load(),
+		Failed:       t.failed.Load(),
+		Dropped:      t.dropped.Load(),
+	}
+}
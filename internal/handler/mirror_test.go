@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMirrorMiddleware_CopiesRequest(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{500}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+server:
+  mirror:
+    url: %s/shadow/
+    headers:
+      X-Env: shadow
+requests:
+  - path: /orders
+    method: POST
+    body: '.*"id".*'
+    response:
+      status-code: 201
+`, srv.URL))
+
+	rr := performRequest(h.MirrorMiddleware(h), http.MethodPost, "/orders?dry=1",
+		map[string]string{"Content-Type": "application/json", "Connection": "keep-alive"}, []byte(`{"id":1}`))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected the mock to see the body and answer 201, got %d", rr.Code)
+	}
+	h.mirror.wg.Wait()
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("expected 1 mirrored request, got %d", len(rcv.requests))
+	}
+	r := rcv.requests[0]
+	if r.Method != http.MethodPost || r.URL.RequestURI() != "/shadow/orders?dry=1" {
+		t.Fatalf("unexpected mirrored request %s %s", r.Method, r.URL.RequestURI())
+	}
+	if rcv.bodies[0] != `{"id":1}` {
+		t.Fatalf("unexpected mirrored body %q", rcv.bodies[0])
+	}
+	if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Env") != "shadow" || r.Header.Get(MirroredHeader) != "true" {
+		t.Fatalf("unexpected mirrored headers %v", r.Header)
+	}
+	// The target's status doesn't count as a failure
+	if s := h.MirrorStats(); s.Sent != 1 || s.Failed != 0 || s.InFlight != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestMirrorMiddleware_Sampling(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{200}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+server:
+  mirror:
+    url: %s
+    sampleRate: 0.25
+requests:
+  - path: /a
+    response:
+      status-code: 200
+`, srv.URL))
+
+	mw := h.MirrorMiddleware(h)
+	for i := 0; i < 400; i++ {
+		if rr := performRequest(mw, http.MethodGet, "/a", nil, nil); rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+	}
+	h.mirror.wg.Wait()
+	if n := len(rcv.requests); n < 60 || n > 140 {
+		t.Fatalf("expected about 100 of 400 requests mirrored, got %d", n)
+	}
+}
+
+func TestMirrorMiddleware_FailureDoesNotAffectResponse(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+server:
+  mirror:
+    url: %s
+requests:
+  - path: /a
+    response:
+      status-code: 200
+      body: ok
+`, url))
+
+	rr := performRequest(h.MirrorMiddleware(h), http.MethodGet, "/a", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+		t.Fatalf("expected 200 ok, got %d %q", rr.Code, rr.Body.String())
+	}
+	h.mirror.wg.Wait()
+	if s := h.MirrorStats(); s.Failed != 1 || s.Sent != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
@@ -1,107 +1,529 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"http-mock-server/internal/config"
+	"http-mock-server/internal/expr"
+	"http-mock-server/internal/storage"
+	"http-mock-server/internal/templating"
+	"http-mock-server/pkg/transform"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 )
 
 // MockHandler handles mock requests based on configuration
 type MockHandler struct {
+	rules       atomic.Pointer[ruleSet]
+	scenarios   *ScenarioStore
+	resources   *ResourceStore
+	datasets    *DatasetStore
+	checkpoints *checkpointStore
+	limits      *loadShedder
+	webhooks    *webhookJournal
+	mirror      *trafficMirror
+	files       *fileCache
+	health      *healthStore
+	journal     *requestJournal
+	metrics     *ruleMetrics
+	freshness   *freshnessTracker
+	golden      *goldenStore
+	sequences   *templating.Sequences
+	holds       *holdQueue
+	observe     func(ObservedRequest) // Set by EnableInteractive before serving
+	now         func() time.Time
+	rand        *rand.Rand
+	randMu      sync.Mutex
+}
+
+// ruleSet is an immutable snapshot of the configuration and everything derived from it.
+// Requests keep using the snapshot they started with while a reload swaps in a new one.
+type ruleSet struct {
 	config       *config.Config
-	rand         *rand.Rand
-	randMu       sync.Mutex
 	cachedBodies map[*config.RandomBodySpec][]byte
+	conditions   map[string]*expr.Program // Keyed by expression source
+	patterns     map[patternKey]*regexp.Regexp
+	calls        map[*config.RequestRule]*atomic.Int64
+	callsMu      sync.Mutex // Serializes committing call counts, see commit
+	concurrency  map[*config.RequestRule]*concurrencyTracker
+	templates    map[*config.ResponseSpec]*responseTemplates
+	webhooks     map[*config.Webhook]*webhookTemplates
+	retries      map[*config.RequestRule]*retryWindows
+	validators   map[*config.ResponseSpec]*validators
+	proxyBodies  map[*config.ProxyBody]*fetchedBody // Cached proxyBody responses
+	transformers map[*config.Transformer]transform.Func
+	store        storage.Store
+	bucketFiles  *storedFiles
+	errorBody    *template.Template // Body of server.errorResponse, nil when it has none
+	ruleKeys     []string
+	prepareTimes []time.Duration // Time spent preparing each rule's templates, conditions, and random bodies
+	warmup       *warmupReport   // Nil unless server.warmup is configured
 }
 
 // NewMockHandler creates a new mock handler
 func NewMockHandler(cfg *config.Config) *MockHandler {
-	h := &MockHandler{
-		config:       cfg,
-		rand:         rand.New(rand.NewSource(rand.Int63())),
-		cachedBodies: make(map[*config.RandomBodySpec][]byte),
-	}
-	h.preGenerateBodies()
-	return h
+	return NewMockHandlerWithRand(cfg, rand.New(rand.NewSource(rand.Int63())))
 }
 
 // NewMockHandlerWithRand creates a new mock handler with a custom random source (for testing)
 func NewMockHandlerWithRand(cfg *config.Config, r *rand.Rand) *MockHandler {
 	h := &MockHandler{
-		config:       cfg,
-		rand:         r,
-		cachedBodies: make(map[*config.RandomBodySpec][]byte),
+		scenarios:   NewScenarioStore(),
+		resources:   NewResourceStore(),
+		datasets:    NewDatasetStore(),
+		checkpoints: newCheckpointStore(),
+		limits:      newLoadShedder(cfg.Server.Limits),
+		webhooks:    newWebhookJournal(),
+		mirror:      newTrafficMirror(),
+		files:       newFileCache(),
+		health:      newHealthStore(cfg.Server.GRPCHealth),
+		journal:     newRequestJournal(),
+		metrics:     newRuleMetrics(),
+		freshness:   newFreshnessTracker(),
+		golden:      newGoldenStore(),
+		sequences:   templating.NewSequences(),
+		holds:       newHoldQueue(),
+		now:         time.Now,
+		rand:        r,
+	}
+	h.datasets.changed = h.reseedResources
+	rs, err := h.newRuleSet(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
-	h.preGenerateBodies()
+	h.rules.Store(rs)
+	h.freshness.sync(rs, h.now())
+	h.restoreFreshness(rs)
+	h.scenarios.setInitial(cfg.Scenarios)
 	return h
 }
 
-func (h *MockHandler) preGenerateBodies() {
-	for i := range h.config.Requests {
-		rb := h.config.Requests[i].Response.RandomBody
-		if rb != nil {
-			data, err := h.generateRandomBody(rb)
-			if err != nil {
-				log.Fatalf("failed to pre-generate random body for rule %d: %v", i, err)
+// Reload atomically replaces the active configuration. In-flight requests finish
+// with the rules they matched; the previous configuration stays active on error.
+func (h *MockHandler) Reload(cfg *config.Config) error {
+	rs, err := h.newRuleSet(cfg)
+	if err != nil {
+		return err
+	}
+	h.rules.Store(rs)
+	h.freshness.sync(rs, h.now())
+	h.restoreFreshness(rs)
+	h.scenarios.setInitial(cfg.Scenarios)
+	return nil
+}
+
+// Config returns the active configuration
+func (h *MockHandler) Config() *config.Config {
+	return h.current().config
+}
+
+// Scenarios returns the scenario state store. States survive configuration reloads.
+func (h *MockHandler) Scenarios() *ScenarioStore {
+	return h.scenarios
+}
+
+func (h *MockHandler) current() *ruleSet {
+	return h.rules.Load()
+}
+
+func (h *MockHandler) newRuleSet(cfg *config.Config) (*ruleSet, error) {
+	rs := &ruleSet{
+		config:       cfg,
+		cachedBodies: make(map[*config.RandomBodySpec][]byte),
+		conditions:   make(map[string]*expr.Program),
+		patterns:     make(map[patternKey]*regexp.Regexp),
+		calls:        make(map[*config.RequestRule]*atomic.Int64),
+		concurrency:  make(map[*config.RequestRule]*concurrencyTracker),
+		templates:    make(map[*config.ResponseSpec]*responseTemplates),
+		webhooks:     make(map[*config.Webhook]*webhookTemplates),
+		retries:      make(map[*config.RequestRule]*retryWindows),
+		validators:   make(map[*config.ResponseSpec]*validators),
+		proxyBodies:  make(map[*config.ProxyBody]*fetchedBody),
+		transformers: make(map[*config.Transformer]transform.Func),
+		store:        h.openStore(cfg.Server.Storage),
+		bucketFiles:  &storedFiles{files: make(map[string]*cachedFile)},
+		ruleKeys:     config.RuleKeys(cfg.Requests),
+	}
+	opts, err := h.templateOptions(rs)
+	if err != nil {
+		return nil, err
+	}
+	rs.prepareTimes = make([]time.Duration, len(cfg.Requests))
+	for i := range cfg.Requests {
+		start := time.Now()
+		rs.calls[&cfg.Requests[i]] = &atomic.Int64{}
+		rs.concurrency[&cfg.Requests[i]] = newConcurrencyTracker()
+		if cfg.Requests[i].RetryAfter != nil {
+			rs.retries[&cfg.Requests[i]] = newRetryWindows()
+		}
+		for _, spec := range cfg.Requests[i].AllResponses() {
+			if spec.Conditional {
+				rs.validators[spec] = &validators{}
+			}
+			if pb := spec.ProxyBody; pb != nil && pb.Cache {
+				rs.proxyBodies[pb] = &fetchedBody{}
 			}
-			h.cachedBodies[rb] = data
+			if t := spec.Transformer; t != nil {
+				fn, err := openTransformer(t)
+				if err != nil {
+					return nil, fmt.Errorf("request rule %d: %w", i, err)
+				}
+				rs.transformers[t] = fn
+			}
+		}
+		if err := h.preGenerateBodies(rs, i); err != nil {
+			return nil, err
+		}
+		rs.compileConditions(i)
+		rs.compilePatterns(i)
+		if err := h.compileTemplates(rs, i, opts); err != nil {
+			return nil, err
+		}
+		rs.prepareTimes[i] = time.Since(start)
+	}
+	h.prefetchProxyBodies(rs)
+	if cfg.Server.Warmup != nil {
+		rs.warmup = h.warmUp(rs)
+	}
+	return rs, nil
+}
+
+// preGenerateBodies generates the random bodies of rule i
+func (h *MockHandler) preGenerateBodies(rs *ruleSet, i int) error {
+	for _, spec := range rs.config.Requests[i].AllResponses() {
+		rb := spec.RandomBody
+		if rb == nil {
+			continue
+		}
+		data, err := h.generateRandomBody(rb)
+		if err != nil {
+			return fmt.Errorf("failed to pre-generate random body for rule %d: %w", i, err)
 		}
+		rs.cachedBodies[rb] = data
 	}
+	return nil
 }
 
 func (h *MockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	rule := h.findMatchingRule(r)
-	if rule == nil {
-		http.NotFound(w, r)
+	rs := h.current()
+	journal, metrics, freshness := rs.config.Server.Journal, rs.config.Server.Metrics, rs.config.Server.Freshness
+	if journal == nil && metrics == nil && freshness == nil && h.observe == nil {
+		h.serve(w, r, rs)
 		return
 	}
 
-	h.writeResponse(w, r, rule)
+	var body []byte
+	if journal != nil {
+		body, _ = requestBody(r)
+	}
+	start := h.now()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	rule := h.serve(sw, r, rs)
+	elapsed := h.now().Sub(start)
+
+	if journal != nil {
+		h.journal.record(journal, r, body, rs, rule, sw.status, start, elapsed)
+	}
+	if metrics != nil {
+		h.metrics.observe(metrics, rs, rule, sw.status, elapsed)
+	}
+	if freshness != nil {
+		if rule != nil {
+			h.freshness.matched(rs.ruleKey(rule), start)
+		}
+		if h.freshness.warn(freshness, start) && freshness.StateKey != "" {
+			h.scheduleFreshnessSave()
+		}
+	}
+	if h.observe != nil {
+		o := ObservedRequest{Method: r.Method, URL: r.URL.RequestURI(), Status: sw.status, Duration: elapsed}
+		if rule != nil {
+			o.Rule = rs.ruleKey(rule)
+		}
+		h.observe(o)
+	}
+}
+
+// serve answers the request from the rule set and returns the rule that matched, if any
+func (h *MockHandler) serve(w http.ResponseWriter, r *http.Request, rs *ruleSet) *config.RequestRule {
+	if len(rs.conditions) > 0 {
+		r = withConditionVars(r)
+	}
+	var rule *config.RequestRule
+	for {
+		var tally callTally
+		rule = h.selectRule(rs.findCandidates(r, h.scenarios, &tally, h.now()))
+		if rule == nil {
+			if !rs.commit(nil, tally, h.scenarios) {
+				continue
+			}
+			http.NotFound(w, r)
+			return nil
+		}
+		if enforceSchema(w, r, rule) {
+			return rule
+		}
+		if h.throttle(w, r, rs, rule) {
+			return rule
+		}
+		if rs.commit(rule, tally, h.scenarios) {
+			break
+		}
+		// A concurrent request moved the scenario or a call count since the rule was selected
+	}
+
+	tracker := rs.concurrency[rule]
+	tracker.begin()
+	defer tracker.end()
+
+	h.writeResponse(w, r, rs, rule)
+	for _, wh := range rule.AllWebhooks() {
+		h.sendWebhook(r, rs, rule, wh)
+	}
+	return rule
+}
+
+// ruleKey returns the identifier of a rule in the active configuration
+func (rs *ruleSet) ruleKey(rule *config.RequestRule) string {
+	for i := range rs.config.Requests {
+		if &rs.config.Requests[i] == rule {
+			return rs.ruleKeys[i]
+		}
+	}
+	return ""
 }
 
-func (h *MockHandler) findMatchingRule(r *http.Request) *config.RequestRule {
-	path := r.URL.Path
+// findCandidates returns the rules eligible to answer the request. Normally this is
+// the first matching rule; if that rule has a weight, it is every matching rule with
+// a weight, from which one is picked at random. The call counts seen on the way are
+// recorded in tally.
+func (rs *ruleSet) findCandidates(r *http.Request, scenarios *ScenarioStore, tally *callTally, now time.Time) []*config.RequestRule {
 	method := strings.ToUpper(r.Method)
+	candidates := rs.candidates(r, method, scenarios, tally, now)
+	// HEAD falls back to the GET rules when no rule answers it explicitly
+	if len(candidates) == 0 && method == http.MethodHead {
+		candidates = rs.candidates(r, http.MethodGet, scenarios, tally, now)
+	}
+	return candidates
+}
+
+// candidates collects the rules matching r as if it had been sent with method
+func (rs *ruleSet) candidates(r *http.Request, method string, scenarios *ScenarioStore, tally *callTally, now time.Time) []*config.RequestRule {
+	var candidates []*config.RequestRule
 
-	for i := range h.config.Requests {
-		rule := &h.config.Requests[i]
+	for i := range rs.config.Requests {
+		rule := &rs.config.Requests[i]
 
-		if rule.Path != path {
+		if len(candidates) > 0 && rule.Weight == 0 {
 			continue
 		}
 
-		if rule.Method != method {
+		if !rs.matches(rule, r, method, scenarios, tally, now) {
 			continue
 		}
 
-		if !h.matchesHeaders(rule.Headers, r.Header) {
-			continue
+		candidates = append(candidates, rule)
+		if rule.Weight == 0 {
+			return candidates
 		}
+	}
 
-		if !h.matchesQueryParams(rule.QueryParams, r.URL.Query()) {
-			continue
+	return candidates
+}
+
+func (rs *ruleSet) matches(rule *config.RequestRule, r *http.Request, method string, scenarios *ScenarioStore, tally *callTally, now time.Time) bool {
+	if !matchesPath(rule, r.URL.Path) {
+		return false
+	}
+
+	if rule.Method != method && rule.Method != config.AnyMethod {
+		return false
+	}
+
+	if !matchesAudience(rule.Audiences, requestAudience(r, rs.config.Server.Audiences)) {
+		return false
+	}
+
+	if !rule.ActiveWindow.Active(now) {
+		return false
+	}
+
+	if !scenarios.matchesScenario(rule) {
+		return false
+	}
+
+	full := rs.fullMatch(rule)
+
+	if !rs.matchesHeaders(rule.Headers, r.Header, full) {
+		return false
+	}
+
+	if !rs.matchesQueryParams(rule.QueryParams, r.URL.Query(), full) {
+		return false
+	}
+
+	if !rs.matchesClientCert(rule.ClientCert, r, full) {
+		return false
+	}
+
+	if !matchesSize(rule, r) {
+		return false
+	}
+
+	if !rs.matchesBody(rule.Body, r, full) {
+		return false
+	}
+
+	if !matchesJSONBody(rule, r) {
+		return false
+	}
+
+	if !matchesSchema(rule, r) {
+		return false
+	}
+
+	if !rs.matchesWhen(rule.When, r) {
+		return false
+	}
+
+	if !rs.matchesGroups(&rule.Groups, r, full) {
+		return false
+	}
+
+	return rs.matchesCallCount(rule, tally)
+}
+
+// selectRule picks one of the candidate rules, at random by weight when there are several
+func (h *MockHandler) selectRule(candidates []*config.RequestRule) *config.RequestRule {
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		return candidates[0]
+	}
+
+	total := 0
+	for _, rule := range candidates {
+		total += rule.Weight
+	}
+	h.randMu.Lock()
+	n := h.rand.Intn(total)
+	h.randMu.Unlock()
+
+	for _, rule := range candidates {
+		if n < rule.Weight {
+			return rule
 		}
+		n -= rule.Weight
+	}
+	return candidates[len(candidates)-1]
+}
 
-		if !h.matchesBody(rule.Body, r) {
-			continue
+// ResetCallCounts restarts onCall/afterCalls counting for every rule
+func (h *MockHandler) ResetCallCounts() {
+	for _, counter := range h.current().calls {
+		counter.Store(0)
+	}
+}
+
+// callTally records the call counts a request saw while it was matched. The request
+// is counted only once it is answered, by commit.
+type callTally []callObservation
+
+// callObservation is the count of a rule with onCall or afterCalls when a request
+// satisfied all of the rule's other matchers
+type callObservation struct {
+	rule    *config.RequestRule
+	seen    int64
+	matched bool // The count met the condition, so the rule was a candidate
+}
+
+// matchesCallCount reports whether the request that satisfied all other matchers of
+// the rule would meet its onCall or afterCalls condition, recording the count in tally
+// without changing it.
+func (rs *ruleSet) matchesCallCount(rule *config.RequestRule, tally *callTally) bool {
+	if rule.OnCall == 0 && rule.AfterCalls == 0 {
+		return true
+	}
+	seen := rs.calls[rule].Load()
+	n := seen + 1
+	matched := n > int64(rule.AfterCalls)
+	if rule.OnCall > 0 {
+		matched = n == int64(rule.OnCall)
+	}
+	for _, o := range *tally {
+		if o.rule == rule {
+			return o.matched // Already seen while matching HEAD as GET
 		}
+	}
+	*tally = append(*tally, callObservation{rule: rule, seen: seen, matched: matched})
+	return matched
+}
 
-		return rule
+// commit applies the effects of answering the request with rule, or with a 404 when
+// rule is nil: the scenario transition and the call counts. The request counts for
+// the rule that answers it and for the rules it only missed because of their call
+// count, not for weighted rules that weren't picked. Commit reports false, changing
+// nothing, when a concurrent request moved the scenario or one of those counts since
+// they were read, and the request has to be matched again.
+func (rs *ruleSet) commit(rule *config.RequestRule, tally callTally, scenarios *ScenarioStore) bool {
+	if len(tally) == 0 {
+		return rule == nil || scenarios.advance(rule)
 	}
 
-	return nil
+	rs.callsMu.Lock()
+	defer rs.callsMu.Unlock()
+	var counted []callObservation
+	for _, o := range tally {
+		if o.matched && o.rule != rule {
+			continue
+		}
+		if rs.calls[o.rule].Load() != o.seen {
+			return false
+		}
+		counted = append(counted, o)
+	}
+	if rule != nil && !scenarios.advance(rule) {
+		return false
+	}
+	for _, o := range counted {
+		rs.calls[o.rule].Add(1)
+	}
+	return true
 }
 
-func (h *MockHandler) writeResponse(w http.ResponseWriter, r *http.Request, rule *config.RequestRule) {
+func (h *MockHandler) writeResponse(w http.ResponseWriter, r *http.Request, rs *ruleSet, rule *config.RequestRule) {
+	response := h.chooseResponse(rs, rule, r)
+	var decision holdDecision
+	if rule.Hold {
+		var ok bool
+		if decision, ok = h.hold(r, rs, rule); !ok {
+			return
+		}
+		if decision.spec != nil {
+			response = decision.spec
+		}
+	}
+
+	// Hints go out before the delay, while the server is still "working" on the response
+	writeEarlyHints(w, r, response.EarlyHints)
+	if !writeInterim(w, r, response.Interim) {
+		return
+	}
+
 	// Apply response delay if configured
 	if delay := rule.ResponseDelay; delay != nil {
 		duration := h.calculateDelay(delay)
@@ -114,56 +536,388 @@ func (h *MockHandler) writeResponse(w http.ResponseWriter, r *http.Request, rule
 		}
 	}
 
-	// Set response headers
-	for key, value := range rule.Response.Headers {
-		w.Header().Set(key, value)
+	if rule.Resource != nil {
+		h.writeResource(w, r, rs, rule)
+		return
+	}
+
+	spec := negotiate(response, r)
+	if spec == nil {
+		writeNotAcceptable(w, response)
+		return
+	}
+	if spec.Fault != "" {
+		h.writeFault(w, r, spec)
+		return
+	}
+	if spec.Proxy != nil {
+		writeProxy(w, r, spec.Proxy)
+		return
+	}
+
+	// Build the body and headers before committing to a status code
+	body, status, headers, err := h.buildResponse(r, rs, rule, spec)
+	if err != nil {
+		log.Printf("Error building response: %v", err)
+		writeMockError(w, r, rs, rule, err)
+		return
+	}
+	if decision.status != 0 {
+		status = decision.status
+	}
+
+	// Collect the response headers so assertions can check them before anything is sent
+	header := make(http.Header, len(headers)+1)
+	if len(response.Variants) > 0 {
+		header.Set("Vary", "Accept")
+	}
+	setHeaders(header, headers)
+	for _, cookie := range spec.Cookies {
+		header.Add("Set-Cookie", cookie.HTTP.String())
+	}
+	setChecksumHeaders(header, spec.Checksums, body)
+	if spec.Conditional {
+		rs.setValidators(spec, header, body, h.now())
+	}
+	if spec.SSE != nil {
+		writeSSE(w, r, status, header, spec.SSE)
+		return
+	}
+	if denyResponse(w, rs, rule, status, header, body) {
+		return
+	}
+	if spec.Golden != "" {
+		h.checkGolden(rs, rule, spec, body)
+	}
+	if (spec.BodyFile != "" || spec.Conditional) && notModified(r, status, header) {
+		status, body = http.StatusNotModified, nil
+	}
+	if !bodyAllowed(status) {
+		body = nil
+	}
+	if spec.Compress != "" && bodyAllowed(status) {
+		if body, err = compressResponse(r, spec.Compress, header, body); err != nil {
+			log.Printf("Error building response: %v", err)
+			writeMockError(w, r, rs, rule, err)
+			return
+		}
+	}
+	// Ranges answer a resumed download with the part of the body it is missing
+	var part *byteRange
+	if spec.Ranges && status == http.StatusOK {
+		size := int64(len(body))
+		if gb := spec.GenerateBody; gb != nil {
+			size = int64(gb.SizeBytes)
+		}
+		header.Set("Accept-Ranges", "bytes")
+		part, err = requestedRange(r, header, size)
+		switch {
+		case err != nil:
+			status, body = http.StatusRequestedRangeNotSatisfiable, nil
+			header.Set("Content-Range", contentRange(nil, size))
+		case part != nil:
+			status = http.StatusPartialContent
+			header.Set("Content-Range", contentRange(part, size))
+			if spec.GenerateBody == nil {
+				body = body[part.start : part.end+1]
+				header.Set("Content-Length", strconv.Itoa(len(body)))
+			}
+		}
+	}
+	// Trailers need a chunked body, so they rule out a declared length
+	trailers := len(spec.Trailers) > 0 && r.Method != http.MethodHead && r.ProtoAtLeast(1, 1) && bodyAllowed(status)
+	if (spec.BodyFile != "" || spec.Download != nil || r.Method == http.MethodHead) && !spec.Streamed() &&
+		bodyAllowed(status) && !trailers && header.Get("Content-Length") == "" {
+		// Declared up front so HEAD responses report the size of the body they leave out
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	if trailers {
+		announceTrailers(header, spec.Trailers)
+	}
+	// A streamed proxyBody is opened before the status goes out, so a failing upstream
+	// can still be reported
+	var stream io.ReadCloser
+	if pb := spec.ProxyBody; pb != nil && !pb.Cache && r.Method != http.MethodHead && bodyAllowed(status) {
+		resp, err := openProxyBody(r.Context(), pb)
+		if err != nil {
+			log.Printf("Error building response: %v", err)
+			writeMockError(w, r, rs, rule, err)
+			return
+		}
+		defer resp.Body.Close()
+		stream = resp.Body
+		streamedBodyHeaders(header, resp, trailers)
+	}
+	if gb := spec.GenerateBody; gb != nil && bodyAllowed(status) && status != http.StatusRequestedRangeNotSatisfiable {
+		offset, length := int64(0), int64(gb.SizeBytes)
+		if part != nil {
+			offset, length = part.start, part.length()
+		}
+		generatedBodyHeaders(header, gb, length, trailers)
+		if r.Method != http.MethodHead {
+			stream = io.NopCloser(h.generatedBody(spec, offset, length))
+		}
+	}
+	if !bodyAllowed(status) {
+		// 1xx, 204, and 304 responses end with their headers
+		stream = nil
+		header.Del("Transfer-Encoding")
+		if status != http.StatusNotModified {
+			header.Del("Content-Length")
+		}
+	}
+	for key, values := range header {
+		w.Header()[key] = values
 	}
 
 	// Set status code
-	w.WriteHeader(rule.Response.StatusCode)
+	w.WriteHeader(status)
 
 	// Write body if present
-	if rule.Response.Body != nil {
-		if err := h.writeBody(w, rule.Response.Body); err != nil {
+	if stream != nil {
+		if _, err := io.Copy(w, stream); err != nil {
 			fmt.Printf("Error writing response body: %v\n", err)
 		}
-		return
+	} else if len(body) > 0 && r.Method != http.MethodHead {
+		if _, err := w.Write(body); err != nil {
+			fmt.Printf("Error writing response body: %v\n", err)
+		}
+	}
+	if trailers {
+		setHeaders(w.Header(), spec.Trailers)
+	}
+}
+
+// bodyAllowed reports whether a response with status may carry a body. 1xx, 204, and
+// 304 responses never do (RFC 9110, section 6.4.1).
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// announceTrailers lists the trailer names in the Trailer header, which the client
+// reads before the body
+func announceTrailers(header http.Header, trailers map[string]string) {
+	names := make([]string, 0, len(trailers))
+	for name := range trailers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+	header.Set("Trailer", strings.Join(names, ", "))
+}
+
+// setHeaders sets configured response headers, one header line per value of headers
+// declared with a list of values
+func setHeaders(header http.Header, headers map[string]string) {
+	for name, value := range headers {
+		header.Del(name)
+		for _, v := range config.HeaderValues(value) {
+			header.Add(name, v)
+		}
+	}
+}
+
+// chooseResponse returns the response the variant header names, or the rule's first
+// response whose condition holds, or picks one of its responses without a condition at
+// random by weight, or returns its only response
+func (h *MockHandler) chooseResponse(rs *ruleSet, rule *config.RequestRule, r *http.Request) *config.ResponseSpec {
+	if len(rule.Responses) == 0 {
+		return &rule.Response
 	}
-	if rb := rule.Response.RandomBody; rb != nil {
-		if data, ok := h.cachedBodies[rb]; ok {
-			if _, err := w.Write(data); err != nil {
-				fmt.Printf("Error writing random body: %v\n", err)
+	if name := r.Header.Get(rs.config.Server.VariantHeader); name != "" {
+		for i := range rule.Responses {
+			if rule.Responses[i].Name == name {
+				return &rule.Responses[i].ResponseSpec
 			}
 		}
+		log.Printf("Rule %s has no response named %q, choosing as usual", rs.ruleKey(rule), name)
+	}
+	for i := range rule.Responses {
+		if wr := &rule.Responses[i]; wr.When != "" && rs.matchesWhen(wr.When, r) {
+			return &wr.ResponseSpec
+		}
+	}
+	// Conditional responses have no weight, so only the defaults take part
+	total := 0
+	for _, wr := range rule.Responses {
+		total += wr.Weight
+	}
+	h.randMu.Lock()
+	n := h.rand.Intn(total)
+	h.randMu.Unlock()
+	for i := range rule.Responses {
+		if n -= rule.Responses[i].Weight; n < 0 {
+			return &rule.Responses[i].ResponseSpec
+		}
 	}
+	return &rule.Responses[len(rule.Responses)-1].ResponseSpec
 }
 
-func (h *MockHandler) calculateDelay(delay *config.ResponseDelay) time.Duration {
-	ms := delay.Min
-	if delay.Max > delay.Min {
+// writeEarlyHints sends a 103 Early Hints response with the given Link headers.
+// HTTP/1.0 clients don't understand informational responses and get none.
+func writeEarlyHints(w http.ResponseWriter, r *http.Request, links []string) {
+	if len(links) == 0 || !r.ProtoAtLeast(1, 1) {
+		return
+	}
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+	// The final response carries only the headers configured for it
+	w.Header().Del("Link")
+}
+
+// buildResponse renders the body, status code, and headers of the response chosen for the request
+func (h *MockHandler) buildResponse(r *http.Request, rs *ruleSet, rule *config.RequestRule, spec *config.ResponseSpec) ([]byte, int, map[string]string, error) {
+	if spec.Transformer != nil {
+		return transformResponse(r, rule, spec, rs.transformers[spec.Transformer])
+	}
+	if spec.Mode == config.ResponseModeEcho {
+		return echoResponse(r, spec)
+	}
+	if spec.Redirect != nil {
+		return redirectResponse(r, spec)
+	}
+	if spec.Paginate != nil {
+		return h.paginateResponse(r, rs, spec)
+	}
+	rt := rs.templates[spec]
+	var data *templateData
+	if rt != nil {
+		var err error
+		if data, err = newTemplateData(r, rs, rule); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	var body []byte
+	var file *cachedFile
+	var fetched *fetchedBody
+	var err error
+	if spec.BodyFile != "" {
+		if file, err = h.bodyFile(r.Context(), rs, spec.BodyFile); err == nil {
+			body = file.data
+		}
+	} else if pb := spec.ProxyBody; pb != nil && pb.Cache {
+		if fetched, err = rs.fetchProxyBody(r.Context(), pb, h.now()); err == nil {
+			body = fetched.data
+		}
+	} else {
+		body, err = h.responseBody(rs, spec, rt, data)
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	status, err := renderStatus(rs, spec, rt, data)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	headers, err := renderHeaders(rs, spec, rt, data)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if file != nil {
+		headers = file.withValidators(headers)
+	}
+	if fetched != nil {
+		headers = withContentType(headers, fetched.contentType)
+	}
+	if !rs.config.Server.UntypedBodies {
+		headers = withContentType(headers, autoContentType(spec, body))
+	}
+	return body, status, headers, nil
+}
+
+// autoContentType returns the Content-Type of an inline body or dataset without one:
+// JSON for structured bodies, datasets, and schema bodies, XML for bodyFormat xml, and the sniffed
+// type of string bodies, usually text/plain. Other body sources get none.
+func autoContentType(spec *config.ResponseSpec, body []byte) string {
+	switch {
+	case spec.BodyFormat == config.BodyFormatXML:
+		return "application/xml; charset=utf-8"
+	case spec.Dataset != "" || spec.SchemaBody != nil:
+		return "application/json; charset=utf-8"
+	case spec.Body == nil:
+		return ""
+	}
+	if _, ok := spec.Body.(string); ok {
+		return http.DetectContentType(body)
+	}
+	return "application/json; charset=utf-8"
+}
+
+// responseBody returns the rendered template, static or binary body, dataset, schema body, or pre-generated random body
+func (h *MockHandler) responseBody(rs *ruleSet, spec *config.ResponseSpec, rt *responseTemplates, data *templateData) ([]byte, error) {
+	if rt != nil && rt.body != nil {
+		body, err := rs.render(rt.body, data)
+		if err != nil {
+			return nil, fmt.Errorf("response template failed: %w", err)
+		}
+		return body, nil
+	}
+	if sb := spec.SchemaBody; sb != nil && sb.Random {
 		h.randMu.Lock()
-		ms = delay.Min + h.rand.Intn(delay.Max-delay.Min+1)
-		h.randMu.Unlock()
+		defer h.randMu.Unlock()
+		return sb.Generate(h.rand)
+	}
+	if spec.BodyBytes != nil {
+		return spec.BodyBytes, nil
+	}
+	if spec.Body != nil {
+		return encodeBody(spec.Body)
+	}
+	if name := spec.Dataset; name != "" {
+		data, err := h.dataset(rs, name)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	}
+	if rb := spec.RandomBody; rb != nil {
+		return rs.cachedBodies[rb], nil
 	}
-	return time.Duration(ms) * time.Millisecond
+	return nil, nil
 }
 
-func (h *MockHandler) writeBody(w http.ResponseWriter, body interface{}) error {
+func (h *MockHandler) calculateDelay(delay *config.ResponseDelay) time.Duration {
+	h.randMu.Lock()
+	defer h.randMu.Unlock()
+	var ms float64
+	switch delay.Distribution {
+	case config.DelayFixed:
+		return time.Duration(delay.P50) * time.Millisecond
+	case config.DelayNormal:
+		ms = float64(delay.P50) + delay.Sigma*h.rand.NormFloat64()
+	case config.DelayLognormal:
+		ms = float64(delay.P50) * math.Exp(delay.Sigma*h.rand.NormFloat64())
+	default:
+		ms := delay.Min
+		if delay.Max > delay.Min {
+			ms = delay.Min + h.rand.Intn(delay.Max-delay.Min+1)
+		}
+		return time.Duration(ms) * time.Millisecond
+	}
+	// Clamp the tails of normal and lognormal delays
+	ms = math.Max(ms, float64(delay.Min))
+	if delay.Max > 0 {
+		ms = math.Min(ms, float64(delay.Max))
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// encodeBody returns a string body as is and marshals structured bodies to JSON
+func encodeBody(body interface{}) ([]byte, error) {
 	switch v := body.(type) {
 	case string:
-		_, err := w.Write([]byte(v))
-		return err
+		return []byte(v), nil
 	default:
 		data, err := json.Marshal(v)
 		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		_, err = w.Write(data)
-		return err
+		return data, nil
 	}
 }
 
-func (h *MockHandler) matchesHeaders(ruleHeaders map[string]string, requestHeaders http.Header) bool {
+func (rs *ruleSet) matchesHeaders(ruleHeaders map[string]string, requestHeaders http.Header, full bool) bool {
 	// If no headers are specified in the rule, it matches any request
 	if len(ruleHeaders) == 0 {
 		return true
@@ -171,69 +925,160 @@ func (h *MockHandler) matchesHeaders(ruleHeaders map[string]string, requestHeade
 
 	// All rule headers must match
 	for headerName, headerPattern := range ruleHeaders {
-		requestValue := requestHeaders.Get(headerName)
-
-		// Compile the regex pattern
-		pattern, err := regexp.Compile(headerPattern)
-		if err != nil {
-			// If pattern is invalid, treat as exact match
-			if requestValue != headerPattern {
-				return false
-			}
-		} else {
-			// Use regex matching
-			if !pattern.MatchString(requestValue) {
-				return false
-			}
+		if !rs.matchPattern(headerPattern, requestHeaders.Get(headerName), full) {
+			return false
 		}
 	}
 
 	return true
 }
 
-func (h *MockHandler) matchesQueryParams(ruleParams map[string]string, requestParams url.Values) bool {
+func (rs *ruleSet) matchesQueryParams(ruleParams config.QueryParams, requestParams url.Values, full bool) bool {
 	// If no query params are specified in the rule, it matches any request
 	if len(ruleParams) == 0 {
 		return true
 	}
 
 	// All rule query params must match
-	for paramName, paramPattern := range ruleParams {
-		requestValue := requestParams.Get(paramName)
-
-		// Compile the regex pattern
-		pattern, err := regexp.Compile(paramPattern)
-		if err != nil {
-			// If pattern is invalid, treat as exact match
-			if requestValue != paramPattern {
+	for paramName, matcher := range ruleParams {
+		if matcher.Present != nil {
+			if requestParams.Has(paramName) != *matcher.Present {
 				return false
 			}
-		} else {
-			// Use regex matching
-			if !pattern.MatchString(requestValue) {
-				return false
+			if matcher.Pattern == "" {
+				continue
 			}
 		}
+		if !rs.matchPattern(matcher.Pattern, requestParams.Get(paramName), full) {
+			return false
+		}
 	}
 
 	return true
 }
 
-func (h *MockHandler) matchesBody(ruleBody string, r *http.Request) bool {
+func (rs *ruleSet) matchesBody(ruleBody string, r *http.Request, full bool) bool {
 	if ruleBody == "" {
 		return true
 	}
 
-	body, err := io.ReadAll(r.Body)
+	body, err := requestBody(r)
 	if err != nil {
 		return false
 	}
-	r.Body = io.NopCloser(strings.NewReader(string(body)))
 
-	pattern, err := regexp.Compile(ruleBody)
-	if err != nil {
+	pattern := rs.pattern(ruleBody, full)
+	if pattern == nil {
 		return false
 	}
 
 	return pattern.Match(body)
 }
+
+// matchesSize checks the declared Content-Length and the actual body size.
+// A request without a declared length, such as a chunked upload, never satisfies contentLength.
+func matchesSize(rule *config.RequestRule, r *http.Request) bool {
+	if cl := rule.ContentLength; cl != nil {
+		declared := declaredLength(r)
+		if declared < 0 || !cl.Contains(declared) {
+			return false
+		}
+	}
+	if rule.BodySize == nil {
+		return true
+	}
+	// A known length is enforced by the server, so large bodies needn't be read to size them
+	if r.ContentLength >= 0 {
+		return rule.BodySize.Contains(r.ContentLength)
+	}
+	body, err := requestBody(r)
+	if err != nil {
+		return false
+	}
+	return rule.BodySize.Contains(int64(len(body)))
+}
+
+// declaredLength returns the Content-Length the client sent, or -1 if it sent none.
+// Decompressed requests no longer have a known length, but keep the header.
+func declaredLength(r *http.Request) int64 {
+	if r.ContentLength >= 0 {
+		return r.ContentLength
+	}
+	n, err := strconv.ParseInt(r.Header.Get("Content-Length"), 10, 64)
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// matchesPath compares the path exactly, against the rule's {name} parameters, or
+// against its prefix
+func matchesPath(rule *config.RequestRule, path string) bool {
+	if rule.PathPrefix != "" {
+		return strings.HasPrefix(path, rule.PathPrefix)
+	}
+	if rule.Resource != nil {
+		return matchesResourcePath(rule, path)
+	}
+	if rule.PathPattern != nil {
+		_, ok := rule.PathPattern.Match(path)
+		return ok
+	}
+	return rule.Path == path
+}
+
+// requestBody reads the request body and replaces it so later matchers can read it again
+func requestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// fullMatch reports whether the rule should anchor its patterns to the whole value.
+// Rules inherit server.matchMode, and a mode unset on both is full, whether the
+// configuration was loaded or built in code.
+func (rs *ruleSet) fullMatch(rule *config.RequestRule) bool {
+	mode := rule.MatchMode
+	if mode == "" {
+		mode = rs.config.Server.MatchMode
+	}
+	return mode != config.MatchModePartial
+}
+
+// compilePattern compiles a matcher regex, anchoring it at both ends when full is set.
+func compilePattern(pattern string, full bool) (*regexp.Regexp, error) {
+	if full {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	return regexp.Compile(pattern)
+}
+
+// matchPattern matches value against a regex pattern.
+// If the pattern is invalid, it is treated as an exact match. Loaded configurations
+// have already replaced invalid patterns, warning about each.
+func (rs *ruleSet) matchPattern(pattern, value string, full bool) bool {
+	re := rs.pattern(pattern, full)
+	if re == nil {
+		return value == pattern
+	}
+	return re.MatchString(value)
+}
+
+// statusWriter remembers the status code written through it
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.status = code
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer, for hijacking and flushing
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -5,9 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"http-mock-server/internal/config"
+	"http-mock-server/internal/expr"
+	"http-mock-server/internal/jsonschema"
+	"math"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -28,8 +36,20 @@ func performRequest(
 	return rr
 }
 
+// newTestHandler parses a YAML configuration and builds a handler with a fixed
+// random source
+func newTestHandler(t *testing.T, yaml string) *MockHandler {
+	t.Helper()
+	cfg, err := config.Parse([]byte(yaml), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return NewMockHandlerWithRand(cfg, rand.New(rand.NewSource(1)))
+}
+
 func TestMockHandler_ExactMatch(t *testing.T) {
 	cfg := &config.Config{
+		Server: config.ServerConfig{MatchMode: config.MatchModePartial},
 		Requests: []config.RequestRule{
 			{
 				Path: "/foo",
@@ -199,8 +219,8 @@ func TestMockHandler_QueryParamExactMatch(t *testing.T) {
 			{
 				Path:   "/search",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"foo": "bar",
+				QueryParams: config.QueryParams{
+					"foo": {Pattern: "bar"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -227,8 +247,8 @@ func TestMockHandler_QueryParamRegexMatch(t *testing.T) {
 			{
 				Path:   "/users",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"id": "[0-9]+",
+				QueryParams: config.QueryParams{
+					"id": {Pattern: "[0-9]+"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -259,9 +279,9 @@ func TestMockHandler_QueryParamMultiple(t *testing.T) {
 			{
 				Path:   "/search",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"q":    ".*",
-					"page": "[0-9]+",
+				QueryParams: config.QueryParams{
+					"q":    {Pattern: ".*"},
+					"page": {Pattern: "[0-9]+"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -292,8 +312,8 @@ func TestMockHandler_QueryParamMissing(t *testing.T) {
 			{
 				Path:   "/api",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"token": "secret",
+				QueryParams: config.QueryParams{
+					"token": {Pattern: "secret"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -353,8 +373,8 @@ func TestMockHandler_QueryParamExtraParamsAllowed(t *testing.T) {
 			{
 				Path:   "/test",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"required": "value",
+				QueryParams: config.QueryParams{
+					"required": {Pattern: "value"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -378,8 +398,8 @@ func TestMockHandler_QueryParamInvalidRegexFallsBackToExact(t *testing.T) {
 			{
 				Path:   "/test",
 				Method: "GET",
-				QueryParams: map[string]string{
-					"pattern": "[invalid(regex",
+				QueryParams: config.QueryParams{
+					"pattern": {Pattern: "[invalid(regex"},
 				},
 				Response: config.ResponseSpec{
 					StatusCode: 200,
@@ -764,3 +784,938 @@ func TestMockHandler_ConcurrentDelayedRequests(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestMockHandler_MatchModeFull(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{MatchMode: config.MatchModeFull},
+		Requests: []config.RequestRule{
+			{
+				Path:   "/typed",
+				Method: "GET",
+				Headers: map[string]string{
+					"Content-Type": "text/plain",
+				},
+				QueryParams: config.QueryParams{
+					"id": {Pattern: "[0-9]+|abc"},
+				},
+				Response: config.ResponseSpec{
+					StatusCode: 200,
+				},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/typed?id=abc", map[string]string{"Content-Type": "text/plain"}, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d for exact value, got %d", http.StatusOK, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodGet, "/typed?id=abc", map[string]string{"Content-Type": "text/plainXYZ"}, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for header suffix, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodGet, "/typed?id=123abc", map[string]string{"Content-Type": "text/plain"}, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for alternation outside anchors, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestMockHandler_MatchModeRuleOverride(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{MatchMode: config.MatchModeFull},
+		Requests: []config.RequestRule{
+			{
+				Path:      "/loose",
+				Method:    "POST",
+				Body:      "foo",
+				MatchMode: config.MatchModePartial,
+				Response: config.ResponseSpec{
+					StatusCode: 201,
+				},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/loose", nil, []byte("xxfooxx"))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+}
+
+func TestMockHandler_MatchModeUnsetIsFull(t *testing.T) {
+	h := newTestHandler(t, `
+requests:
+  - path: /foo
+    method: POST
+    headers:
+      Content-Type: application/json
+    body: ".*foo.*"
+    response:
+      status-code: 201
+`)
+
+	rr := performRequest(h, http.MethodPost, "/foo", map[string]string{"Content-Type": "application/json; charset=utf-8"}, []byte("foo"))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected a loaded config without matchMode to match whole values, got %d", rr.Code)
+	}
+	rr = performRequest(h, http.MethodPost, "/foo", map[string]string{"Content-Type": "application/json"}, []byte("a foo b"))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if got := len(h.current().patterns); got != 2 {
+		t.Errorf("expected the 2 patterns to be compiled with the rules, got %d", got)
+	}
+}
+
+func TestMockHandler_WhenCondition(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:   "/pay",
+				Method: "POST",
+				When:   `header('X-Retry') != '' && json.amount > 100`,
+				Response: config.ResponseSpec{
+					StatusCode: 402,
+				},
+			},
+			{
+				Path:   "/pay",
+				Method: "POST",
+				Response: config.ResponseSpec{
+					StatusCode: 200,
+				},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/pay", map[string]string{"X-Retry": "1"}, []byte(`{"amount": 150}`))
+	if rr.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected status %d, got %d", http.StatusPaymentRequired, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/pay", map[string]string{"X-Retry": "1"}, []byte(`{"amount": 50}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/pay", nil, []byte(`{"amount": 150}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestConditionVars_SharedPerRequest(t *testing.T) {
+	same := func(a, b expr.Vars) bool { return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer() }
+	r := withConditionVars(httptest.NewRequest(http.MethodPost, "/pay", strings.NewReader(`{"amount": 150}`)))
+	vars := conditionVars(r)
+	if !same(vars, conditionVars(r)) {
+		t.Fatal("expected the conditions of a request to share one set of variables")
+	}
+	if amount := vars["json"].(map[string]interface{})["amount"]; amount != 150.0 {
+		t.Fatalf("expected the parsed body, got %v", amount)
+	}
+
+	plain := httptest.NewRequest(http.MethodGet, "/", nil)
+	if same(conditionVars(plain), conditionVars(plain)) {
+		t.Fatal("expected a request without shared variables to get new ones")
+	}
+}
+
+func TestMockHandler_WhenConditionErrorDoesNotMatch(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:   "/q",
+				Method: "GET",
+				When:   `query.page > 1`,
+				Response: config.ResponseSpec{
+					StatusCode: 200,
+				},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	// query values are strings, so comparing with a number is an evaluation error
+	rr := performRequest(h, http.MethodGet, "/q?page=2", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	cfg.Requests[0].When = `int(query('page')) > 1`
+	h = NewMockHandler(cfg)
+	rr = performRequest(h, http.MethodGet, "/q?page=2", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestMockHandler_ActiveWindow(t *testing.T) {
+	schedule, err := config.ParseSchedule("* 2-3 * * SUN")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:         "/orders",
+				Method:       "GET",
+				ActiveWindow: config.ActiveWindow{Schedule: schedule},
+				Response:     config.ResponseSpec{StatusCode: 503},
+			},
+			{
+				Path:   "/orders",
+				Method: "GET",
+				ActiveWindow: config.ActiveWindow{
+					From:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					Until: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"before window", time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC), http.StatusNotFound},
+		{"inside window", time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), http.StatusOK},
+		{"until is exclusive", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), http.StatusNotFound},
+		{"maintenance schedule", time.Date(2025, 1, 12, 2, 30, 0, 0, time.Local), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.now = func() time.Time { return tt.now }
+			rr := performRequest(h, http.MethodGet, "/orders", nil, nil)
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestMockHandler_AfterCalls(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:       "/flaky",
+				Method:     "GET",
+				AfterCalls: 2,
+				Response:   config.ResponseSpec{StatusCode: 200},
+			},
+			{
+				Path:     "/flaky",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 503},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	want := []int{503, 503, 200, 200}
+	for i, code := range want {
+		rr := performRequest(h, http.MethodGet, "/flaky", nil, nil)
+		if rr.Code != code {
+			t.Fatalf("call %d: expected status %d, got %d", i+1, code, rr.Code)
+		}
+	}
+
+	h.ResetCallCounts()
+	rr := performRequest(h, http.MethodGet, "/flaky", nil, nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d after reset, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
+func TestMockHandler_OnCall(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:     "/third",
+				Method:   "GET",
+				OnCall:   3,
+				Response: config.ResponseSpec{StatusCode: 500},
+			},
+			{
+				Path:     "/third",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	want := []int{200, 200, 500, 200}
+	for i, code := range want {
+		rr := performRequest(h, http.MethodGet, "/third", nil, nil)
+		if rr.Code != code {
+			t.Fatalf("call %d: expected status %d, got %d", i+1, code, rr.Code)
+		}
+	}
+}
+
+func TestMockHandler_CallCountOnlyCountsMatchingRequests(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:        "/count",
+				Method:      "GET",
+				QueryParams: config.QueryParams{"id": {Pattern: "1"}},
+				OnCall:      2,
+				Response:    config.ResponseSpec{StatusCode: 201},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	performRequest(h, http.MethodGet, "/count?id=2", nil, nil)
+	performRequest(h, http.MethodGet, "/count?id=1", nil, nil)
+	rr := performRequest(h, http.MethodGet, "/count?id=1", nil, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected second matching call to get %d, got %d", http.StatusCreated, rr.Code)
+	}
+}
+
+func TestMockHandler_CallCountSkipsRejectedRequests(t *testing.T) {
+	schema, err := jsonschema.Parse([]byte(`{"type": "object", "required": ["name"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{Path: "/strict", Method: "POST", Schema: schema, BodySchemaMode: config.SchemaModeEnforce, OnCall: 2, Response: config.ResponseSpec{StatusCode: 201}},
+			{Path: "/strict", Method: "POST", Response: config.ResponseSpec{StatusCode: 200}},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	// The request rejected by the schema doesn't use up the second call
+	want := []struct {
+		body string
+		code int
+	}{{`{"name": "ada"}`, 200}, {`{}`, 400}, {`{"name": "ada"}`, 201}, {`{"name": "ada"}`, 200}}
+	for i, c := range want {
+		if rr := performRequest(h, http.MethodPost, "/strict", nil, []byte(c.body)); rr.Code != c.code {
+			t.Fatalf("call %d: expected status %d, got %d", i+1, c.code, rr.Code)
+		}
+	}
+}
+
+func TestMockHandler_CallCountOnlyCountsSelectedWeightedRule(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{Path: "/weighted", Method: "GET", Weight: 1, AfterCalls: 1, Response: config.ResponseSpec{StatusCode: 201}},
+			{Path: "/weighted", Method: "GET", Weight: 1, Response: config.ResponseSpec{StatusCode: 200}},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	// The first request only reaches the second rule, but counts for the first
+	served := 0
+	for i := 0; i < 50; i++ {
+		if rr := performRequest(h, http.MethodGet, "/weighted", nil, nil); rr.Code == http.StatusCreated {
+			served++
+		}
+	}
+	if served == 0 || served == 50 {
+		t.Fatalf("expected both weighted rules to be picked, first served %d times", served)
+	}
+	if n := h.current().calls[&cfg.Requests[0]].Load(); n != int64(served+1) {
+		t.Fatalf("expected %d counted calls, got %d", served+1, n)
+	}
+}
+
+func TestMockHandler_WeightedRuleSelection(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:     "/weighted",
+				Method:   "GET",
+				Weight:   90,
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+			{
+				Path:     "/weighted",
+				Method:   "GET",
+				Weight:   10,
+				Response: config.ResponseSpec{StatusCode: 500},
+			},
+			{
+				Path:     "/weighted",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 418},
+			},
+		},
+	}
+
+	h := NewMockHandlerWithRand(cfg, rand.New(rand.NewSource(1)))
+
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		rr := performRequest(h, http.MethodGet, "/weighted", nil, nil)
+		counts[rr.Code]++
+	}
+
+	if counts[http.StatusTeapot] != 0 {
+		t.Fatalf("unweighted rule after weighted matches must not be selected, got %d", counts[http.StatusTeapot])
+	}
+	if counts[http.StatusOK] < 850 || counts[http.StatusOK] > 950 {
+		t.Fatalf("expected about 900 successes, got %v", counts)
+	}
+	if counts[http.StatusOK]+counts[http.StatusInternalServerError] != 1000 {
+		t.Fatalf("unexpected status distribution %v", counts)
+	}
+}
+
+func TestMockHandler_UnweightedFirstMatchWins(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:     "/first",
+				Method:   "GET",
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+			{
+				Path:     "/first",
+				Method:   "GET",
+				Weight:   100,
+				Response: config.ResponseSpec{StatusCode: 500},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	for i := 0; i < 20; i++ {
+		if rr := performRequest(h, http.MethodGet, "/first", nil, nil); rr.Code != http.StatusOK {
+			t.Fatalf("expected first unweighted rule to win, got %d", rr.Code)
+		}
+	}
+}
+
+func TestMockHandler_BodySchema(t *testing.T) {
+	schema, err := jsonschema.Parse([]byte(`{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{Path: "/users", Method: "POST", Schema: schema, Response: config.ResponseSpec{StatusCode: 201}},
+			{Path: "/users", Method: "POST", Response: config.ResponseSpec{StatusCode: 422}},
+			{Path: "/strict", Method: "POST", Schema: schema, BodySchemaMode: config.SchemaModeEnforce, Response: config.ResponseSpec{StatusCode: 201}},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/users", nil, []byte(`{"name": "ada"}`))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/users", nil, []byte(`{"name": 1}`))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected fallthrough to status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/strict", nil, []byte(`{}`))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `missing required property \"name\"`) {
+		t.Fatalf("expected validation errors in body, got %s", rr.Body.String())
+	}
+
+	rr = performRequest(h, http.MethodPost, "/strict", nil, []byte(`{"name": "ada"}`))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+}
+
+func TestMockHandler_BodySize(t *testing.T) {
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:     "/upload",
+				Method:   "POST",
+				BodySize: &config.SizeRange{MinBytes: 1, MaxBytes: 10},
+				Response: config.ResponseSpec{StatusCode: 200},
+			},
+			{
+				Path:     "/upload",
+				Method:   "POST",
+				BodySize: &config.SizeRange{MinBytes: 11, MaxBytes: -1},
+				Response: config.ResponseSpec{StatusCode: 413},
+			},
+			{
+				Path:          "/declared",
+				Method:        "POST",
+				ContentLength: &config.SizeRange{MinBytes: 0, MaxBytes: 4},
+				Response:      config.ResponseSpec{StatusCode: 200},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/upload", "small", http.StatusOK},
+		{"/upload", "much larger payload", http.StatusRequestEntityTooLarge},
+		{"/upload", "", http.StatusNotFound},
+		{"/declared", "abcd", http.StatusOK},
+		{"/declared", "abcde", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodPost, tt.path, nil, []byte(tt.body))
+		if rr.Code != tt.want {
+			t.Errorf("%s with %q: expected status %d, got %d", tt.path, tt.body, tt.want, rr.Code)
+		}
+	}
+
+	// Chunked requests declare no length and never satisfy contentLength
+	req := httptest.NewRequest(http.MethodPost, "/declared", strings.NewReader("ab"))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for unknown length, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestMockHandler_QueryParamPresence(t *testing.T) {
+	present, absent := true, false
+	cfg := &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:        "/search",
+				Method:      "GET",
+				QueryParams: config.QueryParams{"debug": {Present: &present}},
+				Response:    config.ResponseSpec{StatusCode: 200, Body: "debug"},
+			},
+			{
+				Path:        "/search",
+				Method:      "GET",
+				QueryParams: config.QueryParams{"legacy": {Present: &absent}},
+				Response:    config.ResponseSpec{StatusCode: 200, Body: "modern"},
+			},
+		},
+	}
+
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/search?debug", "debug"},
+		{"/search?debug=", "debug"},
+		{"/search?debug=1&legacy=1", "debug"},
+		{"/search", "modern"},
+		{"/search?legacy=", ""},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, tt.path, nil, nil)
+		got := rr.Body.String()
+		if rr.Code == http.StatusNotFound {
+			got = ""
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestMockHandler_EarlyHints(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /page
+    responseDelay: { min: 10, max: 10 }
+    response:
+      headers:
+        Content-Type: text/html
+      body: <html></html>
+      earlyHints:
+        - </app.css>; rel=preload; as=style
+        - </app.js>; rel=preload; as=script
+  - path: /plain
+    response:
+      body: ok
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(NewMockHandler(cfg))
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, []int, []textproto.MIMEHeader) {
+		var codes []int
+		var hints []textproto.MIMEHeader
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				codes = append(codes, code)
+				hints = append(hints, header)
+				return nil
+			},
+		}
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp, codes, hints
+	}
+
+	resp, codes, hints := get("/page")
+	if len(codes) != 1 || codes[0] != http.StatusEarlyHints {
+		t.Fatalf("expected one 103 response, got %v", codes)
+	}
+	if links := hints[0]["Link"]; len(links) != 2 || links[0] != "</app.css>; rel=preload; as=style" {
+		t.Fatalf("unexpected early hint links %v", links)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Link") != "" {
+		t.Fatalf("expected final 200 without Link, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	if _, codes, _ := get("/plain"); len(codes) != 0 {
+		t.Fatalf("expected no informational responses, got %v", codes)
+	}
+}
+
+func TestMockHandler_BodyBase64(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /logo.png
+    response:
+      headers:
+        Content-Type: image/png
+      checksums: [etag]
+      bodyBase64: |
+        iVBORw0KGgoAAAAN
+        SUhEUgD/AA==
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0, 0, 0, 0x0d, 'I', 'H', 'D', 'R', 0, 0xff, 0}
+
+	rr := performRequest(NewMockHandler(cfg), http.MethodGet, "/logo.png", nil, nil)
+	if !bytes.Equal(rr.Body.Bytes(), want) {
+		t.Fatalf("expected body %x, got %x", want, rr.Body.Bytes())
+	}
+	if rr.Header().Get("Content-Type") != "image/png" || rr.Header().Get("ETag") == "" {
+		t.Fatalf("unexpected headers: %v", rr.Header())
+	}
+}
+
+func TestMockHandler_WeightedResponses(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /flaky
+    responses:
+      - weight: 80
+        body: ok
+      - weight: 15
+        status-code: 429
+        headers:
+          Retry-After: "1"
+      - weight: 5
+        status-code: 500
+        variants:
+          application/json: {body: '{"error":"internal"}'}
+          text/plain: {body: internal}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandlerWithRand(cfg, rand.New(rand.NewSource(1)))
+
+	counts := map[int]int{}
+	for i := 0; i < 2000; i++ {
+		rr := performRequest(h, http.MethodGet, "/flaky", map[string]string{"Accept": "text/plain"}, nil)
+		counts[rr.Code]++
+		switch rr.Code {
+		case http.StatusTooManyRequests:
+			if rr.Header().Get("Retry-After") != "1" {
+				t.Fatalf("expected Retry-After on 429, got %v", rr.Header())
+			}
+		case http.StatusInternalServerError:
+			if rr.Body.String() != "internal" || rr.Header().Get("Vary") != "Accept" {
+				t.Fatalf("expected negotiated 500 variant, got %q %v", rr.Body.String(), rr.Header())
+			}
+		}
+	}
+	if counts[200] < 1500 || counts[200] > 1700 || counts[429] < 220 || counts[429] > 380 || counts[500] < 50 || counts[500] > 150 {
+		t.Fatalf("unexpected status distribution %v", counts)
+	}
+}
+
+func TestMockHandler_ConditionalResponses(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /payments
+    method: POST
+    responses:
+      - when: header("X-Fail") == "card"
+        status-code: 402
+        body: declined
+      - when: json.amount > 1000
+        status-code: 422
+        body: limit exceeded
+      - status-code: 201
+        body: created
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		headers map[string]string
+		body    string
+		status  int
+		want    string
+	}{
+		{nil, `{"amount": 10}`, http.StatusCreated, "created"},
+		{map[string]string{"X-Fail": "card"}, `{"amount": 5000}`, http.StatusPaymentRequired, "declined"},
+		{nil, `{"amount": 5000}`, http.StatusUnprocessableEntity, "limit exceeded"},
+		{nil, `not json`, http.StatusCreated, "created"},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodPost, "/payments", tt.headers, []byte(tt.body))
+		if rr.Code != tt.status || rr.Body.String() != tt.want {
+			t.Fatalf("%v %s: expected %d %q, got %d %q", tt.headers, tt.body, tt.status, tt.want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestMockHandler_CalculateDelayDistributions(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    config.ResponseDelay
+		p50, p99 float64 // Expected percentiles in milliseconds
+		min, max float64 // Bounds every delay must respect
+	}{
+		{
+			name:  "fixed",
+			delay: config.ResponseDelay{Distribution: config.DelayFixed, P50: 250},
+			p50:   250, p99: 250, min: 250, max: 250,
+		},
+		{
+			name:  "normal",
+			delay: config.ResponseDelay{Distribution: config.DelayNormal, P50: 200, P99: 300, Sigma: 100 / 2.3263479},
+			p50:   200, p99: 300, min: 0, max: 1000,
+		},
+		{
+			name:  "lognormal",
+			delay: config.ResponseDelay{Distribution: config.DelayLognormal, P50: 100, P99: 1000, Sigma: math.Log(10) / 2.3263479},
+			p50:   100, p99: 1000, min: 0, max: 100000,
+		},
+		{
+			name:  "lognormal clamped",
+			delay: config.ResponseDelay{Distribution: config.DelayLognormal, P50: 100, P99: 1000, Min: 80, Max: 500, Sigma: math.Log(10) / 2.3263479},
+			p50:   100, p99: 500, min: 80, max: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMockHandlerWithRand(&config.Config{}, rand.New(rand.NewSource(42)))
+			samples := make([]float64, 20000)
+			for i := range samples {
+				ms := float64(h.calculateDelay(&tt.delay)) / float64(time.Millisecond)
+				if ms < tt.min || ms > tt.max {
+					t.Fatalf("delay %.1fms outside [%.0f, %.0f]", ms, tt.min, tt.max)
+				}
+				samples[i] = ms
+			}
+			sort.Float64s(samples)
+			p50, p99 := samples[len(samples)/2], samples[len(samples)*99/100]
+			if math.Abs(p50-tt.p50) > tt.p50*0.05 {
+				t.Fatalf("expected p50 near %.0fms, got %.1fms", tt.p50, p50)
+			}
+			if math.Abs(p99-tt.p99) > tt.p99*0.1 {
+				t.Fatalf("expected p99 near %.0fms, got %.1fms", tt.p99, p99)
+			}
+		})
+	}
+}
+
+func TestMockHandler_VariantHeader(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  variantHeader: X-Test-Variant
+requests:
+  - path: /orders
+    responses:
+      - name: error-500
+        onDemand: true
+        status-code: 500
+        body: internal
+      - name: declined
+        when: header("X-Fail") == "card"
+        status-code: 402
+        body: declined
+      - name: ok
+        body: ok
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		headers map[string]string
+		status  int
+		want    string
+	}{
+		{nil, http.StatusOK, "ok"},
+		{map[string]string{"X-Test-Variant": "error-500"}, http.StatusInternalServerError, "internal"},
+		{map[string]string{"X-Test-Variant": "ok", "X-Fail": "card"}, http.StatusOK, "ok"},
+		{map[string]string{"X-Fail": "card"}, http.StatusPaymentRequired, "declined"},
+		{map[string]string{"X-Test-Variant": "missing"}, http.StatusOK, "ok"},
+		{map[string]string{"X-Mock-Variant": "error-500"}, http.StatusOK, "ok"},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, "/orders", tt.headers, nil)
+		if rr.Code != tt.status || rr.Body.String() != tt.want {
+			t.Fatalf("headers %v: expected %d %q, got %d %q", tt.headers, tt.status, tt.want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestMockHandler_PathPrefix(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /api/v1/users
+    method: GET
+    response:
+      body: users
+  - pathPrefix: /api/v1/
+    method: GET
+    response:
+      status-code: 501
+      template: true
+      body: "{{.PathRest}} is not mocked yet"
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/api/v1/users", http.StatusOK, "users"},
+		{"/api/v1/orders/42", http.StatusNotImplemented, "orders/42 is not mocked yet"},
+		{"/api/v1/", http.StatusNotImplemented, " is not mocked yet"},
+		{"/api/v1", http.StatusNotFound, ""},
+		{"/api/v2/orders", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, tt.path, nil, nil)
+		if rr.Code != tt.status {
+			t.Fatalf("%s: expected %d, got %d", tt.path, tt.status, rr.Code)
+		}
+		if tt.want != "" && rr.Body.String() != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.path, tt.want, rr.Body.String())
+		}
+	}
+}
+
+func TestMockHandler_LiteralPatterns(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  matchMode: partial
+requests:
+  - path: /search
+    method: GET
+    queryParams:
+      q: "literal:c++"
+    headers:
+      X-Version: "1.0("
+    response:
+      body: found
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		query   string
+		version string
+		status  int
+	}{
+		{"c%2B%2B", "1.0(", http.StatusOK},
+		{"cc", "1.0(", http.StatusNotFound},
+		{"c%2B%2B%20tutorial", "1.0(", http.StatusNotFound},
+		{"c%2B%2B", "1.0(beta", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, "/search?q="+tt.query, map[string]string{"X-Version": tt.version}, nil)
+		if rr.Code != tt.status {
+			t.Fatalf("q=%s version=%s: expected %d, got %d", tt.query, tt.version, tt.status, rr.Code)
+		}
+	}
+}
+
+func TestMockHandler_RepeatedHeaders(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /a
+    response:
+      headers:
+        Vary: [Accept, Origin]
+        Link: ['</a.css>; rel=preload', '</b.css>; rel=preload']
+      body: ok
+  - path: /t
+    response:
+      template: true
+      headers:
+        X-Seen: ['{{ .Query.a }}', '{{ .Query.b }}']
+      body: ok
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/a", nil, nil)
+	if got := rr.Header().Values("Vary"); len(got) != 2 || got[0] != "Accept" || got[1] != "Origin" {
+		t.Fatalf("expected separate Vary lines, got %q", got)
+	}
+	if got := rr.Header().Values("Link"); len(got) != 2 {
+		t.Fatalf("expected separate Link lines, got %q", got)
+	}
+	rr = performRequest(h, http.MethodGet, "/t?a=1&b=2", nil, nil)
+	if got := rr.Header().Values("X-Seen"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Fatalf("expected rendered values on separate lines, got %q", got)
+	}
+}
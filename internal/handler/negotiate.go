@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"http-mock-server/internal/config"
+)
+
+// acceptRange is one media range of an Accept header
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header, skipping malformed ranges
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q-value the most specific matching range gives a media type,
+// or 0 when no range matches
+func quality(ranges []acceptRange, mediaType string) float64 {
+	base, _, _ := mime.ParseMediaType(mediaType)
+	typ, subtype, _ := strings.Cut(base, "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// negotiate picks the response variant the client prefers according to its Accept
+// header. Ties go to the variant declared first, as does a missing or unparseable
+// header. It returns nil when the client accepts none of the variants.
+func negotiate(spec *config.ResponseSpec, r *http.Request) *config.ResponseSpec {
+	if len(spec.Variants) == 0 {
+		return spec
+	}
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if len(ranges) == 0 {
+		return &spec.Variants[0].Response
+	}
+
+	var best *config.ResponseSpec
+	bestQ := 0.0
+	for i := range spec.Variants {
+		if q := quality(ranges, spec.Variants[i].MediaType); q > bestQ {
+			best, bestQ = &spec.Variants[i].Response, q
+		}
+	}
+	return best
+}
+
+// writeNotAcceptable answers 406 listing the media types the rule can produce
+func writeNotAcceptable(w http.ResponseWriter, spec *config.ResponseSpec) {
+	types := make([]string, len(spec.Variants))
+	for i, v := range spec.Variants {
+		types[i] = v.MediaType
+	}
+	w.Header().Set("Vary", "Accept")
+	http.Error(w, "not acceptable, available: "+strings.Join(types, ", "), http.StatusNotAcceptable)
+}
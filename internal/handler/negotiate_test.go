@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_ContentNegotiation(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /users/1
+    response:
+      headers:
+        Cache-Control: no-store
+      variants:
+        application/json:
+          body: {id: 1}
+        application/xml:
+          body: "<user id=\"1\"/>"
+        text/csv; charset=utf-8:
+          body: "id\n1\n"
+          status-code: 203
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{name: "no accept header", wantStatus: 200, wantType: "application/json", wantBody: `{"id":1}`},
+		{name: "exact", accept: "application/xml", wantStatus: 200, wantType: "application/xml", wantBody: `<user id="1"/>`},
+		{name: "q-values", accept: "application/json;q=0.5, application/xml;q=0.9", wantStatus: 200, wantType: "application/xml", wantBody: `<user id="1"/>`},
+		{name: "type wildcard", accept: "text/*", wantStatus: 203, wantType: "text/csv; charset=utf-8", wantBody: "id\n1\n"},
+		{name: "specific range wins", accept: "*/*, application/json;q=0", wantStatus: 200, wantType: "application/xml", wantBody: `<user id="1"/>`},
+		{name: "tie keeps declaration order", accept: "application/xml, application/json", wantStatus: 200, wantType: "application/json", wantBody: `{"id":1}`},
+		{name: "not acceptable", accept: "image/png", wantStatus: http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.accept != "" {
+				headers["Accept"] = tt.accept
+			}
+			rr := performRequest(h, http.MethodGet, "/users/1", headers, nil)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if rr.Header().Get("Vary") != "Accept" {
+				t.Fatalf("expected Vary: Accept, got %q", rr.Header().Get("Vary"))
+			}
+			if tt.wantStatus == http.StatusNotAcceptable {
+				return
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.wantType {
+				t.Fatalf("expected Content-Type %q, got %q", tt.wantType, got)
+			}
+			if rr.Header().Get("Cache-Control") != "no-store" {
+				t.Fatalf("expected base headers to be inherited")
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Fatalf("expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+}
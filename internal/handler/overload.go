@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"log"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// overloadMonitor periodically measures the process's CPU use and how late its own
+// timers fire, and flags the server as overloaded while either is over its limit
+type overloadMonitor struct {
+	maxCPU   float64
+	maxLag   time.Duration
+	status   int
+	interval time.Duration
+	cpuTime  func() (time.Duration, bool) // CPU time used by the process, if the platform reports it
+
+	start      sync.Once
+	lastCPU    time.Duration
+	overloaded atomic.Bool
+	cpu        atomic.Uint64 // Bits of the last CPU fraction
+	lag        atomic.Int64  // Last lag in nanoseconds
+}
+
+func newOverloadMonitor(o *config.Overload) *overloadMonitor {
+	return &overloadMonitor{
+		maxCPU:   o.MaxCPU,
+		maxLag:   time.Duration(o.MaxLag) * time.Millisecond,
+		status:   o.Status,
+		interval: time.Duration(o.Interval) * time.Millisecond,
+		cpuTime:  processCPUTime,
+	}
+}
+
+// run starts measuring in the background, once
+func (m *overloadMonitor) run() {
+	m.start.Do(func() {
+		if _, ok := m.cpuTime(); !ok && m.maxCPU > 0 {
+			log.Printf("Overload maxCPU is not supported on %s; only maxLag applies", runtime.GOOS)
+		}
+		m.lastCPU, _ = m.cpuTime()
+		go func() {
+			for {
+				begin := time.Now()
+				time.Sleep(m.interval)
+				m.sample(time.Since(begin))
+			}
+		}()
+	})
+}
+
+// sample updates the measurements after a sleep of m.interval that took elapsed
+func (m *overloadMonitor) sample(elapsed time.Duration) {
+	lag := elapsed - m.interval
+	if lag < 0 {
+		lag = 0
+	}
+	m.lag.Store(int64(lag))
+	over := m.maxLag > 0 && lag > m.maxLag
+
+	if used, ok := m.cpuTime(); ok {
+		cpu := float64(used-m.lastCPU) / float64(elapsed) / float64(runtime.GOMAXPROCS(0))
+		m.lastCPU = used
+		m.cpu.Store(math.Float64bits(cpu))
+		over = over || (m.maxCPU > 0 && cpu > m.maxCPU)
+	}
+
+	if m.overloaded.Swap(over) != over {
+		if over {
+			log.Printf("Server overloaded (CPU %.0f%%, lag %v); answering mock requests with %d", m.cpuFraction()*100, lag.Round(time.Millisecond), m.status)
+		} else {
+			log.Printf("Server no longer overloaded (CPU %.0f%%, lag %v)", m.cpuFraction()*100, lag.Round(time.Millisecond))
+		}
+	}
+}
+
+func (m *overloadMonitor) cpuFraction() float64 {
+	return math.Float64frombits(m.cpu.Load())
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"http-mock-server/internal/config"
+)
+
+// paginateResponse serves one page of the list dataset of spec, with X-Total-Count and
+// a Link header to the neighbouring pages
+func (h *MockHandler) paginateResponse(r *http.Request, rs *ruleSet, spec *config.ResponseSpec) ([]byte, int, map[string]string, error) {
+	p := spec.Paginate
+	data, err := h.dataset(rs, spec.Dataset)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, 0, nil, fmt.Errorf("dataset %q is not a list and cannot be paginated", spec.Dataset)
+	}
+	query := r.URL.Query()
+	limit, err := pageNumber(query.Get(p.LimitParam), p.LimitParam, p.DefaultLimit)
+	if err != nil {
+		return badPage(err)
+	}
+	limit = min(limit, p.MaxLimit)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"X-Total-Count": strconv.Itoa(len(items)),
+	}
+	var links []string
+	link := func(rel string, values map[string]string) {
+		q := r.URL.Query()
+		for name, value := range values {
+			q.Set(name, value)
+		}
+		links = append(links, fmt.Sprintf("<%s?%s>; rel=%q", r.URL.Path, q.Encode(), rel))
+	}
+
+	var offset int
+	if p.Style == config.PaginateCursor {
+		if cursor := query.Get(p.CursorParam); cursor != "" {
+			if offset, err = decodeCursor(cursor); err != nil {
+				return badPage(fmt.Errorf("invalid %s", p.CursorParam))
+			}
+		}
+		if next := offset + limit; next < len(items) {
+			cursor := encodeCursor(next)
+			headers["X-Next-Cursor"] = cursor
+			link("next", map[string]string{p.CursorParam: cursor, p.LimitParam: strconv.Itoa(limit)})
+		}
+	} else {
+		page, err := pageNumber(query.Get(p.PageParam), p.PageParam, 1)
+		if err != nil {
+			return badPage(err)
+		}
+		offset = (page - 1) * limit
+		last := max(1, (len(items)+limit-1)/limit)
+		pageLink := func(rel string, n int) {
+			link(rel, map[string]string{p.PageParam: strconv.Itoa(n), p.LimitParam: strconv.Itoa(limit)})
+		}
+		pageLink("first", 1)
+		if page > 1 {
+			pageLink("prev", min(page-1, last))
+		}
+		if page < last {
+			pageLink("next", page+1)
+		}
+		pageLink("last", last)
+	}
+	headers["Link"] = strings.Join(links, ", ")
+	for name, value := range spec.Headers {
+		headers[name] = value
+	}
+
+	offset = min(offset, len(items))
+	body, err := json.Marshal(items[offset:min(offset+limit, len(items))])
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return body, spec.StatusCode, headers, nil
+}
+
+// pageNumber parses a positive query parameter, or returns the default when it is absent
+func pageNumber(value, name string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+	return n, nil
+}
+
+// badPage answers a request with invalid paging parameters with 400 Bad Request
+func badPage(err error) ([]byte, int, map[string]string, error) {
+	return []byte(err.Error() + "\n"), http.StatusBadRequest, map[string]string{"Content-Type": "text/plain; charset=utf-8"}, nil
+}
+
+// encodeCursor returns an opaque cursor for the item offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	rest, ok := strings.CutPrefix(string(raw), "offset:")
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(rest)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// paginateConfig writes five items and returns a configuration serving them
+// with the given paginate settings
+func paginateConfig(t *testing.T, paginate string) string {
+	t.Helper()
+	var items []string
+	for i := 1; i <= 5; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d}`, i))
+	}
+	file := filepath.Join(t.TempDir(), "items.json")
+	if err := os.WriteFile(file, []byte("["+strings.Join(items, ",")+"]"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return `
+datasets:
+  items: ` + file + `
+requests:
+  - path: /items
+    response:
+      dataset: items
+      paginate:
+        ` + paginate + `
+`
+}
+
+func TestPaginate_Pages(t *testing.T) {
+	h := newTestHandler(t, paginateConfig(t, "defaultLimit: 2\n        maxLimit: 3"))
+
+	tests := []struct {
+		query  string
+		status int
+		body   string
+		link   string
+	}{
+		{"", http.StatusOK, `[{"id":1},{"id":2}]`, `</items?limit=2&page=1>; rel="first", </items?limit=2&page=2>; rel="next", </items?limit=2&page=3>; rel="last"`},
+		{"?page=2", http.StatusOK, `[{"id":3},{"id":4}]`, `</items?limit=2&page=1>; rel="first", </items?limit=2&page=1>; rel="prev", </items?limit=2&page=3>; rel="next", </items?limit=2&page=3>; rel="last"`},
+		{"?page=2&limit=10", http.StatusOK, `[{"id":4},{"id":5}]`, `</items?limit=3&page=1>; rel="first", </items?limit=3&page=1>; rel="prev", </items?limit=3&page=2>; rel="last"`},
+		{"?page=9", http.StatusOK, `[]`, `</items?limit=2&page=1>; rel="first", </items?limit=2&page=3>; rel="prev", </items?limit=2&page=3>; rel="last"`},
+		{"?page=0", http.StatusBadRequest, "page must be a positive number\n", ""},
+		{"?limit=x", http.StatusBadRequest, "limit must be a positive number\n", ""},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, "/items"+tt.query, nil, nil)
+		if rr.Code != tt.status || rr.Body.String() != tt.body {
+			t.Fatalf("%s: expected %d %s, got %d %s", tt.query, tt.status, tt.body, rr.Code, rr.Body.String())
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if got := rr.Header().Get("Link"); got != tt.link {
+			t.Fatalf("%s: expected Link %s, got %s", tt.query, tt.link, got)
+		}
+		if got := rr.Header().Get("X-Total-Count"); got != "5" {
+			t.Fatalf("%s: expected X-Total-Count 5, got %s", tt.query, got)
+		}
+	}
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	h := newTestHandler(t, paginateConfig(t, "style: cursor\n        defaultLimit: 2"))
+
+	var ids []string
+	path := "/items"
+	for pages := 0; path != ""; pages++ {
+		if pages > 5 {
+			t.Fatalf("expected the cursor to reach the end")
+		}
+		rr := performRequest(h, http.MethodGet, path, nil, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, rr.Code)
+		}
+		ids = append(ids, rr.Body.String())
+		path = ""
+		if link := rr.Header().Get("Link"); link != "" {
+			path = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+	if got := strings.Join(ids, " "); got != `[{"id":1},{"id":2}] [{"id":3},{"id":4}] [{"id":5}]` {
+		t.Fatalf("unexpected pages: %s", got)
+	}
+	if rr := performRequest(h, http.MethodGet, "/items?cursor=nope", nil, nil); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid cursor to be rejected, got %d", rr.Code)
+	}
+}
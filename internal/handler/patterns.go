@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"regexp"
+
+	"http-mock-server/internal/config"
+)
+
+// patternKey identifies a matcher pattern compiled with or without full anchoring
+type patternKey struct {
+	pattern string
+	full    bool
+}
+
+// compilePatterns compiles the header, query parameter, body, and client certificate
+// patterns of rule i and its matcher groups once, anchored by the rule's match mode.
+// An invalid pattern is stored as nil and matched exactly.
+func (rs *ruleSet) compilePatterns(i int) {
+	rule := &rs.config.Requests[i]
+	full := rs.fullMatch(rule)
+	add := func(pattern string) {
+		key := patternKey{pattern, full}
+		if _, ok := rs.patterns[key]; pattern == "" || ok {
+			return
+		}
+		re, err := compilePattern(pattern, full)
+		if err != nil {
+			re = nil
+		}
+		rs.patterns[key] = re
+	}
+	addMatcher := func(headers map[string]string, params config.QueryParams, body string) {
+		for _, pattern := range headers {
+			add(pattern)
+		}
+		for _, m := range params {
+			add(m.Pattern)
+		}
+		add(body)
+	}
+
+	addMatcher(rule.Headers, rule.QueryParams, rule.Body)
+	_ = rule.Groups.Walk(func(m *config.Matcher) error {
+		addMatcher(m.Headers, m.QueryParams, m.Body)
+		return nil
+	})
+	if cc := rule.ClientCert; cc != nil {
+		add(cc.Subject)
+		add(cc.SAN)
+		add(cc.Issuer)
+	}
+}
+
+// pattern returns the compiled pattern, or nil when it is invalid. Patterns of the
+// rule set are compiled when it is built, so this only compiles patterns it hasn't
+// seen, without caching them, since the rule set is read concurrently.
+func (rs *ruleSet) pattern(pattern string, full bool) *regexp.Regexp {
+	if re, ok := rs.patterns[patternKey{pattern, full}]; ok {
+		return re
+	}
+	re, err := compilePattern(pattern, full)
+	if err != nil {
+		return nil
+	}
+	return re
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// writeProxy forwards the request to the proxy target and relays the upstream response.
+// Upstream failures are answered with 502 Bad Gateway, or 504 Gateway Timeout when the
+// upstream didn't answer in time.
+func writeProxy(w http.ResponseWriter, r *http.Request, p *config.Proxy) {
+	if p.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(p.Timeout)*time.Millisecond)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			path := strings.TrimPrefix(pr.In.URL.Path, p.StripPrefix)
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+			pr.Out.URL.Path, pr.Out.URL.RawPath = path, ""
+			pr.SetURL(p.TargetURL)
+			pr.SetXForwarded()
+			// DecompressionMiddleware already decoded the body
+			pr.Out.Header.Del("Content-Encoding")
+			for name, value := range p.Headers {
+				if http.CanonicalHeaderKey(name) == "Host" {
+					pr.Out.Host = value
+				} else {
+					pr.Out.Header.Set(name, value)
+				}
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Proxying %s %s to %s failed: %v", r.Method, r.URL.Path, p.Target, err)
+			status := http.StatusBadGateway
+			if r.Context().Err() == context.DeadlineExceeded {
+				status = http.StatusGatewayTimeout
+			}
+			http.Error(w, http.StatusText(status), status)
+		},
+	}
+	rp.ServeHTTP(w, r)
+}
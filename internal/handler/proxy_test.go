@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestProxy_ForwardsUnmockedRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", "real")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "%s %s?%s host=%s token=%s body=%s", r.Method, r.URL.Path, r.URL.RawQuery, r.Host, r.Header.Get("X-Token"), body)
+	}))
+	defer upstream.Close()
+
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+requests:
+  - path: /mock/api/users
+    method: GET
+    response:
+      body: mocked
+  - pathPrefix: /mock/
+    method: POST
+    response:
+      proxy:
+        target: %[1]s/v2
+        stripPrefix: /mock
+        headers:
+          X-Token: secret
+  - pathPrefix: /mock/
+    method: GET
+    response:
+      proxy:
+        target: %[1]s
+        stripPrefix: /mock
+`, upstream.URL)), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	host := strings.TrimPrefix(upstream.URL, "http://")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{http.MethodGet, "/mock/api/users", "", http.StatusOK, "mocked"},
+		{http.MethodGet, "/mock/api/orders?page=2", "", http.StatusAccepted, "GET /api/orders?page=2 host=" + host + " token= body="},
+		{http.MethodPost, "/mock/api/orders", `{"id":1}`, http.StatusAccepted, "POST /v2/api/orders? host=" + host + ` token=secret body={"id":1}`},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, tt.method, tt.path, nil, []byte(tt.body))
+		if rr.Code != tt.status || rr.Body.String() != tt.want {
+			t.Fatalf("%s %s: expected %d %q, got %d %q", tt.method, tt.path, tt.status, tt.want, rr.Code, rr.Body.String())
+		}
+		if tt.status == http.StatusAccepted && rr.Header().Get("X-Upstream") != "real" {
+			t.Fatalf("%s %s: expected upstream headers, got %v", tt.method, tt.path, rr.Header())
+		}
+	}
+}
+
+func TestProxy_UpstreamFailures(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+requests:
+  - path: /slow
+    response:
+      proxy:
+        target: %s
+        timeout: 50
+  - path: /down
+    response:
+      proxy:
+        target: %s
+`, slow.URL, down.URL)), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	if rr := performRequest(h, http.MethodGet, "/slow", nil, nil); rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504 from a slow upstream, got %d", rr.Code)
+	}
+	if rr := performRequest(h, http.MethodGet, "/down", nil, nil); rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 from an unreachable upstream, got %d", rr.Code)
+	}
+}
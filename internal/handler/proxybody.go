@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// fetchedBody is the in-memory copy of a cached proxyBody
+type fetchedBody struct {
+	mu          sync.Mutex
+	data        []byte
+	contentType string
+	fetchedAt   time.Time // Zero until the first successful fetch
+}
+
+var proxyBodyClient = &http.Client{}
+
+// openProxyBody requests the body from the upstream. The timeout covers waiting for
+// the upstream's response headers, not streaming the body itself.
+func openProxyBody(ctx context.Context, pb *config.ProxyBody) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pb.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	timer := time.AfterFunc(time.Duration(pb.Timeout)*time.Millisecond, cancel)
+	resp, err := proxyBodyClient.Do(req)
+	if !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		err = fmt.Errorf("no response within %dms", pb.Timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("proxyBody %s: %w", pb.URL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("proxyBody %s: upstream answered %s", pb.URL, resp.Status)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request context once the streamed body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// fetchProxyBody returns the cached copy of a proxyBody, fetching it when it is
+// missing or older than cacheTTL
+func (rs *ruleSet) fetchProxyBody(ctx context.Context, pb *config.ProxyBody, now time.Time) (*fetchedBody, error) {
+	f := rs.proxyBodies[pb]
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fresh := !f.fetchedAt.IsZero() && (pb.CacheTTL == 0 || now.Sub(f.fetchedAt) < time.Duration(pb.CacheTTL)*time.Second)
+	if fresh {
+		return f, nil
+	}
+	resp, err := openProxyBody(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("proxyBody %s: %w", pb.URL, err)
+	}
+	f.data, f.contentType, f.fetchedAt = data, resp.Header.Get("Content-Type"), now
+	return f, nil
+}
+
+// prefetchProxyBodies fetches the cached proxyBodies with prefetch set, all at once.
+// A body that can't be fetched is logged and fetched again on its first request.
+func (h *MockHandler) prefetchProxyBodies(rs *ruleSet) {
+	var wg sync.WaitGroup
+	for pb := range rs.proxyBodies {
+		if !pb.Prefetch {
+			continue
+		}
+		wg.Add(1)
+		go func(pb *config.ProxyBody) {
+			defer wg.Done()
+			if _, err := rs.fetchProxyBody(context.Background(), pb, h.now()); err != nil {
+				log.Printf("WARNING: could not prefetch %v; it is fetched again on the first request", err)
+			}
+		}(pb)
+	}
+	wg.Wait()
+}
+
+// withContentType adds the upstream Content-Type unless the rule sets one
+func withContentType(headers map[string]string, contentType string) map[string]string {
+	if contentType == "" || hasHeader(headers, "Content-Type") {
+		return headers
+	}
+	out := make(map[string]string, len(headers)+1)
+	for name, value := range headers {
+		out[name] = value
+	}
+	out["Content-Type"] = contentType
+	return out
+}
+
+// streamedBodyHeaders copies the upstream Content-Type and Content-Length of a
+// streamed proxyBody into the response headers the rule leaves unset
+func streamedBodyHeaders(header http.Header, resp *http.Response, trailers bool) {
+	if header.Get("Content-Type") == "" {
+		if ct := resp.Header.Get("Content-Type"); ct != "" {
+			header.Set("Content-Type", ct)
+		}
+	}
+	if resp.ContentLength >= 0 && !trailers {
+		header.Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	}
+}
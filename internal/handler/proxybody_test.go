@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestProxyBody(t *testing.T) {
+	var fetches atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("payload " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /stream
+    response:
+      status-code: 206
+      headers:
+        X-Mock: stream
+      proxyBody: `+upstream.URL+`/artifact
+  - path: /cached
+    response:
+      headers:
+        Content-Type: text/plain
+      proxyBody: {url: '`+upstream.URL+`/cached', cache: true, cacheTTL: 60}
+  - path: /broken
+    response:
+      proxyBody: `+upstream.URL+`/missing
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	rr := performRequest(h, http.MethodGet, "/stream", nil, nil)
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "payload /artifact" {
+		t.Fatalf("unexpected stream response %d %q", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Mock") != "stream" || rr.Header().Get("Content-Type") != "application/octet-stream" || rr.Header().Get("Content-Length") != "17" {
+		t.Fatalf("unexpected stream headers %v", rr.Header())
+	}
+
+	fetches.Store(0)
+	for i := 0; i < 3; i++ {
+		rr = performRequest(h, http.MethodGet, "/cached", nil, nil)
+		if rr.Code != http.StatusOK || rr.Body.String() != "payload /cached" || rr.Header().Get("Content-Type") != "text/plain" {
+			t.Fatalf("unexpected cached response %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected one upstream fetch, got %d", n)
+	}
+	now = now.Add(time.Minute)
+	performRequest(h, http.MethodGet, "/cached", nil, nil)
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("expected a refetch after cacheTTL, got %d fetches", n)
+	}
+
+	rr = performRequest(h, http.MethodGet, "/broken", nil, nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected upstream failure to give 500, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestProxyBody_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /slow
+    response:
+      proxyBody: {url: '`+upstream.URL+`', timeout: 50}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	start := time.Now()
+	rr := performRequest(h, http.MethodGet, "/slow", nil, nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout took %s", elapsed)
+	}
+}
+
+func TestBodyURL(t *testing.T) {
+	fetches := map[string]*atomic.Int64{"/fixture.json": {}, "/lazy.json": {}, "/missing.json": {}}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches[r.URL.Path].Add(1)
+		if r.URL.Path == "/missing.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"fixture":"` + r.URL.Path + `"}`))
+	}))
+	defer upstream.Close()
+
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /users
+    response:
+      bodyUrl: `+upstream.URL+`/fixture.json
+  - path: /lazy
+    response:
+      bodyUrl: {url: '`+upstream.URL+`/lazy.json', lazy: true, ttl: 60}
+  - path: /missing
+    response:
+      bodyUrl: `+upstream.URL+`/missing.json
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	// Fetched when the rules load, unless lazy
+	if fetches["/fixture.json"].Load() != 1 || fetches["/lazy.json"].Load() != 0 || fetches["/missing.json"].Load() != 1 {
+		t.Fatalf("unexpected fetches at load: fixture %d, lazy %d, missing %d",
+			fetches["/fixture.json"].Load(), fetches["/lazy.json"].Load(), fetches["/missing.json"].Load())
+	}
+	for i := 0; i < 2; i++ {
+		rr := performRequest(h, http.MethodGet, "/users", nil, nil)
+		if rr.Body.String() != `{"fixture":"/fixture.json"}` || rr.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("unexpected response %q %v", rr.Body.String(), rr.Header())
+		}
+	}
+	if n := fetches["/fixture.json"].Load(); n != 1 {
+		t.Fatalf("expected the prefetched body to be served from memory, got %d fetches", n)
+	}
+
+	performRequest(h, http.MethodGet, "/lazy", nil, nil)
+	performRequest(h, http.MethodGet, "/lazy", nil, nil)
+	now = now.Add(61 * time.Second)
+	performRequest(h, http.MethodGet, "/lazy", nil, nil)
+	if n := fetches["/lazy.json"].Load(); n != 2 {
+		t.Fatalf("expected the lazy body to be fetched on first use and after its ttl, got %d fetches", n)
+	}
+
+	// A failed prefetch is retried when the body is first requested
+	if rr := performRequest(h, http.MethodGet, "/missing", nil, nil); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected the error response, got %d", rr.Code)
+	}
+	if n := fetches["/missing.json"].Load(); n != 2 {
+		t.Fatalf("expected the failed prefetch to be retried, got %d fetches", n)
+	}
+}
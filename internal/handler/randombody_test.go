@@ -11,18 +11,8 @@ import (
 	"testing"
 )
 
-func newTestHandler() *MockHandler {
-	cfg := &config.Config{}
-	r := rand.New(rand.NewSource(42))
-	return &MockHandler{
-		config:       cfg,
-		rand:         r,
-		cachedBodies: make(map[*config.RandomBodySpec][]byte),
-	}
-}
-
 func TestGeneratePlaintext_ExactSize(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	sizes := []int{0, 1, 10, 100, 512, 1024, 4096}
 	for _, size := range sizes {
@@ -34,7 +24,7 @@ func TestGeneratePlaintext_ExactSize(t *testing.T) {
 }
 
 func TestGeneratePlaintext_ValidChars(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	data := h.generatePlaintext(1000)
 	for i, b := range data {
@@ -45,7 +35,7 @@ func TestGeneratePlaintext_ValidChars(t *testing.T) {
 }
 
 func TestGeneratePlaintext_ZeroSize(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	data := h.generatePlaintext(0)
 	if len(data) != 0 {
@@ -54,7 +44,7 @@ func TestGeneratePlaintext_ZeroSize(t *testing.T) {
 }
 
 func TestGenerateJSON_ValidJSON(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	sizes := []int{2, 9, 10, 50, 100, 500, 1024, 2048}
 	for _, size := range sizes {
@@ -72,7 +62,7 @@ func TestGenerateJSON_ValidJSON(t *testing.T) {
 }
 
 func TestGenerateJSON_ExactSize(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	// Sizes 3-6 fall back to "{}" (2 bytes) and are not exact; size 2 and size >= 7 are exact.
 	sizes := []int{2, 7, 9, 10, 11, 12, 15, 20, 50, 100, 500, 1024, 2048}
@@ -90,7 +80,7 @@ func TestGenerateJSON_ExactSize(t *testing.T) {
 }
 
 func TestGenerateJSON_MinimalSize(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	data, err := h.generateJSON(2)
 	if err != nil {
@@ -102,7 +92,7 @@ func TestGenerateJSON_MinimalSize(t *testing.T) {
 }
 
 func TestGenerateXML_ValidXML(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	sizes := []int{7, 13, 20, 50, 100, 500, 1024}
 	for _, size := range sizes {
@@ -123,7 +113,7 @@ func TestGenerateXML_ValidXML(t *testing.T) {
 }
 
 func TestGenerateXML_ExactSize(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	sizes := []int{7, 13, 20, 50, 100, 500, 1024}
 	for _, size := range sizes {
@@ -135,7 +125,7 @@ func TestGenerateXML_ExactSize(t *testing.T) {
 }
 
 func TestGenerateXML_ShortRootWrapper(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	// Sizes 7-12 should use the short <r></r> wrapper (7 bytes overhead)
 	for size := 7; size <= 12; size++ {
@@ -167,7 +157,7 @@ func TestGenerateXML_ShortRootWrapper(t *testing.T) {
 }
 
 func TestGenerateXML_HasRootWrapper(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	data := h.generateXML(100)
 	s := string(data)
@@ -180,7 +170,7 @@ func TestGenerateXML_HasRootWrapper(t *testing.T) {
 }
 
 func TestGenerateRandomBody_Dispatcher(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	tests := []struct {
 		name string
@@ -205,7 +195,7 @@ func TestGenerateRandomBody_Dispatcher(t *testing.T) {
 }
 
 func TestGenerateRandomBody_UnsupportedType(t *testing.T) {
-	h := newTestHandler()
+	h := newTestHandler(t, "")
 
 	_, err := h.generateRandomBody(&config.RandomBodySpec{Type: "html", SizeBytes: 100})
 	if err == nil {
@@ -232,7 +222,7 @@ func TestCachedBodies_ConcurrentReads(t *testing.T) {
 	h := NewMockHandlerWithRand(cfg, r)
 
 	// Verify the body was pre-generated
-	data, ok := h.cachedBodies[spec]
+	data, ok := h.current().cachedBodies[spec]
 	if !ok {
 		t.Fatal("expected cached body to be pre-generated")
 	}
@@ -246,7 +236,7 @@ func TestCachedBodies_ConcurrentReads(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			cached := h.cachedBodies[spec]
+			cached := h.current().cachedBodies[spec]
 			if len(cached) != 1024 {
 				t.Errorf("concurrent read got %d bytes, want 1024", len(cached))
 			}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errRangeNotSatisfiable means no byte of a Range header falls within the body
+var errRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// byteRange is an inclusive range of body bytes
+type byteRange struct {
+	start, end int64
+}
+
+func (b byteRange) length() int64 {
+	return b.end - b.start + 1
+}
+
+// requestedRange returns the part of a body of size bytes a GET request asks for, or
+// nil to send the whole body. The Range header is ignored when it isn't a single
+// valid bytes range, or when an If-Range validator no longer matches the response.
+func requestedRange(r *http.Request, header http.Header, size int64) (*byteRange, error) {
+	value := r.Header.Get("Range")
+	if r.Method != http.MethodGet || value == "" || !ifRangeMatches(r, header) {
+		return nil, nil
+	}
+	unit, spec, ok := strings.Cut(value, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(unit), "bytes") || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, nil
+	}
+	if first == "" {
+		// A suffix range asks for the last bytes of the body
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil
+		}
+		if n == 0 || size == 0 {
+			return nil, errRangeNotSatisfiable
+		}
+		return &byteRange{start: max(size-n, 0), end: size - 1}, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil, nil
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return nil, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return nil, errRangeNotSatisfiable
+	}
+	return &byteRange{start: start, end: end}, nil
+}
+
+// ifRangeMatches reports whether the response still has the strong ETag or the exact
+// Last-Modified date an If-Range header names, or the request has no If-Range
+func ifRangeMatches(r *http.Request, header http.Header) bool {
+	value := strings.TrimSpace(r.Header.Get("If-Range"))
+	if value == "" {
+		return true
+	}
+	if strings.HasPrefix(value, `"`) {
+		return value == header.Get("ETag")
+	}
+	if strings.HasPrefix(value, "W/") {
+		return false // Weak validators can't guarantee the parts fit together
+	}
+	since, err := http.ParseTime(value)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	return err == nil && modified.Equal(since)
+}
+
+// contentRange formats the Content-Range header of a partial response
+func contentRange(rng *byteRange, size int64) string {
+	if rng == nil {
+		return fmt.Sprintf("bytes */%d", size)
+	}
+	return fmt.Sprintf("bytes %d-%d/%d", rng.start, rng.end, size)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestRequestedRange(t *testing.T) {
+	tests := []struct {
+		rangeHeader string
+		want        *byteRange
+		err         error
+	}{
+		{"", nil, nil},
+		{"bytes=0-99", &byteRange{0, 99}, nil},
+		{"bytes=500-", &byteRange{500, 999}, nil},
+		{"bytes=900-5000", &byteRange{900, 999}, nil},
+		{"bytes=-100", &byteRange{900, 999}, nil},
+		{"bytes=-5000", &byteRange{0, 999}, nil},
+		{"BYTES = 10-19", &byteRange{10, 19}, nil},
+		{"bytes=1000-", nil, errRangeNotSatisfiable},
+		{"bytes=-0", nil, errRangeNotSatisfiable},
+		{"bytes=0-9,20-29", nil, nil}, // Several ranges get the whole body
+		{"bytes=9-0", nil, nil},
+		{"items=0-9", nil, nil},
+		{"bytes=x-9", nil, nil},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.rangeHeader != "" {
+			r.Header.Set("Range", tt.rangeHeader)
+		}
+		got, err := requestedRange(r, http.Header{}, 1000)
+		if err != tt.err || (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%q: expected %v, %v, got %v, %v", tt.rangeHeader, tt.want, tt.err, got, err)
+		}
+	}
+}
+
+func TestMockHandler_RangesBodyFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	path := filepath.Join(t.TempDir(), "installer.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(fmt.Sprintf(`
+requests:
+  - path: /installer.bin
+    response:
+      ranges: true
+      bodyFile: %s
+  - path: /plain
+    response:
+      body: whole
+`, path)), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/installer.bin", nil, nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("Accept-Ranges") != "bytes" || rr.Body.Len() != 1000 {
+		t.Fatalf("expected the whole file with Accept-Ranges, got %d %v", rr.Code, rr.Header())
+	}
+	etag := rr.Header().Get("ETag")
+
+	rr = performRequest(h, http.MethodGet, "/installer.bin", map[string]string{"Range": "bytes=995-"}, nil)
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "56789" ||
+		rr.Header().Get("Content-Range") != "bytes 995-999/1000" || rr.Header().Get("Content-Length") != "5" {
+		t.Fatalf("unexpected partial response %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	// A resumed download whose validator still matches gets the rest
+	rr = performRequest(h, http.MethodGet, "/installer.bin", map[string]string{"Range": "bytes=10-12", "If-Range": etag}, nil)
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "012" {
+		t.Fatalf("expected a partial response for a matching If-Range, got %d %q", rr.Code, rr.Body.String())
+	}
+	rr = performRequest(h, http.MethodGet, "/installer.bin", map[string]string{"Range": "bytes=10-12", "If-Range": `"stale"`}, nil)
+	if rr.Code != http.StatusOK || rr.Body.Len() != 1000 {
+		t.Fatalf("expected the whole file for a stale If-Range, got %d, %d bytes", rr.Code, rr.Body.Len())
+	}
+
+	rr = performRequest(h, http.MethodGet, "/installer.bin", map[string]string{"Range": "bytes=1000-"}, nil)
+	if rr.Code != http.StatusRequestedRangeNotSatisfiable || rr.Header().Get("Content-Range") != "bytes */1000" || rr.Body.Len() != 0 {
+		t.Fatalf("unexpected unsatisfiable response %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	// Rules without ranges ignore the header
+	rr = performRequest(h, http.MethodGet, "/plain", map[string]string{"Range": "bytes=0-1"}, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "whole" || rr.Header().Get("Accept-Ranges") != "" {
+		t.Fatalf("unexpected response without ranges %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+}
+
+func TestMockHandler_RangesGenerateBody(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /random
+    response:
+      ranges: true
+      generateBody: {sizeBytes: 200000}
+  - path: /lorem
+    response:
+      ranges: true
+      generateBody: {sizeBytes: 1000, pattern: lorem}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	for _, path := range []string{"/random", "/lorem"} {
+		whole := performRequest(h, http.MethodGet, path, nil, nil).Body.Bytes()
+		if again := performRequest(h, http.MethodGet, path, nil, nil).Body.Bytes(); !bytes.Equal(again, whole) {
+			t.Fatalf("%s: expected the same body on every request", path)
+		}
+		rr := performRequest(h, http.MethodGet, path, map[string]string{"Range": "bytes=300-"}, nil)
+		size := len(whole)
+		if rr.Code != http.StatusPartialContent || !bytes.Equal(rr.Body.Bytes(), whole[300:]) ||
+			rr.Header().Get("Content-Range") != fmt.Sprintf("bytes 300-%d/%d", size-1, size) ||
+			rr.Header().Get("Content-Length") != fmt.Sprint(size-300) {
+			t.Fatalf("%s: expected the rest of the body, got %d, %d bytes, %v", path, rr.Code, rr.Body.Len(), rr.Header())
+		}
+	}
+
+	// Parts that cross a block of random bytes fit together too
+	whole := performRequest(h, http.MethodGet, "/random", nil, nil).Body.Bytes()
+	rr := performRequest(h, http.MethodGet, "/random", map[string]string{"Range": "bytes=65530-65545"}, nil)
+	if !bytes.Equal(rr.Body.Bytes(), whole[65530:65546]) {
+		t.Fatalf("expected the part across blocks to match the whole body")
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"http-mock-server/internal/config"
+)
+
+// redirectResponse answers with the next hop of the redirect chain. Hops after the first
+// carry their number in the hop parameter of the request URL, and the last one points at
+// redirect to, or back at the first hop when the chain loops.
+func redirectResponse(r *http.Request, spec *config.ResponseSpec) ([]byte, int, map[string]string, error) {
+	rd := spec.Redirect
+	query := r.URL.Query()
+	hop, err := strconv.Atoi(query.Get(rd.HopParam))
+	if err != nil || hop < 0 || hop >= rd.Hops {
+		hop = 0
+	}
+
+	location := rd.To
+	if hop+1 < rd.Hops {
+		query.Set(rd.HopParam, strconv.Itoa(hop+1))
+		location = hopURL(r, query)
+	} else if rd.Loop {
+		query.Del(rd.HopParam)
+		location = hopURL(r, query)
+	}
+
+	headers := make(map[string]string, len(spec.Headers)+1)
+	for name, value := range spec.Headers {
+		headers[name] = value
+	}
+	headers["Location"] = location
+	return nil, spec.StatusCode, headers, nil
+}
+
+// hopURL returns the request path with the query of another hop
+func hopURL(r *http.Request, query url.Values) string {
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
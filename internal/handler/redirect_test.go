@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestRedirectResponse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /old
+    response:
+      redirect: {to: /v2/thing, status: 301}
+  - path: /chain
+    response:
+      redirect: {to: /v2/thing, hops: 3}
+      headers:
+        X-Mock: chain
+  - path: /loop
+    response:
+      redirect: {loop: true, hops: 2, status: 307}
+  - path: /v2/thing
+    response:
+      body: arrived
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		url      string
+		status   int
+		location string
+	}{
+		{"/old", http.StatusMovedPermanently, "/v2/thing"},
+		{"/chain?q=1", http.StatusFound, "/chain?hop=1&q=1"},
+		{"/chain?hop=1&q=1", http.StatusFound, "/chain?hop=2&q=1"},
+		{"/chain?hop=2&q=1", http.StatusFound, "/v2/thing"},
+		{"/chain?hop=9", http.StatusFound, "/chain?hop=1"},
+		{"/loop", http.StatusTemporaryRedirect, "/loop?hop=1"},
+		{"/loop?hop=1", http.StatusTemporaryRedirect, "/loop"},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, tt.url, nil, nil)
+		if rr.Code != tt.status || rr.Header().Get("Location") != tt.location || rr.Body.Len() != 0 {
+			t.Fatalf("%s: expected %d to %q, got %d %v %q", tt.url, tt.status, tt.location, rr.Code, rr.Header(), rr.Body.String())
+		}
+	}
+	if rr := performRequest(h, http.MethodGet, "/chain", nil, nil); rr.Header().Get("X-Mock") != "chain" {
+		t.Fatalf("expected the rule headers, got %v", rr.Header())
+	}
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+	hops := 0
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		hops = len(via)
+		if len(via) >= 10 {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+	resp, err := client.Get(server.URL + "/chain")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "arrived" || hops != 3 {
+		t.Fatalf("expected to arrive after 3 hops, got %q after %d", body, hops)
+	}
+
+	if _, err := http.Get(server.URL + "/loop"); err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Fatalf("expected the client to give up on the loop, got %v", err)
+	}
+}
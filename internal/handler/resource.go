@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"http-mock-server/internal/config"
+)
+
+// ResourceStore holds the collections of resource rules, keyed by rule path. A
+// collection is seeded when it is first used and survives configuration reloads.
+type ResourceStore struct {
+	mu          sync.Mutex
+	collections map[string]*collection
+}
+
+// collection is the current content of a resource
+type collection struct {
+	items  []map[string]interface{} // In creation order
+	nextID int                      // Next id for items created without one
+}
+
+// ResourceInfo describes a resource collection
+type ResourceInfo struct {
+	Path  string `json:"path"`
+	Items int    `json:"items"`
+}
+
+// NewResourceStore creates an empty resource store
+func NewResourceStore() *ResourceStore {
+	return &ResourceStore{collections: make(map[string]*collection)}
+}
+
+// Reset drops the content of every collection, so each is seeded again on next use
+func (s *ResourceStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections = make(map[string]*collection)
+}
+
+// Resources returns the resource store
+func (h *MockHandler) Resources() *ResourceStore {
+	return h.resources
+}
+
+// ResourceInfo lists the collections of the configured resource rules, sorted by path
+func (h *MockHandler) ResourceInfo() ([]ResourceInfo, error) {
+	rs := h.current()
+	h.resources.mu.Lock()
+	defer h.resources.mu.Unlock()
+	infos := []ResourceInfo{}
+	for i := range rs.config.Requests {
+		rule := &rs.config.Requests[i]
+		if rule.Resource == nil {
+			continue
+		}
+		c, err := h.collection(rs, rule)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, ResourceInfo{Path: rule.Path, Items: len(c.items)})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
+// collection returns the collection of a resource rule, seeding it from its dataset
+// on first use. The caller holds the store's lock.
+func (h *MockHandler) collection(rs *ruleSet, rule *config.RequestRule) (*collection, error) {
+	if c, ok := h.resources.collections[rule.Path]; ok {
+		return c, nil
+	}
+	c := &collection{nextID: 1}
+	if name := rule.Resource.Seed; name != "" {
+		data, err := h.dataset(rs, name)
+		if err != nil {
+			return nil, err
+		}
+		// Copy the items so changes don't leak into the dataset
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("seed dataset %q: %w", name, err)
+		}
+		if err := json.Unmarshal(raw, &c.items); err != nil {
+			return nil, fmt.Errorf("seed dataset %q must be a list of objects", name)
+		}
+		for _, item := range c.items {
+			if n, ok := item[rule.Resource.IDField].(float64); ok && int(n) >= c.nextID {
+				c.nextID = int(n) + 1
+			}
+		}
+	}
+	h.resources.collections[rule.Path] = c
+	return c, nil
+}
+
+// index returns the position of the item with the id, or -1
+func (c *collection) index(idField, id string) int {
+	for i, item := range c.items {
+		if value, ok := item[idField]; ok && fmt.Sprint(value) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchesResourcePath reports whether path is the collection path of the rule or the
+// path of one of its items
+func matchesResourcePath(rule *config.RequestRule, path string) bool {
+	if path == rule.Path {
+		return true
+	}
+	id, ok := strings.CutPrefix(path, rule.Path+"/")
+	return ok && id != "" && !strings.Contains(id, "/")
+}
+
+// writeResource lists and creates items at the rule's path, and gets, replaces,
+// updates, and deletes them at path/{id}
+func (h *MockHandler) writeResource(w http.ResponseWriter, r *http.Request, rs *ruleSet, rule *config.RequestRule) {
+	res := rule.Resource
+	var body map[string]interface{}
+	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+		raw, err := requestBody(r)
+		if err != nil || json.Unmarshal(raw, &body) != nil || body == nil {
+			http.Error(w, "request body must be a JSON object", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.resources.mu.Lock()
+	defer h.resources.mu.Unlock()
+	c, err := h.collection(rs, rule)
+	if err != nil {
+		log.Printf("Error loading resource %s: %v", rule.Path, err)
+		writeMockError(w, r, rs, rule, err)
+		return
+	}
+
+	id, isItem := strings.CutPrefix(r.URL.Path, rule.Path+"/")
+	if !isItem {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			items := make([]map[string]interface{}, 0, len(c.items))
+			for _, item := range c.items {
+				if matchesFilters(item, r.URL.Query()) {
+					items = append(items, item)
+				}
+			}
+			writeResourceJSON(w, rule, http.StatusOK, items)
+		case http.MethodPost:
+			value, ok := body[res.IDField]
+			if !ok {
+				value = c.nextID
+				body[res.IDField] = value
+			}
+			id := fmt.Sprint(value)
+			if c.index(res.IDField, id) >= 0 {
+				http.Error(w, fmt.Sprintf("%s %s already exists", res.IDField, id), http.StatusConflict)
+				return
+			}
+			if n, err := strconv.Atoi(id); err == nil && n >= c.nextID {
+				c.nextID = n + 1
+			}
+			c.items = append(c.items, body)
+			w.Header().Set("Location", rule.Path+"/"+id)
+			writeResourceJSON(w, rule, http.StatusCreated, body)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	i := c.index(res.IDField, id)
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPatch, http.MethodDelete:
+		if i < 0 {
+			http.Error(w, fmt.Sprintf("no item with %s %s", res.IDField, id), http.StatusNotFound)
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		writeResourceJSON(w, rule, http.StatusOK, c.items[i])
+	case http.MethodPut:
+		if i < 0 {
+			body[res.IDField] = parseID(id)
+			if n, err := strconv.Atoi(id); err == nil && n >= c.nextID {
+				c.nextID = n + 1
+			}
+			c.items = append(c.items, body)
+			writeResourceJSON(w, rule, http.StatusCreated, body)
+			return
+		}
+		body[res.IDField] = c.items[i][res.IDField]
+		c.items[i] = body
+		writeResourceJSON(w, rule, http.StatusOK, body)
+	case http.MethodPatch:
+		item := c.items[i]
+		for field, value := range body {
+			switch {
+			case field == res.IDField:
+			case value == nil:
+				delete(item, field)
+			default:
+				item[field] = value
+			}
+		}
+		writeResourceJSON(w, rule, http.StatusOK, item)
+	case http.MethodDelete:
+		c.items = append(c.items[:i], c.items[i+1:]...)
+		setHeaders(w.Header(), rule.Response.Headers)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, PATCH, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// matchesFilters reports whether every query parameter equals the item field of that name
+func matchesFilters(item map[string]interface{}, query map[string][]string) bool {
+	for field, values := range query {
+		value, ok := item[field]
+		if !ok || len(values) == 0 || fmt.Sprint(value) != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseID returns a numeric id from the path as a number, like ids the server assigns
+func parseID(id string) interface{} {
+	if n, err := strconv.Atoi(id); err == nil {
+		return n
+	}
+	return id
+}
+
+func writeResourceJSON(w http.ResponseWriter, rule *config.RequestRule, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	setHeaders(w.Header(), rule.Response.Headers)
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resourceConfig writes the users seed and returns a configuration serving it
+// as a resource
+func resourceConfig(t *testing.T) string {
+	t.Helper()
+	seed := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(seed, []byte(`[{"id": 1, "name": "ada", "role": "admin"}, {"id": 2, "name": "bob", "role": "user"}]`), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return `
+datasets:
+  users: ` + seed + `
+requests:
+  - path: /users
+    resource:
+      seed: users
+    response:
+      headers:
+        X-Mock: resource
+`
+}
+
+func TestResource_CRUD(t *testing.T) {
+	h := newTestHandler(t, resourceConfig(t))
+
+	steps := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{http.MethodGet, "/users", "", http.StatusOK, `[{"id":1,"name":"ada","role":"admin"},{"id":2,"name":"bob","role":"user"}]`},
+		{http.MethodGet, "/users?role=user", "", http.StatusOK, `[{"id":2,"name":"bob","role":"user"}]`},
+		{http.MethodGet, "/users/1", "", http.StatusOK, `{"id":1,"name":"ada","role":"admin"}`},
+		{http.MethodPost, "/users", `{"name": "cy"}`, http.StatusCreated, `{"id":3,"name":"cy"}`},
+		{http.MethodGet, "/users/3", "", http.StatusOK, `{"id":3,"name":"cy"}`},
+		{http.MethodPost, "/users", `{"id": 3, "name": "dup"}`, http.StatusConflict, ""},
+		{http.MethodPost, "/users", `not json`, http.StatusBadRequest, ""},
+		{http.MethodPatch, "/users/3", `{"role": "user", "id": 9}`, http.StatusOK, `{"id":3,"name":"cy","role":"user"}`},
+		{http.MethodPut, "/users/2", `{"name": "bo"}`, http.StatusOK, `{"id":2,"name":"bo"}`},
+		{http.MethodPut, "/users/7", `{"name": "gus"}`, http.StatusCreated, `{"id":7,"name":"gus"}`},
+		{http.MethodDelete, "/users/1", "", http.StatusNoContent, ""},
+		{http.MethodGet, "/users/1", "", http.StatusNotFound, ""},
+		{http.MethodDelete, "/users/1", "", http.StatusNotFound, ""},
+		{http.MethodPost, "/users", `{"name": "hal"}`, http.StatusCreated, `{"id":8,"name":"hal"}`},
+		{http.MethodGet, "/users", "", http.StatusOK, `[{"id":2,"name":"bo"},{"id":3,"name":"cy","role":"user"},{"id":7,"name":"gus"},{"id":8,"name":"hal"}]`},
+		{http.MethodDelete, "/users", "", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/users/1/posts", "", http.StatusNotFound, ""},
+	}
+	for i, step := range steps {
+		var body []byte
+		if step.body != "" {
+			body = []byte(step.body)
+		}
+		rr := performRequest(h, step.method, step.path, nil, body)
+		if rr.Code != step.status {
+			t.Fatalf("step %d %s %s: expected %d, got %d %s", i, step.method, step.path, step.status, rr.Code, rr.Body.String())
+		}
+		if step.want != "" && rr.Body.String() != step.want {
+			t.Fatalf("step %d %s %s: expected %s, got %s", i, step.method, step.path, step.want, rr.Body.String())
+		}
+		if step.status < 300 && rr.Header().Get("X-Mock") != "resource" {
+			t.Fatalf("step %d: expected the rule headers, got %v", i, rr.Header())
+		}
+	}
+}
+
+func TestResource_Reset(t *testing.T) {
+	h := newTestHandler(t, resourceConfig(t))
+	performRequest(h, http.MethodDelete, "/users/1", nil, nil)
+	performRequest(h, http.MethodPost, "/users", nil, []byte(`{"id": "x"}`))
+
+	infos, err := h.ResourceInfo()
+	if err != nil || len(infos) != 1 || infos[0].Items != 2 {
+		t.Fatalf("expected 2 items, got %+v %v", infos, err)
+	}
+	h.Resources().Reset()
+	if rr := performRequest(h, http.MethodGet, "/users/1", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected the seed to be restored, got %d", rr.Code)
+	}
+	if rr := performRequest(h, http.MethodGet, "/users/x", nil, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected the created item to be gone, got %d", rr.Code)
+	}
+}
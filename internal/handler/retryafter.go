@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// retryWindows tracks the clients a rule with retryAfter has asked to wait
+type retryWindows struct {
+	seconds *template.Template // Nil when retryAfter.seconds is a number
+	mu      sync.Mutex
+	clients map[string]retryWindow // Keyed by client address or header value
+}
+
+// retryWindow is the wait a client was asked for
+type retryWindow struct {
+	since time.Time
+	until time.Time
+}
+
+func newRetryWindows() *retryWindows {
+	return &retryWindows{clients: make(map[string]retryWindow)}
+}
+
+// throttle answers the request with the rule's retryAfter response unless the client
+// has waited as long as it was told to, and reports whether it did
+func (h *MockHandler) throttle(w http.ResponseWriter, r *http.Request, rs *ruleSet, rule *config.RequestRule) bool {
+	ra := rule.RetryAfter
+	if ra == nil {
+		return false
+	}
+	windows := rs.retries[rule]
+	client := retryClient(r, ra.ClientHeader)
+	now := h.now()
+
+	windows.mu.Lock()
+	window, waiting := windows.clients[client]
+	if waiting && !now.Before(window.until) {
+		delete(windows.clients, client)
+	}
+	windows.mu.Unlock()
+
+	if waiting {
+		if !now.Before(window.until) {
+			return false
+		}
+		log.Printf("Client %s retried %s %s after %s, before the Retry-After of %s",
+			client, r.Method, r.URL.Path, now.Sub(window.since).Round(time.Millisecond), window.until.Sub(window.since))
+		response := &ra.ThrottleResponse
+		if ra.Early != nil {
+			response = ra.Early
+		}
+		writeThrottle(w, response, int(math.Ceil(window.until.Sub(now).Seconds())))
+		return true
+	}
+
+	seconds, err := windows.render(r, rs, rule)
+	if err != nil {
+		log.Printf("Error rendering retryAfter: %v", err)
+		writeMockError(w, r, rs, rule, err)
+		return true
+	}
+	windows.mu.Lock()
+	windows.clients[client] = retryWindow{since: now, until: now.Add(time.Duration(seconds) * time.Second)}
+	windows.mu.Unlock()
+	writeThrottle(w, &ra.ThrottleResponse, seconds)
+	return true
+}
+
+// render returns the number of seconds the client has to wait
+func (rw *retryWindows) render(r *http.Request, rs *ruleSet, rule *config.RequestRule) (int, error) {
+	if rw.seconds == nil {
+		return strconv.Atoi(rule.RetryAfter.Seconds)
+	}
+	data, err := newTemplateData(r, rs, rule)
+	if err != nil {
+		return 0, err
+	}
+	value, err := rs.render(rw.seconds, data)
+	if err != nil {
+		return 0, fmt.Errorf("seconds template failed: %w", err)
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("seconds template rendered %q, not a positive number", value)
+	}
+	return seconds, nil
+}
+
+// retryClient identifies the client by the header value, or by its address when the
+// header is not configured or missing
+func retryClient(r *http.Request, header string) string {
+	if header != "" {
+		if value := r.Header.Get(header); value != "" {
+			return value
+		}
+	}
+	return clientAddr(r).String()
+}
+
+func writeThrottle(w http.ResponseWriter, response *config.ThrottleResponse, seconds int) {
+	body, err := encodeBody(response.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setHeaders(w.Header(), response.Headers)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(response.StatusCode)
+	w.Write(body)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestRetryAfter(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /export
+    method: GET
+    retryAfter:
+      seconds: "5"
+      body: busy
+      early:
+        status-code: 429
+        body: too soon
+    response:
+      body: done
+  - path: /jobs
+    method: GET
+    retryAfter:
+      seconds: "{{ .Query.wait }}"
+      status-code: 429
+      clientHeader: X-Client
+    response:
+      body: ok
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	steps := []struct {
+		advance    time.Duration
+		path       string
+		client     string
+		status     int
+		retryAfter string
+		body       string
+	}{
+		{0, "/export", "", http.StatusServiceUnavailable, "5", "busy"},
+		{2 * time.Second, "/export", "", http.StatusTooManyRequests, "3", "too soon"},
+		{2500 * time.Millisecond, "/export", "", http.StatusTooManyRequests, "1", "too soon"},
+		{500 * time.Millisecond, "/export", "", http.StatusOK, "", "done"},
+		{0, "/export", "", http.StatusServiceUnavailable, "5", "busy"},
+
+		{0, "/jobs?wait=10", "a", http.StatusTooManyRequests, "10", ""},
+		{0, "/jobs?wait=10", "b", http.StatusTooManyRequests, "10", ""},
+		{4 * time.Second, "/jobs?wait=10", "a", http.StatusTooManyRequests, "6", ""},
+		{6 * time.Second, "/jobs?wait=10", "b", http.StatusOK, "", "ok"},
+		{0, "/jobs?wait=x", "c", http.StatusInternalServerError, "", ""},
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		rr := performRequest(h, http.MethodGet, step.path, map[string]string{"X-Client": step.client}, nil)
+		if rr.Code != step.status {
+			t.Fatalf("step %d: expected %d, got %d", i, step.status, rr.Code)
+		}
+		if got := rr.Header().Get("Retry-After"); got != step.retryAfter {
+			t.Fatalf("step %d: expected Retry-After %q, got %q", i, step.retryAfter, got)
+		}
+		if step.body != "" && rr.Body.String() != step.body {
+			t.Fatalf("step %d: expected body %q, got %q", i, step.body, rr.Body.String())
+		}
+	}
+}
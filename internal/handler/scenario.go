@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"sort"
+	"sync"
+
+	"http-mock-server/internal/config"
+)
+
+// ScenarioStarted is the state every scenario is in until a rule transitions it, unless
+// the configuration gives it another initial state
+const ScenarioStarted = "Started"
+
+// ScenarioStore tracks the current state of each scenario
+type ScenarioStore struct {
+	mu      sync.Mutex
+	states  map[string]string
+	initial map[string]string // States from the configuration that replace Started
+}
+
+// NewScenarioStore creates an empty scenario store
+func NewScenarioStore() *ScenarioStore {
+	return &ScenarioStore{states: make(map[string]string), initial: make(map[string]string)}
+}
+
+// setInitial replaces the initial states taken from the configuration. Scenarios that
+// haven't moved since the last reset follow the new initial states.
+func (s *ScenarioStore) setInitial(initial map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initial = make(map[string]string, len(initial))
+	for name, state := range initial {
+		s.initial[name] = state
+	}
+}
+
+// State returns the current state of a scenario
+func (s *ScenarioStore) State(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateLocked(name)
+}
+
+func (s *ScenarioStore) stateLocked(name string) string {
+	if state, ok := s.states[name]; ok {
+		return state
+	}
+	if state, ok := s.initial[name]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+// SetState moves a scenario to the given state
+func (s *ScenarioStore) SetState(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[name] = state
+}
+
+// Replace sets the given scenarios to their states and returns every other scenario to
+// its initial state, in one step
+func (s *ScenarioStore) Replace(states map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = make(map[string]string, len(states))
+	for name, state := range states {
+		s.states[name] = state
+	}
+}
+
+// Reset returns all scenarios to their initial state, Started unless configured
+func (s *ScenarioStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = make(map[string]string)
+}
+
+// ResetScenario returns a single scenario to its initial state
+func (s *ScenarioStore) ResetScenario(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, name)
+}
+
+// Snapshot returns the state of every scenario in names plus any scenario that has left
+// its initial state, sorted by name.
+func (s *ScenarioStore) Snapshot(names []string) []ScenarioState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	result := []ScenarioState{}
+	add := func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		result = append(result, ScenarioState{Name: name, State: s.stateLocked(name)})
+	}
+	for _, name := range names {
+		add(name)
+	}
+	for name := range s.states {
+		add(name)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ScenarioState is the current state of a named scenario
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// matchesScenario reports whether the rule's scenario is in its required state
+func (s *ScenarioStore) matchesScenario(rule *config.RequestRule) bool {
+	if rule.Scenario == "" || rule.RequiredState == "" {
+		return true
+	}
+	return s.State(rule.Scenario) == rule.RequiredState
+}
+
+// advance applies the rule's state transition, if any, checking the required state
+// again in the same step. It reports false, leaving the state alone, when another
+// request moved the scenario after the rule was selected.
+func (s *ScenarioStore) advance(rule *config.RequestRule) bool {
+	if rule.Scenario == "" || (rule.RequiredState == "" && rule.NewState == "") {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rule.RequiredState != "" && s.stateLocked(rule.Scenario) != rule.RequiredState {
+		return false
+	}
+	if rule.NewState != "" {
+		s.states[rule.Scenario] = rule.NewState
+	}
+	return true
+}
+
+// ScenarioNames returns the distinct scenario names referenced by the rules or given an
+// initial state
+func ScenarioNames(cfg *config.Config) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for name := range cfg.Scenarios {
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	for _, rule := range cfg.Requests {
+		if rule.Scenario == "" {
+			continue
+		}
+		if _, ok := seen[rule.Scenario]; !ok {
+			seen[rule.Scenario] = struct{}{}
+			names = append(names, rule.Scenario)
+		}
+	}
+	return names
+}
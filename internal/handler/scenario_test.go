@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func scenarioConfig() *config.Config {
+	return &config.Config{
+		Requests: []config.RequestRule{
+			{
+				Path:          "/items/1",
+				Method:        "GET",
+				Scenario:      "item",
+				RequiredState: ScenarioStarted,
+				Response:      config.ResponseSpec{StatusCode: 200, Body: "exists"},
+			},
+			{
+				Path:          "/items/1",
+				Method:        "DELETE",
+				Scenario:      "item",
+				RequiredState: ScenarioStarted,
+				NewState:      "deleted",
+				Response:      config.ResponseSpec{StatusCode: 204},
+			},
+			{
+				Path:          "/items/1",
+				Method:        "GET",
+				Scenario:      "item",
+				RequiredState: "deleted",
+				Response:      config.ResponseSpec{StatusCode: 404},
+			},
+		},
+	}
+}
+
+func TestScenario_Transitions(t *testing.T) {
+	h := NewMockHandler(scenarioConfig())
+
+	rr := performRequest(h, http.MethodGet, "/items/1", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "exists" {
+		t.Fatalf("expected 200 exists before delete, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = performRequest(h, http.MethodDelete, "/items/1", nil, nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if state := h.Scenarios().State("item"); state != "deleted" {
+		t.Fatalf("expected state deleted, got %q", state)
+	}
+
+	rr = performRequest(h, http.MethodGet, "/items/1", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d after delete, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	// A second delete no longer matches because the scenario left the Started state
+	rr = performRequest(h, http.MethodDelete, "/items/1", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected unmatched delete to get %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	h.Scenarios().Reset()
+	rr = performRequest(h, http.MethodGet, "/items/1", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d after reset, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestScenario_ConcurrentTransition(t *testing.T) {
+	h := NewMockHandler(scenarioConfig())
+
+	// Only one of the parallel deletes may see the Started state and move it on
+	var wg sync.WaitGroup
+	var deleted atomic.Int32
+	start := make(chan struct{})
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			rr := performRequest(h, http.MethodDelete, "/items/1", nil, nil)
+			switch rr.Code {
+			case http.StatusNoContent:
+				deleted.Add(1)
+			case http.StatusNotFound:
+			default:
+				t.Errorf("unexpected status %d", rr.Code)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := deleted.Load(); n != 1 {
+		t.Fatalf("expected exactly one delete to succeed, got %d", n)
+	}
+}
+
+func TestScenarioStore_AdvanceRechecksState(t *testing.T) {
+	store := NewScenarioStore()
+	rule := &scenarioConfig().Requests[1]
+	if !store.matchesScenario(rule) {
+		t.Fatal("expected the delete rule to match in the Started state")
+	}
+
+	// Another request moves the scenario between selection and transition
+	store.SetState("item", "archived")
+	if store.advance(rule) {
+		t.Fatal("expected advance to fail once the required state no longer holds")
+	}
+	if state := store.State("item"); state != "archived" {
+		t.Fatalf("expected state to stay archived, got %q", state)
+	}
+
+	store.SetState("item", ScenarioStarted)
+	if !store.advance(rule) || store.State("item") != "deleted" {
+		t.Fatalf("expected advance to move the scenario to deleted, got %q", store.State("item"))
+	}
+}
+
+func TestScenario_StateSurvivesReload(t *testing.T) {
+	h := NewMockHandler(scenarioConfig())
+	performRequest(h, http.MethodDelete, "/items/1", nil, nil)
+
+	if err := h.Reload(scenarioConfig()); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+
+	rr := performRequest(h, http.MethodGet, "/items/1", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d after reload, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestScenarioStore_Snapshot(t *testing.T) {
+	s := NewScenarioStore()
+	s.SetState("orphan", "gone")
+
+	got := s.Snapshot([]string{"b", "a", "b"})
+	want := []ScenarioState{{"a", ScenarioStarted}, {"b", ScenarioStarted}, {"orphan", "gone"}}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Snapshot()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	s.ResetScenario("orphan")
+	if len(s.Snapshot(nil)) != 0 {
+		t.Fatalf("expected empty snapshot after reset, got %v", s.Snapshot(nil))
+	}
+}
+
+func TestScenario_InitialStates(t *testing.T) {
+	cfg := scenarioConfig()
+	cfg.Scenarios = map[string]string{"item": "deleted"}
+	h := NewMockHandler(cfg)
+
+	if rr := performRequest(h, http.MethodGet, "/items/1", nil, nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected the item to start deleted, got %d", rr.Code)
+	}
+
+	h.Scenarios().Replace(map[string]string{"item": ScenarioStarted, "other": "x"})
+	if rr := performRequest(h, http.MethodGet, "/items/1", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected the replaced state to apply, got %d", rr.Code)
+	}
+
+	h.Scenarios().Reset()
+	if state := h.Scenarios().State("item"); state != "deleted" {
+		t.Fatalf("expected reset to return to the initial state, got %q", state)
+	}
+	if state := h.Scenarios().State("other"); state != ScenarioStarted {
+		t.Fatalf("expected scenarios without an initial state to return to Started, got %q", state)
+	}
+
+	if err := h.Reload(scenarioConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state := h.Scenarios().State("item"); state != ScenarioStarted {
+		t.Fatalf("expected the reloaded configuration to drop the initial state, got %q", state)
+	}
+}
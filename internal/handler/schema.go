@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/jsonschema"
+)
+
+// schemaErrors validates the request body against the rule's schema, returning
+// nil when the rule has no schema or the body conforms.
+func schemaErrors(rule *config.RequestRule, r *http.Request) []jsonschema.ValidationError {
+	if rule.Schema == nil {
+		return nil
+	}
+	body, _ := requestBody(r)
+	return rule.Schema.ValidateJSON(body)
+}
+
+// matchesSchema reports whether the body satisfies a schema used as a matcher.
+// Rules in enforce mode match regardless and reject invalid bodies when serving.
+func matchesSchema(rule *config.RequestRule, r *http.Request) bool {
+	if rule.BodySchemaMode == config.SchemaModeEnforce {
+		return true
+	}
+	return len(schemaErrors(rule, r)) == 0
+}
+
+// enforceSchema answers with 400 and the validation errors when an enforce-mode
+// rule receives a non-conforming body. It reports whether a response was written.
+func enforceSchema(w http.ResponseWriter, r *http.Request, rule *config.RequestRule) bool {
+	if rule.BodySchemaMode != config.SchemaModeEnforce {
+		return false
+	}
+	errs := schemaErrors(rule, r)
+	if len(errs) == 0 {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  "request body does not match schema",
+		"errors": errs,
+	})
+	if err != nil {
+		log.Printf("Error writing schema validation response: %v", err)
+	}
+	return true
+}
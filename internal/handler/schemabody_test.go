@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_SchemaBody(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(spec, []byte(`
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, email, role]
+      properties:
+        id: {type: string, format: uuid}
+        email: {type: string, format: email}
+        role: {type: string, enum: [admin, member, guest]}
+        age: {type: integer, minimum: 18, maximum: 99}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /user
+    response:
+      schemaBody: `+spec+`#/components/schemas/User
+  - path: /random
+    response:
+      schemaBody: {schema: "`+spec+`#/components/schemas/User", random: true}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	decode := func(path string) map[string]interface{} {
+		rr := performRequest(h, http.MethodGet, path, nil, nil)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+			t.Fatalf("%s: unexpected response %d, %v", path, rr.Code, rr.Header())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid JSON %q: %v", path, rr.Body.String(), err)
+		}
+		if body["id"] == nil || body["email"] == nil || body["role"] == nil {
+			t.Fatalf("%s: missing required properties in %v", path, body)
+		}
+		return body
+	}
+
+	// Bodies generated once are the same for every request
+	first := decode("/user")
+	if again := decode("/user"); again["id"] != first["id"] {
+		t.Fatalf("expected the same body, got %v and %v", first, again)
+	}
+
+	// Random bodies differ between requests
+	ids := make(map[interface{}]bool)
+	for i := 0; i < 5; i++ {
+		ids[decode("/random")["id"]] = true
+	}
+	if len(ids) < 2 {
+		t.Fatalf("expected random bodies to differ, got ids %v", ids)
+	}
+}
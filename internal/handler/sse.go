@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// writeSSE streams the events of an sse response, flushing each one as it is sent.
+// The stream ends after the configured repetitions or when the client disconnects.
+func writeSSE(w http.ResponseWriter, r *http.Request, status int, header http.Header, sse *config.SSEStream) {
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/event-stream")
+	}
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "no-cache")
+	}
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead || !bodyAllowed(status) {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		log.Printf("Streaming events to %s %s without flushing: %v", r.Method, r.URL.Path, err)
+	}
+	for n := 0; sse.Repeat < 0 || n < sse.Repeat; n++ {
+		for i := range sse.Events {
+			e := &sse.Events[i]
+			if e.Delay > 0 {
+				select {
+				case <-time.After(time.Duration(e.Delay) * time.Millisecond):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			data, err := formatSSEEvent(e)
+			if err != nil {
+				log.Printf("Error encoding event %d: %v", i, err)
+				return
+			}
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+}
+
+// formatSSEEvent frames an event in the text/event-stream format. Each line of the
+// data becomes its own data field, which clients join back together.
+func formatSSEEvent(e *config.SSEEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry)
+	}
+	if e.Data != nil {
+		data, err := encodeBody(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\r", "\n")
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(&buf, "data: %s\n", line)
+		}
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_SSE(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /events
+    response:
+      sse:
+        repeat: 2
+        events:
+          - id: "1"
+            event: price
+            data: {symbol: ACME, price: 12.5}
+            retry: 3000
+          - data: "line one\nline two"
+            delay: 10
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/events", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("expected no-cache, got %q", cc)
+	}
+	once := "id: 1\nevent: price\nretry: 3000\ndata: {\"price\":12.5,\"symbol\":\"ACME\"}\n\n" +
+		"data: line one\ndata: line two\n\n"
+	if got := rr.Body.String(); got != once+once {
+		t.Fatalf("unexpected stream:\n%s", got)
+	}
+	if !rr.Flushed {
+		t.Fatalf("expected events to be flushed")
+	}
+}
+
+func TestMockHandler_SSERepeatsUntilDisconnect(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /ticks
+    response:
+      sse:
+        repeat: -1
+        events:
+          - event: tick
+            delay: 5
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(NewMockHandler(cfg))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ticks")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Events arrive as they are sent, without waiting for the stream to end
+	scanner := bufio.NewScanner(resp.Body)
+	ticks := 0
+	for ticks < 5 && scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "event: tick" {
+			ticks++
+		}
+	}
+	resp.Body.Close()
+	if ticks != 5 {
+		t.Fatalf("expected 5 ticks, got %d", ticks)
+	}
+}
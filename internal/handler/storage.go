@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/storage"
+)
+
+// storedFiles keeps the body files fetched from a bucket for the life of a rule set,
+// so each is downloaded once per configuration
+type storedFiles struct {
+	mu    sync.Mutex
+	files map[string]*cachedFile
+}
+
+// openStore returns the store of a new configuration, keeping the current one while
+// the storage settings stay the same so objects in memory survive reloads
+func (h *MockHandler) openStore(cfg *config.Storage) storage.Store {
+	if prev := h.current(); prev != nil && reflect.DeepEqual(prev.config.Server.Storage, cfg) {
+		return prev.store
+	}
+	return storage.New(cfg)
+}
+
+// Store returns the storage of the active configuration
+func (h *MockHandler) Store() storage.Store {
+	return h.current().store
+}
+
+// bodyFile returns the content of a bodyFile from local files, or from the configured
+// storage. Files in a bucket are fetched once per configuration, and those in memory
+// on every request, so uploads replace them right away. A local file configured as a
+// dataset is served from the dataset's upload while there is one.
+func (h *MockHandler) bodyFile(ctx context.Context, rs *ruleSet, key string) (*cachedFile, error) {
+	st := rs.config.Server.Storage
+	if !st.Remote() {
+		path := st.LocalPath(key)
+		if f, ok := h.uploadedFile(rs, path); ok {
+			return f, nil
+		}
+		return h.files.get(path)
+	}
+	if st.Type != config.StorageS3 {
+		obj, err := rs.store.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("bodyFile: %w", err)
+		}
+		return storedFile(obj), nil
+	}
+
+	rs.bucketFiles.mu.Lock()
+	defer rs.bucketFiles.mu.Unlock()
+	if f, ok := rs.bucketFiles.files[key]; ok {
+		return f, nil
+	}
+	obj, err := rs.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("bodyFile: %w", err)
+	}
+	f := storedFile(obj)
+	rs.bucketFiles.files[key] = f
+	return f, nil
+}
+
+func storedFile(obj storage.Object) *cachedFile {
+	return &cachedFile{
+		data:    obj.Data,
+		modTime: obj.ModTime,
+		size:    int64(len(obj.Data)),
+		etag:    fmt.Sprintf(`"%x-%x"`, obj.ModTime.UnixNano(), len(obj.Data)),
+	}
+}
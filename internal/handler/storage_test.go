@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_BodyFileFromMemoryStorage(t *testing.T) {
+	yaml := `
+server:
+  storage:
+    type: memory
+requests:
+  - path: /users
+    response:
+      bodyFile: fixtures/users.json
+`
+	cfg, err := config.Parse([]byte(yaml), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/users", nil, nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 before the object exists, got %d", rr.Code)
+	}
+	if err := h.Store().Put(context.Background(), "fixtures/users.json", []byte(`[{"id":1}]`)); err != nil {
+		t.Fatal(err)
+	}
+	rr = performRequest(h, http.MethodGet, "/users", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != `[{"id":1}]` || rr.Header().Get("ETag") == "" {
+		t.Fatalf("expected the stored object, got %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	// Objects in memory survive a reload with the same storage settings
+	cfg, _ = config.Parse([]byte(yaml), "test")
+	if err := h.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	rr = performRequest(h, http.MethodGet, "/users", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != `[{"id":1}]` {
+		t.Fatalf("expected the object after reloading, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMockHandler_BodyFileFromStorageDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte("server:\n  storage: {dir: "+dir+"}\nrequests:\n  - path: /hello\n    response:\n      bodyFile: hello.txt\n"), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rr := performRequest(NewMockHandler(cfg), http.MethodGet, "/hello", nil, nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "hello" {
+		t.Fatalf("expected the file below the storage dir, got %d %q", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/templating"
+)
+
+// templateData is the request information available to response templates
+type templateData struct {
+	Method   string
+	URL      string            // Request path and query string
+	Path     map[string]string // Values of the {name} parameters in the rule path
+	PathRest string            // Remainder of the path after the rule's pathPrefix
+	Headers  map[string]string // First value of each header, by canonical name
+	Query    map[string]string // First value of each query parameter
+	Cookies  map[string]string // First value of each request cookie
+	Body     string
+	JSON     interface{} // Parsed request body, nil when it isn't JSON
+	Audience string      // Audience the request selected, empty when none
+}
+
+// responseTemplates are the parsed templates of a response with template set
+type responseTemplates struct {
+	body    *template.Template // Nil when the response has no body
+	status  *template.Template // Nil when the status code is a number
+	headers map[string]*template.Template
+}
+
+// templateOptions returns the options every template of the rule set is parsed with,
+// and parses the body of the server errorResponse
+func (h *MockHandler) templateOptions(rs *ruleSet) (templating.Options, error) {
+	keys, err := templating.NewKeyring(rs.config.Server.KeyValues())
+	if err != nil {
+		return templating.Options{}, fmt.Errorf("server keys: %w", err)
+	}
+	opts := templating.Options{
+		Keys:      keys,
+		Datasets:  func(name string) (interface{}, error) { return h.dataset(rs, name) },
+		Denied:    rs.config.Server.TemplateLimits.DeniedFunctions,
+		Sequences: h.sequences,
+		Partials:  rs.config.PartialSet,
+	}
+	if e := rs.config.Server.ErrorResponse; e != nil && e.Body != "" {
+		if rs.errorBody, err = templating.Parse("server errorResponse", e.Body, opts); err != nil {
+			return templating.Options{}, fmt.Errorf("server errorResponse: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// compileTemplates parses the body and header templates of every response and
+// variant of rule i with template set, of its webhooks with template set, and its
+// retryAfter seconds
+func (h *MockHandler) compileTemplates(rs *ruleSet, i int, opts templating.Options) error {
+	var err error
+	for _, spec := range rs.config.Requests[i].AllResponses() {
+		if !spec.Template {
+			continue
+		}
+		rt := &responseTemplates{headers: make(map[string]*template.Template, len(spec.Headers))}
+		if body, ok := spec.Body.(string); ok {
+			if rt.body, err = templating.Parse(rs.ruleKeys[i], body, opts); err != nil {
+				return fmt.Errorf("request rule %d: %w", i, err)
+			}
+		}
+		if t := spec.Status.Template; t != "" {
+			if rt.status, err = templating.Parse(rs.ruleKeys[i]+" status-code", t, opts); err != nil {
+				return fmt.Errorf("request rule %d: status-code: %w", i, err)
+			}
+		}
+		for name, value := range spec.Headers {
+			if rt.headers[name], err = templating.Parse(rs.ruleKeys[i]+" "+name, value, opts); err != nil {
+				return fmt.Errorf("request rule %d: header %s: %w", i, name, err)
+			}
+		}
+		rs.templates[spec] = rt
+	}
+	if ra := rs.config.Requests[i].RetryAfter; ra != nil && ra.Template() {
+		if rs.retries[&rs.config.Requests[i]].seconds, err = templating.Parse(rs.ruleKeys[i]+" retryAfter", ra.Seconds, opts); err != nil {
+			return fmt.Errorf("request rule %d: retryAfter seconds: %w", i, err)
+		}
+	}
+	for _, wh := range rs.config.Requests[i].AllWebhooks() {
+		if !wh.Template {
+			continue
+		}
+		if rs.webhooks[wh], err = compileWebhook(rs.ruleKeys[i], wh, opts); err != nil {
+			return fmt.Errorf("request rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ResetSequences starts every seq template counter over from 1
+func (h *MockHandler) ResetSequences() {
+	h.sequences.Set(nil)
+}
+
+// newTemplateData collects the request information for the rule's templates
+func newTemplateData(r *http.Request, rs *ruleSet, rule *config.RequestRule) (*templateData, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	data := &templateData{
+		Method:   r.Method,
+		URL:      r.URL.RequestURI(),
+		Path:     map[string]string{},
+		Headers:  firstValues(r.Header),
+		Query:    firstValues(r.URL.Query()),
+		Cookies:  requestCookies(r),
+		Body:     string(body),
+		Audience: requestAudience(r, rs.config.Server.Audiences),
+	}
+	if rule.PathPattern != nil {
+		if params, ok := rule.PathPattern.Match(r.URL.Path); ok {
+			data.Path = params
+		}
+	}
+	if rule.PathPrefix != "" {
+		data.PathRest = strings.TrimPrefix(r.URL.Path, rule.PathPrefix)
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &data.JSON); err != nil {
+			data.JSON = nil
+		}
+	}
+	return data, nil
+}
+
+// render executes a template against the request data within server.templateLimits,
+// logging executions that break the limits against the template's rule
+func (rs *ruleSet) render(t *template.Template, data interface{}) ([]byte, error) {
+	out, err := templating.Execute(t, data, rs.config.Server.TemplateLimits.Limits())
+	if errors.Is(err, templating.ErrLimitExceeded) {
+		log.Printf("WARNING: template %s: %v", t.Name(), err)
+	}
+	return out, err
+}
+
+// renderStatus returns the response status code, rendering a templated one
+func renderStatus(rs *ruleSet, spec *config.ResponseSpec, rt *responseTemplates, data *templateData) (int, error) {
+	if rt == nil || rt.status == nil {
+		return spec.StatusCode, nil
+	}
+	value, err := rs.render(rt.status, data)
+	if err != nil {
+		return 0, fmt.Errorf("status-code template failed: %w", err)
+	}
+	status, err := strconv.Atoi(strings.TrimSpace(string(value)))
+	if err != nil || status < 100 || status > 599 {
+		return 0, fmt.Errorf("status-code template rendered %q, not a status code between 100 and 599", value)
+	}
+	return status, nil
+}
+
+// renderHeaders returns the response headers, rendering templated values
+func renderHeaders(rs *ruleSet, spec *config.ResponseSpec, rt *responseTemplates, data *templateData) (map[string]string, error) {
+	if rt == nil || len(rt.headers) == 0 {
+		return spec.Headers, nil
+	}
+	headers := make(map[string]string, len(spec.Headers))
+	for name, t := range rt.headers {
+		value, err := rs.render(t, data)
+		if err != nil {
+			return nil, fmt.Errorf("header %s template failed: %w", name, err)
+		}
+		headers[name] = string(value)
+	}
+	return headers, nil
+}
+
+func requestCookies(r *http.Request) map[string]string {
+	m := map[string]string{}
+	for _, c := range r.Cookies() {
+		if _, ok := m[c.Name]; !ok {
+			m[c.Name] = c.Value
+		}
+	}
+	return m
+}
+
+func firstValues(values map[string][]string) map[string]string {
+	m := make(map[string]string, len(values))
+	for name, v := range values {
+		if len(v) > 0 {
+			m[name] = v[0]
+		}
+	}
+	return m
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_ResponseTemplate(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  keys:
+    webhook: key
+requests:
+  - path: /manifest
+    method: POST
+    response:
+      template: true
+      headers:
+        Content-Type: application/json
+      body: '{"method":"{{ .Method }}","q":"{{ .Query.q }}","sha256":"{{ sha256 .Body }}","sig":"{{ hmacSHA256 "webhook" .Body }}"}'
+  - path: /broken
+    response:
+      template: true
+      body: '{{ sign "missing" "x" }}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/manifest?q=files", nil, []byte("The quick brown fox jumps over the lazy dog"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	want := `{"method":"POST","q":"files",` +
+		`"sha256":"d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592",` +
+		`"sig":"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"}`
+	if rr.Body.String() != want {
+		t.Fatalf("expected body %s, got %s", want, rr.Body.String())
+	}
+
+	rr = performRequest(h, http.MethodGet, "/broken", nil, nil)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestMockHandler_DatasetTemplate(t *testing.T) {
+	cfg := &config.Config{
+		DatasetData: map[string]interface{}{
+			"users": []interface{}{map[string]interface{}{"name": "ada"}},
+		},
+		Requests: []config.RequestRule{
+			{
+				Path:   "/users",
+				Method: "GET",
+				Response: config.ResponseSpec{
+					StatusCode: 200,
+					Template:   true,
+					Body:       `{"count":{{ len (dataset "users") }},"items":{{ dataset "users" | toJson }}}`,
+				},
+			},
+		},
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodGet, "/users", nil, nil)
+	if want := `{"count":1,"items":[{"name":"ada"}]}`; rr.Body.String() != want {
+		t.Fatalf("expected body %s, got %s", want, rr.Body.String())
+	}
+
+	h.Datasets().Set("users", []interface{}{"x", "y"}, []byte(`["x","y"]`), time.Now())
+	rr = performRequest(h, http.MethodGet, "/users", nil, nil)
+	if want := `{"count":2,"items":["x","y"]}`; rr.Body.String() != want {
+		t.Fatalf("expected body %s, got %s", want, rr.Body.String())
+	}
+}
+
+func TestMockHandler_TemplateRequestData(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /users/{id}/files/{name...}
+    method: PUT
+    response:
+      template: true
+      status-code: 201
+      headers:
+        Location: /users/{{ .Path.id }}/files/{{ .Path.name }}
+        X-Echo: '{{ .Query.q }}'
+      body: '{"id":"{{ .Path.id }}","file":"{{ .Path.name }}","owner":"{{ .JSON.owner.name }}","tags":{{ len .JSON.tags }}}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	body := []byte(`{"owner":{"name":"ada"},"tags":["a","b"]}`)
+	rr := performRequest(h, http.MethodPut, "/users/42/files/docs/report.pdf?q=hello", nil, body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	want := `{"id":"42","file":"docs/report.pdf","owner":"ada","tags":2}`
+	if rr.Body.String() != want {
+		t.Fatalf("expected body %s, got %s", want, rr.Body.String())
+	}
+	if got := rr.Header().Get("Location"); got != "/users/42/files/docs/report.pdf" {
+		t.Fatalf("unexpected Location header %q", got)
+	}
+	if got := rr.Header().Get("X-Echo"); got != "hello" {
+		t.Fatalf("unexpected X-Echo header %q", got)
+	}
+
+	if rr := performRequest(h, http.MethodPut, "/users//files/x", nil, body); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected empty parameter not to match, got %d", rr.Code)
+	}
+}
+
+func TestMockHandler_TemplatedStatus(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders
+    response:
+      template: true
+      status-code: '{{ or .Query.force_status "200" }}'
+      headers:
+        Retry-After: '{{ if eq .Query.force_status "503" }}30{{ end }}'
+      body: '{"ok":true}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		query      string
+		status     int
+		retryAfter string
+	}{
+		{"", http.StatusOK, ""},
+		{"?force_status=503", http.StatusServiceUnavailable, "30"},
+		{"?force_status=404", http.StatusNotFound, ""},
+		{"?force_status=oops", http.StatusInternalServerError, ""},
+		{"?force_status=700", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		rr := performRequest(h, http.MethodGet, "/orders"+tt.query, nil, nil)
+		if rr.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d", tt.query, tt.status, rr.Code)
+		}
+		if got := rr.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Fatalf("%s: expected Retry-After %q, got %q", tt.query, tt.retryAfter, got)
+		}
+	}
+}
+
+func TestMockHandler_TemplateLimits(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+server:
+  templateLimits:
+    maxOutput: 1kb
+    timeout: 50
+  errorResponse:
+    status-code: 502
+    body: '{{ .Rule }}: {{ .Error }}'
+requests:
+  - path: /big
+    response:
+      template: true
+      body: '{{ range 1000 }}xx{{ end }}'
+  - path: /slow
+    response:
+      template: true
+      body: '{{ range 1000000000 }}{{ if false }}x{{ end }}{{ "" }}{{ end }}'
+  - path: /small
+    response:
+      template: true
+      body: '{{ range 10 }}xx{{ end }}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/big", http.StatusBadGateway, "GET /big: response template failed: template limit exceeded: output is larger than 1024 bytes"},
+		{"/slow", http.StatusBadGateway, "GET /slow: response template failed: template limit exceeded: ran longer than 50ms"},
+		{"/small", http.StatusOK, "xxxxxxxxxxxxxxxxxxxx"},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		rr := performRequest(h, http.MethodGet, tt.path, nil, nil)
+		if rr.Code != tt.status || rr.Body.String() != tt.body {
+			t.Fatalf("%s: expected %d %q, got %d %q", tt.path, tt.status, tt.body, rr.Code, rr.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("%s: took %s", tt.path, elapsed)
+		}
+	}
+}
+
+func TestMockHandler_BodyExtractTemplate(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders
+    method: POST
+    response:
+      template: true
+      status-code: 201
+      headers:
+        Location: '/orders/{{ jsonPath "$.orderId" .Body }}'
+      body: '{"orderId":"{{ jsonPath "$.orderId" .Body }}","items":{{ jsonPath "$.items[*].sku" .Body }}}'
+  - path: /login
+    method: POST
+    response:
+      template: true
+      body: 'welcome {{ formValue "user" .Body }}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/orders", nil, []byte(`{"orderId":"A-17","items":[{"sku":"x1"},{"sku":"y2"}]}`))
+	if want := `{"orderId":"A-17","items":["x1","y2"]}`; rr.Code != http.StatusCreated || rr.Body.String() != want {
+		t.Fatalf("expected 201 %s, got %d %s", want, rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Location"); got != "/orders/A-17" {
+		t.Fatalf("unexpected Location header %q", got)
+	}
+
+	rr = performRequest(h, http.MethodPost, "/login", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, []byte("user=ada&password=x"))
+	if rr.Body.String() != "welcome ada" {
+		t.Fatalf("unexpected body %q", rr.Body.String())
+	}
+}
+
+func TestMockHandler_SeqTemplate(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders
+    method: POST
+    response:
+      template: true
+      status-code: 201
+      body: '{"id":"ord-{{ seq "orders" }}"}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	next := func() string { return performRequest(h, http.MethodPost, "/orders", nil, nil).Body.String() }
+	if got := next(); got != `{"id":"ord-1"}` {
+		t.Fatalf("unexpected body %s", got)
+	}
+	if _, _, err := h.SaveCheckpoint("one-order"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next()
+	// Counters survive reloads, and checkpoints and resets rewind them
+	if err := h.Reload(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := next(); got != `{"id":"ord-3"}` {
+		t.Fatalf("expected the counter to survive the reload, got %s", got)
+	}
+	h.RestoreCheckpoint("one-order")
+	if got := next(); got != `{"id":"ord-2"}` {
+		t.Fatalf("expected the checkpoint to rewind the counter, got %s", got)
+	}
+	h.ResetSequences()
+	if got := next(); got != `{"id":"ord-1"}` {
+		t.Fatalf("expected the counter to start over, got %s", got)
+	}
+}
+
+func TestMockHandler_TemplatePartials(t *testing.T) {
+	envelope := filepath.Join(t.TempDir(), "envelope.tmpl")
+	if err := os.WriteFile(envelope, []byte(`{"status":"ok","meta":{"path":"{{ .Request.URL }}"},"data":{{ toJson .Data }}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+partials:
+  envelope: `+envelope+`
+requests:
+  - path: /users/{id}
+    response:
+      template: true
+      body: '{{ template "envelope" dict "Request" . "Data" (dict "id" .Path.id) }}'
+  - path: /orders
+    response:
+      template: true
+      body: '{{ template "envelope" dict "Request" . "Data" (list) }}'
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	for path, want := range map[string]string{
+		"/users/7": `{"status":"ok","meta":{"path":"/users/7"},"data":{"id":"7"}}`,
+		"/orders":  `{"status":"ok","meta":{"path":"/orders"},"data":[]}`,
+	} {
+		if got := performRequest(h, http.MethodGet, path, nil, nil).Body.String(); got != want {
+			t.Fatalf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestResponseTrailers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(file, []byte("file body"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /grpc
+    method: POST
+    response:
+      headers:
+        Content-Type: application/grpc
+      body: payload
+      trailers:
+        grpc-status: "0"
+        Grpc-Message: ok
+  - path: /file
+    response:
+      bodyFile: `+file+`
+      trailers:
+        X-Checksum: abc
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv := httptest.NewServer(NewMockHandler(cfg))
+	defer srv.Close()
+
+	tests := []struct {
+		method, path, body string
+		trailers           map[string]string
+	}{
+		{http.MethodPost, "/grpc", "payload", map[string]string{"Grpc-Status": "0", "Grpc-Message": "ok"}},
+		{http.MethodGet, "/file", "file body", map[string]string{"X-Checksum": "abc"}},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+			t.Fatalf("%s: expected a chunked body, got %v", tt.path, resp.TransferEncoding)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tt.body {
+			t.Fatalf("%s: expected body %q, got %q", tt.path, tt.body, body)
+		}
+		for name, want := range tt.trailers {
+			if got := resp.Trailer.Get(name); got != want {
+				t.Errorf("%s: expected trailer %s %q, got %q (%v)", tt.path, name, want, got, resp.Trailer)
+			}
+			if resp.Header.Get(name) != "" {
+				t.Errorf("%s: trailer %s also sent as a header", tt.path, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/pkg/transform"
+)
+
+// openTransformer loads the function of a transformer plugin, replaced in tests
+var openTransformer = (*config.Transformer).Open
+
+// transformResponse builds the response with fn, the transformer of spec. The headers of
+// spec are sent as well, unless the transformer sets them itself.
+func transformResponse(r *http.Request, rule *config.RequestRule, spec *config.ResponseSpec, fn transform.Func) ([]byte, int, map[string]string, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("reading request body: %w", err)
+	}
+	req := &transform.Request{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.Query(),
+		Header:     r.Header.Clone(),
+		Body:       body,
+		PathParams: map[string]string{},
+		Options:    spec.Transformer.Options,
+	}
+	if rule.PathPattern != nil {
+		if params, ok := rule.PathPattern.Match(r.URL.Path); ok {
+			req.PathParams = params
+		}
+	}
+	resp, err := callTransformer(fn, req)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("transformer %s: %w", spec.Transformer.Symbol, err)
+	}
+	status := spec.StatusCode
+	if resp.Status != 0 {
+		if resp.Status < 100 || resp.Status > 599 {
+			return nil, 0, nil, fmt.Errorf("transformer %s returned status %d", spec.Transformer.Symbol, resp.Status)
+		}
+		status = resp.Status
+	}
+	headers := make(map[string]string, len(spec.Headers)+len(resp.Headers))
+	for name, value := range spec.Headers {
+		headers[name] = value
+	}
+	for name, value := range resp.Headers {
+		headers[name] = value
+	}
+	return resp.Body, status, headers, nil
+}
+
+// callTransformer runs the plugin function, turning a panic or a nil response into an error
+func callTransformer(fn transform.Func, req *transform.Request) (resp *transform.Response, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	resp, err = fn(req)
+	if err == nil && resp == nil {
+		err = fmt.Errorf("returned no response")
+	}
+	return resp, err
+}
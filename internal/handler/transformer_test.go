@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/pkg/transform"
+)
+
+func TestTransformer(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+requests:
+  - path: /orders/{id}
+    method: POST
+    response:
+      status-code: 201
+      headers:
+        Content-Type: text/plain
+        X-Source: rule
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec := &cfg.Requests[0].Response
+	spec.Transformer = &config.Transformer{
+		Plugin:  "./orders.so",
+		Symbol:  config.DefaultTransformerSymbol,
+		Options: map[string]string{"prefix": "order"},
+	}
+	defer func(open func(*config.Transformer) (transform.Func, error)) { openTransformer = open }(openTransformer)
+	openTransformer = func(*config.Transformer) (transform.Func, error) {
+		return func(req *transform.Request) (*transform.Response, error) {
+			switch string(req.Body) {
+			case "fail":
+				return nil, errors.New("rejected")
+			case "panic":
+				panic("boom")
+			case "teapot":
+				return &transform.Response{Status: http.StatusTeapot}, nil
+			}
+			body := req.Options["prefix"] + " " + req.PathParams["id"] + " " + strings.ToUpper(string(req.Body)) + " " + req.Query.Get("v")
+			return &transform.Response{Headers: map[string]string{"X-Source": "plugin"}, Body: []byte(body)}, nil
+		}, nil
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/orders/42?v=2", nil, []byte("hello"))
+	if rr.Code != http.StatusCreated || rr.Body.String() != "order 42 HELLO 2" {
+		t.Fatalf("expected 201 with the transformed body, got %d %q", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("X-Source"); got != "plugin" {
+		t.Fatalf("expected the transformer header to win, got %q", got)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/plain" {
+		t.Fatalf("expected the rule header to be kept, got %q", got)
+	}
+
+	if rr := performRequest(h, http.MethodPost, "/orders/42", nil, []byte("teapot")); rr.Code != http.StatusTeapot {
+		t.Fatalf("expected the transformer status, got %d", rr.Code)
+	}
+	for _, body := range []string{"fail", "panic"} {
+		if rr := performRequest(h, http.MethodPost, "/orders/42", nil, []byte(body)); rr.Code != http.StatusInternalServerError {
+			t.Fatalf("%s: expected 500, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestTransformer_MissingPlugin(t *testing.T) {
+	cfg, err := config.Parse([]byte("requests:\n  - path: /render\n    response:\n      transformer:\n        plugin: ./does-not-exist.so\n"), "test")
+	if err != nil {
+		t.Fatalf("expected the plugin to be left unopened by validation, got %v", err)
+	}
+	h := NewMockHandler(&config.Config{})
+	if err := h.Reload(cfg); err == nil || !strings.Contains(err.Error(), "transformer plugin ./does-not-exist.so") {
+		t.Fatalf("expected a plugin error, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// ruleWarmup is how long it took to get one rule ready to serve
+type ruleWarmup struct {
+	rule    string
+	load    time.Duration // Validating the rule and compiling its patterns, schemas, and plugins
+	prepare time.Duration // Parsing its templates and conditions and generating random bodies
+	files   time.Duration // Loading its body files
+	total   time.Duration
+	err     error // Why a body file couldn't be loaded ahead of time
+}
+
+// warmupReport lists the preparation time of every rule of a configuration
+type warmupReport struct {
+	total time.Duration
+	rules []ruleWarmup // In configuration order
+}
+
+// warmUp loads the body files of every rule ahead of the first request and logs
+// how long each rule took to prepare
+func (h *MockHandler) warmUp(rs *ruleSet) *warmupReport {
+	cfg := rs.config.Server.Warmup
+	report := &warmupReport{rules: make([]ruleWarmup, len(rs.config.Requests))}
+	for i := range rs.config.Requests {
+		rw := ruleWarmup{rule: rs.ruleKeys[i], prepare: rs.prepareTimes[i]}
+		if i < len(rs.config.RuleLoadTimes) {
+			rw.load = rs.config.RuleLoadTimes[i]
+		}
+		start := time.Now()
+		if rw.err = h.loadBodyFiles(rs, &rs.config.Requests[i]); rw.err != nil {
+			log.Printf("WARNING: rule %s: %v", rw.rule, rw.err)
+		}
+		rw.files = time.Since(start)
+		rw.total = rw.load + rw.prepare + rw.files
+		if rw.total > cfg.WarnAfterDuration() {
+			log.Printf("WARNING: rule %s took %s to prepare (%s)", rw.rule, rw.total.Round(time.Microsecond), rw.breakdown())
+		}
+		report.total += rw.total
+		report.rules[i] = rw
+	}
+	log.Print(report.summary(cfg.Slowest))
+	return report
+}
+
+// loadBodyFiles reads the body files of a rule into the file cache. Files in memory
+// storage are read on every request, so there is nothing to load.
+func (h *MockHandler) loadBodyFiles(rs *ruleSet, rule *config.RequestRule) error {
+	if st := rs.config.Server.Storage; st != nil && st.Type == config.StorageMemory {
+		return nil
+	}
+	for _, spec := range rule.AllResponses() {
+		if spec.BodyFile == "" {
+			continue
+		}
+		if _, err := h.bodyFile(context.Background(), rs, spec.BodyFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rw ruleWarmup) breakdown() string {
+	return fmt.Sprintf("load %s, prepare %s, files %s",
+		rw.load.Round(time.Microsecond), rw.prepare.Round(time.Microsecond), rw.files.Round(time.Microsecond))
+}
+
+// summary describes the warm-up in one line, followed by the slowest rules
+func (r *warmupReport) summary(slowest int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Warmed up %d request rules in %s", len(r.rules), r.total.Round(time.Microsecond))
+	rules := r.slowest(slowest)
+	if len(rules) == 0 {
+		return b.String()
+	}
+	b.WriteString(", slowest first:")
+	width := 0
+	for _, rw := range rules {
+		width = max(width, len(rw.rule))
+	}
+	for _, rw := range rules {
+		fmt.Fprintf(&b, "\n  %-*s  %10s  (%s)", width, rw.rule, rw.total.Round(time.Microsecond), rw.breakdown())
+	}
+	return b.String()
+}
+
+// slowest returns up to n rules that took longest to prepare, slowest first
+func (r *warmupReport) slowest(n int) []ruleWarmup {
+	rules := append([]ruleWarmup(nil), r.rules...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].total > rules[j].total })
+	if n < len(rules) {
+		rules = rules[:n]
+	}
+	return rules
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_Warmup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.csv")
+	if err := os.WriteFile(path, []byte("id\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse([]byte(`
+server:
+  warmup: {slowest: 2}
+requests:
+  - path: /orders.csv
+    response:
+      bodyFile: `+path+`
+  - path: /greeting
+    response:
+      template: true
+      body: "Hello {{ .Query.name }}"
+  - path: /big
+    response:
+      randomBody: {type: plaintext, size: 1 MB}
+`), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.RuleLoadTimes) != 3 {
+		t.Fatalf("expected a load time per rule, got %v", cfg.RuleLoadTimes)
+	}
+
+	var buf bytes.Buffer
+	oldOut := log.Writer()
+	defer log.SetOutput(oldOut)
+	log.SetOutput(&buf)
+	h := NewMockHandler(cfg)
+
+	if _, ok := h.files.files[path]; !ok {
+		t.Fatalf("expected the body file to be loaded before the first request")
+	}
+	report := h.current().warmup
+	if report == nil || len(report.rules) != 3 || report.rules[1].rule != "GET /greeting" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	var sum time.Duration
+	for _, rw := range report.rules {
+		if rw.total != rw.load+rw.prepare+rw.files || rw.err != nil {
+			t.Fatalf("unexpected rule timing %+v", rw)
+		}
+		sum += rw.total
+	}
+	if report.total != sum {
+		t.Fatalf("expected the total %s to add up the rules, got %s", sum, report.total)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Warmed up 3 request rules in ") || strings.Count(out, "(load ") < 2 {
+		t.Fatalf("expected a timing report listing the 2 slowest rules, got %q", out)
+	}
+}
+
+func TestWarmupReport_Slowest(t *testing.T) {
+	report := &warmupReport{rules: []ruleWarmup{
+		{rule: "GET /a", total: 2 * time.Millisecond},
+		{rule: "GET /b", total: 9 * time.Millisecond},
+		{rule: "GET /c", total: 5 * time.Millisecond},
+	}}
+	got := report.slowest(2)
+	if len(got) != 2 || got[0].rule != "GET /b" || got[1].rule != "GET /c" {
+		t.Fatalf("unexpected slowest rules %+v", got)
+	}
+	if report.rules[0].rule != "GET /a" {
+		t.Fatalf("expected the report to keep configuration order")
+	}
+	if summary := report.summary(0); strings.Contains(summary, "slowest") {
+		t.Fatalf("expected no rule list with slowest 0, got %q", summary)
+	}
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/templating"
+)
+
+// maxWebhookDeliveries bounds the journal; the oldest deliveries are dropped first
+const maxWebhookDeliveries = 1000
+
+// Headers sent with every webhook attempt. The delivery ID stays the same across
+// retries so receivers can deduplicate redeliveries.
+const (
+	WebhookDeliveryHeader = "X-Mock-Delivery"
+	WebhookAttemptHeader  = "X-Mock-Attempt"
+)
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"   // Waiting for an attempt or a retry
+	DeliveryDelivered = "delivered" // An attempt was accepted
+	DeliveryFailed    = "failed"    // Attempts ran out or the status was not retryable
+)
+
+// WebhookDelivery is a webhook sent for one matched request, with every attempt made so far
+type WebhookDelivery struct {
+	ID        string           `json:"id"`
+	Rule      string           `json:"rule"`
+	Method    string           `json:"method"`
+	URL       string           `json:"url"`
+	Status    string           `json:"status"`
+	NextRetry *time.Time       `json:"nextRetry,omitempty"`
+	Attempts  []WebhookAttempt `json:"attempts"`
+}
+
+// WebhookAttempt records a single delivery attempt
+type WebhookAttempt struct {
+	Attempt    int       `json:"attempt"`
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// webhookTemplates are the parsed templates of a webhook with template set
+type webhookTemplates struct {
+	url     *template.Template
+	body    *template.Template // Nil when the body isn't a string
+	headers map[string]*template.Template
+}
+
+// webhookRequest is a rendered webhook, ready to be sent
+type webhookRequest struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    []byte
+	json    bool // The body was encoded from a structured value
+}
+
+// webhookJournal delivers webhooks in the background and keeps a record of each attempt.
+// It outlives configuration reloads.
+type webhookJournal struct {
+	client *http.Client
+	sleep  func(time.Duration)
+	now    func() time.Time
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	deliveries []*WebhookDelivery
+}
+
+func newWebhookJournal() *webhookJournal {
+	return &webhookJournal{
+		client: &http.Client{},
+		sleep:  time.Sleep,
+		now:    time.Now,
+	}
+}
+
+// compileWebhook parses the URL, body, and header templates of a webhook
+func compileWebhook(name string, wh *config.Webhook, opts templating.Options) (*webhookTemplates, error) {
+	wt := &webhookTemplates{headers: make(map[string]*template.Template, len(wh.Headers))}
+	var err error
+	if wt.url, err = templating.Parse(name+" webhook url", wh.URL, opts); err != nil {
+		return nil, fmt.Errorf("webhook url: %w", err)
+	}
+	if body, ok := wh.Body.(string); ok {
+		if wt.body, err = templating.Parse(name+" webhook body", body, opts); err != nil {
+			return nil, fmt.Errorf("webhook body: %w", err)
+		}
+	}
+	for header, value := range wh.Headers {
+		if wt.headers[header], err = templating.Parse(name+" webhook "+header, value, opts); err != nil {
+			return nil, fmt.Errorf("webhook header %s: %w", header, err)
+		}
+	}
+	return wt, nil
+}
+
+// sendWebhook renders one of the rule's webhooks against the request and delivers it in the background
+func (h *MockHandler) sendWebhook(r *http.Request, rs *ruleSet, rule *config.RequestRule, wh *config.Webhook) {
+	req, err := renderWebhook(r, rs, rule, wh)
+	if err != nil {
+		log.Printf("Webhook for %s not sent: %v", rs.ruleKey(rule), err)
+		return
+	}
+	h.webhooks.deliver(rs.ruleKey(rule), wh, req)
+}
+
+func renderWebhook(r *http.Request, rs *ruleSet, rule *config.RequestRule, wh *config.Webhook) (*webhookRequest, error) {
+	wt := rs.webhooks[wh]
+	req := &webhookRequest{method: wh.Method, url: wh.URL, headers: wh.Headers}
+	if _, ok := wh.Body.(string); !ok && wh.Body != nil {
+		req.json = true
+	}
+	if wt == nil {
+		body, err := encodeBody(wh.Body)
+		if err != nil {
+			return nil, err
+		}
+		if wh.Body != nil {
+			req.body = body
+		}
+		return req, nil
+	}
+
+	data, err := newTemplateData(r, rs, rule)
+	if err != nil {
+		return nil, err
+	}
+	u, err := rs.render(wt.url, data)
+	if err != nil {
+		return nil, fmt.Errorf("url template failed: %w", err)
+	}
+	req.url = string(u)
+	if wt.body != nil {
+		if req.body, err = rs.render(wt.body, data); err != nil {
+			return nil, fmt.Errorf("body template failed: %w", err)
+		}
+	} else if wh.Body != nil {
+		if req.body, err = encodeBody(wh.Body); err != nil {
+			return nil, err
+		}
+	}
+	req.headers = make(map[string]string, len(wt.headers))
+	for name, t := range wt.headers {
+		value, err := rs.render(t, data)
+		if err != nil {
+			return nil, fmt.Errorf("header %s template failed: %w", name, err)
+		}
+		req.headers[name] = string(value)
+	}
+	return req, nil
+}
+
+// deliver records a new delivery and attempts it in the background
+func (j *webhookJournal) deliver(rule string, wh *config.Webhook, req *webhookRequest) {
+	d := &WebhookDelivery{
+		ID:       newDeliveryID(),
+		Rule:     rule,
+		Method:   req.method,
+		URL:      req.url,
+		Status:   DeliveryPending,
+		Attempts: []WebhookAttempt{},
+	}
+	j.mu.Lock()
+	j.deliveries = append(j.deliveries, d)
+	if n := len(j.deliveries) - maxWebhookDeliveries; n > 0 {
+		j.deliveries = append([]*WebhookDelivery(nil), j.deliveries[n:]...)
+	}
+	j.mu.Unlock()
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		j.run(d, wh, req)
+	}()
+}
+
+// run makes attempts until one is accepted, the status isn't retryable, or attempts run out
+func (j *webhookJournal) run(d *WebhookDelivery, wh *config.Webhook, req *webhookRequest) {
+	if wh.Delay > 0 {
+		j.sleep(time.Duration(wh.Delay) * time.Millisecond)
+	}
+	for attempt := 1; ; attempt++ {
+		a := j.attempt(d.ID, attempt, wh, req)
+
+		status := DeliveryPending
+		var backoff time.Duration
+		switch {
+		case a.Error == "" && a.StatusCode >= 200 && a.StatusCode <= 299:
+			status = DeliveryDelivered
+		case attempt >= wh.Retry.MaxAttempts || !wh.Retry.Retryable(a.StatusCode):
+			status = DeliveryFailed
+		default:
+			backoff = time.Duration(wh.Retry.BackoffMillis(attempt)) * time.Millisecond
+		}
+
+		j.mu.Lock()
+		d.Attempts = append(d.Attempts, a)
+		d.Status = status
+		d.NextRetry = nil
+		if status == DeliveryPending {
+			next := a.Time.Add(backoff)
+			d.NextRetry = &next
+		}
+		j.mu.Unlock()
+
+		if status != DeliveryPending {
+			return
+		}
+		j.sleep(backoff)
+	}
+}
+
+// attempt sends the webhook once
+func (j *webhookJournal) attempt(id string, n int, wh *config.Webhook, req *webhookRequest) WebhookAttempt {
+	a := WebhookAttempt{Attempt: n, Time: j.now()}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(wh.Timeout)*time.Millisecond)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, req.method, req.url, bytes.NewReader(req.body))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	for name, value := range req.headers {
+		r.Header.Set(name, value)
+	}
+	if req.json && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set(WebhookDeliveryHeader, id)
+	r.Header.Set(WebhookAttemptHeader, strconv.Itoa(n))
+
+	start := time.Now()
+	resp, err := j.client.Do(r)
+	a.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	a.StatusCode = resp.StatusCode
+	return a
+}
+
+// snapshot returns copies of the recorded deliveries, oldest first
+func (j *webhookJournal) snapshot() []WebhookDelivery {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]WebhookDelivery, len(j.deliveries))
+	for i, d := range j.deliveries {
+		out[i] = *d
+		out[i].Attempts = append([]WebhookAttempt{}, d.Attempts...)
+	}
+	return out
+}
+
+func (j *webhookJournal) reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.deliveries = nil
+}
+
+// WebhookDeliveries returns the journal of webhook deliveries, oldest first
+func (h *MockHandler) WebhookDeliveries() []WebhookDelivery {
+	return h.webhooks.snapshot()
+}
+
+// ResetWebhookDeliveries clears the webhook journal. Deliveries still retrying keep
+// running but are no longer reported.
+func (h *MockHandler) ResetWebhookDeliveries() {
+	h.webhooks.reset()
+}
+
+// newDeliveryID returns a random version 4 UUID
+func newDeliveryID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
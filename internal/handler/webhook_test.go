@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver answers webhook attempts with the given statuses in turn
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	status := rcv.statuses[len(rcv.requests)%len(rcv.statuses)]
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, string(body))
+	w.WriteHeader(status)
+}
+
+// recordSleeps records the handler's webhook backoffs instead of sleeping
+func recordSleeps(h *MockHandler) *[]time.Duration {
+	sleeps := &[]time.Duration{}
+	h.webhooks.sleep = func(d time.Duration) { *sleeps = append(*sleeps, d) }
+	return sleeps
+}
+
+func TestWebhook_RetriesWithBackoff(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{500, 503, 500, 204}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+requests:
+  - name: order
+    path: /orders
+    method: POST
+    response:
+      status-code: 201
+    webhook:
+      url: %s/hook
+      headers:
+        X-Event: order.created
+      body:
+        event: order.created
+      retry:
+        maxAttempts: 5
+        backoff: 100
+        multiplier: 3
+        maxBackoff: 500
+`, srv.URL))
+	sleeps := recordSleeps(h)
+
+	if rr := performRequest(h, http.MethodPost, "/orders", nil, nil); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	h.webhooks.wg.Wait()
+
+	if len(rcv.requests) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(rcv.requests))
+	}
+	id := rcv.requests[0].Header.Get(WebhookDeliveryHeader)
+	for i, r := range rcv.requests {
+		if r.Header.Get(WebhookDeliveryHeader) != id {
+			t.Fatalf("attempt %d: delivery ID changed", i+1)
+		}
+		if got := r.Header.Get(WebhookAttemptHeader); got != fmt.Sprint(i+1) {
+			t.Fatalf("attempt %d: expected attempt header %d, got %q", i+1, i+1, got)
+		}
+		if r.Header.Get("X-Event") != "order.created" || r.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("attempt %d: unexpected headers %v", i+1, r.Header)
+		}
+		if rcv.bodies[i] != `{"event":"order.created"}` {
+			t.Fatalf("attempt %d: unexpected body %q", i+1, rcv.bodies[i])
+		}
+	}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 500 * time.Millisecond}
+	if fmt.Sprint(*sleeps) != fmt.Sprint(want) {
+		t.Fatalf("expected backoffs %v, got %v", want, *sleeps)
+	}
+
+	deliveries := h.WebhookDeliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.ID != id || d.Rule != "order" || d.Status != DeliveryDelivered || d.NextRetry != nil || len(d.Attempts) != 4 {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+	if d.Attempts[1].StatusCode != 503 || d.Attempts[3].StatusCode != 204 {
+		t.Fatalf("unexpected attempts: %+v", d.Attempts)
+	}
+
+	h.ResetWebhookDeliveries()
+	if n := len(h.WebhookDeliveries()); n != 0 {
+		t.Fatalf("expected empty journal after reset, got %d", n)
+	}
+}
+
+func TestWebhook_FailureOutcomes(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retry    string
+		attempts int
+	}{
+		{"attempts run out", []int{500}, "maxAttempts: 3", 3},
+		{"status not in retryOn", []int{503, 400}, "maxAttempts: 5\n        retryOn: [503]", 2},
+		{"no retry by default", []int{502}, "{}", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := &webhookReceiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+
+			h := newTestHandler(t, fmt.Sprintf(`
+requests:
+  - path: /event
+    webhook:
+      url: %s
+      retry:
+        %s
+`, srv.URL, tt.retry))
+			recordSleeps(h)
+			performRequest(h, http.MethodGet, "/event", nil, nil)
+			h.webhooks.wg.Wait()
+
+			d := h.WebhookDeliveries()[0]
+			if d.Status != DeliveryFailed || len(d.Attempts) != tt.attempts {
+				t.Fatalf("expected failed after %d attempts, got %s after %d", tt.attempts, d.Status, len(d.Attempts))
+			}
+		})
+	}
+}
+
+func TestWebhook_ConnectionErrorIsRetried(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+requests:
+  - path: /event
+    webhook:
+      url: %s
+      retry:
+        maxAttempts: 2
+`, url))
+	recordSleeps(h)
+	performRequest(h, http.MethodGet, "/event", nil, nil)
+	h.webhooks.wg.Wait()
+
+	d := h.WebhookDeliveries()[0]
+	if d.Status != DeliveryFailed || len(d.Attempts) != 2 || d.Attempts[0].Error == "" {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+}
+
+func TestWebhook_Template(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{200}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+requests:
+  - path: /payments/{id}
+    method: POST
+    webhook:
+      url: '%s/callbacks/{{ .Path.id }}'
+      template: true
+      headers:
+        Content-Type: application/json
+      body: '{"payment":"{{ .Path.id }}","amount":{{ .JSON.amount }}}'
+`, srv.URL))
+	recordSleeps(h)
+	performRequest(h, http.MethodPost, "/payments/p-1", nil, []byte(`{"amount":42}`))
+	h.webhooks.wg.Wait()
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("expected 1 attempt, got %d", len(rcv.requests))
+	}
+	if got := rcv.requests[0].URL.Path; got != "/callbacks/p-1" {
+		t.Fatalf("unexpected webhook path %q", got)
+	}
+	if rcv.bodies[0] != `{"payment":"p-1","amount":42}` {
+		t.Fatalf("unexpected webhook body %q", rcv.bodies[0])
+	}
+}
+
+func TestWebhook_SeveralPerRule(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{204}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	h := newTestHandler(t, fmt.Sprintf(`
+requests:
+  - name: payment
+    path: /payments/{id}
+    method: POST
+    response:
+      status-code: 202
+    webhooks:
+      - url: %[1]s/authorized
+        template: true
+        body: '{"payment":"{{ .Path.id }}"}'
+      - url: %[1]s/settled
+        method: PUT
+        delay: 3000
+        body: settled
+`, srv.URL))
+	sleeps := recordSleeps(h)
+
+	if rr := performRequest(h, http.MethodPost, "/payments/pay_1", nil, nil); rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+	h.webhooks.wg.Wait()
+
+	got := map[string]string{}
+	for i, r := range rcv.requests {
+		got[r.Method+" "+r.URL.Path] = rcv.bodies[i]
+	}
+	if len(got) != 2 || got["POST /authorized"] != `{"payment":"pay_1"}` || got["PUT /settled"] != "settled" {
+		t.Fatalf("expected both webhooks, got %v", got)
+	}
+	if fmt.Sprint(*sleeps) != "[3s]" {
+		t.Fatalf("expected only the second webhook to wait, got %v", *sleeps)
+	}
+	if deliveries := h.WebhookDeliveries(); len(deliveries) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(deliveries))
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestMockHandler_XMLBody(t *testing.T) {
+	rules := `
+requests:
+  - path: /soap/users
+    method: POST
+    response:
+      bodyFormat: xml
+      xml:
+        root: soap:Envelope
+        namespaces:
+          soap: http://schemas.xmlsoap.org/soap/envelope/
+      body:
+        soap:Body:
+          GetUserResponse:
+            id: 7
+            name: Ada
+  - path: /typed
+    response:
+      headers:
+        content-type: text/xml
+      bodyFormat: xml
+      body: {ok: true}
+`
+	cfg, err := config.Parse([]byte(rules), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := NewMockHandler(cfg)
+
+	rr := performRequest(h, http.MethodPost, "/soap/users", nil, nil)
+	want := xml.Header + `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">` +
+		`<soap:Body><GetUserResponse><id>7</id><name>Ada</name></GetUserResponse></soap:Body></soap:Envelope>`
+	if rr.Body.String() != want {
+		t.Fatalf("expected %s, got %s", want, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/xml; charset=utf-8" {
+		t.Fatalf("expected an XML Content-Type, got %q", got)
+	}
+	var envelope struct {
+		Name string `xml:"Body>GetUserResponse>name"`
+	}
+	if err := xml.Unmarshal(rr.Body.Bytes(), &envelope); err != nil || envelope.Name != "Ada" {
+		t.Fatalf("expected well-formed XML, got %+v, %v", envelope, err)
+	}
+
+	if got := performRequest(h, http.MethodGet, "/typed", nil, nil).Header().Get("Content-Type"); got != "text/xml" {
+		t.Fatalf("expected the configured Content-Type, got %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bruMethods are the blocks of a .bru file that hold the request method and URL
+var bruMethods = map[string]bool{
+	"get": true, "post": true, "put": true, "delete": true, "patch": true,
+	"options": true, "head": true, "connect": true, "trace": true,
+}
+
+// bruBlock is a top-level block of a .bru file. Dictionary blocks such as meta
+// and headers have entries; body, docs, script, and tests blocks have text.
+type bruBlock struct {
+	name    string
+	entries map[string]interface{} // Values are strings or nested maps
+	text    string
+}
+
+// Bruno converts a Bruno collection directory, or a single .bru file, into rules
+// named after their folder and request names. A request with saved examples
+// answers with its first example; other requests answer 200 with an empty body.
+func Bruno(path string) ([]Rule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		rule, ok, err := brunoFile(path, "")
+		if err != nil || !ok {
+			return nil, err
+		}
+		return []Rule{rule}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "bruno.json")); err != nil {
+		return nil, fmt.Errorf("%s is not a Bruno collection: bruno.json not found", path)
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "environments" || d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) && p != path {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(p) == ".bru" && d.Name() != "folder.bru" && d.Name() != "collection.bru" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var rules []Rule
+	for _, file := range files {
+		rel, _ := filepath.Rel(path, filepath.Dir(file))
+		folder := ""
+		if rel != "." {
+			folder = filepath.ToSlash(rel) + "/"
+		}
+		rule, ok, err := brunoFile(file, folder)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// brunoFile converts one .bru file. It reports false for files without an HTTP request.
+func brunoFile(path, folder string) (Rule, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rule{}, false, err
+	}
+	blocks, err := parseBru(string(data))
+	if err != nil {
+		return Rule{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".bru")
+	var rule Rule
+	found := false
+	var example map[string]interface{}
+	for _, b := range blocks {
+		switch {
+		case b.name == "meta":
+			if n, ok := b.entries["name"].(string); ok && n != "" {
+				name = n
+			}
+		case bruMethods[b.name]:
+			url, _ := b.entries["url"].(string)
+			rule = newRule("", b.name, url)
+			found = true
+		case b.name == "example" && example == nil:
+			example = b.entries
+		}
+	}
+	if !found {
+		return Rule{}, false, nil
+	}
+	rule.Name = folder + name
+	if example != nil {
+		applyBrunoExample(&rule.Response, example)
+	}
+	return rule, true, nil
+}
+
+// applyBrunoExample copies the status, headers, and body of a saved example response
+func applyBrunoExample(resp *Response, example map[string]interface{}) {
+	r, _ := example["response"].(map[string]interface{})
+	if r == nil {
+		return
+	}
+	if status, ok := r["status"].(map[string]interface{}); ok {
+		if code, err := strconv.Atoi(fmt.Sprint(status["code"])); err == nil && code >= 100 && code <= 599 {
+			resp.StatusCode = code
+		}
+	}
+	contentType := ""
+	if headers, ok := r["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			v, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if strings.EqualFold(name, "Content-Type") {
+				contentType = v
+				continue
+			}
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]string)
+			}
+			resp.Headers[name] = v
+		}
+	}
+	if body, ok := r["body"].(map[string]interface{}); ok {
+		content, _ := body["content"].(string)
+		resp.setBody(content, contentType)
+	} else if contentType != "" {
+		resp.setBody("", contentType)
+	}
+}
+
+// isBruTextBlock reports whether a top-level block holds free text rather than entries
+func isBruTextBlock(name string) bool {
+	return (strings.HasPrefix(name, "body") && name != "body:form-urlencoded" && name != "body:multipart-form") ||
+		name == "docs" || name == "tests" || strings.HasPrefix(name, "script")
+}
+
+// parseBru parses the block structure of a .bru file
+func parseBru(src string) ([]bruBlock, error) {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var blocks []bruBlock
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, "{") {
+			return nil, fmt.Errorf("line %d: expected a block, got %q", i+1, line)
+		}
+		b := bruBlock{name: strings.TrimSpace(strings.TrimSuffix(line, "{"))}
+		i++
+		if isBruTextBlock(b.name) {
+			start := i
+			for i < len(lines) && strings.TrimRight(lines[i], " \t") != "}" {
+				i++
+			}
+			b.text = dedent(lines[start:min(i, len(lines))])
+		} else {
+			var err error
+			if b.entries, err = parseBruEntries(lines, &i); err != nil {
+				return nil, err
+			}
+		}
+		if i >= len(lines) {
+			return nil, fmt.Errorf("block %s is not closed", b.name)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// parseBruEntries reads "key: value" entries up to the closing brace, leaving i on it.
+// Disabled entries, prefixed with ~, are skipped.
+func parseBruEntries(lines []string, i *int) (map[string]interface{}, error) {
+	entries := make(map[string]interface{})
+	for ; *i < len(lines); *i++ {
+		line := strings.TrimSpace(lines[*i])
+		if line == "" {
+			continue
+		}
+		if line == "}" {
+			return entries, nil
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value, got %q", *i+1, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch value {
+		case "{":
+			*i++
+			nested, err := parseBruEntries(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			if *i >= len(lines) {
+				return nil, fmt.Errorf("entry %s is not closed", key)
+			}
+			entries[key] = nested
+		case "'''":
+			start := *i + 1
+			for *i++; *i < len(lines) && strings.TrimSpace(lines[*i]) != "'''"; *i++ {
+			}
+			if *i >= len(lines) {
+				return nil, fmt.Errorf("entry %s: multiline value is not closed", key)
+			}
+			entries[key] = dedent(lines[start:*i])
+		default:
+			if !strings.HasPrefix(key, "~") {
+				entries[key] = value
+			}
+		}
+	}
+	return entries, nil
+}
+
+// dedent removes the indentation common to all non-blank lines
+func dedent(lines []string) string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		}
+		out[i] = l
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const bruGetUser = `meta {
+  name: Get user
+  type: http
+  seq: 1
+}
+
+get {
+  url: {{baseUrl}}/users/:id
+  body: none
+  auth: bearer
+}
+
+params:path {
+  id: 42
+}
+
+headers {
+  Accept: application/json
+  ~X-Debug: 1
+}
+
+docs {
+  Returns a user.
+  }
+}
+
+example {
+  name: Found
+  description: An existing user
+
+  request: {
+    url: {{baseUrl}}/users/:id
+    method: get
+  }
+
+  response: {
+    headers: {
+      Content-Type: application/json
+      X-Request-Id: abc
+    }
+
+    status: {
+      code: 200
+      text: OK
+    }
+
+    body: {
+      type: json
+      content: '''
+        {"id": 42, "name": "Ada"}
+      '''
+    }
+  }
+}
+`
+
+const bruCreateOrder = `meta {
+  name: Create order
+  type: http
+  seq: 2
+}
+
+post {
+  url: https://shop.example.com/orders
+  body: json
+  auth: none
+}
+
+body:json {
+  {
+    "item": "book"
+  }
+}
+`
+
+func TestBruno_Collection(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("bruno.json", `{"version": "1", "name": "Shop", "type": "collection"}`)
+	write("users/Get user.bru", bruGetUser)
+	write("users/folder.bru", "meta {\n  name: Users\n}\n")
+	write("Create order.bru", bruCreateOrder)
+	write("environments/Local.bru", "vars {\n  baseUrl: http://localhost:3000\n}\n")
+
+	format, err := Detect(dir)
+	if err != nil || format != FormatBruno {
+		t.Fatalf("expected bruno format, got %q, %v", format, err)
+	}
+	rules, err := Bruno(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", rules)
+	}
+
+	order := rules[0]
+	if order.Name != "Create order" || order.Method != "POST" || order.Path != "/orders" || order.Response.Body != "" {
+		t.Fatalf("unexpected rule: %+v", order)
+	}
+
+	user := rules[1]
+	if user.Name != "users/Get user" || user.Method != "GET" || user.Path != "/users/{id}" {
+		t.Fatalf("unexpected rule: %+v", user)
+	}
+	resp := user.Response
+	if resp.StatusCode != 200 || resp.Headers["Content-Type"] != "application/json" || resp.Headers["X-Request-Id"] != "abc" {
+		t.Fatalf("unexpected example response: %+v", resp)
+	}
+	if resp.Body != "{\n  \"id\": 42,\n  \"name\": \"Ada\"\n}" {
+		t.Fatalf("unexpected example body %q", resp.Body)
+	}
+}
+
+func TestBruno_Errors(t *testing.T) {
+	if _, err := Bruno(t.TempDir()); err == nil {
+		t.Fatal("expected an error for a directory without bruno.json")
+	}
+	if _, err := parseBru("get {\n  url: /x\n"); err == nil {
+		t.Fatal("expected an error for an unclosed block")
+	}
+	blocks, err := parseBru("body:json {\n  {\n    \"a\": 1\n  }\n}\n")
+	if err != nil || len(blocks) != 1 || blocks[0].text != "{\n  \"a\": 1\n}" {
+		t.Fatalf("unexpected text block: %+v, %v", blocks, err)
+	}
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// harFile is the part of an HTTP Archive, as exported by browser developer tools,
+// that describes the recorded exchanges
+type harFile struct {
+	Log *struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method   string `json:"method"`
+		URL      string `json:"url"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers []harHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HAR converts the entries of an HTTP Archive into rules the way the recorder
+// does: each rule matches the method, path, query parameters, and JSON body of the
+// recorded request and answers with the recorded response. Repeated requests keep
+// the response recorded first, and entries that never got a response, such as
+// blocked or cancelled requests, are skipped.
+func HAR(data []byte) ([]Rule, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %w", err)
+	}
+	if har.Log == nil {
+		return nil, fmt.Errorf("not a HAR file: expected a log object")
+	}
+
+	var rules []Rule
+	seen := make(map[string]bool)
+	for i, entry := range har.Log.Entries {
+		if entry.Response.Status == 0 {
+			continue
+		}
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid URL: %w", i, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue // data:, blob:, and extension URLs never reach a server
+		}
+		rule, err := harRule(entry, u)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d (%s %s): %w", i, entry.Request.Method, entry.Request.URL, err)
+		}
+
+		query := u.Query()
+		key := fmt.Sprintf("%s %s?%s %s", rule.Method, rule.Path, query.Encode(), canonicalJSON(rule.JSONBody))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rules = append(rules, rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return matchers(rules[i]) > matchers(rules[j]) })
+	return rules, nil
+}
+
+// harRule builds the rule for one archived exchange
+func harRule(entry harEntry, u *url.URL) (Rule, error) {
+	method := strings.ToUpper(entry.Request.Method)
+	if method == "" {
+		method = "GET"
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	rule := Rule{Method: method, Path: path, Response: Response{StatusCode: entry.Response.Status}}
+	for name, values := range u.Query() {
+		if rule.QueryParams == nil {
+			rule.QueryParams = make(map[string]string)
+		}
+		rule.QueryParams[name] = regexp.QuoteMeta(values[0])
+	}
+	if post := entry.Request.PostData; post != nil && json.Valid([]byte(post.Text)) {
+		_ = json.Unmarshal([]byte(post.Text), &rule.JSONBody)
+	}
+
+	for _, name := range recordedHeaders {
+		// Browsers store the decoded body, so the original encoding no longer applies
+		if name == "Content-Encoding" {
+			continue
+		}
+		for _, h := range entry.Response.Headers {
+			if http.CanonicalHeaderKey(h.Name) == name && h.Value != "" {
+				if rule.Response.Headers == nil {
+					rule.Response.Headers = make(map[string]string)
+				}
+				rule.Response.Headers[name] = h.Value
+				break
+			}
+		}
+	}
+
+	content := entry.Response.Content
+	body := []byte(content.Text)
+	if content.Encoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(content.Text); err != nil {
+			return Rule{}, fmt.Errorf("invalid base64 response body: %w", err)
+		}
+	}
+	contentType := ""
+	if len(body) > 0 && content.MimeType != "" {
+		contentType = content.MimeType
+	}
+	if utf8.Valid(body) {
+		rule.Response.setBody(string(body), contentType)
+	} else {
+		rule.Response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+		if _, ok := rule.Response.Headers["Content-Type"]; !ok && contentType != "" {
+			if rule.Response.Headers == nil {
+				rule.Response.Headers = make(map[string]string)
+			}
+			rule.Response.Headers["Content-Type"] = contentType
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+const harExport = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "WebInspector", "version": "537.36"},
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "https://shop.example.com/api/products?page=2&sort=price%2Bdesc", "headers": [], "queryString": []},
+        "response": {
+          "status": 200,
+          "headers": [
+            {"name": "content-type", "value": "application/json; charset=utf-8"},
+            {"name": "content-encoding", "value": "br"},
+            {"name": "date", "value": "Mon, 12 Oct 2026 09:00:00 GMT"}
+          ],
+          "content": {"size": 18, "mimeType": "application/json", "text": "[{\"id\":1}]"}
+        }
+      },
+      {
+        "request": {
+          "method": "POST", "url": "https://shop.example.com/api/orders",
+          "postData": {"mimeType": "application/json", "text": "{\"item\":\"book\"}"}
+        },
+        "response": {"status": 201, "headers": [{"name": "Location", "value": "/api/orders/7"}], "content": {"size": 0, "mimeType": "x-unknown"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://shop.example.com/logo.png"},
+        "response": {"status": 200, "headers": [], "content": {"mimeType": "image/png", "text": "iVBORw0KGgo=", "encoding": "base64"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://shop.example.com/api/products?page=2&sort=price%2Bdesc"},
+        "response": {"status": 200, "headers": [], "content": {"mimeType": "application/json", "text": "[]"}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://ads.example.net/track"},
+        "response": {"status": 0, "headers": [], "content": {"size": 0, "mimeType": ""}}
+      },
+      {
+        "request": {"method": "GET", "url": "data:image/gif;base64,R0lGODlhAQABAAAAACw="},
+        "response": {"status": 200, "headers": [], "content": {"mimeType": "image/gif"}}
+      }
+    ]
+  }
+}`
+
+func TestImport_HAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.har")
+	if err := os.WriteFile(path, []byte(harExport), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	format, err := Detect(path)
+	if err != nil || format != FormatHAR {
+		t.Fatalf("expected har format, got %q, %v", format, err)
+	}
+	file, err := Import(path, format, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Requests) != 3 {
+		t.Fatalf("expected 3 rules, got %+v", file.Requests)
+	}
+
+	products := file.Requests[0]
+	if products.Name != "GET /api/products" || products.QueryParams["sort"] != `price\+desc` || products.Response.Body != "[\n  {\n    \"id\": 1\n  }\n]" {
+		t.Errorf("unexpected products rule: %+v", products)
+	}
+	if got := products.Response.Headers; len(got) != 1 || got["Content-Type"] != "application/json; charset=utf-8" {
+		t.Errorf("expected only the content type to be kept, got %v", got)
+	}
+
+	order := file.Requests[1]
+	if order.Method != "POST" || order.Response.StatusCode != 201 || order.Response.Headers["Location"] != "/api/orders/7" || order.Response.Body != "" {
+		t.Errorf("unexpected order rule: %+v", order)
+	}
+	if body, ok := order.JSONBody.(map[string]interface{}); !ok || body["item"] != "book" {
+		t.Errorf("expected the JSON body matcher, got %v", order.JSONBody)
+	}
+
+	logo := file.Requests[2]
+	if logo.Response.BodyBase64 != "iVBORw0KGgo=" || logo.Response.Headers["Content-Type"] != "image/png" {
+		t.Errorf("unexpected logo rule: %+v", logo)
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := config.Parse(data, "test"); err != nil {
+		t.Fatalf("generated configuration did not load: %v\n%s", err, data)
+	}
+}
+
+func TestHAR_Errors(t *testing.T) {
+	if _, err := HAR([]byte(`{"entries": []}`)); err == nil {
+		t.Fatal("expected an error for a file without a log")
+	}
+	if _, err := HAR([]byte(`{"log": {"entries": [{"request": {"url": "https://x/"}, "response": {"status": 200, "content": {"text": "!", "encoding": "base64"}}}]}}`)); err == nil {
+		t.Fatal("expected an error for an invalid base64 body")
+	}
+}
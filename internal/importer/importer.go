@@ -0,0 +1,288 @@
+// Package importer converts requests saved in other API tools, or recorded from live
+// traffic, into request rules
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"http-mock-server/internal/config"
+)
+
+// Formats that can be imported
+const (
+	FormatInsomnia = "insomnia"
+	FormatBruno    = "bruno"
+	FormatHAR      = "har"
+	FormatWireMock = "wiremock"
+	FormatOpenAPI  = "openapi"
+)
+
+// Options tune how a collection is converted
+type Options struct {
+	RandomBodies bool // Generate OpenAPI schema bodies for every request instead of once
+}
+
+// File is a generated configuration
+type File struct {
+	Requests []Rule   `yaml:"requests"`
+	Warnings []string `yaml:"-"` // Parts of the source that the rules leave out
+}
+
+// Rule is a generated request rule. Rules imported from saved requests match on
+// method and path only, since saved requests usually carry credentials and sample
+// values that would make stricter matchers too narrow. The other matchers are set
+// for recorded traffic and WireMock mappings.
+type Rule struct {
+	Name          string            `yaml:"name"`
+	Method        string            `yaml:"method"`
+	Path          string            `yaml:"path,omitempty"`
+	PathPrefix    string            `yaml:"pathPrefix,omitempty"`
+	Headers       map[string]string `yaml:"headers,omitempty"`
+	QueryParams   map[string]string `yaml:"queryParams,omitempty"`
+	Body          string            `yaml:"body,omitempty"`
+	JSONBody      interface{}       `yaml:"jsonBody,omitempty"`
+	JSONBodyMatch *jsonBodyMatch    `yaml:"jsonBodyMatch,omitempty"`
+	When          string            `yaml:"when,omitempty"`
+	MatchMode     string            `yaml:"matchMode,omitempty"`
+	Scenario      string            `yaml:"scenario,omitempty"`
+	RequiredState string            `yaml:"requiredState,omitempty"`
+	NewState      string            `yaml:"newState,omitempty"`
+	ResponseDelay *responseDelay    `yaml:"responseDelay,omitempty"`
+	Response      Response          `yaml:"response"`
+}
+
+// Response is the response of a generated rule
+type Response struct {
+	StatusCode int               `yaml:"status-code"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	Body       string            `yaml:"body,omitempty"`
+	BodyBase64 string            `yaml:"bodyBase64,omitempty"` // Recorded bodies that aren't text
+	SchemaBody *schemaBody       `yaml:"schemaBody,omitempty"` // OpenAPI responses without an example
+	Fault      string            `yaml:"fault,omitempty"`
+	Proxy      *proxy            `yaml:"proxy,omitempty"`
+}
+
+// jsonBodyMatch, responseDelay, and proxy write the settings of the same name
+// without their zero values
+type jsonBodyMatch struct {
+	IgnoreArrayOrder bool `yaml:"ignoreArrayOrder,omitempty"`
+}
+
+type responseDelay struct {
+	Distribution string `yaml:"distribution,omitempty"`
+	Min          int    `yaml:"min,omitempty"`
+	Max          int    `yaml:"max,omitempty"`
+	P50          int    `yaml:"p50,omitempty"`
+	P90          int    `yaml:"p90,omitempty"`
+}
+
+type proxy struct {
+	Target  string            `yaml:"target"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// Detect guesses the format of path: a WireMock root or mappings directory, or a
+// JSON file of WireMock mappings, is read as WireMock; a JSON or YAML file with an
+// openapi version is an OpenAPI document, and one with a swagger version is refused
+// as unsupported; any other directory or .bru
+// file is a Bruno collection, a .har file is an HTTP Archive, and anything else is
+// treated as an Insomnia export
+func Detect(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		if !isDir(filepath.Join(path, "mappings")) && filepath.Base(path) != "mappings" {
+			return FormatBruno, nil
+		}
+		if _, err := os.Stat(filepath.Join(path, "bruno.json")); err == nil {
+			return FormatBruno, nil
+		}
+		return FormatWireMock, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bru":
+		return FormatBruno, nil
+	case ".har":
+		return FormatHAR, nil
+	case ".json":
+		if data, err := os.ReadFile(path); err == nil && isWireMock(data) {
+			return FormatWireMock, nil
+		}
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		openapi, swagger := openAPIVersions(data)
+		if swagger != "" {
+			return "", swaggerUnsupported(swagger)
+		}
+		if openapi != "" {
+			return FormatOpenAPI, nil
+		}
+	}
+	return FormatInsomnia, nil
+}
+
+// Import reads the collection at path in the given format
+func Import(path, format string, opts Options) (*File, error) {
+	var rules []Rule
+	var warnings []string
+	var err error
+	switch format {
+	case FormatInsomnia:
+		var data []byte
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		rules, err = Insomnia(data)
+	case FormatBruno:
+		rules, err = Bruno(path)
+	case FormatHAR:
+		var data []byte
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		rules, err = HAR(data)
+	case FormatWireMock:
+		rules, warnings, err = WireMock(path)
+	case FormatOpenAPI:
+		rules, warnings, err = OpenAPI(path, opts.RandomBodies)
+	default:
+		return nil, fmt.Errorf("unknown format %q, use insomnia, bruno, har, wiremock, or openapi", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no requests found in %s", path)
+	}
+	return &File{Requests: uniqueNames(rules), Warnings: warnings}, nil
+}
+
+// Marshal encodes the configuration as YAML and checks that it loads
+func (f *File) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	if _, err := config.Parse(data, "import"); err != nil {
+		return nil, fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	return data, nil
+}
+
+// newRule builds a rule for a saved request. Without a saved response the rule
+// answers 200 with an empty body, to be filled in by hand.
+func newRule(name, method, rawURL string) Rule {
+	if method == "" {
+		method = "GET"
+	}
+	return Rule{
+		Name:     name,
+		Method:   strings.ToUpper(method),
+		Path:     rulePath(rawURL),
+		Response: Response{StatusCode: 200},
+	}
+}
+
+// setBody fills in a saved response body, pretty-printing JSON
+func (r *Response) setBody(body, contentType string) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return
+	}
+	if strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[") {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, []byte(body), "", "  "); err == nil {
+			body = pretty.String()
+			if contentType == "" {
+				contentType = "application/json"
+			}
+		}
+	}
+	r.Body = body
+	if contentType != "" {
+		if r.Headers == nil {
+			r.Headers = make(map[string]string)
+		}
+		if _, ok := r.Headers["Content-Type"]; !ok {
+			r.Headers["Content-Type"] = contentType
+		}
+	}
+}
+
+// templateVar matches a {{ variable }} reference, with Insomnia's optional "_." prefix
+var templateVar = regexp.MustCompile(`\{\{\s*(?:_\.)?([^{}\s]+)\s*\}\}`)
+
+// rulePath turns a saved request URL into a rule path. The scheme, host, and any
+// leading {{baseUrl}} variable are dropped, and segments that are :name or contain
+// {{variables}} become {name} path parameters.
+func rulePath(rawURL string) string {
+	u := strings.TrimSpace(rawURL)
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	if loc := templateVar.FindStringIndex(u); loc != nil && loc[0] == 0 {
+		u = u[loc[1]:]
+	}
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+		if j := strings.Index(u, "/"); j >= 0 {
+			u = u[j:]
+		} else {
+			u = "/"
+		}
+	}
+	if !strings.HasPrefix(u, "/") {
+		u = "/" + u
+	}
+
+	segments := strings.Split(u, "/")
+	seen := make(map[string]bool)
+	for i, s := range segments {
+		var name string
+		switch {
+		case strings.HasPrefix(s, ":") && len(s) > 1:
+			name = s[1:]
+		case templateVar.MatchString(s):
+			name = templateVar.FindStringSubmatch(s)[1]
+		default:
+			continue
+		}
+		name = strings.Trim(strings.NewReplacer(".", "_", "{", "", "}", "").Replace(name), "_")
+		if name == "" || seen[name] {
+			name = fmt.Sprintf("param%d", i)
+		}
+		seen[name] = true
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/")
+}
+
+// uniqueNames numbers rules whose names would otherwise collide
+func uniqueNames(rules []Rule) []Rule {
+	count := make(map[string]int)
+	for i := range rules {
+		name := rules[i].Name
+		if name == "" {
+			name = rules[i].Method + " " + rules[i].Path
+		}
+		count[name]++
+		if n := count[name]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		rules[i].Name = name
+	}
+	return rules
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+func TestRulePath(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://api.example.com/v1/users?page=2", "/v1/users"},
+		{"https://api.example.com", "/"},
+		{"{{baseUrl}}/users/:id", "/users/{id}"},
+		{"{{ _.base_url }}/users/{{ _.user_id }}/orders", "/users/{user_id}/orders"},
+		{"http://localhost:3000/files/{{path.to.file}}#top", "/files/{path_to_file}"},
+		{"/a/:id/b/:id", "/a/{id}/b/{param4}"},
+		{"users", "/users"},
+	}
+	for _, tt := range tests {
+		if got := rulePath(tt.url); got != tt.want {
+			t.Errorf("rulePath(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestImport_Insomnia(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	export := `{
+  "_type": "export",
+  "__export_format": 4,
+  "resources": [
+    {"_id": "wrk_1", "_type": "workspace", "name": "Shop"},
+    {"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "Users"},
+    {"_id": "req_1", "_type": "request", "parentId": "fld_1", "name": "Get user", "method": "GET", "url": "{{ _.base_url }}/users/{{ _.id }}"},
+    {"_id": "req_2", "_type": "request", "parentId": "wrk_1", "name": "Ping", "method": "get", "url": "https://shop.example.com/ping"},
+    {"_id": "req_3", "_type": "request", "parentId": "wrk_1", "name": "Ping", "method": "HEAD", "url": "https://shop.example.com/ping"},
+    {"_id": "env_1", "_type": "environment", "name": "Base"}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(export), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := Detect(path)
+	if err != nil || format != FormatInsomnia {
+		t.Fatalf("expected insomnia format, got %q, %v", format, err)
+	}
+	file, err := Import(path, format, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Rule{
+		{Name: "Users/Get user", Method: "GET", Path: "/users/{id}", Response: Response{StatusCode: 200}},
+		{Name: "Ping", Method: "GET", Path: "/ping", Response: Response{StatusCode: 200}},
+		{Name: "Ping (2)", Method: "HEAD", Path: "/ping", Response: Response{StatusCode: 200}},
+	}
+	if len(file.Requests) != len(want) {
+		t.Fatalf("expected %d rules, got %+v", len(want), file.Requests)
+	}
+	for i, rule := range file.Requests {
+		if rule.Name != want[i].Name || rule.Method != want[i].Method || rule.Path != want[i].Path || rule.Response.StatusCode != 200 {
+			t.Errorf("rule %d: got %+v, want %+v", i, rule, want[i])
+		}
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := config.Parse(data, "test")
+	if err != nil || len(cfg.Requests) != 3 || cfg.Requests[0].PathPattern == nil {
+		t.Fatalf("generated configuration did not load: %v\n%s", err, data)
+	}
+}
+
+func TestInsomnia_V5(t *testing.T) {
+	rules, err := Insomnia([]byte(`
+type: collection.insomnia.rest/5.0
+name: Shop
+collection:
+  - name: Orders
+    children:
+      - name: Create order
+        method: POST
+        url: "{{ _.base_url }}/orders"
+      - name: Archive
+        children:
+          - name: List archived
+            url: "{{ _.base_url }}/orders/archived"
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Name != "Orders/Create order" || rules[0].Method != "POST" ||
+		rules[1].Name != "Orders/Archive/List archived" || rules[1].Method != "GET" || rules[1].Path != "/orders/archived" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	if _, err := Insomnia([]byte(`{"info": {"name": "postman"}}`)); err == nil || !strings.Contains(err.Error(), "not an Insomnia export") {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// insomniaExport covers the v4 export format (a flat list of resources, usually
+// JSON) and the v5 format (a nested collection, usually YAML)
+type insomniaExport struct {
+	Type       string             `yaml:"_type"`      // "export" in v4
+	V5Type     string             `yaml:"type"`       // "collection.insomnia.rest/5.0" in v5
+	Resources  []insomniaResource `yaml:"resources"`  // v4
+	Collection []insomniaItem     `yaml:"collection"` // v5
+}
+
+type insomniaResource struct {
+	ID       string `yaml:"_id"`
+	Type     string `yaml:"_type"`
+	ParentID string `yaml:"parentId"`
+	Name     string `yaml:"name"`
+	Method   string `yaml:"method"`
+	URL      string `yaml:"url"`
+}
+
+type insomniaItem struct {
+	Name     string         `yaml:"name"`
+	Method   string         `yaml:"method"`
+	URL      string         `yaml:"url"`
+	Children []insomniaItem `yaml:"children"` // Set on folders
+}
+
+// Insomnia converts the requests in an Insomnia export into rules named after
+// their folder and request names. Exports don't include responses, so every rule
+// answers 200 with an empty body.
+func Insomnia(data []byte) ([]Rule, error) {
+	var export insomniaExport
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Insomnia export: %w", err)
+	}
+	switch {
+	case export.Type == "export":
+		return insomniaV4(export.Resources), nil
+	case strings.HasPrefix(export.V5Type, "collection.insomnia.rest/"):
+		var rules []Rule
+		insomniaV5(export.Collection, "", &rules)
+		return rules, nil
+	default:
+		return nil, fmt.Errorf("not an Insomnia export: expected _type export or a collection.insomnia.rest type")
+	}
+}
+
+func insomniaV4(resources []insomniaResource) []Rule {
+	folders := make(map[string]insomniaResource)
+	for _, r := range resources {
+		if r.Type == "request_group" {
+			folders[r.ID] = r
+		}
+	}
+	var rules []Rule
+	for _, r := range resources {
+		if r.Type != "request" {
+			continue
+		}
+		name := r.Name
+		for parent, ok := folders[r.ParentID]; ok; parent, ok = folders[parent.ParentID] {
+			name = parent.Name + "/" + name
+		}
+		rules = append(rules, newRule(name, r.Method, r.URL))
+	}
+	return rules
+}
+
+func insomniaV5(items []insomniaItem, folder string, rules *[]Rule) {
+	for _, item := range items {
+		name := folder + item.Name
+		if item.Children != nil || item.URL == "" {
+			insomniaV5(item.Children, name+"/", rules)
+			continue
+		}
+		*rules = append(*rules, newRule(name, item.Method, item.URL))
+	}
+}
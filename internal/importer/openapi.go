@@ -0,0 +1,359 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods are the operations of a path item, in the order rules are written
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDoc is the part of an OpenAPI 3 document that describes the responses
+type openAPIDoc struct {
+	OpenAPI    string                          `yaml:"openapi"`
+	Swagger    string                          `yaml:"swagger"`
+	Servers    []openAPIServer                 `yaml:"servers"`
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Responses map[string]openAPIResponse `yaml:"responses"`
+		Examples  map[string]openAPIExample  `yaml:"examples"`
+	} `yaml:"components"`
+}
+
+type openAPIServer struct {
+	URL       string `yaml:"url"`
+	Variables map[string]struct {
+		Default string `yaml:"default"`
+	} `yaml:"variables"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `yaml:"operationId"`
+	Summary     string                     `yaml:"summary"`
+	Responses   map[string]openAPIResponse `yaml:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string                  `yaml:"$ref"`
+	Content map[string]openAPIMedia `yaml:"content"`
+}
+
+type openAPIMedia struct {
+	Schema   yaml.Node                 `yaml:"schema"`
+	Example  yaml.Node                 `yaml:"example"`
+	Examples map[string]openAPIExample `yaml:"examples"`
+}
+
+type openAPIExample struct {
+	Ref   string    `yaml:"$ref"`
+	Value yaml.Node `yaml:"value"`
+}
+
+// openAPIVersions returns the openapi and swagger versions a JSON or YAML file
+// declares, which are empty for other files
+func openAPIVersions(data []byte) (openapi, swagger string) {
+	var top struct {
+		OpenAPI string `yaml:"openapi"`
+		Swagger string `yaml:"swagger"`
+	}
+	if yaml.Unmarshal(data, &top) != nil {
+		return "", ""
+	}
+	return top.OpenAPI, top.Swagger
+}
+
+// swaggerUnsupported is the error for a Swagger document
+func swaggerUnsupported(version string) error {
+	return fmt.Errorf("swagger %s documents are not supported, convert them to OpenAPI 3 first", version)
+}
+
+// OpenAPI converts the operations of an OpenAPI 3 document into rules. Each rule
+// answers with the operation's success response: its example when the spec has one,
+// and otherwise a schemaBody that generates the body from the response schema, for
+// every request when random is set. Schema bodies refer back to the document at
+// path, so the configuration needs the spec next to it. Operations whose response
+// has neither an example nor a JSON schema answer with an empty body and are
+// reported as warnings.
+func OpenAPI(path string, random bool) ([]Rule, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if doc.Swagger != "" {
+		return nil, nil, swaggerUnsupported(doc.Swagger)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, nil, fmt.Errorf("not an OpenAPI 3 document")
+	}
+
+	c := &openAPIConverter{doc: &doc, path: path, random: random}
+	c.basePath = c.serverPath()
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var rules []Rule
+	for _, p := range paths {
+		item := doc.Paths[p]
+		for _, method := range openAPIMethods {
+			node, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return nil, nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), p, err)
+			}
+			rule, err := c.rule(p, method, &op)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), p, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	// Literal segments must win over parameters, as in /users/me and /users/{id}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return strings.Count(rules[i].Path, "{") < strings.Count(rules[j].Path, "{")
+	})
+	return rules, c.warnings, nil
+}
+
+// openAPIConverter builds rules for the operations of one document
+type openAPIConverter struct {
+	doc      *openAPIDoc
+	path     string
+	basePath string // Path of the first server URL, which prefixes every rule path
+	random   bool
+	warnings []string
+}
+
+// serverPath returns the path of the document's first server URL without a
+// trailing slash, with server variables replaced by their defaults. Servers whose
+// paths differ from the first are reported as warnings.
+func (c *openAPIConverter) serverPath() string {
+	var base string
+	for i, server := range c.doc.Servers {
+		raw := server.URL
+		for name, v := range server.Variables {
+			raw = strings.ReplaceAll(raw, "{"+name+"}", v.Default)
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			c.warnings = append(c.warnings, fmt.Sprintf("server %q: %v", server.URL, err))
+			continue
+		}
+		path := strings.TrimSuffix(u.Path, "/")
+		if i == 0 {
+			base = path
+		} else if path != base {
+			c.warnings = append(c.warnings, fmt.Sprintf("server %q: path %q is not mocked, rules use %q from the first server", server.URL, path, base))
+		}
+	}
+	return base
+}
+
+// rule builds the rule for one operation
+func (c *openAPIConverter) rule(path, method string, op *openAPIOperation) (Rule, error) {
+	rule := Rule{Name: op.OperationID, Method: strings.ToUpper(method), Path: c.basePath + c.rulePath(path)}
+	if rule.Name == "" {
+		rule.Name = op.Summary
+	}
+	describe := rule.Method + " " + path
+
+	code, status := openAPIStatus(op.Responses)
+	rule.Response.StatusCode = status
+	if code == "" {
+		return rule, nil
+	}
+	response := op.Responses[code]
+	pointer := "/paths/" + escapePointer(path) + "/" + method + "/responses/" + escapePointer(code)
+	if response.Ref != "" {
+		name, ok := strings.CutPrefix(response.Ref, "#/components/responses/")
+		shared, found := c.doc.Components.Responses[name]
+		if !ok || !found {
+			return Rule{}, fmt.Errorf("response $ref %q not found", response.Ref)
+		}
+		response = shared
+		pointer = "/components/responses/" + escapePointer(name)
+	}
+
+	mediaType := openAPIMediaType(response.Content)
+	if mediaType == "" {
+		return rule, nil
+	}
+	media := response.Content[mediaType]
+	example, err := c.example(media)
+	if err != nil {
+		return Rule{}, err
+	}
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	switch {
+	case example != nil:
+		var body string
+		if s, ok := example.(string); ok && !isJSON {
+			body = s
+		} else {
+			data, err := json.Marshal(example)
+			if err != nil {
+				return Rule{}, fmt.Errorf("example for %s: %w", mediaType, err)
+			}
+			body = string(data)
+		}
+		rule.Response.setBody(body, mediaType)
+	case !media.Schema.IsZero() && isJSON:
+		rule.Response.SchemaBody = &schemaBody{
+			Schema: c.path + "#" + pointer + "/content/" + escapePointer(mediaType) + "/schema",
+			Random: c.random,
+		}
+		if mediaType != "application/json" {
+			rule.Response.Headers = map[string]string{"Content-Type": mediaType}
+		}
+	default:
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: %s response has no example or JSON schema, the rule answers with an empty body", describe, mediaType))
+	}
+	return rule, nil
+}
+
+// rulePath keeps OpenAPI path templates, which use the same {name} syntax as rules.
+// A parameter sharing a segment with other text, such as {name}.json, can't be
+// expressed, so the rule takes the whole segment as the parameter.
+func (c *openAPIConverter) rulePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		start, end := strings.Index(s, "{"), strings.Index(s, "}")
+		if start < 0 || end < start || (start == 0 && end == len(s)-1) {
+			continue
+		}
+		segments[i] = s[start : end+1]
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: segment %q matches any value of %s", path, s, segments[i]))
+	}
+	return strings.Join(segments, "/")
+}
+
+// example returns the example value of a media type: the media type's example, or
+// the first of its named examples
+func (c *openAPIConverter) example(media openAPIMedia) (interface{}, error) {
+	node := media.Example
+	if node.IsZero() && len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		example := media.Examples[names[0]]
+		if example.Ref != "" {
+			name, ok := strings.CutPrefix(example.Ref, "#/components/examples/")
+			shared, found := c.doc.Components.Examples[name]
+			if !ok || !found {
+				return nil, fmt.Errorf("example $ref %q not found", example.Ref)
+			}
+			example = shared
+		}
+		node = example.Value
+	}
+	if node.IsZero() {
+		return nil, nil
+	}
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// openAPIStatus picks the response a mock should give: the lowest 2xx, then the
+// default response, then the lowest status declared. It returns the key of the
+// response and the status code to answer with.
+func openAPIStatus(responses map[string]openAPIResponse) (string, int) {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if status := openAPIStatusCode(code); status >= 200 && status < 300 {
+			return code, status
+		}
+	}
+	if _, ok := responses["default"]; ok {
+		return "default", 200
+	}
+	for _, code := range codes {
+		if status := openAPIStatusCode(code); status != 0 {
+			return code, status
+		}
+	}
+	return "", 200
+}
+
+// openAPIStatusCode returns the status of a response key such as 404, or the first
+// status of a range such as 2XX, and 0 for any other key
+func openAPIStatusCode(code string) int {
+	if len(code) != 3 {
+		return 0
+	}
+	if strings.EqualFold(code[1:], "XX") {
+		code = code[:1] + "00"
+	}
+	status, err := strconv.Atoi(code)
+	if err != nil || status < 100 || status > 599 {
+		return 0
+	}
+	return status
+}
+
+// openAPIMediaType picks the media type to answer with, preferring JSON
+func openAPIMediaType(content map[string]openAPIMedia) string {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if t == "application/json" {
+			return t
+		}
+	}
+	for _, t := range types {
+		if strings.HasSuffix(t, "+json") {
+			return t
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// escapePointer escapes a JSON pointer token
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// schemaBody writes a rule's schemaBody, as the schema alone unless it is random
+type schemaBody struct {
+	Schema string `yaml:"schema"`
+	Random bool   `yaml:"random"`
+}
+
+// MarshalYAML writes the short form when the body isn't random
+func (b schemaBody) MarshalYAML() (interface{}, error) {
+	if !b.Random {
+		return b.Schema, nil
+	}
+	type plain schemaBody
+	return plain(b), nil
+}
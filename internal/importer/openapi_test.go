@@ -0,0 +1,206 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+const openAPISpec = `openapi: 3.0.3
+info: {title: Users, version: "1"}
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      responses:
+        "404": {description: Not found}
+        200:
+          description: The user
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+  /users/me:
+    get:
+      summary: Current user
+      responses:
+        "200":
+          description: The signed-in user
+          content:
+            application/json:
+              example: {id: 1, name: Ada}
+  /users:
+    post:
+      operationId: createUser
+      responses:
+        "201": {$ref: "#/components/responses/Created"}
+  /files/{name}.txt:
+    get:
+      responses:
+        "200":
+          description: A file
+          content:
+            text/plain: {}
+  /health:
+    get:
+      responses:
+        default:
+          description: Health
+          content:
+            text/plain:
+              example: ok
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, email]
+      properties:
+        id: {type: integer, minimum: 1}
+        email: {type: string, format: email}
+  responses:
+    Created:
+      description: Created
+      content:
+        application/json:
+          examples:
+            ada: {$ref: "#/components/examples/Ada"}
+  examples:
+    Ada:
+      value: {id: 2, name: Ada}
+`
+
+func TestImport_OpenAPI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(openAPISpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if format, err := Detect(path); err != nil || format != FormatOpenAPI {
+		t.Fatalf("expected openapi format, got %q, %v", format, err)
+	}
+	file, err := Import(path, FormatOpenAPI, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := make([]string, len(file.Requests))
+	for i, rule := range file.Requests {
+		names[i] = rule.Method + " " + rule.Path
+	}
+	// Rules without path parameters come first, so /users/me wins over /users/{id}
+	want := "GET /health, POST /users, GET /users/me, GET /files/{name}, GET /users/{id}"
+	if got := strings.Join(names, ", "); got != want {
+		t.Fatalf("expected rules %s, got %s", want, got)
+	}
+
+	health, create, me, user := file.Requests[0], file.Requests[1], file.Requests[2], file.Requests[4]
+	if health.Response.StatusCode != 200 || health.Response.Body != "ok" || health.Response.Headers["Content-Type"] != "text/plain" {
+		t.Errorf("unexpected health response: %+v", health.Response)
+	}
+	if me.Name != "Current user" || !strings.Contains(me.Response.Body, `"name": "Ada"`) {
+		t.Errorf("unexpected me rule: %+v", me)
+	}
+	if create.Name != "createUser" || create.Response.StatusCode != 201 || !strings.Contains(create.Response.Body, `"id": 2`) {
+		t.Errorf("unexpected create rule: %+v", create)
+	}
+	if user.Name != "getUser" || user.Response.StatusCode != 200 || user.Response.SchemaBody == nil {
+		t.Fatalf("expected getUser to generate its body, got %+v", user)
+	}
+	if want := path + "#/paths/~1users~1{id}/get/responses/200/content/application~1json/schema"; user.Response.SchemaBody.Schema != want {
+		t.Errorf("expected schema %s, got %s", want, user.Response.SchemaBody.Schema)
+	}
+
+	warnings := strings.Join(file.Warnings, "\n")
+	for _, want := range []string{`segment "{name}.txt"`, "GET /files/{name}.txt: text/plain response has no example"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("expected a warning about %s, got:\n%s", want, warnings)
+		}
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := config.Parse(data, "test")
+	if err != nil {
+		t.Fatalf("generated configuration did not load: %v\n%s", err, data)
+	}
+	var generated map[string]interface{}
+	if err := json.Unmarshal(cfg.Requests[4].Response.BodyBytes, &generated); err != nil {
+		t.Fatalf("expected a generated JSON body, got %q: %v", cfg.Requests[4].Response.BodyBytes, err)
+	}
+	if id, ok := generated["id"].(float64); !ok || id < 1 || !strings.Contains(generated["email"].(string), "@") {
+		t.Errorf("expected the generated user to follow the schema, got %v", generated)
+	}
+}
+
+func TestImport_OpenAPIRandomBodies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(openAPISpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Import(path, FormatOpenAPI, Options{RandomBodies: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), "random: true") {
+		t.Fatalf("expected a random schemaBody, got:\n%s", data)
+	}
+}
+
+func TestOpenAPI_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "spec.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	swagger := write("swagger: \"2.0\"\npaths: {}\n")
+	if _, _, err := OpenAPI(swagger, false); err == nil || !strings.Contains(err.Error(), "OpenAPI 3") {
+		t.Fatalf("expected a Swagger 2.0 error, got %v", err)
+	}
+	if format, err := Detect(swagger); err == nil || !strings.Contains(err.Error(), "swagger 2.0 documents are not supported") {
+		t.Fatalf("expected detection to refuse Swagger 2.0, got %q, %v", format, err)
+	}
+	spec := "openapi: 3.1.0\npaths:\n  /x:\n    get:\n      responses:\n        \"200\": {$ref: \"#/components/responses/Missing\"}\n"
+	if _, _, err := OpenAPI(write(spec), false); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a missing $ref error, got %v", err)
+	}
+}
+
+func TestOpenAPI_ServerBasePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	spec := `openapi: 3.0.3
+servers:
+  - url: https://api.example.com/{version}/
+    variables:
+      version: {default: v2}
+  - url: /legacy
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "204": {description: No content}
+`
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, warnings, err := OpenAPI(path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Path != "/v2/users/{id}" {
+		t.Fatalf("expected the server path to prefix the rule, got %+v", rules)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `path "/legacy" is not mocked`) {
+		t.Fatalf("expected a warning about the second server, got %v", warnings)
+	}
+}
@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// recordedHeaders are the response headers kept in recorded rules. Others, such as
+// Date or Set-Cookie, describe the recorded moment rather than the endpoint.
+var recordedHeaders = []string{
+	"Cache-Control", "Content-Encoding", "Content-Type", "ETag", "Link", "Location", "Retry-After", "WWW-Authenticate",
+}
+
+// exchangeKey carries the request side of an exchange from ServeHTTP to the response hook
+type exchangeKey struct{}
+
+type exchange struct {
+	method string
+	path   string
+	query  url.Values
+	body   []byte
+}
+
+// Recorder proxies requests to a target and records each exchange as a rule that
+// matches the method, path, query parameters, and JSON body of the request.
+// Repeated requests keep the response recorded first.
+type Recorder struct {
+	proxy *httputil.ReverseProxy
+	mu    sync.Mutex
+	rules []Rule
+	seen  map[string]bool
+}
+
+// NewRecorder returns a recorder forwarding to target
+func NewRecorder(target *url.URL) *Recorder {
+	rec := &Recorder{seen: make(map[string]bool)}
+	rec.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			// Let the transport negotiate compression so bodies are recorded decoded
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: rec.record,
+	}
+	return rec
+}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ex := &exchange{method: r.Method, path: r.URL.Path, query: r.URL.Query()}
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ex.body = body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	rec.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), exchangeKey{}, ex)))
+}
+
+// record turns the upstream response and the request that caused it into a rule
+func (rec *Recorder) record(resp *http.Response) error {
+	ex, _ := resp.Request.Context().Value(exchangeKey{}).(*exchange)
+	if ex == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rule := Rule{Method: ex.method, Path: ex.path, Response: Response{StatusCode: resp.StatusCode}}
+	for name, values := range ex.query {
+		if rule.QueryParams == nil {
+			rule.QueryParams = make(map[string]string)
+		}
+		rule.QueryParams[name] = regexp.QuoteMeta(values[0])
+	}
+	if len(ex.body) > 0 && json.Valid(ex.body) {
+		_ = json.Unmarshal(ex.body, &rule.JSONBody)
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if rule.Response.Headers == nil {
+				rule.Response.Headers = make(map[string]string)
+			}
+			rule.Response.Headers[name] = value
+		}
+	}
+	if utf8.Valid(body) {
+		rule.Response.setBody(string(body), "")
+	} else {
+		rule.Response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+
+	key := fmt.Sprintf("%s %s?%s %s", ex.method, ex.path, ex.query.Encode(), canonicalJSON(rule.JSONBody))
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.seen[key] {
+		return nil
+	}
+	rec.seen[key] = true
+	rec.rules = append(rec.rules, rule)
+	log.Printf("Recorded %s %s -> %d", ex.method, ex.path, resp.StatusCode)
+	return nil
+}
+
+// File returns the recorded rules. Rules with more matchers come first, so a
+// request with query parameters or a body isn't answered by a rule for the same
+// path without them.
+func (rec *Recorder) File() (*File, error) {
+	rec.mu.Lock()
+	rules := append([]Rule(nil), rec.rules...)
+	rec.mu.Unlock()
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no requests recorded")
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return matchers(rules[i]) > matchers(rules[j]) })
+	return &File{Requests: uniqueNames(rules)}, nil
+}
+
+func matchers(r Rule) int {
+	n := len(r.QueryParams)
+	if r.JSONBody != nil {
+		n++
+	}
+	return n
+}
+
+// canonicalJSON encodes a decoded JSON value with sorted keys, for comparing bodies
+func canonicalJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
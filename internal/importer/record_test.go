@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+	"http-mock-server/internal/handler"
+)
+
+func TestRecorder_ReplaysRecordedExchanges(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		switch {
+		case r.URL.Path == "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+		case r.Method == http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/orders/7")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id":7,"call":%d}`, calls)
+		case r.URL.Query().Get("page") == "2":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"orders":[],"page":2}`)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"orders":[{"id":1}]}`)
+		}
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+	rec := NewRecorder(target)
+
+	exchanges := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/orders", ""},
+		{http.MethodGet, "/orders?page=2", ""},
+		{http.MethodPost, "/orders", `{"item": "book", "qty": 1}`},
+		{http.MethodPost, "/orders", `{"qty":1,"item":"book"}`},
+		{http.MethodGet, "/logo.png", ""},
+	}
+	for _, ex := range exchanges {
+		rr := httptest.NewRecorder()
+		rec.ServeHTTP(rr, httptest.NewRequest(ex.method, ex.path, strings.NewReader(ex.body)))
+		if rr.Code >= 300 {
+			t.Fatalf("%s %s: proxied status %d", ex.method, ex.path, rr.Code)
+		}
+	}
+
+	file, err := rec.File()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Requests) != 4 {
+		t.Fatalf("expected 4 rules with the repeated POST recorded once, got %+v", file.Requests)
+	}
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "Date") {
+		t.Fatalf("expected volatile headers to be dropped, got\n%s", data)
+	}
+	cfg, err := config.Parse(data, "recorded")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := handler.NewMockHandler(cfg)
+
+	replays := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{http.MethodGet, "/orders", "", http.StatusOK, `"id": 1`},
+		{http.MethodGet, "/orders?page=2", "", http.StatusOK, `"page": 2`},
+		{http.MethodPost, "/orders", `{"qty": 1, "item": "book"}`, http.StatusCreated, `"call": 3`},
+		{http.MethodPost, "/orders", `{"item": "pen"}`, http.StatusNotFound, ""},
+		{http.MethodGet, "/logo.png", "", http.StatusOK, "\x89PNG\xff"},
+	}
+	for _, tt := range replays {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rr.Code != tt.status || !bytes.Contains(rr.Body.Bytes(), []byte(tt.want)) {
+			t.Fatalf("replay %s %s: expected %d containing %q, got %d %q", tt.method, tt.path, tt.status, tt.want, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,585 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// wiremockDefaultPriority is the priority of mappings that don't set one
+const wiremockDefaultPriority = 5
+
+// wiremockFaults maps WireMock faults to the faults a response can inject
+var wiremockFaults = map[string]string{
+	"CONNECTION_RESET_BY_PEER": "connectionReset",
+	"EMPTY_RESPONSE":           "emptyResponse",
+	"MALFORMED_RESPONSE_CHUNK": "malformedChunk",
+	"RANDOM_DATA_THEN_CLOSE":   "randomGarbage",
+}
+
+// wiremockMapping is a WireMock stub mapping, as stored under mappings/ or returned
+// by the admin API
+type wiremockMapping struct {
+	Name                  string           `json:"name"`
+	Priority              int              `json:"priority"`
+	Request               wiremockRequest  `json:"request"`
+	Response              wiremockResponse `json:"response"`
+	ScenarioName          string           `json:"scenarioName"`
+	RequiredScenarioState string           `json:"requiredScenarioState"`
+	NewScenarioState      string           `json:"newScenarioState"`
+	PostServeActions      json.RawMessage  `json:"postServeActions"`
+	ServeEventListeners   json.RawMessage  `json:"serveEventListeners"`
+}
+
+type wiremockRequest struct {
+	Method               string                     `json:"method"`
+	URL                  string                     `json:"url"`
+	URLPath              string                     `json:"urlPath"`
+	URLPattern           string                     `json:"urlPattern"`
+	URLPathPattern       string                     `json:"urlPathPattern"`
+	URLPathTemplate      string                     `json:"urlPathTemplate"`
+	PathParameters       map[string]json.RawMessage `json:"pathParameters"`
+	QueryParameters      map[string]wiremockMatcher `json:"queryParameters"`
+	Headers              map[string]wiremockMatcher `json:"headers"`
+	Cookies              map[string]json.RawMessage `json:"cookies"`
+	BasicAuthCredentials *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"basicAuthCredentials"`
+	BodyPatterns []wiremockMatcher `json:"bodyPatterns"`
+}
+
+// wiremockMatcher is a WireMock value matcher such as {"equalTo": "x"}, with its
+// operator and modifiers side by side
+type wiremockMatcher map[string]interface{}
+
+type wiremockResponse struct {
+	Status                        int                    `json:"status"`
+	Headers                       map[string]interface{} `json:"headers"`
+	Body                          string                 `json:"body"`
+	JSONBody                      interface{}            `json:"jsonBody"`
+	Base64Body                    string                 `json:"base64Body"`
+	BodyFileName                  string                 `json:"bodyFileName"`
+	FixedDelayMilliseconds        int                    `json:"fixedDelayMilliseconds"`
+	DelayDistribution             *wiremockDelay         `json:"delayDistribution"`
+	Fault                         string                 `json:"fault"`
+	ProxyBaseURL                  string                 `json:"proxyBaseUrl"`
+	AdditionalProxyRequestHeaders map[string]string      `json:"additionalProxyRequestHeaders"`
+	Transformers                  []string               `json:"transformers"`
+	ChunkedDribbleDelay           json.RawMessage        `json:"chunkedDribbleDelay"`
+}
+
+type wiremockDelay struct {
+	Type   string  `json:"type"`
+	Median int     `json:"median"`
+	Sigma  float64 `json:"sigma"`
+	Lower  int     `json:"lower"`
+	Upper  int     `json:"upper"`
+}
+
+// isWireMock reports whether a JSON file holds WireMock mappings: either a single
+// mapping or a {"mappings": [...]} list
+func isWireMock(data []byte) bool {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(data, &top) != nil {
+		return false
+	}
+	if _, ok := top["mappings"]; ok {
+		return true
+	}
+	_, hasRequest := top["request"]
+	_, hasResponse := top["response"]
+	return hasRequest && hasResponse
+}
+
+// WireMock converts WireMock stub mappings into rules. path is a WireMock root
+// directory holding mappings/ and __files/, a mappings directory, or a single
+// mapping file. Rules are ordered by mapping priority, then by file name. Matchers
+// and features without an equivalent are left out of the rules and reported as
+// warnings, since they make a rule match more requests than its mapping did.
+func WireMock(path string) ([]Rule, []string, error) {
+	root := path
+	mappingsDir := filepath.Join(path, "mappings")
+	if info, err := os.Stat(path); err != nil {
+		return nil, nil, err
+	} else if !info.IsDir() {
+		mappingsDir = path
+		root = filepath.Dir(filepath.Dir(path))
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), "__files")); err == nil {
+			root = filepath.Dir(path)
+		}
+	} else if !isDir(mappingsDir) {
+		mappingsDir = path
+		root = filepath.Dir(path)
+	}
+	filesDir := filepath.Join(root, "__files")
+
+	type entry struct {
+		mapping wiremockMapping
+		source  string
+	}
+	var entries []entry
+	err := filepath.WalkDir(mappingsDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(file), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		mappings, err := parseWireMock(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, m := range mappings {
+			entries = append(entries, entry{mapping: m, source: file})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return wiremockPriority(entries[i].mapping) < wiremockPriority(entries[j].mapping)
+	})
+
+	var rules []Rule
+	var warnings []string
+	for _, e := range entries {
+		c := &wiremockConverter{filesDir: filesDir}
+		rule, err := c.convert(e.mapping)
+		prefix := fmt.Sprintf("%s: %s", e.source, describeWireMock(e.mapping))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+		for _, w := range c.warnings {
+			warnings = append(warnings, prefix+": "+w)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, warnings, nil
+}
+
+// parseWireMock reads the mappings of one file
+func parseWireMock(data []byte) ([]wiremockMapping, error) {
+	if !isWireMock(data) {
+		return nil, fmt.Errorf("not a WireMock mapping: expected request and response, or mappings")
+	}
+	var list struct {
+		Mappings []wiremockMapping `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid WireMock mapping: %w", err)
+	}
+	if list.Mappings != nil {
+		return list.Mappings, nil
+	}
+	var m wiremockMapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid WireMock mapping: %w", err)
+	}
+	return []wiremockMapping{m}, nil
+}
+
+func wiremockPriority(m wiremockMapping) int {
+	if m.Priority == 0 {
+		return wiremockDefaultPriority
+	}
+	return m.Priority
+}
+
+// describeWireMock names a mapping in rules, warnings, and errors
+func describeWireMock(m wiremockMapping) string {
+	if m.Name != "" {
+		return m.Name
+	}
+	req := m.Request
+	for _, u := range []string{req.URL, req.URLPath, req.URLPathTemplate, req.URLPattern, req.URLPathPattern} {
+		if u != "" {
+			return strings.TrimSpace(req.Method + " " + u)
+		}
+	}
+	return strings.TrimSpace(req.Method + " any URL")
+}
+
+// wiremockConverter builds the rule of one mapping. Matchers that the rule's matcher
+// maps can't express become conditions joined into its when expression.
+type wiremockConverter struct {
+	filesDir   string
+	rule       Rule
+	conditions []string
+	warnings   []string
+}
+
+func (c *wiremockConverter) warnf(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+func (c *wiremockConverter) convert(m wiremockMapping) (Rule, error) {
+	c.rule = Rule{
+		Name:          describeWireMock(m),
+		Method:        strings.ToUpper(m.Request.Method),
+		Scenario:      m.ScenarioName,
+		RequiredState: m.RequiredScenarioState,
+		NewState:      m.NewScenarioState,
+	}
+	if c.rule.Method == "" || c.rule.Method == "ANY" {
+		c.rule.Method = "*"
+	}
+	if err := c.convertURL(m.Request); err != nil {
+		return Rule{}, err
+	}
+	if err := c.convertRequest(m.Request); err != nil {
+		return Rule{}, err
+	}
+	if err := c.convertResponse(m.Response); err != nil {
+		return Rule{}, err
+	}
+	if len(m.PostServeActions) > 0 && string(m.PostServeActions) != "null" || len(m.ServeEventListeners) > 0 && string(m.ServeEventListeners) != "null" {
+		c.warnf("post-serve actions are not converted; add a webhook by hand")
+	}
+	if len(c.conditions) > 0 {
+		c.rule.When = strings.Join(c.conditions, " && ")
+	}
+	return c.rule, nil
+}
+
+// convertURL sets the path matcher. Regex URL patterns that are a literal path, or a
+// literal prefix followed by .*, become path and pathPrefix; others become a
+// condition on the path.
+func (c *wiremockConverter) convertURL(req wiremockRequest) error {
+	switch {
+	case req.URL != "":
+		path, rawQuery, _ := strings.Cut(req.URL, "?")
+		c.rule.Path = path
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return fmt.Errorf("url %q: %w", req.URL, err)
+		}
+		for name, values := range query {
+			c.setQueryParam(name, regexp.QuoteMeta(values[0]))
+		}
+	case req.URLPath != "":
+		c.rule.Path = req.URLPath
+	case req.URLPathTemplate != "":
+		// WireMock path templates use the same {name} segments as rule paths
+		c.rule.Path = req.URLPathTemplate
+		if len(req.PathParameters) > 0 {
+			c.warnf("pathParameters matchers are not converted")
+		}
+	case req.URLPattern != "" || req.URLPathPattern != "":
+		pattern := req.URLPathPattern
+		if pattern == "" {
+			pattern = req.URLPattern
+			if strings.Contains(pattern, `\?`) {
+				c.warnf("urlPattern %q is matched against the path only, without the query string", pattern)
+			}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+		if prefix, complete := re.LiteralPrefix(); complete && strings.HasPrefix(prefix, "/") {
+			c.rule.Path = prefix
+			return nil
+		}
+		if trimmed := strings.TrimSuffix(pattern, ".*"); trimmed != pattern {
+			if re, err := regexp.Compile(trimmed); err == nil {
+				if prefix, complete := re.LiteralPrefix(); complete && strings.HasPrefix(prefix, "/") {
+					c.rule.PathPrefix = prefix
+					return nil
+				}
+			}
+		}
+		c.rule.PathPrefix = "/"
+		c.conditions = append(c.conditions, fmt.Sprintf("path.matches(%s)", exprString("^(?:"+pattern+")$")))
+	default:
+		c.rule.PathPrefix = "/"
+	}
+	return nil
+}
+
+// convertRequest converts the query, header, and body matchers
+func (c *wiremockConverter) convertRequest(req wiremockRequest) error {
+	for _, name := range sortedKeys(req.QueryParameters) {
+		value := fmt.Sprintf("query(%s)", exprString(name))
+		present := fmt.Sprintf("%s in query", exprString(name))
+		pattern, err := c.stringMatch(req.QueryParameters[name], value, present)
+		if err != nil {
+			return fmt.Errorf("query parameter %s: %w", name, err)
+		}
+		if pattern != "" {
+			c.setQueryParam(name, pattern)
+		}
+	}
+	for _, name := range sortedKeys(req.Headers) {
+		value := fmt.Sprintf("header(%s)", exprString(name))
+		present := fmt.Sprintf("%s in headers", exprString(strings.ToLower(name)))
+		pattern, err := c.stringMatch(req.Headers[name], value, present)
+		if err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+		if pattern != "" {
+			c.setHeader(name, pattern)
+		}
+	}
+	if auth := req.BasicAuthCredentials; auth != nil {
+		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		c.setHeader("Authorization", regexp.QuoteMeta("Basic "+credentials))
+	}
+	if len(req.Cookies) > 0 {
+		c.warnf("cookie matchers are not converted; match the Cookie header by hand")
+	}
+
+	for i, p := range req.BodyPatterns {
+		if expected, ok := p["equalToJson"]; ok {
+			if err := c.setJSONBody(p, expected); err != nil {
+				return fmt.Errorf("body pattern %d: %w", i+1, err)
+			}
+			continue
+		}
+		if op := matcherOperator(p); op == "matchesJsonPath" || op == "matchesJsonSchema" || op == "equalToXml" || op == "matchesXPath" || op == "binaryEqualTo" {
+			c.warnf("%s body pattern is not converted", op)
+			continue
+		}
+		pattern, err := c.stringMatch(p, "body", "")
+		if err != nil {
+			return fmt.Errorf("body pattern %d: %w", i+1, err)
+		}
+		switch {
+		case pattern == "":
+		case c.rule.Body == "":
+			c.rule.Body = "(?s)" + pattern
+			c.rule.MatchMode = "full"
+		default:
+			c.conditions = append(c.conditions, fmt.Sprintf("body.matches(%s)", exprString("(?s)^(?:"+pattern+")$")))
+		}
+	}
+	return nil
+}
+
+// setJSONBody converts an equalToJson body pattern into the rule's jsonBody
+func (c *wiremockConverter) setJSONBody(p wiremockMatcher, expected interface{}) error {
+	if c.rule.JSONBody != nil {
+		c.warnf("only the first equalToJson body pattern is converted")
+		return nil
+	}
+	if s, ok := expected.(string); ok {
+		if err := json.Unmarshal([]byte(s), &expected); err != nil {
+			return fmt.Errorf("equalToJson: %w", err)
+		}
+	}
+	c.rule.JSONBody = expected
+	if p["ignoreArrayOrder"] == true {
+		c.rule.JSONBodyMatch = &jsonBodyMatch{IgnoreArrayOrder: true}
+	}
+	if p["ignoreExtraElements"] == true {
+		c.warnf("equalToJson ignoreExtraElements is not supported; the body must match exactly")
+	}
+	return nil
+}
+
+// stringMatch converts a WireMock string matcher into a regex pattern, anchored by
+// the rule's full match mode, or else adds a condition on the value expression.
+// present is an expression that holds when the value is sent at all.
+func (c *wiremockConverter) stringMatch(m wiremockMatcher, value, present string) (string, error) {
+	op := matcherOperator(m)
+	operand := fmt.Sprint(m[op])
+	ci := ""
+	if m["caseInsensitive"] == true {
+		ci = "(?i)"
+	}
+	var pattern string
+	switch op {
+	case "equalTo":
+		pattern = ci + regexp.QuoteMeta(operand)
+	case "matches":
+		pattern = ci + operand
+	case "contains":
+		pattern = ci + ".*" + regexp.QuoteMeta(operand) + ".*"
+	case "doesNotMatch":
+		c.conditions = append(c.conditions, fmt.Sprintf("!%s.matches(%s)", value, exprString("^(?:"+operand+")$")))
+		return "", nil
+	case "doesNotContain":
+		c.conditions = append(c.conditions, fmt.Sprintf("!%s.contains(%s)", value, exprString(operand)))
+		return "", nil
+	case "absent":
+		if present == "" {
+			return "", fmt.Errorf("absent is not supported here")
+		}
+		if m[op] == true {
+			c.conditions = append(c.conditions, "!("+present+")")
+		} else {
+			c.conditions = append(c.conditions, present)
+		}
+		return "", nil
+	case "":
+		return "", fmt.Errorf("no matcher operator")
+	default:
+		c.warnf("%s matcher for %s is not converted", op, value)
+		return "", nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	c.rule.MatchMode = "full"
+	return pattern, nil
+}
+
+// matcherOperator returns the operator of a matcher, skipping its modifiers
+func matcherOperator(m wiremockMatcher) string {
+	for _, key := range sortedKeys(m) {
+		switch key {
+		case "caseInsensitive", "ignoreArrayOrder", "ignoreExtraElements":
+			continue
+		}
+		return key
+	}
+	return ""
+}
+
+// convertResponse converts the response, delay, fault, and proxy settings
+func (c *wiremockConverter) convertResponse(resp wiremockResponse) error {
+	r := &c.rule.Response
+	r.StatusCode = resp.Status
+	if r.StatusCode == 0 {
+		r.StatusCode = 200
+	}
+	for _, name := range sortedKeys(resp.Headers) {
+		var value string
+		switch v := resp.Headers[name].(type) {
+		case []interface{}:
+			values := make([]string, len(v))
+			for i := range v {
+				values[i] = fmt.Sprint(v[i])
+			}
+			value = strings.Join(values, ", ")
+		default:
+			value = fmt.Sprint(v)
+		}
+		if r.Headers == nil {
+			r.Headers = make(map[string]string)
+		}
+		r.Headers[name] = value
+	}
+
+	switch {
+	case resp.JSONBody != nil:
+		data, err := json.Marshal(resp.JSONBody)
+		if err != nil {
+			return fmt.Errorf("jsonBody: %w", err)
+		}
+		r.setBody(string(data), "application/json")
+	case resp.Base64Body != "":
+		r.BodyBase64 = resp.Base64Body
+	case resp.BodyFileName != "":
+		data, err := os.ReadFile(filepath.Join(c.filesDir, filepath.FromSlash(resp.BodyFileName)))
+		if err != nil {
+			return fmt.Errorf("bodyFileName: %w", err)
+		}
+		if utf8.Valid(data) {
+			r.setBody(string(data), "")
+		} else {
+			r.BodyBase64 = base64.StdEncoding.EncodeToString(data)
+		}
+	default:
+		r.setBody(resp.Body, "")
+	}
+	for _, t := range resp.Transformers {
+		if t == "response-template" {
+			c.warnf("Handlebars response templates are copied as plain text; rewrite them as Go templates")
+		} else {
+			c.warnf("transformer %s is not converted", t)
+		}
+	}
+
+	if delay := c.delay(resp); delay != nil {
+		c.rule.ResponseDelay = delay
+	}
+	if resp.Fault != "" {
+		fault, ok := wiremockFaults[resp.Fault]
+		if !ok {
+			return fmt.Errorf("unknown fault %s", resp.Fault)
+		}
+		r.Fault = fault
+	}
+	if resp.ProxyBaseURL != "" {
+		r.Proxy = &proxy{Target: resp.ProxyBaseURL, Headers: resp.AdditionalProxyRequestHeaders}
+	}
+	if len(resp.ChunkedDribbleDelay) > 0 && string(resp.ChunkedDribbleDelay) != "null" {
+		c.warnf("chunkedDribbleDelay is not converted")
+	}
+	return nil
+}
+
+// delay converts a fixed delay and a delay distribution, which WireMock adds
+// together, into a response delay
+func (c *wiremockConverter) delay(resp wiremockResponse) *responseDelay {
+	fixed := resp.FixedDelayMilliseconds
+	d := resp.DelayDistribution
+	switch {
+	case d == nil && fixed == 0:
+		return nil
+	case d == nil:
+		return &responseDelay{Distribution: "fixed", P50: fixed}
+	case d.Type == "uniform":
+		return &responseDelay{Min: fixed + d.Lower, Max: fixed + d.Upper}
+	case d.Type == "lognormal":
+		// 1.2816 standard deviations above the mean is the 90th percentile
+		p90 := int(math.Round(float64(d.Median) * math.Exp(1.2816*d.Sigma)))
+		return &responseDelay{Distribution: "lognormal", P50: fixed + d.Median, P90: fixed + p90}
+	default:
+		c.warnf("%s delay distribution is not converted", d.Type)
+		if fixed == 0 {
+			return nil
+		}
+		return &responseDelay{Distribution: "fixed", P50: fixed}
+	}
+}
+
+func (c *wiremockConverter) setQueryParam(name, pattern string) {
+	if c.rule.QueryParams == nil {
+		c.rule.QueryParams = make(map[string]string)
+	}
+	c.rule.QueryParams[name] = pattern
+	c.rule.MatchMode = "full"
+}
+
+func (c *wiremockConverter) setHeader(name, pattern string) {
+	if c.rule.Headers == nil {
+		c.rule.Headers = make(map[string]string)
+	}
+	c.rule.Headers[name] = pattern
+	c.rule.MatchMode = "full"
+}
+
+// exprString quotes s as a string literal in a when expression
+func exprString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
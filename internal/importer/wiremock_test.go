@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"http-mock-server/internal/config"
+)
+
+const wiremockUsers = `{
+  "mappings": [
+    {
+      "name": "Get user",
+      "request": {
+        "method": "GET",
+        "urlPathTemplate": "/users/{id}",
+        "headers": {
+          "Accept": {"contains": "json"},
+          "X-Debug": {"absent": true}
+        },
+        "queryParameters": {
+          "fields": {"equalTo": "name", "caseInsensitive": true},
+          "trace": {"doesNotMatch": "on|yes"}
+        }
+      },
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]},
+        "jsonBody": {"id": 42, "name": "Ada"}
+      }
+    },
+    {
+      "request": {"method": "ANY", "urlPathPattern": "/users/[0-9]+/avatar"},
+      "response": {"status": 200, "bodyFileName": "avatar.png", "fixedDelayMilliseconds": 150}
+    }
+  ]
+}`
+
+const wiremockOrders = `{
+  "priority": 1,
+  "request": {
+    "method": "POST",
+    "url": "/orders?source=web",
+    "basicAuthCredentials": {"username": "shop", "password": "secret"},
+    "cookies": {"session": {"matches": ".+"}},
+    "bodyPatterns": [
+      {"equalToJson": "{\"items\": [1, 2]}", "ignoreArrayOrder": true},
+      {"matchesJsonPath": "$.items"}
+    ]
+  },
+  "response": {
+    "status": 201,
+    "body": "Created {{request.path}}",
+    "transformers": ["response-template"],
+    "delayDistribution": {"type": "uniform", "lower": 20, "upper": 80}
+  },
+  "scenarioName": "checkout",
+  "requiredScenarioState": "Started",
+  "newScenarioState": "ordered"
+}`
+
+const wiremockFallback = `{
+  "priority": 10,
+  "request": {"urlPattern": "/api/.*"},
+  "response": {"proxyBaseUrl": "https://api.example.com", "additionalProxyRequestHeaders": {"X-Mock": "1"}}
+}`
+
+func TestImport_WireMock(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"mappings/users.json":         wiremockUsers,
+		"mappings/shop/orders.json":   wiremockOrders,
+		"mappings/shop/fallback.json": wiremockFallback,
+		"mappings/README.md":          "not a mapping",
+		"__files/avatar.png":          "\x89PNG\r\n\x1a\n\xff",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{root, filepath.Join(root, "mappings"), filepath.Join(root, "mappings/users.json")} {
+		if format, err := Detect(path); err != nil || format != FormatWireMock {
+			t.Fatalf("expected wiremock format for %s, got %q, %v", path, format, err)
+		}
+	}
+	file, err := Import(root, FormatWireMock, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Requests) != 4 {
+		t.Fatalf("expected 4 rules, got %+v", file.Requests)
+	}
+
+	// Priority 1 comes first, then the default priority in file order, then 10
+	orders := file.Requests[0]
+	if orders.Method != "POST" || orders.Path != "/orders" || orders.QueryParams["source"] != "web" || orders.Headers["Authorization"] != `Basic c2hvcDpzZWNyZXQ=` {
+		t.Errorf("unexpected orders matchers: %+v", orders)
+	}
+	if orders.JSONBodyMatch == nil || !orders.JSONBodyMatch.IgnoreArrayOrder || orders.JSONBody == nil {
+		t.Errorf("expected the JSON body matcher, got %+v", orders)
+	}
+	if orders.Scenario != "checkout" || orders.RequiredState != "Started" || orders.NewState != "ordered" {
+		t.Errorf("expected the scenario, got %+v", orders)
+	}
+	if d := orders.ResponseDelay; d == nil || d.Min != 20 || d.Max != 80 || orders.Response.StatusCode != 201 {
+		t.Errorf("unexpected orders response: %+v", orders)
+	}
+
+	avatar := file.Requests[2]
+	if avatar.Name != "ANY /users/[0-9]+/avatar" || avatar.Method != "*" || avatar.PathPrefix != "/" || avatar.When != `path.matches('^(?:/users/[0-9]+/avatar)$')` {
+		t.Errorf("unexpected avatar matchers: %+v", avatar)
+	}
+	if avatar.Response.BodyBase64 != "iVBORw0KGgr/" || avatar.ResponseDelay == nil || avatar.ResponseDelay.P50 != 150 {
+		t.Errorf("unexpected avatar response: %+v", avatar)
+	}
+
+	user := file.Requests[1]
+	if user.Name != "Get user" || user.Path != "/users/{id}" || user.MatchMode != "full" {
+		t.Errorf("unexpected user rule: %+v", user)
+	}
+	if user.Headers["Accept"] != ".*json.*" || user.QueryParams["fields"] != "(?i)name" {
+		t.Errorf("unexpected user patterns: %+v", user)
+	}
+	if want := `!query('trace').matches('^(?:on|yes)$') && !('x-debug' in headers)`; user.When != want {
+		t.Errorf("expected when %q, got %q", want, user.When)
+	}
+	if user.Response.Headers["Vary"] != "Accept, Origin" || !strings.Contains(user.Response.Body, `"name": "Ada"`) {
+		t.Errorf("unexpected user response: %+v", user.Response)
+	}
+
+	fallback := file.Requests[3]
+	if fallback.PathPrefix != "/api/" || fallback.Method != "*" || fallback.Response.Proxy == nil || fallback.Response.Proxy.Target != "https://api.example.com" {
+		t.Errorf("unexpected fallback rule: %+v", fallback)
+	}
+
+	warnings := strings.Join(file.Warnings, "\n")
+	for _, want := range []string{"cookie matchers", "matchesJsonPath body pattern", "Handlebars response templates"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("expected a warning about %s, got:\n%s", want, warnings)
+		}
+	}
+
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := config.Parse(data, "test"); err != nil {
+		t.Fatalf("generated configuration did not load: %v\n%s", err, data)
+	}
+}
+
+func TestWireMock_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "mapping.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if _, _, err := WireMock(write(`{"request": {"urlPath": "/x"}, "response": {"fault": "TEAPOT"}}`)); err == nil || !strings.Contains(err.Error(), "unknown fault") {
+		t.Fatalf("expected an unknown fault error, got %v", err)
+	}
+	if _, _, err := WireMock(write(`{"request": {"urlPath": "/x"}, "response": {"bodyFileName": "missing.json"}}`)); err == nil || !strings.Contains(err.Error(), "bodyFileName") {
+		t.Fatalf("expected a missing body file error, got %v", err)
+	}
+	if _, _, err := WireMock(write(`{"name": "not a mapping"}`)); err == nil {
+		t.Fatal("expected an error for a file that isn't a mapping")
+	}
+}
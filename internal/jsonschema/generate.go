@@ -0,0 +1,464 @@
+package jsonschema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// generateDepth is how deep generated values nest before optional properties and
+// array items are left out, so recursive schemas such as trees still end
+const generateDepth = 8
+
+// generateBase is the reference time for generated dates, so a body generated from
+// a fixed seed stays the same across runs
+var generateBase = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Word lists for generated strings. Email domains use the reserved example domains.
+var (
+	genFirstNames = []string{"Ada", "Alan", "Ana", "Carlos", "Grace", "Hugo", "Joana", "Kenji", "Lara", "Maria", "Noah", "Priya", "Tiago", "Zoe"}
+	genLastNames  = []string{"Almeida", "Costa", "Hopper", "Ito", "Lovelace", "Martins", "Novak", "Silva", "Smith", "Turing"}
+	genCities     = []string{"Lisbon", "Porto", "Madrid", "Paris", "Berlin", "London", "Toronto", "Tokyo", "Sydney"}
+	genCountries  = []string{"Portugal", "Spain", "France", "Germany", "United Kingdom", "Canada", "Japan", "Australia"}
+	genWords      = []string{"alpha", "bright", "cloud", "delta", "echo", "forest", "harbor", "lumen", "nova", "orbit", "quartz", "river", "summit"}
+	genDomains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// Generate returns a value that satisfies the schema found at ref, a local reference
+// such as "#/components/schemas/User", or the whole schema when ref is empty. Values
+// follow types, enums, consts, formats, length and range limits, and required
+// properties, and examples or defaults declared in the schema are used as they are.
+// OpenAPI's nullable, example, and writeOnly are understood too. Patterns are not,
+// so strings constrained only by a pattern may not match it. Choices are drawn from
+// r, so a fixed seed always produces the same value.
+func (s *Schema) Generate(ref string, r *rand.Rand) (interface{}, error) {
+	node := s.root
+	if ref != "" {
+		var err error
+		if node, err = s.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+	g := &generator{schema: s, rand: r}
+	v := g.generate(node, "", 0)
+	if g.err != nil {
+		return nil, g.err
+	}
+	return v, nil
+}
+
+type generator struct {
+	schema *Schema
+	rand   *rand.Rand
+	err    error
+}
+
+func (g *generator) generate(node interface{}, name string, depth int) interface{} {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		// true and unknown schemas accept anything, false accepts nothing
+		if b, isBool := node.(bool); isBool && !b {
+			return nil
+		}
+		return g.word()
+	}
+
+	if ref, ok := n["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			g.fail("$ref nesting exceeds %d levels", maxRefDepth)
+			return nil
+		}
+		target, err := g.schema.resolve(ref)
+		if err != nil {
+			g.fail("%v", err)
+			return nil
+		}
+		return g.generate(target, name, depth+1)
+	}
+
+	if c, ok := n["const"]; ok {
+		return c
+	}
+	if examples, ok := n["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[g.rand.Intn(len(examples))]
+	}
+	if example, ok := n["example"]; ok {
+		return example
+	}
+	if def, ok := n["default"]; ok {
+		return def
+	}
+	if enum, ok := n["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[g.rand.Intn(len(enum))]
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if choices, ok := n[key].([]interface{}); ok && len(choices) > 0 {
+			return g.merge(g.generateType(n, name, depth), g.generate(choices[g.rand.Intn(len(choices))], name, depth+1))
+		}
+	}
+	if all, ok := n["allOf"].([]interface{}); ok {
+		v := g.generateType(n, name, depth)
+		for _, sub := range all {
+			v = g.merge(v, g.generate(sub, name, depth+1))
+		}
+		return v
+	}
+	return g.generateType(n, name, depth)
+}
+
+// merge combines the objects generated for a schema and its allOf or oneOf parts;
+// any other value generated for a part replaces what came before
+func (g *generator) merge(base, v interface{}) interface{} {
+	b, ok1 := base.(map[string]interface{})
+	m, ok2 := v.(map[string]interface{})
+	if !ok1 || !ok2 {
+		if v == nil {
+			return base
+		}
+		return v
+	}
+	for k, item := range m {
+		b[k] = item
+	}
+	return b
+}
+
+// generateType generates a value of the schema's own type, or a word when it declares
+// neither a type nor keywords implying one
+func (g *generator) generateType(n map[string]interface{}, name string, depth int) interface{} {
+	switch schemaType(n) {
+	case "object":
+		return g.object(n, depth)
+	case "array":
+		return g.array(n, name, depth)
+	case "string":
+		return g.string(n, name)
+	case "integer":
+		return g.number(n, true)
+	case "number":
+		return g.number(n, false)
+	case "boolean":
+		return g.rand.Intn(2) == 0
+	case "null":
+		return nil
+	}
+	return g.word()
+}
+
+// schemaType returns the type to generate, preferring a non-null one from a list of
+// types and inferring the type from other keywords when none is declared
+func schemaType(n map[string]interface{}) string {
+	switch t := n["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && s != "null" {
+				return s
+			}
+		}
+		return "null"
+	}
+	switch {
+	case n["properties"] != nil || n["additionalProperties"] != nil || n["required"] != nil:
+		return "object"
+	case n["items"] != nil || n["prefixItems"] != nil:
+		return "array"
+	case n["format"] != nil || n["minLength"] != nil || n["maxLength"] != nil || n["pattern"] != nil:
+		return "string"
+	case n["minimum"] != nil || n["maximum"] != nil || n["multipleOf"] != nil:
+		return "number"
+	}
+	return ""
+}
+
+func (g *generator) object(n map[string]interface{}, depth int) interface{} {
+	obj := make(map[string]interface{})
+	required := make(map[string]bool)
+	list, _ := n["required"].([]interface{})
+	for _, r := range list {
+		if s, ok := r.(string); ok {
+			required[s] = true
+		}
+	}
+
+	props, _ := n["properties"].(map[string]interface{})
+	names := make([]string, 0, len(props))
+	for key := range props {
+		names = append(names, key)
+	}
+	sort.Strings(names) // Draw from the generator in a stable order
+	for _, key := range names {
+		prop := props[key]
+		if p, ok := prop.(map[string]interface{}); ok {
+			if writeOnly, _ := p["writeOnly"].(bool); writeOnly && !required[key] {
+				continue
+			}
+		}
+		if !required[key] && depth >= generateDepth {
+			continue
+		}
+		obj[key] = g.generateNullable(prop, key, depth+1)
+	}
+	// Required properties without a schema of their own
+	for _, r := range list {
+		if key, ok := r.(string); ok {
+			if _, done := obj[key]; !done {
+				obj[key] = g.word()
+			}
+		}
+	}
+
+	// A map with no named properties gets one entry
+	if extra, ok := n["additionalProperties"].(map[string]interface{}); ok && len(props) == 0 && depth < generateDepth {
+		obj[g.word()] = g.generateNullable(extra, "", depth+1)
+	}
+	return obj
+}
+
+// generateNullable generates a value for node, which is occasionally null when
+// OpenAPI's nullable allows it
+func (g *generator) generateNullable(node interface{}, name string, depth int) interface{} {
+	if n, ok := node.(map[string]interface{}); ok {
+		if nullable, _ := n["nullable"].(bool); nullable && g.rand.Intn(5) == 0 {
+			return nil
+		}
+	}
+	return g.generate(node, name, depth)
+}
+
+func (g *generator) array(n map[string]interface{}, name string, depth int) interface{} {
+	min, max := 0, -1
+	if f, ok := number(n["minItems"]); ok {
+		min = int(f)
+	}
+	if f, ok := number(n["maxItems"]); ok {
+		max = int(f)
+	}
+
+	prefix, _ := n["prefixItems"].([]interface{})
+	rest, hasRest := n["items"]
+	if tuple, ok := rest.([]interface{}); ok {
+		prefix = tuple
+		rest, hasRest = n["additionalItems"]
+	}
+	if b, ok := rest.(bool); ok && !b {
+		hasRest = false
+	}
+
+	count := len(prefix)
+	if hasRest || len(prefix) == 0 {
+		count = min
+		if depth < generateDepth {
+			count = intMax(min, 1) + g.rand.Intn(3)
+		}
+		count = intMax(count, len(prefix))
+	}
+	if max >= 0 && count > max {
+		count = max
+	}
+
+	items := make([]interface{}, 0, count)
+	unique, _ := n["uniqueItems"].(bool)
+	for i := 0; i < count; i++ {
+		var node interface{} = true
+		switch {
+		case i < len(prefix):
+			node = prefix[i]
+		case hasRest:
+			node = rest
+		}
+		item := g.generateNullable(node, name, depth+1)
+		// Retry a few times for a distinct item, and settle for fewer items otherwise
+		for attempt := 0; unique && attempt < 10 && contains(items, item); attempt++ {
+			item = g.generateNullable(node, name, depth+1)
+		}
+		if unique && contains(items, item) {
+			if len(items) >= min {
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (g *generator) string(n map[string]interface{}, name string) interface{} {
+	format, _ := n["format"].(string)
+	s := g.formatted(format, name)
+
+	min, max := 0, -1
+	if f, ok := number(n["minLength"]); ok {
+		min = int(f)
+	}
+	if f, ok := number(n["maxLength"]); ok {
+		max = int(f)
+	}
+	for utf8.RuneCountInString(s) < min {
+		s += " " + g.word()
+	}
+	if max >= 0 && utf8.RuneCountInString(s) > max {
+		s = string([]rune(s)[:max])
+	}
+	return s
+}
+
+// formatted returns a string in the given format, or one suggested by the property
+// name when the format is unknown or missing
+func (g *generator) formatted(format, name string) string {
+	switch format {
+	case "date-time":
+		return g.time().Format(time.RFC3339)
+	case "date":
+		return g.time().Format("2006-01-02")
+	case "time":
+		return g.time().Format("15:04:05Z07:00")
+	case "email", "idn-email":
+		return g.email()
+	case "uuid":
+		return g.uuid()
+	case "uri", "url", "iri", "uri-reference":
+		return "https://" + g.pick(genDomains) + "/" + g.word()
+	case "hostname", "idn-hostname":
+		return g.word() + "." + g.pick(genDomains)
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", 1+g.rand.Intn(254))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", 1+g.rand.Intn(0xfffe))
+	case "byte":
+		return base64.StdEncoding.EncodeToString([]byte(g.word()))
+	case "password":
+		return fmt.Sprintf("%s-%04d", g.word(), g.rand.Intn(10000))
+	}
+
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "email"):
+		return g.email()
+	case lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(name, "Id"):
+		return g.uuid()
+	case strings.Contains(lower, "url") || strings.Contains(lower, "uri") || strings.Contains(lower, "link"):
+		return "https://" + g.pick(genDomains) + "/" + g.word()
+	case strings.Contains(lower, "firstname") || strings.Contains(lower, "first_name"):
+		return g.pick(genFirstNames)
+	case strings.Contains(lower, "lastname") || strings.Contains(lower, "last_name") || strings.Contains(lower, "surname"):
+		return g.pick(genLastNames)
+	case strings.Contains(lower, "name"):
+		return g.pick(genFirstNames) + " " + g.pick(genLastNames)
+	case strings.Contains(lower, "city"):
+		return g.pick(genCities)
+	case strings.Contains(lower, "country"):
+		return g.pick(genCountries)
+	case strings.Contains(lower, "phone"):
+		return fmt.Sprintf("+1 555 %04d", g.rand.Intn(10000))
+	}
+	return g.word()
+}
+
+func (g *generator) number(n map[string]interface{}, integer bool) interface{} {
+	lo, hi := 0.0, 1000.0
+	if f, ok := number(n["minimum"]); ok {
+		lo = f
+	}
+	if f, ok := number(n["exclusiveMinimum"]); ok {
+		lo = math.Max(lo, f+exclusiveStep(integer))
+	}
+	if f, ok := number(n["maximum"]); ok {
+		hi = f
+	}
+	if f, ok := number(n["exclusiveMaximum"]); ok {
+		hi = math.Min(hi, f-exclusiveStep(integer))
+	}
+	// Keep a default range near whichever bound is given
+	_, hasMin := n["minimum"]
+	_, hasMax := n["maximum"]
+	switch {
+	case hi < lo && !hasMax:
+		hi = lo + 1000
+	case hi < lo && !hasMin:
+		lo = hi - 1000
+	case hi < lo:
+		return lo
+	}
+	if hi-lo > 1e12 {
+		hi = lo + 1e12 // Keep the range within what the generator can draw from
+	}
+	if integer {
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+	}
+
+	if m, ok := number(n["multipleOf"]); ok && m > 0 {
+		first, last := math.Ceil(lo/m), math.Floor(hi/m)
+		if last < first {
+			return first * m
+		}
+		return (first + float64(g.rand.Int63n(int64(last-first)+1))) * m
+	}
+	if integer {
+		return lo + float64(g.rand.Int63n(int64(hi-lo)+1))
+	}
+	v := lo + g.rand.Float64()*(hi-lo)
+	if rounded := math.Round(v*100) / 100; rounded >= lo && rounded <= hi {
+		v = rounded
+	}
+	return v
+}
+
+func exclusiveStep(integer bool) float64 {
+	if integer {
+		return 1
+	}
+	return 0.01
+}
+
+func (g *generator) time() time.Time {
+	return generateBase.Add(time.Duration(g.rand.Int63n(int64(365*24*time.Hour/time.Second))) * time.Second)
+}
+
+func (g *generator) email() string {
+	return strings.ToLower(g.pick(genFirstNames)+"."+g.pick(genLastNames)) + "@" + g.pick(genDomains)
+}
+
+func (g *generator) uuid() string {
+	b := make([]byte, 16)
+	g.rand.Read(b) //nolint:staticcheck // math/rand Read is fine for non-crypto use
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (g *generator) word() string {
+	return g.pick(genWords)
+}
+
+func (g *generator) pick(list []string) string {
+	return list[g.rand.Intn(len(list))]
+}
+
+func (g *generator) fail(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func contains(items []interface{}, item interface{}) bool {
+	for _, existing := range items {
+		if reflect.DeepEqual(existing, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func intMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,135 @@
+package jsonschema
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name, status]
+      properties:
+        id: {type: integer, format: int64, minimum: 1}
+        name: {type: string, minLength: 2, maxLength: 20}
+        email: {type: string, format: email}
+        status: {type: string, enum: [available, pending, sold]}
+        createdAt: {type: string, format: date-time}
+        birthday: {type: string, format: date}
+        homepage: {type: string, format: uri}
+        price: {type: number, exclusiveMinimum: 0, maximum: 50, multipleOf: 0.5}
+        tags:
+          type: array
+          minItems: 1
+          maxItems: 3
+          uniqueItems: true
+          items: {type: string, enum: [small, large, friendly]}
+        owner:
+          allOf:
+            - $ref: '#/components/schemas/Person'
+            - type: object
+              properties:
+                vip: {type: boolean}
+        secret: {type: string, writeOnly: true}
+        children:
+          type: array
+          items: {$ref: '#/components/schemas/Pet'}
+    Person:
+      type: object
+      required: [firstName]
+      properties:
+        firstName: {type: string}
+        country: {type: string}
+    Nickname:
+      type: object
+      properties:
+        nickname: {type: string, nullable: true}
+    Greeting:
+      type: string
+      example: hello
+`
+
+func TestGenerate(t *testing.T) {
+	s, err := Parse([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pet, err := s.resolve("#/components/schemas/Pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		v, err := s.Generate("#/components/schemas/Pet", rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("seed %d: unexpected error: %v", seed, err)
+		}
+		obj := v.(map[string]interface{})
+		if _, ok := obj["secret"]; ok {
+			t.Fatalf("seed %d: writeOnly property generated: %v", seed, v)
+		}
+		check := &validator{schema: s}
+		check.validate(pet, normalize(v), "", 0)
+		if len(check.errors) > 0 {
+			t.Fatalf("seed %d: generated value %v is invalid: %v", seed, v, check.errors)
+		}
+	}
+
+	// The same seed always gives the same value
+	a, _ := s.Generate("#/components/schemas/Pet", rand.New(rand.NewSource(7)))
+	b, _ := s.Generate("#/components/schemas/Pet", rand.New(rand.NewSource(7)))
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same value for the same seed, got %v and %v", a, b)
+	}
+
+	// nullable properties are sometimes null
+	r := rand.New(rand.NewSource(1))
+	var nulls, strs int
+	for i := 0; i < 50; i++ {
+		v, _ := s.Generate("#/components/schemas/Nickname", r)
+		if _, ok := v.(map[string]interface{})["nickname"].(string); ok {
+			strs++
+		} else {
+			nulls++
+		}
+	}
+	if nulls == 0 || strs == 0 {
+		t.Errorf("expected both null and string nicknames, got %d null and %d strings", nulls, strs)
+	}
+
+	if v, err := s.Generate("#/components/schemas/Greeting", rand.New(rand.NewSource(1))); err != nil || v != "hello" {
+		t.Errorf("expected the example, got %v, %v", v, err)
+	}
+	if _, err := s.Generate("#/components/schemas/Missing", rand.New(rand.NewSource(1))); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a missing reference error, got %v", err)
+	}
+}
+
+func TestGenerate_Bounds(t *testing.T) {
+	s, err := Parse([]byte(`{
+  "type": "object",
+  "required": ["count", "code", "list"],
+  "properties": {
+    "count": {"type": "integer", "minimum": 5000},
+    "code": {"type": "string", "maxLength": 3},
+    "list": {"type": "array", "minItems": 4, "items": {"type": ["null", "number"], "maximum": -10}}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		v, err := s.Generate("", rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if errs := s.Validate(v); len(errs) > 0 {
+			t.Fatalf("seed %d: generated value %v is invalid: %v", seed, v, errs)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+// Package jsonschema validates JSON documents against a JSON Schema. It supports
+// the commonly used validation keywords of drafts 7 through 2020-12: type, enum,
+// const, properties, required, additionalProperties, patternProperties, items,
+// prefixItems, minItems, maxItems, uniqueItems, minLength, maxLength, pattern,
+// format (date-time, date, email, uuid, uri), minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, minProperties, maxProperties, allOf, anyOf,
+// oneOf, not, and local $ref pointers such as "#/$defs/item". It also generates
+// sample documents from a schema, for responses known only from a contract.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Load reads a schema from a JSON or YAML file
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read schema %s: %w", path, err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse compiles a schema from JSON or YAML data
+func Parse(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return New(doc)
+}
+
+// New compiles a schema from a decoded document
+func New(doc interface{}) (*Schema, error) {
+	s := &Schema{root: normalize(doc), patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(s.root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compilePatterns compiles every regex in the schema up front, so invalid
+// patterns are reported when the schema is loaded.
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if p, ok := n["pattern"].(string); ok {
+			if err := s.addPattern(p); err != nil {
+				return err
+			}
+		}
+		if pp, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p := range pp {
+				if err := s.addPattern(p); err != nil {
+					return err
+				}
+			}
+		}
+		for _, v := range n {
+			if err := s.compilePatterns(v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if err := s.compilePatterns(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) addPattern(p string) error {
+	if _, ok := s.patterns[p]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", p, err)
+	}
+	s.patterns[p] = re
+	return nil
+}
+
+// ValidationError describes one way a document violates the schema
+type ValidationError struct {
+	Path    string `json:"path"` // JSON pointer to the offending value, "" for the document root
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidateJSON parses data as JSON and validates it
+func (s *Schema) ValidateJSON(data []byte) []ValidationError {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []ValidationError{{Message: "invalid JSON: " + err.Error()}}
+	}
+	return s.Validate(doc)
+}
+
+// Validate validates a decoded JSON document and returns all violations
+func (s *Schema) Validate(doc interface{}) []ValidationError {
+	v := &validator{schema: s}
+	v.validate(s.root, normalize(doc), "", 0)
+	return v.errors
+}
+
+// maxRefDepth guards against schemas with reference cycles that never consume input
+const maxRefDepth = 64
+
+type validator struct {
+	schema *Schema
+	errors []ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether value satisfies node without recording errors
+func (v *validator) valid(node, value interface{}, path string, depth int) bool {
+	sub := &validator{schema: v.schema}
+	sub.validate(node, value, path, depth)
+	return len(sub.errors) == 0
+}
+
+func (v *validator) validate(node, value interface{}, path string, depth int) {
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObjectSchema(n, value, path, depth)
+	default:
+		// Unknown schema forms accept everything
+	}
+}
+
+func (v *validator) validateObjectSchema(n map[string]interface{}, value interface{}, path string, depth int) {
+	if ref, ok := n["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			v.fail(path, "$ref nesting exceeds %d levels", maxRefDepth)
+			return
+		}
+		target, err := v.schema.resolve(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		v.validate(target, value, path, depth+1)
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, value) {
+		v.fail(path, "expected %s, got %s", describeType(t), typeOf(value))
+		return
+	}
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := n["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.fail(path, "value must be %s", compactJSON(c))
+	}
+
+	switch val := value.(type) {
+	case string:
+		v.validateString(n, val, path)
+	case float64:
+		v.validateNumber(n, val, path)
+	case []interface{}:
+		v.validateArray(n, val, path, depth)
+	case map[string]interface{}:
+		v.validateObject(n, val, path, depth)
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path, depth)
+		}
+	}
+	if any, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if v.valid(sub, value, path, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any schema in anyOf")
+		}
+	}
+	if one, ok := n["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, sub := range one {
+			if v.valid(sub, value, path, depth) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(path, "value must match exactly one schema in oneOf, matched %d", count)
+		}
+	}
+	if not, ok := n["not"]; ok && v.valid(not, value, path, depth) {
+		v.fail(path, "value must not match the schema in not")
+	}
+}
+
+func (v *validator) validateString(n map[string]interface{}, s, path string) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := number(n["minLength"]); ok && float64(length) < min {
+		v.fail(path, "string is shorter than %v characters", min)
+	}
+	if max, ok := number(n["maxLength"]); ok && float64(length) > max {
+		v.fail(path, "string is longer than %v characters", max)
+	}
+	if p, ok := n["pattern"].(string); ok && !v.schema.patterns[p].MatchString(s) {
+		v.fail(path, "string does not match pattern %q", p)
+	}
+	if f, ok := n["format"].(string); ok && !matchesFormat(f, s) {
+		v.fail(path, "string is not a valid %s", f)
+	}
+}
+
+func (v *validator) validateNumber(n map[string]interface{}, f float64, path string) {
+	if min, ok := number(n["minimum"]); ok && f < min {
+		v.fail(path, "value must be >= %v", min)
+	}
+	if max, ok := number(n["maximum"]); ok && f > max {
+		v.fail(path, "value must be <= %v", max)
+	}
+	if min, ok := number(n["exclusiveMinimum"]); ok && f <= min {
+		v.fail(path, "value must be > %v", min)
+	}
+	if max, ok := number(n["exclusiveMaximum"]); ok && f >= max {
+		v.fail(path, "value must be < %v", max)
+	}
+	if m, ok := number(n["multipleOf"]); ok && m > 0 {
+		q := f / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "value must be a multiple of %v", m)
+		}
+	}
+}
+
+func (v *validator) validateArray(n map[string]interface{}, items []interface{}, path string, depth int) {
+	if min, ok := number(n["minItems"]); ok && float64(len(items)) < min {
+		v.fail(path, "array has fewer than %v items", min)
+	}
+	if max, ok := number(n["maxItems"]); ok && float64(len(items)) > max {
+		v.fail(path, "array has more than %v items", max)
+	}
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					v.fail(path, "array items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	start := 0
+	prefix, _ := n["prefixItems"].([]interface{})
+	// Draft 7 expresses tuples as an items array
+	if tuple, ok := n["items"].([]interface{}); ok {
+		prefix = tuple
+	}
+	for i, sub := range prefix {
+		if i < len(items) {
+			v.validate(sub, items[i], path+"/"+strconv.Itoa(i), depth)
+		}
+	}
+	start = len(prefix)
+
+	rest, hasRest := n["items"]
+	if _, isTuple := rest.([]interface{}); isTuple {
+		rest, hasRest = n["additionalItems"]
+	}
+	if hasRest {
+		for i := start; i < len(items); i++ {
+			v.validate(rest, items[i], path+"/"+strconv.Itoa(i), depth)
+		}
+	}
+}
+
+func (v *validator) validateObject(n map[string]interface{}, obj map[string]interface{}, path string, depth int) {
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+	if min, ok := number(n["minProperties"]); ok && float64(len(obj)) < min {
+		v.fail(path, "object has fewer than %v properties", min)
+	}
+	if max, ok := number(n["maxProperties"]); ok && float64(len(obj)) > max {
+		v.fail(path, "object has more than %v properties", max)
+	}
+
+	props, _ := n["properties"].(map[string]interface{})
+	patternProps, _ := n["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+
+	// Visit properties in a stable order so errors are reported deterministically
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := obj[name]
+		childPath := path + "/" + escapePointer(name)
+		covered := false
+		if sub, ok := props[name]; ok {
+			v.validate(sub, value, childPath, depth)
+			covered = true
+		}
+		for p, sub := range patternProps {
+			if v.schema.patterns[p].MatchString(name) {
+				v.validate(sub, value, childPath, depth)
+				covered = true
+			}
+		}
+		if !covered && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(childPath, "additional property %q is not allowed", name)
+			} else {
+				v.validate(additional, value, childPath, depth)
+			}
+		}
+	}
+}
+
+// resolve looks up a local reference such as "#/$defs/item" or "#/definitions/item"
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q, only local references are supported", ref)
+	}
+	node := s.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("$ref %q not found", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+	return node, nil
+}
+
+func matchesType(t, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, value)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, value interface{}) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// matchesFormat checks the formats commonly used in API contracts; unknown formats always pass
+func matchesFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uuid":
+		return uuidPattern.MatchString(s)
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// normalize converts YAML-decoded values to the types produced by encoding/json
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	}
+	return v
+}
@@ -0,0 +1,143 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+  "type": "object",
+  "required": ["id", "items"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "string", "format": "uuid"},
+    "email": {"type": "string", "format": "email"},
+    "status": {"enum": ["new", "paid"]},
+    "items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}}
+  },
+  "$defs": {
+    "item": {
+      "type": "object",
+      "required": ["sku", "qty"],
+      "properties": {
+        "sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+        "qty": {"type": "integer", "minimum": 1, "maximum": 10}
+      }
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(orderSchema))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			body: `{"id":"123e4567-e89b-12d3-a456-426614174000","status":"paid","items":[{"sku":"ABC-1","qty":2}]}`,
+		},
+		{name: "invalid json", body: `{`, wantErr: []string{"/: invalid JSON"}},
+		{name: "wrong type", body: `[]`, wantErr: []string{"/: expected object, got array"}},
+		{
+			name:    "missing required",
+			body:    `{"id":"123e4567-e89b-12d3-a456-426614174000"}`,
+			wantErr: []string{`/: missing required property "items"`},
+		},
+		{
+			name:    "additional property",
+			body:    `{"id":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ABC-1","qty":1}],"extra":1}`,
+			wantErr: []string{`/extra: additional property "extra" is not allowed`},
+		},
+		{
+			name:    "enum and format",
+			body:    `{"id":"nope","status":"shipped","items":[{"sku":"ABC-1","qty":1}]}`,
+			wantErr: []string{"/id: string is not a valid uuid", "/status: value must be one of"},
+		},
+		{
+			name: "nested via ref",
+			body: `{"id":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"abc","qty":1.5},{"qty":11}]}`,
+			wantErr: []string{
+				`/items/0/qty: expected integer, got number`,
+				`/items/0/sku: string does not match pattern`,
+				`/items/1: missing required property "sku"`,
+				`/items/1/qty: value must be <= 10`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := s.ValidateJSON([]byte(tt.body))
+			if len(errs) != len(tt.wantErr) {
+				t.Fatalf("expected %d errors, got %v", len(tt.wantErr), errs)
+			}
+			for i, want := range tt.wantErr {
+				if !strings.HasPrefix(errs[i].String(), want) {
+					t.Fatalf("error %d: expected prefix %q, got %q", i, want, errs[i].String())
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Combinators(t *testing.T) {
+	s, err := Parse([]byte(`
+oneOf:
+  - {type: string, maxLength: 3}
+  - {type: integer, multipleOf: 5}
+not: {const: 10}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		body  string
+		valid bool
+	}{
+		{`"abc"`, true},
+		{`"abcd"`, false},
+		{`15`, true},
+		{`10`, false},
+		{`7`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		if got := len(s.ValidateJSON([]byte(tt.body))) == 0; got != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.body, tt.valid, got)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(`{"type": "object", "properties": {"a": {"pattern": "("}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestValidate_RefCycle(t *testing.T) {
+	s, err := Parse([]byte(`{"$ref": "#"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	errs := s.ValidateJSON([]byte(`{}`))
+	if len(errs) == 0 || !strings.Contains(errs[0].Message, "nesting") {
+		t.Fatalf("expected nesting error, got %v", errs)
+	}
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// s3MaxObject is the largest object read from S3
+var s3MaxObject int64 = 1 << 30
+
+// S3 stores objects in an S3 bucket, or a bucket of an S3-compatible service, signing
+// requests with AWS Signature Version 4
+type S3 struct {
+	Bucket       string
+	Prefix       string
+	Region       string
+	Endpoint     string // Set for S3-compatible services, which are addressed path-style
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+func newS3(cfg *config.Storage) *S3 {
+	return &S3{
+		Bucket:       cfg.Bucket,
+		Prefix:       cfg.Prefix,
+		Region:       cfg.Region,
+		Endpoint:     strings.TrimSuffix(cfg.Endpoint, "/"),
+		AccessKey:    secretOrEnv(cfg.AccessKey, "AWS_ACCESS_KEY_ID"),
+		SecretKey:    secretOrEnv(cfg.SecretKey, "AWS_SECRET_ACCESS_KEY"),
+		SessionToken: secretOrEnv(cfg.SessionToken, "AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: 5 * time.Minute},
+		now:          time.Now,
+	}
+}
+
+func secretOrEnv(s config.Secret, env string) string {
+	if v := s.Value(); v != "" {
+		return v
+	}
+	return os.Getenv(env)
+}
+
+// objectURL returns the URL of a key, virtual-hosted style on AWS and path-style
+// on a custom endpoint
+func (s *S3) objectURL(key string) string {
+	path := "/" + s3Escape(s.Prefix+key)
+	if s.Endpoint != "" {
+		return s.Endpoint + "/" + s3Escape(s.Bucket) + path
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", s.Bucket, s.Region, path)
+}
+
+// Get downloads the object for key
+func (s *S3) Get(ctx context.Context, key string) (Object, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength > s3MaxObject {
+		return Object{}, fmt.Errorf("s3 get %s: object of %d bytes exceeds the %d byte limit", key, resp.ContentLength, s3MaxObject)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s3MaxObject+1))
+	if err != nil {
+		return Object{}, fmt.Errorf("s3 get %s: %w", key, err)
+	}
+	if int64(len(data)) > s3MaxObject {
+		return Object{}, fmt.Errorf("s3 get %s: object exceeds the %d byte limit", key, s3MaxObject)
+	}
+	obj := Object{Data: data}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.ModTime = t
+	}
+	return obj, nil
+}
+
+// Put uploads data as the object for key
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, s.Prefix)
+}
+
+// do sends a signed request for key and returns the response of a 2xx status
+func (s *S3) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+	s.sign(req, body)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s: %w", strings.ToLower(method), key, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("s3 %s %s: %w", strings.ToLower(method), key, ErrNotExist)
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", strings.ToLower(method), key, resp.Status, bytes.TrimSpace(detail))
+}
+
+// sign adds the AWS Signature Version 4 Authorization header, signing the host and
+// every header already set
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQuery(values url.Values) string {
+	parts := make([]string, 0, len(values))
+	for name, vs := range values {
+		for _, v := range vs {
+			parts = append(parts, s3Escape(name)+"="+s3Escape(v))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters and slashes, as
+// Signature Version 4 expects
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
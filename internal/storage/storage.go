@@ -0,0 +1,142 @@
+// Package storage reads and writes the files the server works with, such as body
+// files and journal exports, on the local file system, in memory, or in S3.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"http-mock-server/internal/config"
+)
+
+// ErrNotExist is returned for keys the store has no object for
+var ErrNotExist = fs.ErrNotExist
+
+// Object is the content of a key and when it was last written
+type Object struct {
+	Data    []byte
+	ModTime time.Time
+}
+
+// Store keeps objects by key. Keys use forward slashes, like relative file paths.
+type Store interface {
+	Get(ctx context.Context, key string) (Object, error)
+	Put(ctx context.Context, key string, data []byte) error
+	String() string // Describes the store in logs
+}
+
+// New returns the store a configuration describes. Without storage configured,
+// keys are file paths relative to the working directory.
+func New(cfg *config.Storage) Store {
+	if cfg == nil {
+		return &Local{}
+	}
+	switch cfg.Type {
+	case config.StorageMemory:
+		return NewMemory()
+	case config.StorageS3:
+		return newS3(cfg)
+	}
+	return &Local{Dir: cfg.Dir}
+}
+
+// Local stores objects as files below Dir
+type Local struct {
+	Dir string // Empty for the working directory
+}
+
+func (l *Local) path(key string) string {
+	return (&config.Storage{Dir: l.Dir}).LocalPath(filepath.FromSlash(key))
+}
+
+// Get reads the file for key
+func (l *Local) Get(ctx context.Context, key string) (Object, error) {
+	path := l.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return Object{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Data: data, ModTime: info.ModTime()}, nil
+}
+
+// Put writes the file for key, creating its directories
+func (l *Local) Put(ctx context.Context, key string, data []byte) error {
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (l *Local) String() string {
+	if l.Dir == "" {
+		return "local files"
+	}
+	return "local files in " + l.Dir
+}
+
+// Memory keeps objects in memory until the server stops
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]Object
+	now     func() time.Time
+}
+
+// NewMemory returns an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]Object), now: time.Now}
+}
+
+// Get returns a copy of the object for key
+func (m *Memory) Get(ctx context.Context, key string) (Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return Object{}, fmt.Errorf("%s: %w", key, ErrNotExist)
+	}
+	obj.Data = append([]byte(nil), obj.Data...)
+	return obj, nil
+}
+
+// Put stores a copy of data under key
+func (m *Memory) Put(ctx context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = Object{Data: append([]byte(nil), data...), ModTime: m.now()}
+	return nil
+}
+
+func (m *Memory) String() string {
+	return "memory"
+}
+
+// IsNotExist reports whether err means a key has no object
+func IsNotExist(err error) bool {
+	return errors.Is(err, ErrNotExist)
+}
+
+// CheckKey rejects keys that could reach outside the store, such as absolute paths
+// or paths with .. segments
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid key %q, use a relative path with forward slashes", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid key %q, use a relative path without empty, . or .. segments", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package templating
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"math/big"
+	"sort"
+)
+
+// Keyring holds the named keys available to signing helpers. A key is either a
+// PEM-encoded RSA or ECDSA private key, or any other value used as an HMAC secret.
+type Keyring struct {
+	keys map[string]signingKey
+}
+
+type signingKey struct {
+	secret []byte        // Raw key material, used for HMAC
+	signer crypto.Signer // Set for PEM private keys
+}
+
+// NewKeyring parses named key values
+func NewKeyring(values map[string]string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]signingKey, len(values))}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := values[name]
+		key := signingKey{secret: []byte(value)}
+		if block, _ := pem.Decode([]byte(value)); block != nil {
+			signer, err := parsePrivateKey(block)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", name, err)
+			}
+			key.signer = signer
+		}
+		k.keys[name] = key
+	}
+	return k, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key in PEM block %q", block.Type)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T, use RSA or ECDSA", key)
+}
+
+func (k *Keyring) get(name string) (signingKey, error) {
+	if k == nil {
+		return signingKey{}, fmt.Errorf("no keys are configured")
+	}
+	key, ok := k.keys[name]
+	if !ok {
+		return signingKey{}, fmt.Errorf("unknown key %q", name)
+	}
+	return key, nil
+}
+
+func cryptoFuncs(keys *Keyring) map[string]interface{} {
+	return map[string]interface{}{
+		"md5":       hexDigest(md5.New),
+		"sha1":      hexDigest(sha1.New),
+		"sha256":    hexDigest(sha256.New),
+		"sha512":    hexDigest(sha512.New),
+		"base64":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"base64url": func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) },
+		"hex":       func(s string) string { return hex.EncodeToString([]byte(s)) },
+		"hmacSHA256": func(keyName, data string) (string, error) {
+			return keys.hmac(sha256.New, keyName, data)
+		},
+		"hmacSHA512": func(keyName, data string) (string, error) {
+			return keys.hmac(sha512.New, keyName, data)
+		},
+		"sign": keys.sign,
+		"jwt":  keys.jwt,
+	}
+}
+
+func hexDigest(newHash func() hash.Hash) func(string) string {
+	return func(s string) string {
+		h := newHash()
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// hmac returns the hex-encoded HMAC of data under the named key
+func (k *Keyring) hmac(newHash func() hash.Hash, keyName, data string) (string, error) {
+	key, err := k.get(keyName)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(newHash, key.secret)
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// sign returns the base64-encoded SHA-256 signature of data: RSA PKCS #1 v1.5 for
+// RSA keys and ASN.1 DER for ECDSA keys, the formats most verifiers expect.
+func (k *Keyring) sign(keyName, data string) (string, error) {
+	key, err := k.get(keyName)
+	if err != nil {
+		return "", err
+	}
+	if key.signer == nil {
+		return "", fmt.Errorf("key %q is not a private key", keyName)
+	}
+	digest := sha256.Sum256([]byte(data))
+	sig, err := key.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("signing with key %q: %w", keyName, err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// jwt mints a compact JWS over the claims. The algorithm follows from the key:
+// RS256 for RSA, ES256/ES384/ES512 by ECDSA curve, and HS256 for other secrets.
+func (k *Keyring) jwt(keyName string, claims map[string]interface{}) (string, error) {
+	key, err := k.get(keyName)
+	if err != nil {
+		return "", err
+	}
+
+	var alg string
+	var hashFunc crypto.Hash
+	switch signer := key.signer.(type) {
+	case nil:
+		alg, hashFunc = "HS256", crypto.SHA256
+	case *rsa.PrivateKey:
+		alg, hashFunc = "RS256", crypto.SHA256
+	case *ecdsa.PrivateKey:
+		switch signer.Curve {
+		case elliptic.P256():
+			alg, hashFunc = "ES256", crypto.SHA256
+		case elliptic.P384():
+			alg, hashFunc = "ES384", crypto.SHA384
+		case elliptic.P521():
+			alg, hashFunc = "ES512", crypto.SHA512
+		default:
+			return "", fmt.Errorf("key %q uses an unsupported curve", keyName)
+		}
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("jwt claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var sig []byte
+	if key.signer == nil {
+		mac := hmac.New(sha256.New, key.secret)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	} else {
+		h := hashFunc.New()
+		h.Write([]byte(signingInput))
+		digest := h.Sum(nil)
+		if ec, ok := key.signer.(*ecdsa.PrivateKey); ok {
+			// JWS uses the fixed-width r||s encoding rather than ASN.1
+			r, s, err := ecdsa.Sign(rand.Reader, ec, digest)
+			if err != nil {
+				return "", fmt.Errorf("signing with key %q: %w", keyName, err)
+			}
+			size := (ec.Curve.Params().BitSize + 7) / 8
+			sig = append(padded(r, size), padded(s, size)...)
+		} else if sig, err = key.signer.Sign(rand.Reader, digest, hashFunc); err != nil {
+			return "", fmt.Errorf("signing with key %q: %w", keyName, err)
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func padded(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
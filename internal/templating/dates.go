@@ -0,0 +1,304 @@
+package templating
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namedLayouts maps layout names accepted by format and parseTime to Go layouts
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"ISO8601":     "2006-01-02T15:04:05Z0700",
+	"HTTP":        "Mon, 02 Jan 2006 15:04:05 GMT",
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// nowFunc is replaced in tests
+var nowFunc = time.Now
+
+func dateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"now":        now,
+		"nowPlus":    nowPlus,
+		"parseTime":  parseTime,
+		"format":     format,
+		"addDays":    func(n int, t interface{}) (time.Time, error) { return addDate(0, 0, n, t) },
+		"addMonths":  func(n int, t interface{}) (time.Time, error) { return addDate(0, n, 0, t) },
+		"addYears":   func(n int, t interface{}) (time.Time, error) { return addDate(n, 0, 0, t) },
+		"addHours":   func(n int, t interface{}) (time.Time, error) { return add(time.Duration(n)*time.Hour, t) },
+		"addMinutes": func(n int, t interface{}) (time.Time, error) { return add(time.Duration(n)*time.Minute, t) },
+		"addSeconds": func(n int, t interface{}) (time.Time, error) { return add(time.Duration(n)*time.Second, t) },
+		"addDuration": func(d string, t interface{}) (time.Time, error) {
+			duration, err := time.ParseDuration(d)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return add(duration, t)
+		},
+		"inZone": func(zone string, t interface{}) (time.Time, error) {
+			tm, err := toTime(t)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return inZone(tm, zone)
+		},
+	}
+}
+
+// now returns the current time, or formats it when given a layout and optional zone
+// and locale, e.g. now "RFC3339"
+func now(args ...string) (interface{}, error) {
+	if len(args) == 0 {
+		return nowFunc(), nil
+	}
+	if len(args) > 3 {
+		return nil, fmt.Errorf("now expects an optional layout, zone, and locale")
+	}
+	return formatNow(nowFunc(), args)
+}
+
+// nowPlus formats the current time moved by a duration such as "24h", "-90m", or "7d",
+// in RFC 3339 unless a layout and optional zone and locale are given
+func nowPlus(offset string, args ...string) (string, error) {
+	d, err := parseOffset(offset)
+	if err != nil {
+		return "", err
+	}
+	if len(args) > 3 {
+		return "", fmt.Errorf("nowPlus expects a duration and an optional layout, zone, and locale")
+	}
+	if len(args) == 0 {
+		args = []string{"RFC3339"}
+	}
+	return formatNow(nowFunc().Add(d), args)
+}
+
+func formatNow(t time.Time, args []string) (string, error) {
+	rest := make([]interface{}, 0, len(args))
+	for _, arg := range args[1:] {
+		rest = append(rest, arg)
+	}
+	return format(args[0], append(rest, t)...)
+}
+
+// parseOffset parses a Go duration, or a whole number of days such as "7d"
+func parseOffset(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, use a Go duration such as 24h or a number of days such as 7d", s)
+	}
+	return d, nil
+}
+
+func addDate(years, months, days int, t interface{}) (time.Time, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tm.AddDate(years, months, days), nil
+}
+
+func add(d time.Duration, t interface{}) (time.Time, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tm.Add(d), nil
+}
+
+// toTime accepts a time, an RFC 3339 string, or Unix seconds
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		if tm, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return tm, nil
+		}
+		if secs, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return time.Unix(secs, 0), nil
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as a time, use RFC 3339 or Unix seconds", t)
+	case int:
+		return time.Unix(int64(t), 0), nil
+	case int64:
+		return time.Unix(t, 0), nil
+	case float64:
+		return time.Unix(int64(t), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("expected a time, got %T", v)
+}
+
+// parseTime parses value with a named or Go layout
+func parseTime(layout, value string) (time.Time, error) {
+	switch layout {
+	case "unix":
+		secs, err := strconv.ParseInt(value, 10, 64)
+		return time.Unix(secs, 0), err
+	case "unixMilli":
+		ms, err := strconv.ParseInt(value, 10, 64)
+		return time.UnixMilli(ms), err
+	}
+	if named, ok := namedLayouts[layout]; ok {
+		layout = named
+	}
+	return time.Parse(layout, value)
+}
+
+// format renders a time: format LAYOUT [ZONE [LOCALE]] TIME. The layout is a name such as
+// RFC3339, unix, or unixMilli, or a Go layout. An empty zone keeps the time's own zone.
+func format(layout string, args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return "", fmt.Errorf("format expects a layout, an optional zone and locale, and a time")
+	}
+	t, err := toTime(args[len(args)-1])
+	if err != nil {
+		return "", err
+	}
+	var zone, locale string
+	for i, arg := range args[:len(args)-1] {
+		s, ok := arg.(string)
+		if !ok {
+			return "", fmt.Errorf("format zone and locale must be strings, got %T", arg)
+		}
+		if i == 0 {
+			zone = s
+		} else {
+			locale = s
+		}
+	}
+	if t, err = inZone(t, zone); err != nil {
+		return "", err
+	}
+
+	switch layout {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "unixMilli":
+		return strconv.FormatInt(t.UnixMilli(), 10), nil
+	case "HTTP":
+		t = t.UTC()
+	}
+	if named, ok := namedLayouts[layout]; ok {
+		layout = named
+	}
+	return formatLocale(t, layout, locale)
+}
+
+func inZone(t time.Time, zone string) (time.Time, error) {
+	if zone == "" {
+		return t, nil
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone %q", zone)
+	}
+	return t.In(loc), nil
+}
+
+// dateNames holds localized month and weekday names
+type dateNames struct {
+	months, shortMonths [12]string
+	days, shortDays     [7]string // Starting with Sunday
+}
+
+var locales = map[string]*dateNames{
+	"pt": {
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"de": {
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"it": {
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+}
+
+// formatLocale formats t, replacing the month and weekday name elements of the layout
+// with the locale's names. English and an empty locale use Go's names.
+func formatLocale(t time.Time, layout, locale string) (string, error) {
+	if locale == "" {
+		return t.Format(layout), nil
+	}
+	lang, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(locale, "_", "-")), "-")
+	if lang == "en" {
+		return t.Format(layout), nil
+	}
+	names, ok := locales[lang]
+	if !ok {
+		return "", fmt.Errorf("unsupported locale %q, use en, pt, es, fr, de, or it", locale)
+	}
+
+	var b strings.Builder
+	for layout != "" {
+		// Go layouts spell names as January/Jan and Monday/Mon; match the longer forms first
+		i, name := nextNameElement(layout)
+		b.WriteString(t.Format(layout[:i]))
+		if name == "" {
+			break
+		}
+		switch name {
+		case "January":
+			b.WriteString(names.months[t.Month()-1])
+		case "Jan":
+			b.WriteString(names.shortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(names.days[t.Weekday()])
+		case "Mon":
+			b.WriteString(names.shortDays[t.Weekday()])
+		}
+		layout = layout[i+len(name):]
+	}
+	return b.String(), nil
+}
+
+// nextNameElement finds the first month or weekday name element in layout,
+// returning its index and text, or len(layout) and "" if there is none.
+func nextNameElement(layout string) (int, string) {
+	for i := 0; i < len(layout); i++ {
+		for _, name := range []string{"January", "Jan", "Monday", "Mon"} {
+			if strings.HasPrefix(layout[i:], name) {
+				return i, name
+			}
+		}
+	}
+	return len(layout), ""
+}
@@ -0,0 +1,65 @@
+package templating
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateHelpers(t *testing.T) {
+	fixed := time.Date(2024, time.January, 31, 22, 30, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return fixed }
+	defer func() { nowFunc = time.Now }()
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{ now | format "RFC3339" }}`, "2024-01-31T22:30:00Z"},
+		{`{{ now | addDays 30 | format "RFC3339" "Europe/Lisbon" }}`, "2024-03-01T22:30:00Z"},
+		{`{{ now | format "RFC3339" "America/New_York" }}`, "2024-01-31T17:30:00-05:00"},
+		{`{{ now | addMonths 1 | format "DateOnly" }}`, "2024-03-02"},
+		{`{{ now | addYears -1 | addHours 2 | format "DateTime" }}`, "2023-02-01 00:30:00"},
+		{`{{ now | addDuration "-90m" | format "Kitchen" }}`, "9:00PM"},
+		{`{{ now | format "unix" }}`, "1706740200"},
+		{`{{ now | format "unixMilli" }}`, "1706740200000"},
+		{`{{ now | format "HTTP" "Europe/Lisbon" }}`, "Wed, 31 Jan 2024 22:30:00 GMT"},
+		{`{{ now | format "Monday, 2 January 2006" "" "pt-PT" }}`, "quarta-feira, 31 janeiro 2024"},
+		{`{{ now | format "Mon 2 Jan" "Asia/Tokyo" "de" }}`, "Do. 1 Feb."},
+		{`{{ now | format "Monday 2 January" "" "en-GB" }}`, "Wednesday 31 January"},
+		{`{{ parseTime "DateOnly" "2024-02-29" | addDays 1 | format "DateOnly" }}`, "2024-03-01"},
+		{`{{ "2024-05-01T10:00:00Z" | addMinutes 15 | format "TimeOnly" }}`, "10:15:00"},
+		{`{{ now | inZone "Asia/Tokyo" | format "15:04 MST" }}`, "07:30 JST"},
+		{`{{ now "RFC3339" }}`, "2024-01-31T22:30:00Z"},
+		{`{{ now "15:04" "Asia/Tokyo" }}`, "07:30"},
+		{`{{ nowPlus "24h" }}`, "2024-02-01T22:30:00Z"},
+		{`{{ nowPlus "-7d" "DateOnly" }}`, "2024-01-24"},
+		{`{{ nowPlus "90m" | addDays 1 | format "DateTime" }}`, "2024-02-02 00:00:00"},
+	}
+	for _, tt := range tests {
+		if got := render(t, nil, tt.text); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDateHelpers_Errors(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr string
+	}{
+		{`{{ now | format "RFC3339" "Mars/Olympus" }}`, "unknown time zone"},
+		{`{{ now | format "RFC3339" "" "xx" }}`, "unsupported locale"},
+		{`{{ "yesterday" | addDays 1 }}`, "cannot parse"},
+		{`{{ nowPlus "tomorrow" }}`, "invalid duration"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse("test", tt.text, Options{})
+		if err != nil {
+			t.Fatalf("parse %s: %v", tt.text, err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, nil); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.text, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package templating
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func extractFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"jsonPath":     jsonPath,
+		"xPath":        xPath,
+		"regexExtract": regexExtract,
+		"formValue":    formValue,
+	}
+}
+
+// jsonPath returns the value at a JSONPath such as $.order.items[0].id in a JSON
+// document. Strings, numbers, and booleans are returned as they are, objects and lists
+// as JSON text, and lists of every match when the path has a [*] or .* wildcard.
+// Missing values and documents that aren't JSON give an empty string.
+func jsonPath(path, doc string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	var root interface{}
+	if err := dec.Decode(&root); err != nil {
+		return "", nil
+	}
+	values, wildcard := []interface{}{root}, false
+	for _, step := range steps {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, step.apply(v)...)
+		}
+		values = next
+		wildcard = wildcard || step.wildcard
+	}
+	if wildcard {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return jsonText(values)
+	}
+	if len(values) == 0 || values[0] == nil {
+		return "", nil
+	}
+	switch values[0].(type) {
+	case map[string]interface{}, []interface{}:
+		return jsonText(values[0])
+	}
+	return values[0], nil
+}
+
+// jsonPathStep is a member name, list index, or wildcard of a JSONPath
+type jsonPathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func (s jsonPathStep) apply(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			out := make([]interface{}, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				out = append(out, v[key])
+			}
+			return out
+		}
+		if item, ok := v[s.name]; ok && !s.isIndex {
+			return []interface{}{item}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return v
+		}
+		i := s.index
+		if i < 0 {
+			i += len(v)
+		}
+		if s.isIndex && i >= 0 && i < len(v) {
+			return []interface{}{v[i]}
+		}
+	}
+	return nil
+}
+
+// parseJSONPath splits a path into steps. The leading $ is optional.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var steps []jsonPathStep
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonPath %q: missing ]", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if inner == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else if n, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, jsonPathStep{index: n, isIndex: true})
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{name: inner[1 : len(inner)-1]})
+			} else {
+				return nil, fmt.Errorf("jsonPath %q: invalid selector [%s]", path, inner)
+			}
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("jsonPath %q: empty member name", path)
+			}
+			if name == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				steps = append(steps, jsonPathStep{name: name})
+			}
+		default:
+			if len(steps) > 0 {
+				return nil, fmt.Errorf("jsonPath %q: expected . or [ at %q", path, rest)
+			}
+			// A path may start with a member name, as in order.id
+			rest = "." + rest
+		}
+	}
+	return steps, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonText(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	name     string // Local name, without the namespace
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder // Character data directly inside the element
+	parts    []interface{}   // Character data and child elements in document order
+}
+
+// textContent returns the character data of the element and its descendants
+func (n *xmlNode) textContent() string {
+	var b strings.Builder
+	for _, part := range n.parts {
+		switch p := part.(type) {
+		case string:
+			b.WriteString(p)
+		case *xmlNode:
+			b.WriteString(p.textContent())
+		}
+	}
+	return b.String()
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func parseXML(doc string) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			top.children = append(top.children, n)
+			top.parts = append(top.parts, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.text.Write(t)
+			top.parts = append(top.parts, string(t))
+		}
+	}
+}
+
+// xPath returns the text of the first node an XPath such as /order/items/item[2]/@sku
+// selects in an XML document. It supports child (/) and descendant (//) steps, * for
+// any element, [n] and [@attr='value'] predicates, and a final @attr or text() step.
+// Names match regardless of namespace. No match, or a document that isn't XML, gives
+// an empty string.
+func xPath(path, doc string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("xPath %q must start with /", path)
+	}
+	root, err := parseXML(doc)
+	if err != nil {
+		return "", nil
+	}
+	nodes, rest := []*xmlNode{root}, path
+	for rest != "" {
+		descendant := strings.HasPrefix(rest, "//")
+		rest = strings.TrimPrefix(rest[1:], "/")
+		step := rest
+		if end := strings.Index(rest, "/"); end >= 0 {
+			step, rest = rest[:end], rest[end:]
+		} else {
+			rest = ""
+		}
+		switch {
+		case step == "text()":
+			if rest != "" {
+				return "", fmt.Errorf("xPath %q: text() must be the last step", path)
+			}
+			if len(nodes) == 0 {
+				return "", nil
+			}
+			return nodes[0].text.String(), nil
+		case strings.HasPrefix(step, "@"):
+			if rest != "" {
+				return "", fmt.Errorf("xPath %q: %s must be the last step", path, step)
+			}
+			for _, n := range nodes {
+				if v, ok := n.attr(step[1:]); ok {
+					return v, nil
+				}
+			}
+			return "", nil
+		}
+		name, pred, err := parseXPathStep(path, step)
+		if err != nil {
+			return "", err
+		}
+		var next []*xmlNode
+		for _, n := range nodes {
+			next = append(next, pred.filter(matchElements(n, name, descendant))...)
+		}
+		nodes = next
+	}
+	if len(nodes) == 0 {
+		return "", nil
+	}
+	return strings.TrimSpace(nodes[0].textContent()), nil
+}
+
+// xPathPredicate selects among the elements a step matched, by position or attribute
+type xPathPredicate struct {
+	position  int // 1-based, 0 when the predicate doesn't select by position
+	attr      string
+	attrValue string
+}
+
+func (p xPathPredicate) filter(nodes []*xmlNode) []*xmlNode {
+	switch {
+	case p.position > 0:
+		if p.position <= len(nodes) {
+			return nodes[p.position-1 : p.position]
+		}
+		return nil
+	case p.attr != "":
+		var out []*xmlNode
+		for _, n := range nodes {
+			if v, ok := n.attr(p.attr); ok && v == p.attrValue {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+	return nodes
+}
+
+var xPathAttrPredicate = regexp.MustCompile(`^@([\w.-]+)\s*=\s*(?:'([^']*)'|"([^"]*)")$`)
+
+func parseXPathStep(path, step string) (string, xPathPredicate, error) {
+	name, inner, hasPred := strings.Cut(step, "[")
+	if name == "" {
+		return "", xPathPredicate{}, fmt.Errorf("xPath %q: empty step", path)
+	}
+	if !hasPred {
+		return name, xPathPredicate{}, nil
+	}
+	inner, ok := strings.CutSuffix(inner, "]")
+	if !ok {
+		return "", xPathPredicate{}, fmt.Errorf("xPath %q: missing ]", path)
+	}
+	if n, err := strconv.Atoi(inner); err == nil && n > 0 {
+		return name, xPathPredicate{position: n}, nil
+	}
+	if m := xPathAttrPredicate.FindStringSubmatch(inner); m != nil {
+		return name, xPathPredicate{attr: m[1], attrValue: m[2] + m[3]}, nil
+	}
+	return "", xPathPredicate{}, fmt.Errorf("xPath %q: unsupported predicate [%s]", path, inner)
+}
+
+// matchElements returns the children, or all descendants, of n with the given name
+func matchElements(n *xmlNode, name string, descendant bool) []*xmlNode {
+	var out []*xmlNode
+	for _, c := range n.children {
+		if name == "*" || c.name == name {
+			out = append(out, c)
+		}
+		if descendant {
+			out = append(out, matchElements(c, name, true)...)
+		}
+	}
+	return out
+}
+
+// regexExtract returns the first capture group of the first match of pattern in s,
+// or the whole match when the pattern has no groups. No match gives an empty string.
+func regexExtract(pattern, s string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("regexExtract: %w", err)
+	}
+	m := re.FindStringSubmatch(s)
+	switch {
+	case m == nil:
+		return "", nil
+	case len(m) > 1:
+		return m[1], nil
+	}
+	return m[0], nil
+}
+
+// formValue returns the first value of a field in a URL-encoded form body, or an
+// empty string when the field is missing
+func formValue(name, body string) string {
+	values, _ := url.ParseQuery(body)
+	return values.Get(name)
+}
@@ -0,0 +1,84 @@
+package templating
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderBody(t *testing.T, text, body string) (string, error) {
+	t.Helper()
+	tmpl, err := Parse("test", text, Options{})
+	if err != nil {
+		t.Fatalf("parse %s: %v", text, err)
+	}
+	var buf strings.Builder
+	err = tmpl.Execute(&buf, map[string]string{"Body": body})
+	return buf.String(), err
+}
+
+func TestExtractHelpers(t *testing.T) {
+	order := `{"orderId":"A-17","total":1999.5,"big":12345678901,"paid":true,"items":[{"sku":"x1","qty":2},{"sku":"y2","qty":1}],"note":null,"odd key":"v"}`
+	xmlDoc := `<?xml version="1.0"?>
+<ns:order xmlns:ns="urn:shop" id="A-17">
+  <customer><name> Ada Lovelace </name></customer>
+  <items>
+    <item sku="x1">first</item>
+    <item sku="y2">second</item>
+  </items>
+</ns:order>`
+	tests := []struct {
+		text string
+		body string
+		want string
+	}{
+		{`{{ jsonPath "$.orderId" .Body }}`, order, "A-17"},
+		{`{{ .Body | jsonPath "orderId" }}`, order, "A-17"},
+		{`{{ jsonPath "$.total" .Body }} {{ jsonPath "$.big" .Body }} {{ jsonPath "$.paid" .Body }}`, order, "1999.5 12345678901 true"},
+		{`{{ jsonPath "$.items[1].sku" .Body }}`, order, "y2"},
+		{`{{ jsonPath "$.items[-1].qty" .Body }}`, order, "1"},
+		{`{{ jsonPath "$.items[0]" .Body }}`, order, `{"qty":2,"sku":"x1"}`},
+		{`{{ jsonPath "$.items[*].sku" .Body }}`, order, `["x1","y2"]`},
+		{`{{ jsonPath "$['odd key']" .Body }}`, order, "v"},
+		{`[{{ jsonPath "$.missing" .Body }}][{{ jsonPath "$.note" .Body }}][{{ jsonPath "$.items[9]" .Body }}]`, order, "[][][]"},
+		{`[{{ jsonPath "$.id" .Body }}]`, "not json", "[]"},
+		{`{{ xPath "/order/@id" .Body }}`, xmlDoc, "A-17"},
+		{`{{ xPath "/order/customer/name" .Body }}`, xmlDoc, "Ada Lovelace"},
+		{`{{ xPath "//item[2]" .Body }} {{ xPath "//item[2]/@sku" .Body }}`, xmlDoc, "second y2"},
+		{`{{ xPath "//item[@sku='x1']" .Body }}`, xmlDoc, "first"},
+		{`[{{ xPath "/order/*/name/text()" .Body }}]`, xmlDoc, "[ Ada Lovelace ]"},
+		{`[{{ xPath "/order/missing" .Body }}][{{ xPath "/a" "not <xml" }}]`, xmlDoc, "[][]"},
+		{`{{ regexExtract "order-(\\d+)" .Body }}`, "ref order-42 ok", "42"},
+		{`{{ regexExtract "[A-Z]{3}" .Body }}`, "code EUR", "EUR"},
+		{`[{{ regexExtract "x(\\d)" .Body }}]`, "none", "[]"},
+		{`{{ formValue "orderId" .Body }}/{{ formValue "name" .Body }}/{{ formValue "missing" .Body }}`, "orderId=A-17&name=Ada+Lovelace&orderId=B", "A-17/Ada Lovelace/"},
+	}
+	for _, tt := range tests {
+		got, err := renderBody(t, tt.text, tt.body)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExtractHelpers_Errors(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr string
+	}{
+		{`{{ jsonPath "$.items[" .Body }}`, "missing ]"},
+		{`{{ jsonPath "$.items[x]" .Body }}`, "invalid selector"},
+		{`{{ xPath "order" .Body }}`, "must start with /"},
+		{`{{ xPath "/order[last()]" .Body }}`, "unsupported predicate"},
+		{`{{ xPath "/order/@id/x" .Body }}`, "must be the last step"},
+		{`{{ regexExtract "(" .Body }}`, "regexExtract"},
+	}
+	for _, tt := range tests {
+		if _, err := renderBody(t, tt.text, "<order/>"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.text, tt.wantErr, err)
+		}
+	}
+}
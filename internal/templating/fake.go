@@ -0,0 +1,131 @@
+package templating
+
+import (
+	"crypto/rand"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+)
+
+// Word lists for the fake data helpers. Email domains use the reserved example
+// domains so generated addresses can never reach anyone.
+var (
+	fakeFirstNames = []string{
+		"Ada", "Alan", "Ana", "Beatriz", "Carlos", "Chloe", "Daniel", "Diana", "Emma", "Ethan",
+		"Fatima", "Grace", "Hannah", "Hugo", "Inês", "Isaac", "James", "Joana", "Kenji", "Lara",
+		"Liam", "Lucas", "Maria", "Mateo", "Mia", "Noah", "Olivia", "Pedro", "Priya", "Rafael",
+		"Sara", "Sofia", "Tiago", "Yara", "Zoe",
+	}
+	fakeLastNames = []string{
+		"Almeida", "Brown", "Costa", "Dubois", "Ferreira", "Garcia", "Hopper", "Ito", "Jones", "Kowalski",
+		"Lovelace", "Martins", "Miller", "Nakamura", "Novak", "Oliveira", "Pereira", "Rossi", "Santos", "Schmidt",
+		"Silva", "Smith", "Sousa", "Turing", "Williams",
+	}
+	fakeCompanySuffixes = []string{"Labs", "Systems", "Group", "Industries", "Logistics", "Software", "Partners"}
+	fakeCities          = []string{
+		"Lisbon", "Porto", "Madrid", "Paris", "Berlin", "Amsterdam", "London", "Dublin", "New York",
+		"Toronto", "São Paulo", "Tokyo", "Sydney", "Cape Town", "Singapore",
+	}
+	fakeCountries = []string{
+		"Portugal", "Spain", "France", "Germany", "Netherlands", "United Kingdom", "Ireland",
+		"United States", "Canada", "Brazil", "Japan", "Australia", "South Africa", "Singapore",
+	}
+	fakeStreets = []string{"Main Street", "High Street", "Oak Avenue", "Rua Augusta", "Park Lane", "Elm Road", "Market Square"}
+	fakeWords   = []string{
+		"alpha", "bright", "cloud", "delta", "echo", "forest", "garden", "harbor", "island", "jade",
+		"kernel", "lumen", "meadow", "nova", "orbit", "pixel", "quartz", "river", "summit", "tide",
+		"umber", "vivid", "willow", "xenon", "yield", "zephyr",
+	}
+	fakeDomains = []string{"example.com", "example.org", "example.net"}
+)
+
+func fakeFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"fakeFirstName": func() string { return pick(fakeFirstNames) },
+		"fakeLastName":  func() string { return pick(fakeLastNames) },
+		"fakeName":      func() string { return pick(fakeFirstNames) + " " + pick(fakeLastNames) },
+		"fakeUsername":  fakeUsername,
+		"fakeEmail":     fakeEmail,
+		"fakeUUID":      fakeUUID,
+		"uuid":          fakeUUID,
+		"fakeCompany":   func() string { return pick(fakeLastNames) + " " + pick(fakeCompanySuffixes) },
+		"fakeCity":      func() string { return pick(fakeCities) },
+		"fakeCountry":   func() string { return pick(fakeCountries) },
+		"fakeStreet":    func() string { return fmt.Sprintf("%d %s", 1+mathrand.Intn(250), pick(fakeStreets)) },
+		"fakePhone":     func() string { return fmt.Sprintf("+1-555-%03d-%04d", mathrand.Intn(1000), mathrand.Intn(10000)) },
+		"fakeWord":      func() string { return pick(fakeWords) },
+		"fakeSentence":  fakeSentence,
+		"randomInt":     randomInt,
+		"randomFloat":   randomFloat,
+		"randomBool":    func() bool { return mathrand.Intn(2) == 1 },
+		"randomItem":    randomItem,
+	}
+}
+
+func pick(words []string) string {
+	return words[mathrand.Intn(len(words))]
+}
+
+// asciiFold replaces the accented letters in the name lists for use in addresses
+var asciiFold = strings.NewReplacer("ê", "e", "ã", "a", "é", "e", "á", "a", "ç", "c")
+
+func fakeUsername() string {
+	name := strings.ToLower(pick(fakeFirstNames) + "." + pick(fakeLastNames))
+	return asciiFold.Replace(name) + fmt.Sprint(mathrand.Intn(100))
+}
+
+func fakeEmail() string {
+	return fakeUsername() + "@" + pick(fakeDomains)
+}
+
+// fakeUUID returns a random (version 4) UUID
+func fakeUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// fakeSentence returns n random words as a capitalized sentence, 8 without n
+func fakeSentence(n ...int) string {
+	count := 8
+	if len(n) > 0 && n[0] > 0 {
+		count = n[0]
+	}
+	words := make([]string, count)
+	for i := range words {
+		words[i] = pick(fakeWords)
+	}
+	sentence := strings.Join(words, " ")
+	return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+}
+
+// randomInt returns a random integer between min and max, inclusive
+func randomInt(min, max int) (int, error) {
+	if min > max {
+		return 0, fmt.Errorf("randomInt: min (%d) exceeds max (%d)", min, max)
+	}
+	return min + mathrand.Intn(max-min+1), nil
+}
+
+// randomFloat returns a random number between min and max
+func randomFloat(min, max float64) (float64, error) {
+	if min > max {
+		return 0, fmt.Errorf("randomFloat: min (%g) exceeds max (%g)", min, max)
+	}
+	return min + mathrand.Float64()*(max-min), nil
+}
+
+// randomItem returns one of its arguments, or one element of a single list argument
+func randomItem(items ...interface{}) (interface{}, error) {
+	if len(items) == 1 {
+		if list, ok := items[0].([]interface{}); ok {
+			items = list
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("randomItem requires at least one item")
+	}
+	return items[mathrand.Intn(len(items))], nil
+}
@@ -0,0 +1,49 @@
+package templating
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestFakeHelpers(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // Regex the output must fully match
+	}{
+		{`{{ fakeName }}`, `\p{Lu}\pL+ \p{Lu}\pL+`},
+		{`{{ fakeEmail }}`, `[a-z]+\.[a-z]+\d{1,2}@example\.(com|org|net)`},
+		{`{{ fakeUUID }}`, `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`},
+		{`{{ uuid }}`, `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`},
+		{`{{ fakePhone }}`, `\+1-555-\d{3}-\d{4}`},
+		{`{{ fakeStreet }}`, `\d+ [\pL ]+`},
+		{`{{ fakeSentence 3 }}`, `[A-Z][a-z]+ [a-z]+ [a-z]+\.`},
+		{`{{ randomItem "a" "b" }}`, `a|b`},
+		{`{{ randomItem (list 1 2) }}`, `1|2`},
+		{`{{ randomBool }}`, `true|false`},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(`^(` + tt.want + `)$`)
+		for i := 0; i < 20; i++ {
+			if got := render(t, nil, tt.text); !re.MatchString(got) {
+				t.Fatalf("%s: %q does not match %s", tt.text, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestRandomNumbers(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		n, err := strconv.Atoi(render(t, nil, `{{ randomInt 1 3 }}`))
+		if err != nil || n < 1 || n > 3 {
+			t.Fatalf("randomInt out of range: %d, %v", n, err)
+		}
+		f, err := strconv.ParseFloat(render(t, nil, `{{ randomFloat 0.5 1 }}`), 64)
+		if err != nil || f < 0.5 || f > 1 {
+			t.Fatalf("randomFloat out of range: %g, %v", f, err)
+		}
+	}
+	if _, err := randomInt(5, 1); err == nil {
+		t.Fatal("expected error for inverted range")
+	}
+}
@@ -0,0 +1,103 @@
+package templating
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Limits bound the output and running time of one template execution. Zero values
+// mean no limit.
+type Limits struct {
+	MaxOutputBytes int
+	Timeout        time.Duration
+}
+
+// ErrLimitExceeded is wrapped by the errors of executions that broke their limits
+var ErrLimitExceeded = errors.New("template limit exceeded")
+
+var (
+	errOutputLimit = errors.New("output limit reached")
+	errTimedOut    = errors.New("timed out")
+)
+
+// limitedBuffer collects template output and fails writes beyond the limit or after
+// the execution was abandoned
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	abandoned atomic.Bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.abandoned.Load() {
+		return 0, errTimedOut
+	}
+	if b.max > 0 && b.buf.Len()+len(p) > b.max {
+		return 0, errOutputLimit
+	}
+	return b.buf.Write(p)
+}
+
+// Execute renders a template within the limits. A template that runs past the timeout
+// is abandoned: it stops at its next write, but one stuck without writing finishes in
+// the background.
+func Execute(t *template.Template, data interface{}, limits Limits) ([]byte, error) {
+	out := &limitedBuffer{max: limits.MaxOutputBytes}
+	if limits.Timeout <= 0 {
+		if err := t.Execute(out, data); err != nil {
+			return nil, limitError(err, limits)
+		}
+		return out.buf.Bytes(), nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- t.Execute(out, data)
+	}()
+	timer := time.NewTimer(limits.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, limitError(err, limits)
+		}
+		return out.buf.Bytes(), nil
+	case <-timer.C:
+		out.abandoned.Store(true)
+		return nil, fmt.Errorf("%w: ran longer than %s", ErrLimitExceeded, limits.Timeout)
+	}
+}
+
+// limitError describes a limit violation, and returns other errors unchanged
+func limitError(err error, limits Limits) error {
+	if errors.Is(err, errOutputLimit) {
+		return fmt.Errorf("%w: output is larger than %d bytes", ErrLimitExceeded, limits.MaxOutputBytes)
+	}
+	return err
+}
+
+// checkDenied returns an error naming the first denied function the template calls
+func checkDenied(t *template.Template, denied []string) error {
+	if len(denied) == 0 {
+		return nil
+	}
+	deny := make(map[string]bool, len(denied))
+	for _, name := range denied {
+		deny[name] = true
+	}
+	var found string
+	inspect(t, func(node parse.Node) {
+		if n, ok := node.(*parse.IdentifierNode); ok && found == "" && deny[n.Ident] {
+			found = n.Ident
+		}
+	})
+	if found != "" {
+		return fmt.Errorf("function %q is denied by server.templateLimits", found)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package templating
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteLimits(t *testing.T) {
+	tests := []struct {
+		text    string
+		limits  Limits
+		want    string
+		wantErr string
+	}{
+		{`{{ range 3 }}ab{{ end }}`, Limits{MaxOutputBytes: 6, Timeout: time.Second}, "ababab", ""},
+		{`{{ range 4 }}ab{{ end }}`, Limits{MaxOutputBytes: 6}, "", "output is larger than 6 bytes"},
+		{`{{ range 1000000000 }}ab{{ end }}`, Limits{Timeout: 20 * time.Millisecond}, "", "ran longer than 20ms"},
+		{`{{ .Missing.Field }}`, Limits{Timeout: time.Second}, "", "nil pointer"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse("test", tt.text, Options{})
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tt.text, err)
+		}
+		var data struct{ Missing *struct{ Field string } }
+		got, err := Execute(tmpl, data, tt.limits)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("%s: expected error containing %q, got %v", tt.text, tt.wantErr, err)
+			}
+			if limit := strings.Contains(tt.wantErr, "than"); errors.Is(err, ErrLimitExceeded) != limit {
+				t.Fatalf("%s: expected ErrLimitExceeded %v, got %v", tt.text, limit, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != tt.want {
+			t.Fatalf("%s: expected %q, got %q, %v", tt.text, tt.want, got, err)
+		}
+	}
+}
+
+func TestParseDeniedFunctions(t *testing.T) {
+	for _, text := range []string{
+		`{{ fakeUUID }}`,
+		`{{ with .Body }}{{ . | fakeUUID }}{{ end }}`,
+		`{{ define "x" }}{{ fakeUUID }}{{ end }}{{ template "x" }}`,
+	} {
+		if _, err := Parse("test", text, Options{Denied: []string{"fakeUUID"}}); err == nil || !strings.Contains(err.Error(), `function "fakeUUID" is denied`) {
+			t.Errorf("%s: expected fakeUUID to be denied, got %v", text, err)
+		}
+	}
+	if _, err := Parse("test", `{{ .Body }} fakeUUID`, Options{Denied: []string{"fakeUUID"}}); err != nil {
+		t.Errorf("expected a template not calling fakeUUID to parse, got %v", err)
+	}
+}
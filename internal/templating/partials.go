@@ -0,0 +1,115 @@
+package templating
+
+import (
+	"fmt"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// ParsePartials compiles the shared templates response templates can include with
+// {{ template "name" . }}. Each file is available under its name, along with the
+// templates it defines.
+func ParsePartials(texts map[string]string, opts Options) (*template.Template, error) {
+	names := make([]string, 0, len(texts))
+	for name := range texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := template.New("").Option("missingkey=zero").Funcs(Funcs(opts))
+	for _, name := range names {
+		if _, err := root.New(name).Parse(texts[name]); err != nil {
+			return nil, fmt.Errorf("partial %q: invalid template: %w", name, err)
+		}
+	}
+	if err := checkDenied(root, opts.Denied); err != nil {
+		return nil, fmt.Errorf("partials: invalid template: %w", err)
+	}
+	if err := checkIncludes(root); err != nil {
+		return nil, fmt.Errorf("partials: invalid template: %w", err)
+	}
+	return root, nil
+}
+
+// addPartials makes the partials available to t. Templates t defines itself take
+// precedence over partials of the same name.
+func addPartials(t *template.Template, partials *template.Template) error {
+	if partials == nil {
+		return nil
+	}
+	for _, p := range partials.Templates() {
+		if p.Tree == nil || t.Lookup(p.Name()) != nil {
+			continue
+		}
+		if _, err := t.AddParseTree(p.Name(), p.Tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIncludes returns an error naming the first template t includes that isn't
+// defined, which would otherwise only fail when a request renders it
+func checkIncludes(t *template.Template) error {
+	var missing string
+	inspect(t, func(node parse.Node) {
+		if n, ok := node.(*parse.TemplateNode); ok && missing == "" && t.Lookup(n.Name) == nil {
+			missing = n.Name
+		}
+	})
+	if missing != "" {
+		return fmt.Errorf("template %q is not defined", missing)
+	}
+	return nil
+}
+
+// inspect calls fn for every node of t and the templates associated with it
+func inspect(t *template.Template, fn func(parse.Node)) {
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		if node == nil {
+			return
+		}
+		fn(node)
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			walk(tmpl.Tree.Root)
+		}
+	}
+}
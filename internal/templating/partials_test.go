@@ -0,0 +1,58 @@
+package templating
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePartials(t *testing.T) {
+	partials, err := ParsePartials(map[string]string{
+		"envelope": `{"status": "ok", "data": {{ template "data" . }}}`,
+		"shared":   `{{ define "data" }}{{ toJson . }}{{ end }}{{ define "meta" }}{"n": {{ len . }}}{{ end }}`,
+	}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct{ text, want string }{
+		{`{{ template "envelope" .Items }}`, `{"status": "ok", "data": [1,2]}`},
+		{`{{ template "meta" .Items }}`, `{"n": 2}`},
+		// A template defined by the response takes precedence over the partial
+		{`{{ define "data" }}"mine"{{ end }}{{ template "envelope" . }}`, `{"status": "ok", "data": "mine"}`},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse("test", tt.text, Options{Partials: partials})
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tt.text, err)
+		}
+		got, err := Execute(tmpl, map[string]interface{}{"Items": []int{1, 2}}, Limits{})
+		if err != nil || string(got) != tt.want {
+			t.Fatalf("%s: expected %q, got %q, %v", tt.text, tt.want, got, err)
+		}
+	}
+	// The partials themselves are left untouched by responses redefining them
+	tmpl, _ := Parse("test", `{{ template "envelope" . }}`, Options{Partials: partials})
+	if got, _ := Execute(tmpl, 1, Limits{}); string(got) != `{"status": "ok", "data": 1}` {
+		t.Fatalf("expected the shared data template, got %q", got)
+	}
+}
+
+func TestParsePartials_Errors(t *testing.T) {
+	tests := []struct {
+		texts map[string]string
+		opts  Options
+		want  string
+	}{
+		{map[string]string{"a": `{{ .X`}, Options{}, `partial "a": invalid template`},
+		{map[string]string{"a": `{{ template "b" }}`}, Options{}, `template "b" is not defined`},
+		{map[string]string{"a": `{{ fakeUUID }}`}, Options{Denied: []string{"fakeUUID"}}, `function "fakeUUID" is denied`},
+	}
+	for _, tt := range tests {
+		if _, err := ParsePartials(tt.texts, tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.texts, tt.want, err)
+		}
+	}
+	if _, err := Parse("test", `{{ template "envelope" . }}`, Options{}); err == nil || !strings.Contains(err.Error(), `template "envelope" is not defined`) {
+		t.Errorf("expected a missing partial to be reported, got %v", err)
+	}
+}
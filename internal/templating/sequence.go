@@ -0,0 +1,44 @@
+package templating
+
+import "sync"
+
+// Sequences are named counters for the seq helper. Each name counts up from 1,
+// shared by every template that uses it.
+type Sequences struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+// NewSequences returns sequences that all start at 1
+func NewSequences() *Sequences {
+	return &Sequences{values: make(map[string]int64)}
+}
+
+// Next advances the named sequence and returns its new value
+func (s *Sequences) Next(name string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name]++
+	return s.values[name]
+}
+
+// Values returns the last value of each sequence used so far
+func (s *Sequences) Values() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64, len(s.values))
+	for name, v := range s.values {
+		out[name] = v
+	}
+	return out
+}
+
+// Set replaces the sequence values, so the next value of each is one more
+func (s *Sequences) Set(values map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]int64, len(values))
+	for name, v := range values {
+		s.values[name] = v
+	}
+}
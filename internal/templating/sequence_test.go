@@ -0,0 +1,39 @@
+package templating
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeqHelper(t *testing.T) {
+	seqs := NewSequences()
+	tmpl, err := Parse("test", `{{ seq "orders" }},{{ seq "orders" }},{{ seq "users" }}`, Options{Sequences: seqs})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, want := range []string{"1,2,1", "3,4,2"} {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		if buf.String() != want {
+			t.Fatalf("expected %q, got %q", want, buf.String())
+		}
+	}
+
+	seqs.Set(map[string]int64{"orders": 100})
+	if n := seqs.Next("orders"); n != 101 {
+		t.Fatalf("expected 101 after Set, got %d", n)
+	}
+	if got := seqs.Values(); len(got) != 1 || got["orders"] != 101 {
+		t.Fatalf("unexpected values %v", got)
+	}
+
+	tmpl, err = Parse("test", `{{ seq "orders" }}`, Options{})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, nil); err == nil || !strings.Contains(err.Error(), "no sequences are available") {
+		t.Fatalf("expected an error without sequences, got %v", err)
+	}
+}
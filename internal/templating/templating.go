@@ -0,0 +1,94 @@
+// Package templating provides the Go text/template engine and helper functions
+// used to render dynamic response bodies.
+package templating
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// Options provides the server state that helper functions depend on. Helpers whose
+// dependency is nil still parse but fail when executed.
+type Options struct {
+	Keys      *Keyring                               // Keys for the signing helpers
+	Datasets  func(name string) (interface{}, error) // Looks up dataset content by name
+	Denied    []string                               // Helpers and builtins the template may not call
+	Sequences *Sequences                             // Counters for the seq helper
+	Partials  *template.Template                     // Shared templates the template may include
+}
+
+// Parse compiles a response template with the helper functions bound to opts
+func Parse(name, text string, opts Options) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=zero").Funcs(Funcs(opts)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := addPartials(t, opts.Partials); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := checkDenied(t, opts.Denied); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := checkIncludes(t); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// Funcs returns the helper functions available to response templates
+func Funcs(opts Options) template.FuncMap {
+	funcs := template.FuncMap{
+		"dict":   dict,
+		"list":   list,
+		"toJson": toJSON,
+		"dataset": func(name string) (interface{}, error) {
+			if opts.Datasets == nil {
+				return nil, fmt.Errorf("no datasets are available")
+			}
+			return opts.Datasets(name)
+		},
+		"seq": func(name string) (int64, error) {
+			if opts.Sequences == nil {
+				return 0, fmt.Errorf("no sequences are available")
+			}
+			return opts.Sequences.Next(name), nil
+		},
+	}
+	for _, group := range []map[string]interface{}{cryptoFuncs(opts.Keys), dateFuncs(), fakeFuncs(), extractFuncs()} {
+		for name, fn := range group {
+			funcs[name] = fn
+		}
+	}
+	return funcs
+}
+
+// dict builds a map from alternating keys and values, e.g. dict "sub" "42" "admin" true
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires an even number of arguments")
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// toJSON encodes a value as JSON
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// list builds a slice from its arguments
+func list(items ...interface{}) []interface{} {
+	return items
+}
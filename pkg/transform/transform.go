@@ -0,0 +1,30 @@
+// Package transform is the interface between the mock server and response transformer
+// plugins. A plugin is built with go build -buildmode=plugin and exports a function of
+// type Func, by default named Transform.
+package transform
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Request is the matched request handed to a transformer
+type Request struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte            // Decompressed request body
+	PathParams map[string]string // Values of the {name} parameters in the rule path
+	Options    map[string]string // Options of the transformer in the rule
+}
+
+// Response is the response a transformer produces
+type Response struct {
+	Status  int               // Zero keeps the status-code of the rule's response, 200 unless set
+	Headers map[string]string // Set over the headers of the rule's response
+	Body    []byte
+}
+
+// Func produces the response to a request. An error is answered with 500.
+type Func func(*Request) (*Response, error)